@@ -0,0 +1,352 @@
+package boto3manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// CORSRule allows browsers on other origins to make requests to a bucket.
+type CORSRule struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposeHeaders  []string
+	MaxAgeSeconds  int32
+}
+
+// PublicAccessBlock controls whether ACLs and policies can make a bucket public.
+type PublicAccessBlock struct {
+	BlockPublicAcls       bool
+	IgnorePublicAcls      bool
+	BlockPublicPolicy     bool
+	RestrictPublicBuckets bool
+}
+
+// policyDocument is the JSON structure of a bucket policy.
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+type policyStatement struct {
+	Sid       string `json:"Sid,omitempty"`
+	Effect    string `json:"Effect"`
+	Principal string `json:"Principal"`
+	Action    any    `json:"Action"`
+	Resource  any    `json:"Resource"`
+}
+
+// storedPolicy is a bucket policy read back from a bucket. Its statements are kept as they are, so those the
+// package didn't write survive the policy being changed.
+type storedPolicy struct {
+	Version   string            `json:"Version"`
+	ID        string            `json:"Id,omitempty"`
+	Statement []json.RawMessage `json:"Statement"`
+}
+
+// policyVersion is the policy language version of the policies the package writes.
+const policyVersion = "2012-10-17"
+
+// publicReadSid is the Sid of the statement MakePublic adds to a bucket policy, so LockDown can find it again.
+const publicReadSid = "PublicRead"
+
+// publicReadStatement returns a policy statement that lets anyone read objects under prefix in the bucket.
+func publicReadStatement(bucketName string, prefix string) policyStatement {
+	return policyStatement{
+		Sid:       publicReadSid,
+		Effect:    "Allow",
+		Principal: "*",
+		Action:    "s3:GetObject",
+		Resource:  objectARN(bucketName, prefix+"*"),
+	}
+}
+
+// PublicReadPolicy returns a bucket policy that lets anyone read objects under prefix in the bucket. An empty
+// prefix makes the whole bucket readable.
+func PublicReadPolicy(bucketName string, prefix string) string {
+	policy := policyDocument{
+		Version:   policyVersion,
+		Statement: []policyStatement{publicReadStatement(bucketName, prefix)},
+	}
+
+	encoded, _ := json.Marshal(policy)
+	return string(encoded)
+}
+
+// GetBucketPolicy takes a bucket name and returns its policy as JSON.
+func (basics BucketBasics) GetBucketPolicy(bucketName string) (string, error) {
+	output, err := basics.client(bucketName).GetBucketPolicy(context.TODO(), &s3.GetBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get policy of bucket %v: %v", bucketName, err)
+		return "", err
+	}
+
+	return aws.ToString(output.Policy), nil
+}
+
+// PutBucketPolicy takes a bucket name and a policy as JSON and replaces the bucket's policy.
+func (basics BucketBasics) PutBucketPolicy(bucketName string, policy string) error {
+	_, err := basics.client(bucketName).PutBucketPolicy(context.TODO(), &s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+		Policy: aws.String(policy),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't put policy on bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// DeleteBucketPolicy takes a bucket name and removes its policy.
+func (basics BucketBasics) DeleteBucketPolicy(bucketName string) error {
+	_, err := basics.client(bucketName).DeleteBucketPolicy(context.TODO(), &s3.DeleteBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't delete policy of bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// GetCORS takes a bucket name and returns its CORS rules.
+func (basics BucketBasics) GetCORS(bucketName string) ([]CORSRule, error) {
+	output, err := basics.client(bucketName).GetBucketCors(context.TODO(), &s3.GetBucketCorsInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get CORS configuration of bucket %v: %v", bucketName, err)
+		return nil, err
+	}
+
+	rules := make([]CORSRule, 0, len(output.CORSRules))
+	for _, rule := range output.CORSRules {
+		rules = append(rules, CORSRule{
+			AllowedOrigins: rule.AllowedOrigins,
+			AllowedMethods: rule.AllowedMethods,
+			AllowedHeaders: rule.AllowedHeaders,
+			ExposeHeaders:  rule.ExposeHeaders,
+			MaxAgeSeconds:  aws.ToInt32(rule.MaxAgeSeconds),
+		})
+	}
+
+	return rules, nil
+}
+
+// PutCORS takes a bucket name and CORS rules and replaces the bucket's CORS configuration.
+func (basics BucketBasics) PutCORS(bucketName string, rules []CORSRule) error {
+	corsRules := make([]types.CORSRule, 0, len(rules))
+	for _, rule := range rules {
+		corsRule := types.CORSRule{
+			AllowedOrigins: rule.AllowedOrigins,
+			AllowedMethods: rule.AllowedMethods,
+			AllowedHeaders: rule.AllowedHeaders,
+			ExposeHeaders:  rule.ExposeHeaders,
+		}
+
+		if rule.MaxAgeSeconds > 0 {
+			corsRule.MaxAgeSeconds = aws.Int32(rule.MaxAgeSeconds)
+		}
+
+		corsRules = append(corsRules, corsRule)
+	}
+
+	_, err := basics.client(bucketName).PutBucketCors(context.TODO(), &s3.PutBucketCorsInput{
+		Bucket:            aws.String(bucketName),
+		CORSConfiguration: &types.CORSConfiguration{CORSRules: corsRules},
+	})
+
+	if err != nil {
+		log.Printf("Couldn't put CORS configuration on bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// DeleteCORS takes a bucket name and removes its CORS configuration.
+func (basics BucketBasics) DeleteCORS(bucketName string) error {
+	_, err := basics.client(bucketName).DeleteBucketCors(context.TODO(), &s3.DeleteBucketCorsInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't delete CORS configuration of bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// GetPublicAccessBlock takes a bucket name and returns its public access block settings.
+func (basics BucketBasics) GetPublicAccessBlock(bucketName string) (PublicAccessBlock, error) {
+	output, err := basics.client(bucketName).GetPublicAccessBlock(context.TODO(), &s3.GetPublicAccessBlockInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get public access block of bucket %v: %v", bucketName, err)
+		return PublicAccessBlock{}, err
+	}
+
+	config := output.PublicAccessBlockConfiguration
+	return PublicAccessBlock{
+		BlockPublicAcls:       aws.ToBool(config.BlockPublicAcls),
+		IgnorePublicAcls:      aws.ToBool(config.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.ToBool(config.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.ToBool(config.RestrictPublicBuckets),
+	}, nil
+}
+
+// PutPublicAccessBlock takes a bucket name and public access block settings and applies them to the bucket.
+func (basics BucketBasics) PutPublicAccessBlock(bucketName string, block PublicAccessBlock) error {
+	_, err := basics.client(bucketName).PutPublicAccessBlock(context.TODO(), &s3.PutPublicAccessBlockInput{
+		Bucket: aws.String(bucketName),
+		PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(block.BlockPublicAcls),
+			IgnorePublicAcls:      aws.Bool(block.IgnorePublicAcls),
+			BlockPublicPolicy:     aws.Bool(block.BlockPublicPolicy),
+			RestrictPublicBuckets: aws.Bool(block.RestrictPublicBuckets),
+		},
+	})
+
+	if err != nil {
+		log.Printf("Couldn't put public access block on bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// DeletePublicAccessBlock takes a bucket name and removes its public access block settings.
+func (basics BucketBasics) DeletePublicAccessBlock(bucketName string) error {
+	_, err := basics.client(bucketName).DeletePublicAccessBlock(context.TODO(), &s3.DeletePublicAccessBlockInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't delete public access block of bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// readPolicy returns a bucket's policy, or an empty one if it has none.
+func (basics BucketBasics) readPolicy(bucketName string) (storedPolicy, error) {
+	output, err := basics.client(bucketName).GetBucketPolicy(context.TODO(), &s3.GetBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchBucketPolicy" {
+			return storedPolicy{Version: policyVersion}, nil
+		}
+
+		log.Printf("Couldn't get policy of bucket %v: %v", bucketName, err)
+		return storedPolicy{}, err
+	}
+
+	var document struct {
+		Version   string          `json:"Version"`
+		ID        string          `json:"Id"`
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(aws.ToString(output.Policy)), &document); err != nil {
+		log.Printf("Couldn't parse policy of bucket %v: %v", bucketName, err)
+		return storedPolicy{}, err
+	}
+
+	policy := storedPolicy{Version: document.Version, ID: document.ID}
+
+	// A policy with one statement may give it on its own rather than in a list
+	if err := json.Unmarshal(document.Statement, &policy.Statement); err != nil {
+		policy.Statement = []json.RawMessage{document.Statement}
+	}
+
+	return policy, nil
+}
+
+// withoutPublicRead returns the policy without the statement MakePublic adds, and whether it had one.
+func (policy storedPolicy) withoutPublicRead() (storedPolicy, bool) {
+	statements := make([]json.RawMessage, 0, len(policy.Statement))
+	for _, statement := range policy.Statement {
+		var fields struct {
+			Sid string `json:"Sid"`
+		}
+		if json.Unmarshal(statement, &fields) == nil && fields.Sid == publicReadSid {
+			continue
+		}
+		statements = append(statements, statement)
+	}
+
+	found := len(statements) < len(policy.Statement)
+	policy.Statement = statements
+	return policy, found
+}
+
+// MakePublic takes a bucket name and a prefix and makes the objects under the prefix readable by anyone. The bucket's
+// public access block is replaced with one that only blocks and ignores public ACLs, so it no longer stops a public
+// policy from taking effect. The public-read statement is added to the bucket's policy, replacing one an earlier
+// MakePublic added, and the policy's other statements are kept.
+func (basics BucketBasics) MakePublic(bucketName string, prefix string) error {
+	policy, err := basics.readPolicy(bucketName)
+
+	if err != nil {
+		return err
+	}
+
+	policy, _ = policy.withoutPublicRead()
+	statement, _ := json.Marshal(publicReadStatement(bucketName, prefix))
+	policy.Statement = append(policy.Statement, statement)
+
+	err = basics.PutPublicAccessBlock(bucketName, PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true})
+
+	if err != nil {
+		return err
+	}
+
+	encoded, _ := json.Marshal(policy)
+	return basics.PutBucketPolicy(bucketName, string(encoded))
+}
+
+// LockDown takes a bucket name and blocks all public access to it. The public-read statement MakePublic adds is
+// removed from the bucket's policy, and the policy is deleted if nothing else is left in it.
+func (basics BucketBasics) LockDown(bucketName string) error {
+	policy, err := basics.readPolicy(bucketName)
+
+	if err != nil {
+		return err
+	}
+
+	// Change the policy first, since a public access block would reject a policy that is still public
+	policy, found := policy.withoutPublicRead()
+	switch {
+	case found && len(policy.Statement) == 0:
+		err = basics.DeleteBucketPolicy(bucketName)
+	case found:
+		encoded, _ := json.Marshal(policy)
+		err = basics.PutBucketPolicy(bucketName, string(encoded))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return basics.PutPublicAccessBlock(bucketName, PublicAccessBlock{
+		BlockPublicAcls:       true,
+		IgnorePublicAcls:      true,
+		BlockPublicPolicy:     true,
+		RestrictPublicBuckets: true,
+	})
+}
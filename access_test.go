@@ -0,0 +1,160 @@
+package boto3manager
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPublicReadPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		prefix string
+		wanted string
+	}{
+		{
+			name:   "whole bucket",
+			prefix: "",
+			wanted: `{"Version":"2012-10-17","Statement":[{"Sid":"PublicRead","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::data/*"}]}`,
+		},
+		{
+			name:   "prefix",
+			prefix: "public/",
+			wanted: `{"Version":"2012-10-17","Statement":[{"Sid":"PublicRead","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::data/public/*"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PublicReadPolicy("data", tt.prefix); got != tt.wanted {
+				t.Errorf("PublicReadPolicy(\"data\", %q) = %v, want %v", tt.prefix, got, tt.wanted)
+			}
+		})
+	}
+}
+
+// denyInsecure is a policy statement written by someone else, with fields the package doesn't model.
+const denyInsecure = `{"Sid":"DenyInsecureTransport","Effect":"Deny","Principal":{"AWS":"*"},"Action":"s3:*","Resource":["arn:aws:s3:::data","arn:aws:s3:::data/*"],"Condition":{"Bool":{"aws:SecureTransport":"false"}}}`
+
+func TestMakePublicKeepsStatements(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.policies["data"] = `{"Version":"2012-10-17","Statement":[` + denyInsecure + `]}`
+	basics := fake.basics()
+
+	// Making a prefix public twice replaces the first public-read statement
+	for _, prefix := range []string{"old/", "public/"} {
+		if err := basics.MakePublic("data", prefix); err != nil {
+			t.Fatalf("MakePublic(%q) = %v, want nil", prefix, err)
+		}
+	}
+
+	publicRead := `{"Sid":"PublicRead","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::data/public/*"}`
+	if got, wanted := fake.policies["data"], `{"Version":"2012-10-17","Statement":[`+denyInsecure+`,`+publicRead+`]}`; got != wanted {
+		t.Errorf("MakePublic() left policy %v, want %v", got, wanted)
+	}
+
+	if err := basics.LockDown("data"); err != nil {
+		t.Fatalf("LockDown() = %v, want nil", err)
+	}
+	if got, wanted := fake.policies["data"], `{"Version":"2012-10-17","Statement":[`+denyInsecure+`]}`; got != wanted {
+		t.Errorf("LockDown() left policy %v, want %v", got, wanted)
+	}
+	if !strings.Contains(fake.publicAccessBlocks["data"], "<RestrictPublicBuckets>true</RestrictPublicBuckets>") {
+		t.Errorf("LockDown() put public access block %v, want every setting on", fake.publicAccessBlocks["data"])
+	}
+}
+
+func TestLockDownDeletesEmptyPolicy(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	basics := fake.basics()
+
+	if err := basics.MakePublic("data", ""); err != nil {
+		t.Fatalf("MakePublic() = %v, want nil", err)
+	}
+	if got, wanted := fake.policies["data"], PublicReadPolicy("data", ""); got != wanted {
+		t.Errorf("MakePublic() left policy %v, want %v", got, wanted)
+	}
+
+	if err := basics.LockDown("data"); err != nil {
+		t.Fatalf("LockDown() = %v, want nil", err)
+	}
+	if policy, ok := fake.policies["data"]; ok {
+		t.Errorf("LockDown() left policy %v, want none", policy)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	basics := fake.basics()
+
+	if _, err := basics.GetCORS("data"); err == nil {
+		t.Errorf("GetCORS() with no configuration returned no error")
+	}
+
+	rules := []CORSRule{
+		{AllowedOrigins: []string{"https://example.org"}, AllowedMethods: []string{"GET", "HEAD"}, AllowedHeaders: []string{"*"}, ExposeHeaders: []string{"ETag"}, MaxAgeSeconds: 3600},
+		{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}},
+	}
+	if err := basics.PutCORS("data", rules); err != nil {
+		t.Fatalf("PutCORS() = %v, want nil", err)
+	}
+
+	got, err := basics.GetCORS("data")
+	if err != nil {
+		t.Fatalf("GetCORS() = %v, want nil", err)
+	}
+	if !reflect.DeepEqual(got, rules) {
+		t.Errorf("GetCORS() = %+v, want %+v", got, rules)
+	}
+
+	if err := basics.DeleteCORS("data"); err != nil {
+		t.Fatalf("DeleteCORS() = %v, want nil", err)
+	}
+	if _, err := basics.GetCORS("data"); err == nil {
+		t.Errorf("GetCORS() after DeleteCORS() returned no error")
+	}
+}
+
+func TestPublicAccessBlock(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	basics := fake.basics()
+
+	block := PublicAccessBlock{BlockPublicAcls: true, BlockPublicPolicy: true}
+	if err := basics.PutPublicAccessBlock("data", block); err != nil {
+		t.Fatalf("PutPublicAccessBlock() = %v, want nil", err)
+	}
+
+	got, err := basics.GetPublicAccessBlock("data")
+	if err != nil {
+		t.Fatalf("GetPublicAccessBlock() = %v, want nil", err)
+	}
+	if got != block {
+		t.Errorf("GetPublicAccessBlock() = %+v, want %+v", got, block)
+	}
+
+	// MakePublic leaves only the ACL settings on, so the public policy takes effect
+	if err := basics.MakePublic("data", "public/"); err != nil {
+		t.Fatalf("MakePublic() = %v, want nil", err)
+	}
+	got, err = basics.GetPublicAccessBlock("data")
+	if wanted := (PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true}); err != nil || got != wanted {
+		t.Errorf("GetPublicAccessBlock() after MakePublic() = %+v, %v, want %+v", got, err, wanted)
+	}
+
+	if err := basics.DeletePublicAccessBlock("data"); err != nil {
+		t.Fatalf("DeletePublicAccessBlock() = %v, want nil", err)
+	}
+	if _, err := basics.GetPublicAccessBlock("data"); err == nil {
+		t.Errorf("GetPublicAccessBlock() after DeletePublicAccessBlock() returned no error")
+	}
+}
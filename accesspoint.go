@@ -0,0 +1,88 @@
+package boto3manager
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Anywhere a bucket name is taken, the ARN of an access point, an Outposts access point, or a Multi-Region Access
+// Point can be given instead, e.g.
+//
+//	arn:aws:s3:us-west-2:123456789012:accesspoint/lab-readers
+//	arn:aws:s3-outposts:us-west-2:123456789012:outpost/op-01ac5d28a6a232904/accesspoint/lab-readers
+//	arn:aws:s3::123456789012:accesspoint/mfzwi23gnjvgw.mrap
+//
+// The SDK routes requests for ARNs to the access point's endpoint; Multi-Region Access Points are signed with
+// SigV4A.
+
+// isBucketARN reports whether a bucket name is an access point ARN rather than the name of a bucket.
+func isBucketARN(bucketName string) bool {
+	return arn.IsARN(bucketName)
+}
+
+// bucketARN returns the ARN of a bucket, or the ARN itself for an access point.
+func bucketARN(bucketName string) string {
+	if isBucketARN(bucketName) {
+		return bucketName
+	}
+	return "arn:aws:s3:::" + bucketName
+}
+
+// objectARN returns the ARN of an object, or of the objects matching a key ending in "*", in a bucket or through
+// an access point.
+func objectARN(bucketName string, key string) string {
+	if isBucketARN(bucketName) {
+		return bucketName + "/object/" + key
+	}
+	return "arn:aws:s3:::" + bucketName + "/" + key
+}
+
+// accessPointSegments returns how many slash separated segments an access point ARN has: two for
+// accesspoint/name, or four for outpost/id/accesspoint/name.
+func accessPointSegments(bucketName string) int {
+	if strings.Contains(bucketName, ":outpost/") {
+		return 4
+	}
+	return 2
+}
+
+// SplitBucketPath splits a path such as bucket/data/a.csv into a bucket name and a key. If the path starts with
+// an access point ARN, which contains slashes itself, the whole ARN is returned as the bucket name.
+func SplitBucketPath(path string) (bucketName string, key string) {
+	if !isBucketARN(path) {
+		bucketName, key, _ = strings.Cut(path, "/")
+		return bucketName, key
+	}
+
+	segments := strings.SplitN(path, "/", accessPointSegments(path)+1)
+	if len(segments) <= accessPointSegments(path) {
+		return path, ""
+	}
+
+	return strings.Join(segments[:len(segments)-1], "/"), segments[len(segments)-1]
+}
+
+// virtualHostedClients caches the copies of path-style clients made for access points and directory buckets.
+var virtualHostedClients sync.Map
+
+// virtualHostedClient returns a client for an access point or directory bucket. Neither can be addressed
+// path-style, so a client for an endpoint that is has a virtual-hosted copy made once and reused.
+func virtualHostedClient(client *s3.Client) *s3.Client {
+	if !client.Options().UsePathStyle {
+		return client
+	}
+
+	if cached, ok := virtualHostedClients.Load(client); ok {
+		return cached.(*s3.Client)
+	}
+
+	virtualHosted := s3.New(client.Options(), func(o *s3.Options) {
+		o.UsePathStyle = false
+	})
+
+	cached, _ := virtualHostedClients.LoadOrStore(client, virtualHosted)
+	return cached.(*s3.Client)
+}
@@ -0,0 +1,78 @@
+package boto3manager
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	testAccessPoint = "arn:aws:s3:us-west-2:123456789012:accesspoint/lab-readers"
+	testOutpostsAP  = "arn:aws:s3-outposts:us-west-2:123456789012:outpost/op-01ac5d28a6a232904/accesspoint/lab-readers"
+)
+
+func TestSplitBucketPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path         string
+		wantedBucket string
+		wantedKey    string
+	}{
+		{path: "lab/data/a.csv", wantedBucket: "lab", wantedKey: "data/a.csv"},
+		{path: "lab", wantedBucket: "lab", wantedKey: ""},
+		{path: testAccessPoint + "/data/a.csv", wantedBucket: testAccessPoint, wantedKey: "data/a.csv"},
+		{path: testAccessPoint, wantedBucket: testAccessPoint, wantedKey: ""},
+		{path: testOutpostsAP + "/a.csv", wantedBucket: testOutpostsAP, wantedKey: "a.csv"},
+		{path: "arn:aws:s3::123456789012:accesspoint/mfzwi23gnjvgw.mrap/x/y", wantedBucket: "arn:aws:s3::123456789012:accesspoint/mfzwi23gnjvgw.mrap", wantedKey: "x/y"},
+	}
+
+	for _, test := range tests {
+		if bucketName, key := SplitBucketPath(test.path); bucketName != test.wantedBucket || key != test.wantedKey {
+			t.Errorf("SplitBucketPath(%v) = %v, %v, want %v, %v", test.path, bucketName, key, test.wantedBucket, test.wantedKey)
+		}
+	}
+}
+
+func TestAccessPointARNs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		got    string
+		wanted string
+	}{
+		{name: "bucket ARN", got: bucketARN("lab"), wanted: "arn:aws:s3:::lab"},
+		{name: "access point ARN", got: bucketARN(testAccessPoint), wanted: testAccessPoint},
+		{name: "object ARN", got: objectARN("lab", "data/*"), wanted: "arn:aws:s3:::lab/data/*"},
+		{name: "access point object ARN", got: objectARN(testAccessPoint, "data/*"), wanted: testAccessPoint + "/object/data/*"},
+		{name: "copy source", got: copySource("lab", "a b.csv"), wanted: "lab/a%20b.csv"},
+		{name: "access point copy source", got: copySource(testAccessPoint, "a b.csv"), wanted: testAccessPoint + "/object/a%20b.csv"},
+	}
+
+	for _, test := range tests {
+		if test.got != test.wanted {
+			t.Errorf("%v = %v, want %v", test.name, test.got, test.wanted)
+		}
+	}
+}
+
+func TestAccessPointClient(t *testing.T) {
+	t.Parallel()
+
+	pathStyle := s3.New(s3.Options{Region: "us-west-2", BaseEndpoint: aws.String("https://s3.example.com"), UsePathStyle: true})
+	basics := BucketBasics{S3Client: pathStyle}
+
+	if basics.client("lab") != pathStyle {
+		t.Errorf("client(lab) isn't S3Client")
+	}
+
+	client := basics.client(testAccessPoint)
+	if client.Options().UsePathStyle {
+		t.Errorf("client(%v) addresses the access point path-style", testAccessPoint)
+	}
+	if basics.client(testAccessPoint) != client {
+		t.Errorf("client(%v) made a new client on each call", testAccessPoint)
+	}
+}
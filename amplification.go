@@ -0,0 +1,89 @@
+package boto3manager
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+)
+
+// RequestWarningThreshold is the number of S3 requests above which batch operations log a warning before they
+// start, to catch options that quietly turn a job into millions of requests. Zero disables the warning.
+var RequestWarningThreshold int64 = 100_000
+
+// requestEstimate counts the S3 requests an operation is expected to make, not counting retries.
+type requestEstimate struct {
+	lists int64
+	heads int64
+	gets  int64
+	puts  int64
+}
+
+// total returns the total number of requests.
+func (estimate requestEstimate) total() int64 {
+	return estimate.lists + estimate.heads + estimate.gets + estimate.puts
+}
+
+func (estimate requestEstimate) String() string {
+	return fmt.Sprintf("%v requests (%v lists, %v HEADs, %v GETs, %v PUTs)", estimate.total(), estimate.lists, estimate.heads, estimate.gets, estimate.puts)
+}
+
+// addUpload counts the requests to upload an object of a size in parts of partSize: a single PUT if it fits in one
+// part, or creating the upload, each part, and completing it otherwise.
+func (estimate *requestEstimate) addUpload(size int64, partSize int64) {
+	if size <= partSize {
+		estimate.puts++
+		return
+	}
+
+	estimate.puts += 2 + partCount(size, partSize)
+}
+
+// addDownload counts the requests to download an object of a size in ranges of partSize.
+func (estimate *requestEstimate) addDownload(size int64, partSize int64) {
+	estimate.gets += max(1, partCount(size, partSize))
+}
+
+// partCount returns the number of parts of partSize needed to hold size bytes.
+func partCount(size int64, partSize int64) int64 {
+	return (size + partSize - 1) / partSize
+}
+
+// uploadRequests estimates the requests UploadObjects makes for the given files.
+func uploadRequests(uploads []FileUpload, options UploadObjectsOptions) requestEstimate {
+	partSize := int64(manager.DefaultUploadPartSize)
+	if options.VerifyMD5 || options.Retention != nil || options.LegalHold {
+		partSize = verifiedPartSize
+	}
+
+	var estimate requestEstimate
+	for _, upload := range uploads {
+		estimate.addUpload(upload.Size, partSize)
+	}
+
+	if options.ChecksumsFile != "" {
+		estimate.puts++
+	}
+	if options.SuccessMarker {
+		estimate.puts++
+	}
+
+	return estimate
+}
+
+// downloadRequests estimates the requests to download the given objects.
+func downloadRequests(downloads []FileDownload) requestEstimate {
+	var estimate requestEstimate
+	for _, download := range downloads {
+		estimate.addDownload(download.Size, manager.DefaultDownloadPartSize)
+	}
+
+	return estimate
+}
+
+// warnRequests logs a warning if an operation is expected to make more requests than RequestWarningThreshold.
+func warnRequests(operation string, estimate requestEstimate) {
+	if RequestWarningThreshold > 0 && estimate.total() > RequestWarningThreshold {
+		log.Printf("Warning: %v will make about %v, more than the warning threshold of %v", operation, estimate, RequestWarningThreshold)
+	}
+}
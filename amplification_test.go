@@ -0,0 +1,61 @@
+package boto3manager
+
+import "testing"
+
+func TestUploadRequests(t *testing.T) {
+	t.Parallel()
+
+	const mib = 1024 * 1024
+
+	uploads := []FileUpload{
+		{Path: "small", Size: 1},
+		{Path: "empty", Size: 0},
+		{Path: "large", Size: 20 * mib},
+	}
+
+	tests := []struct {
+		name    string
+		options UploadObjectsOptions
+		wanted  requestEstimate
+	}{
+		{
+			name:   "default parts",
+			wanted: requestEstimate{puts: 2 + 2 + 4},
+		},
+		{
+			name:    "verified parts",
+			options: UploadObjectsOptions{VerifyMD5: true},
+			wanted:  requestEstimate{puts: 2 + 2 + 2},
+		},
+		{
+			name:    "checksums and marker",
+			options: UploadObjectsOptions{ChecksumsFile: "MD5SUMS", SuccessMarker: true},
+			wanted:  requestEstimate{puts: 2 + 2 + 4 + 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := uploadRequests(uploads, tt.options); got != tt.wanted {
+				t.Errorf("uploadRequests(%+v) = %v, want %v", tt.options, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestDownloadRequests(t *testing.T) {
+	t.Parallel()
+
+	downloads := []FileDownload{
+		{Key: "empty", Size: 0},
+		{Key: "small", Size: 1},
+		{Key: "large", Size: 11 * 1024 * 1024},
+	}
+
+	wanted := requestEstimate{gets: 1 + 1 + 3}
+	if got := downloadRequests(downloads); got != wanted {
+		t.Errorf("downloadRequests() = %v, want %v", got, wanted)
+	}
+}
@@ -0,0 +1,177 @@
+package boto3manager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/schollz/progressbar/v3"
+)
+
+// ArchiveFormat is the format DownloadToArchive writes.
+type ArchiveFormat int
+
+const (
+	ArchiveTar ArchiveFormat = iota
+	ArchiveZip
+)
+
+type ArchiveOptions struct {
+	// RetryPolicy controls retries of opening each object. Once an object has started streaming into the archive
+	// it can't be retried, so a failure part way through fails the whole archive. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+}
+
+// archiveWriter adds files to a tar or zip archive.
+type archiveWriter interface {
+	create(name string, size int64, modified time.Time) (io.Writer, error)
+	Close() error
+}
+
+type tarArchive struct {
+	*tar.Writer
+}
+
+func (archive tarArchive) create(name string, size int64, modified time.Time) (io.Writer, error) {
+	header := &tar.Header{Name: name, Size: size, Mode: 0o644, ModTime: modified, Typeflag: tar.TypeReg}
+	if err := archive.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	return archive.Writer, nil
+}
+
+type zipArchive struct {
+	*zip.Writer
+}
+
+func (archive zipArchive) create(name string, size int64, modified time.Time) (io.Writer, error) {
+	return archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+}
+
+// newArchiveWriter returns a writer for the format writing to w.
+func newArchiveWriter(w io.Writer, format ArchiveFormat) (archiveWriter, error) {
+	switch format {
+	case ArchiveTar:
+		return tarArchive{tar.NewWriter(w)}, nil
+	case ArchiveZip:
+		return zipArchive{zip.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive format %v", format)
+	}
+}
+
+// archiveName returns the name of an object in an archive of a pattern: its key relative to the folder the
+// pattern's literal prefix is in, so downloading "data/run1/*" gives entries named like "run1/a.csv".
+func archiveName(pattern string, key string) string {
+	base := literalPrefix(pattern)
+	base = strings.TrimSuffix(base, "/")
+	if i := strings.LastIndex(base, "/"); i > -1 {
+		return strings.TrimPrefix(key, base[:i+1])
+	}
+	return key
+}
+
+// DownloadToArchive takes a glob pattern for objects, a bucket name, a writer, and a format and streams every
+// matching object into a tar or zip archive written to w, without storing anything locally, so a web service can
+// offer a folder of the bucket as a single download. Objects are written one at a time in key order. Entries are
+// named by their key relative to the folder the pattern's literal part is in.
+func (basics BucketBasics) DownloadToArchive(pattern string, bucketName string, w io.Writer, format ArchiveFormat, options ArchiveOptions) (*TransferReport, error) {
+	return basics.DownloadToArchiveWithContext(context.Background(), pattern, bucketName, w, format, options)
+}
+
+// DownloadToArchiveWithContext is DownloadToArchive with a context.
+func (basics BucketBasics) DownloadToArchiveWithContext(ctx context.Context, pattern string, bucketName string, w io.Writer, format ArchiveFormat, options ArchiveOptions) (*TransferReport, error) {
+	archive, err := newArchiveWriter(w, format)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := basics.matchObjects(ctx, pattern, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	bar := newBytesBar(totalObjectSize(matches), "archiving")
+	policy := retryPolicy(options.RetryPolicy)
+
+	report := &TransferReport{}
+	report.start()
+
+	for _, object := range matches {
+		key := aws.ToString(object.Key)
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+
+		started := time.Now()
+
+		var body io.ReadCloser
+		attempts, err := policy.do(ctx, func() error {
+			output, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+				Bucket: aws.String(bucketName),
+				Key:    aws.String(key),
+			})
+			if err != nil {
+				return err
+			}
+
+			body = output.Body
+			return nil
+		})
+
+		result := TransferResult{Key: key, Size: aws.ToInt64(object.Size), Attempts: attempts}
+		if err != nil {
+			log.Printf("Couldn't get object %v: %v", key, err)
+			result.Err = classifyError(err)
+			result.Duration = time.Since(started)
+			report.record(result)
+			continue
+		}
+
+		err = writeArchiveEntry(archive, archiveName(pattern, key), result.Size, aws.ToTime(object.LastModified), body, bar)
+		body.Close()
+
+		result.Duration = time.Since(started)
+		if err != nil {
+			// The archive now holds part of the object, so nothing more can be added to it
+			log.Printf("Couldn't add object %v to archive: %v", key, err)
+			result.Err = classifyError(err)
+			report.record(result)
+			report.finish()
+			return report, err
+		}
+
+		report.record(result)
+	}
+
+	report.finish()
+
+	if err := archive.Close(); err != nil {
+		log.Printf("Couldn't finish archive: %v", err)
+		return report, err
+	}
+
+	return report, nil
+}
+
+// writeArchiveEntry adds an entry to the archive and copies its contents from r.
+func writeArchiveEntry(archive archiveWriter, name string, size int64, modified time.Time, r io.Reader, bar *progressbar.ProgressBar) error {
+	entry, err := archive.create(name, size, modified)
+	if err != nil {
+		return err
+	}
+
+	if bar != nil {
+		entry = io.MultiWriter(entry, bar)
+	}
+
+	_, err = io.Copy(entry, r)
+	return err
+}
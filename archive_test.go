@@ -0,0 +1,195 @@
+package boto3manager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestArchiveName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		key     string
+		wanted  string
+	}{
+		{pattern: "data/run1/*", key: "data/run1/a.csv", wanted: "run1/a.csv"},
+		{pattern: "data/run*/*.csv", key: "data/run2/b.csv", wanted: "run2/b.csv"},
+		{pattern: "data/run1", key: "data/run1/a.csv", wanted: "run1/a.csv"},
+		{pattern: "*.csv", key: "a.csv", wanted: "a.csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			t.Parallel()
+
+			if got := archiveName(tt.pattern, tt.key); got != tt.wanted {
+				t.Errorf("archiveName(%v, %v) = %v, want %v", tt.pattern, tt.key, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestWriteArchiveEntry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		format ArchiveFormat
+		read   func(t *testing.T, data []byte) (string, []byte)
+	}{
+		{
+			name:   "tar",
+			format: ArchiveTar,
+			read: func(t *testing.T, data []byte) (string, []byte) {
+				r := tar.NewReader(bytes.NewReader(data))
+				header, err := r.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				contents, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return header.Name, contents
+			},
+		},
+		{
+			name:   "zip",
+			format: ArchiveZip,
+			read: func(t *testing.T, data []byte) (string, []byte) {
+				r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				f, err := r.File[0].Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				contents, err := io.ReadAll(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return r.File[0].Name, contents
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			archive, err := newArchiveWriter(&buf, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contents := []byte("hello, archive")
+			if err := writeArchiveEntry(archive, "run1/a.txt", int64(len(contents)), time.Now(), bytes.NewReader(contents), nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := archive.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			name, got := tt.read(t, buf.Bytes())
+			if name != "run1/a.txt" || !bytes.Equal(got, contents) {
+				t.Errorf("archive entry = %v %q, want run1/a.txt %q", name, got, contents)
+			}
+		})
+	}
+}
+
+// archiveEntry is an entry read back from an archive.
+type archiveEntry struct {
+	name string
+	body string
+}
+
+// readArchive returns the entries of a tar or zip archive in order.
+func readArchive(t *testing.T, data []byte, format ArchiveFormat) []archiveEntry {
+	t.Helper()
+
+	entries := make([]archiveEntry, 0)
+	if format == ArchiveTar {
+		r := tar.NewReader(bytes.NewReader(data))
+		for {
+			header, err := r.Next()
+			if err == io.EOF {
+				return entries
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, archiveEntry{name: header.Name, body: string(body)})
+		}
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range r.File {
+		f, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, archiveEntry{name: file.Name, body: string(body)})
+	}
+
+	return entries
+}
+
+func TestDownloadToArchive(t *testing.T) {
+	t.Parallel()
+
+	wanted := []archiveEntry{
+		{name: "run1/a.csv", body: "a,1\n"},
+		{name: "run1/b.csv", body: "b,2\n"},
+		{name: "run1/sub/c.csv", body: "c,3\n"},
+	}
+
+	for _, format := range []ArchiveFormat{ArchiveTar, ArchiveZip} {
+		t.Run(fmt.Sprint(format), func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			fake.put("bucket", "data/run1/sub/c.csv", "c,3\n", nil)
+			fake.put("bucket", "data/run1/b.csv", "b,2\n", nil)
+			fake.put("bucket", "data/run1/a.csv", "a,1\n", nil)
+			fake.put("bucket", "data/run1/sub/", "", nil)
+			fake.put("bucket", "data/run2/d.csv", "d,4\n", nil)
+
+			var archive bytes.Buffer
+			report, err := fake.basics().DownloadToArchive("data/run1/**/*", "bucket", &archive, format, ArchiveOptions{})
+			if err != nil {
+				t.Fatalf("DownloadToArchive() = %v, want nil", err)
+			}
+			if len(report.Failed()) > 0 {
+				t.Errorf("DownloadToArchive() failed %v", report.Failed())
+			}
+
+			if got := readArchive(t, archive.Bytes(), format); !reflect.DeepEqual(got, wanted) {
+				t.Errorf("DownloadToArchive() wrote %v, want %v", got, wanted)
+			}
+		})
+	}
+}
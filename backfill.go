@@ -0,0 +1,298 @@
+package boto3manager
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ChecksumMetadataKey is the user metadata key (x-amz-meta-sha256) holding an object's hex encoded SHA-256 checksum.
+const ChecksumMetadataKey = "sha256"
+
+// BackfillMode is where back-filled checksums are recorded.
+type BackfillMode int
+
+const (
+	// BackfillMetadata copies each object onto itself with the checksum added to its metadata
+	BackfillMetadata BackfillMode = iota
+	// BackfillSidecar records checksums in a sha256sum-style index object in the prefix, leaving objects untouched
+	BackfillSidecar
+)
+
+type BackfillOptions struct {
+	Mode BackfillMode
+
+	// SidecarName is the name of the index object for BackfillSidecar. Defaults to "SHA256SUMS"
+	SidecarName string
+
+	// RetryPolicy controls retries of each object. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+}
+
+// BackfillChecksums takes a prefix, a bucket name, and options and computes SHA-256 checksums for the objects under
+// the prefix that don't have one recorded yet, streaming each object once. The checksums are stored in object metadata
+// or a sidecar index so objects can be verified later without downloading them again.
+func (basics BucketBasics) BackfillChecksums(prefix string, bucketName string, options BackfillOptions) (*TransferReport, error) {
+	ctx := context.TODO()
+
+	sidecarName := options.SidecarName
+	if sidecarName == "" {
+		sidecarName = "SHA256SUMS"
+	}
+	sidecarKey := prefix + sidecarName
+
+	// Load checksums that were already recorded in the sidecar
+	sums := make(map[string]string)
+	if options.Mode == BackfillSidecar {
+		existing, err := basics.readSumsFile(ctx, sidecarKey, bucketName)
+		if err != nil {
+			return nil, err
+		}
+		sums = existing
+	}
+
+	// Find the objects that still need a checksum
+	pending := make([]FileDownload, 0)
+	var totalSize int64
+	for object, err := range basics.listObjectsSeq(ctx, bucketName, prefix) {
+		if err != nil {
+			return nil, err
+		}
+
+		key := aws.ToString(object.Key)
+		if key == sidecarKey {
+			continue
+		}
+
+		if _, ok := sums[strings.TrimPrefix(key, prefix)]; ok {
+			continue
+		}
+
+		pending = append(pending, FileDownload{Key: key, Size: aws.ToInt64(object.Size)})
+		totalSize += aws.ToInt64(object.Size)
+	}
+
+	// Make a progress bar
+	bar := newBytesBar(totalSize, "hashing")
+
+	var mu sync.Mutex
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  25,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		backpressure: basics.Backpressure,
+	}
+
+	err := runBatch(ctx, pending, config, func(ctx context.Context, file FileDownload) error {
+		if options.Mode == BackfillSidecar {
+			sum, err := basics.sha256Object(ctx, file.Key, bucketName)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			sums[strings.TrimPrefix(file.Key, prefix)] = sum
+			mu.Unlock()
+		} else if err := basics.backfillMetadata(ctx, file.Key, bucketName); err != nil {
+			return err
+		}
+
+		bar.Add64(file.Size)
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	if options.Mode == BackfillSidecar {
+		err = basics.writeSumsFile(ctx, sidecarKey, bucketName, sums)
+	}
+
+	return report, err
+}
+
+// backfillMetadata adds a checksum to an object's metadata unless it already has one.
+func (basics BucketBasics) backfillMetadata(ctx context.Context, key string, bucketName string) error {
+	head, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get object %v: %v", key, err)
+		return err
+	}
+
+	if head.Metadata[ChecksumMetadataKey] != "" {
+		return nil
+	}
+
+	sum, err := basics.sha256Object(ctx, key, bucketName)
+
+	if err != nil {
+		return err
+	}
+
+	metadata := maps.Clone(head.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata[ChecksumMetadataKey] = sum
+
+	return basics.replaceMetadata(ctx, key, bucketName, head, metadata)
+}
+
+// replaceMetadata copies an object onto itself with new user metadata, keeping its other headers and storage class.
+// The copy only goes ahead if the object still has the ETag in head, so an object overwritten since it was read
+// isn't given stale headers. Objects too large for CopyObject are copied with a multipart copy.
+func (basics BucketBasics) replaceMetadata(ctx context.Context, key string, bucketName string, head *s3.HeadObjectOutput, metadata map[string]string) error {
+	if aws.ToInt64(head.ContentLength) > maxCopyPartSize {
+		return basics.replaceMetadataMultipart(ctx, key, bucketName, head, metadata)
+	}
+
+	_, err := basics.client(bucketName).CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:                  aws.String(bucketName),
+		Key:                     aws.String(key),
+		CopySource:              aws.String(copySource(bucketName, key)),
+		CopySourceIfMatch:       head.ETag,
+		MetadataDirective:       types.MetadataDirectiveReplace,
+		Metadata:                metadata,
+		ContentType:             head.ContentType,
+		ContentEncoding:         head.ContentEncoding,
+		ContentDisposition:      head.ContentDisposition,
+		ContentLanguage:         head.ContentLanguage,
+		CacheControl:            head.CacheControl,
+		Expires:                 head.Expires,
+		WebsiteRedirectLocation: head.WebsiteRedirectLocation,
+		StorageClass:            head.StorageClass,
+		ServerSideEncryption:    head.ServerSideEncryption,
+		SSEKMSKeyId:             head.SSEKMSKeyId,
+	})
+
+	if err != nil {
+		log.Printf("Couldn't update metadata of %v: %v", key, err)
+	}
+
+	return err
+}
+
+// replaceMetadataMultipart is replaceMetadata for objects too large for CopyObject, copying the object onto itself
+// in parts.
+func (basics BucketBasics) replaceMetadataMultipart(ctx context.Context, key string, bucketName string, head *s3.HeadObjectOutput, metadata map[string]string) error {
+	splice, err := basics.startRewrite(ctx, key, bucketName, head, metadata)
+	if err != nil {
+		return err
+	}
+
+	if err := splice.copyRange(bucketName, key, 0, aws.ToInt64(head.ContentLength)); err != nil {
+		splice.abort()
+		return err
+	}
+
+	if err := splice.complete(); err != nil {
+		splice.abort()
+		return err
+	}
+
+	return nil
+}
+
+// sha256Object streams an object and returns its hex encoded SHA-256 checksum.
+func (basics BucketBasics) sha256Object(ctx context.Context, key string, bucketName string) (string, error) {
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get object %v: %v", key, err)
+		return "", err
+	}
+
+	defer obj.Body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, obj.Body); err != nil {
+		log.Printf("Couldn't read object %v: %v", key, err)
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readSumsFile reads a sha256sum-style index object into a map from path to checksum. A missing index is empty.
+func (basics BucketBasics) readSumsFile(ctx context.Context, key string, bucketName string) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		if isNotFound(err) {
+			return sums, nil
+		}
+		log.Printf("Couldn't get checksums file %v: %v", key, err)
+		return nil, err
+	}
+
+	defer obj.Body.Close()
+
+	return parseSums(obj.Body)
+}
+
+// parseSums parses lines of "<checksum>  <path>" into a map from path to checksum.
+func parseSums(r io.Reader) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		sum, path, ok := strings.Cut(scanner.Text(), "  ")
+		if ok {
+			sums[path] = sum
+		}
+	}
+
+	return sums, scanner.Err()
+}
+
+// formatSums formats a map from path to checksum as sha256sum-style lines, sorted by path.
+func formatSums(sums map[string]string) string {
+	var b strings.Builder
+	for _, path := range slices.Sorted(maps.Keys(sums)) {
+		fmt.Fprintf(&b, "%v  %v\n", sums[path], path)
+	}
+
+	return b.String()
+}
+
+// writeSumsFile writes a map from path to checksum as a sha256sum-style index object, sorted by path.
+func (basics BucketBasics) writeSumsFile(ctx context.Context, key string, bucketName string, sums map[string]string) error {
+	_, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		Body:        strings.NewReader(formatSums(sums)),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't upload checksums file %v to bucket %v: %v", key, bucketName, err)
+	}
+
+	return err
+}
@@ -0,0 +1,115 @@
+package boto3manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// sha256Hex returns the hex encoded SHA-256 checksum of s.
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseSums(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		text   string
+		wanted map[string]string
+	}{
+		{name: "empty", text: "", wanted: map[string]string{}},
+		{name: "paths", text: "aaa  a.csv\nbbb  dir/b c.csv\n", wanted: map[string]string{"a.csv": "aaa", "dir/b c.csv": "bbb"}},
+		{name: "malformed lines", text: "aaa a.csv\n\nbbb  b.csv", wanted: map[string]string{"b.csv": "bbb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseSums(strings.NewReader(tt.text))
+			if err != nil || !maps.Equal(got, tt.wanted) {
+				t.Errorf("parseSums(%q) = %v, %v, want %v", tt.text, got, err, tt.wanted)
+			}
+
+			// Formatting and parsing again gives the same checksums
+			if again, err := parseSums(strings.NewReader(formatSums(got))); err != nil || !maps.Equal(again, got) {
+				t.Errorf("parseSums(formatSums(%v)) = %v, %v", got, again, err)
+			}
+		})
+	}
+}
+
+func TestBackfillChecksumsMetadata(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "set/a.csv", "alpha", http.Header{"Content-Type": {"text/csv"}})
+	fake.put("data", "set/b.csv", "beta", http.Header{"X-Amz-Meta-Sha256": {sha256Hex("beta")}})
+	fake.put("data", "other/c.csv", "gamma", nil)
+
+	report, err := fake.basics().BackfillChecksums("set/", "data", BackfillOptions{})
+	if err != nil {
+		t.Fatalf("BackfillChecksums() = %v, want nil", err)
+	}
+	if got := len(report.Succeeded()); got != 2 {
+		t.Errorf("BackfillChecksums() succeeded %v objects, want 2", got)
+	}
+
+	tests := []struct {
+		key    string
+		wanted string
+	}{
+		{key: "set/a.csv", wanted: sha256Hex("alpha")},
+		{key: "set/b.csv", wanted: sha256Hex("beta")},
+		{key: "other/c.csv", wanted: ""},
+	}
+
+	for _, tt := range tests {
+		object, _ := fake.object("data", tt.key)
+		if got := object.header.Get("X-Amz-Meta-Sha256"); got != tt.wanted {
+			t.Errorf("BackfillChecksums() recorded %v for %v, want %q", got, tt.key, tt.wanted)
+		}
+	}
+
+	// The checksum is added without losing the object's headers, and objects that have one are left alone
+	if object, _ := fake.object("data", "set/a.csv"); object.header.Get("Content-Type") != "text/csv" || string(object.body) != "alpha" {
+		t.Errorf("BackfillChecksums() rewrote set/a.csv as %q with %v", object.body, object.header)
+	}
+	if got := fake.count("PUT data/set/b.csv"); got != 0 {
+		t.Errorf("BackfillChecksums() copied set/b.csv %v times, want 0", got)
+	}
+}
+
+func TestBackfillChecksumsSidecar(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "set/a.csv", "alpha", nil)
+	fake.put("data", "set/nested/b.csv", "beta", nil)
+	fake.put("data", "set/SUMS", "recorded  a.csv\n", nil)
+
+	_, err := fake.basics().BackfillChecksums("set/", "data", BackfillOptions{Mode: BackfillSidecar, SidecarName: "SUMS"})
+	if err != nil {
+		t.Fatalf("BackfillChecksums() = %v, want nil", err)
+	}
+
+	// The recorded checksum is kept rather than computed again, and the new one added
+	object, _ := fake.object("data", "set/SUMS")
+	wanted := "recorded  a.csv\n" + sha256Hex("beta") + "  nested/b.csv\n"
+	if got := string(object.body); got != wanted {
+		t.Errorf("BackfillChecksums() wrote the sidecar %q, want %q", got, wanted)
+	}
+
+	if got := fake.count("GET data/set/a.csv"); got != 0 {
+		t.Errorf("BackfillChecksums() read set/a.csv %v times, want 0", got)
+	}
+	if object, _ := fake.object("data", "set/nested/b.csv"); object.header.Get("X-Amz-Meta-Sha256") != "" {
+		t.Errorf("BackfillChecksums() with a sidecar changed the metadata of set/nested/b.csv")
+	}
+}
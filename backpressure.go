@@ -0,0 +1,209 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// BackpressureOptions tunes how batch operations slow down when the server says it is overloaded. When a transfer
+// fails with SlowDown or a similar response, every worker of the batch pauses, not just the one whose request
+// failed, and the number of workers allowed to run is halved. The pause doubles while the server keeps refusing and
+// workers are restored one at a time as transfers succeed again, so a batch settles at a rate the gateway can take
+// instead of retrying into it at full concurrency.
+type BackpressureOptions struct {
+	// Disabled turns off backpressure, leaving only the retries of the batch's RetryPolicy and the SDK
+	Disabled bool
+
+	// MinWorkers is the fewest workers a batch is reduced to. Defaults to 1
+	MinWorkers int
+
+	// InitialPause is how long every worker pauses after the first refusal. Defaults to 1 second
+	InitialPause time.Duration
+
+	// MaxPause caps the pause. Defaults to 1 minute
+	MaxPause time.Duration
+
+	// RecoverAfter is how many transfers must succeed in a row to restore a worker. Defaults to 10
+	RecoverAfter int
+}
+
+// slowDownCodes are error codes servers use to ask clients to send fewer requests.
+var slowDownCodes = map[string]bool{
+	"SlowDown":               true,
+	"RequestLimitExceeded":   true,
+	"Throttling":             true,
+	"ThrottlingException":    true,
+	"TooManyRequests":        true,
+	"ServiceUnavailable":     true,
+	"RequestThrottled":       true,
+	"BandwidthLimitExceeded": true,
+}
+
+// isSlowDown reports whether an error means the server is overloaded: a throttling error code, a 503 or 429
+// response, or the SDK running out of retry quota because too many requests failed.
+func isSlowDown(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && slowDownCodes[apiErr.ErrorCode()] {
+		return true
+	}
+
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) && (respErr.HTTPStatusCode() == 503 || respErr.HTTPStatusCode() == 429) {
+		return true
+	}
+
+	var quotaErr ratelimit.QuotaExceededError
+	return errors.As(err, &quotaErr)
+}
+
+// backpressure is shared by the workers of a batch to pause them all and limit how many run when the server is
+// overloaded.
+type backpressure struct {
+	options BackpressureOptions
+
+	mu sync.Mutex
+
+	// limit is how many workers may run; workers numbered limit and above wait
+	limit int
+	max   int
+
+	// pause is the current pause, and pausedUntil when it ends
+	pause       time.Duration
+	pausedUntil time.Time
+
+	// successes counts the transfers that have succeeded since the last refusal or restored worker
+	successes int
+
+	// drained is set once the batch has no items left to hand out, lifting the limit
+	drained bool
+
+	// changed is closed and replaced whenever the limit rises or is lifted, to wake waiting workers
+	changed chan struct{}
+}
+
+// newBackpressure returns the backpressure for a batch of workerCount workers.
+func newBackpressure(options BackpressureOptions, workerCount int) *backpressure {
+	if options.MinWorkers <= 0 {
+		options.MinWorkers = 1
+	}
+	if options.InitialPause <= 0 {
+		options.InitialPause = time.Second
+	}
+	if options.MaxPause <= 0 {
+		options.MaxPause = time.Minute
+	}
+	if options.RecoverAfter <= 0 {
+		options.RecoverAfter = 10
+	}
+
+	return &backpressure{
+		options: options,
+		limit:   workerCount,
+		max:     workerCount,
+		changed: make(chan struct{}),
+	}
+}
+
+// wait blocks a worker while the batch is paused or the worker is above the limit, returning the context's error if
+// it finishes first. Once the batch is drained only the pause is waited for, since the limit can only rise as
+// transfers succeed and there may be none left to do so.
+func (b *backpressure) wait(ctx context.Context, worker int) error {
+	if b.options.Disabled {
+		return nil
+	}
+
+	for {
+		b.mu.Lock()
+		remaining := time.Until(b.pausedUntil)
+		allowed := worker < b.limit || b.drained
+		changed := b.changed
+		b.mu.Unlock()
+
+		switch {
+		case remaining > 0:
+			select {
+			case <-time.After(remaining):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case !allowed:
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// observe updates the backpressure with the outcome of a transfer attempt.
+func (b *backpressure) observe(err error) {
+	if b.options.Disabled {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if isSlowDown(err) {
+		b.successes = 0
+
+		// The workers refused during a pause were all sent before it, so only the first counts
+		if time.Now().Before(b.pausedUntil) {
+			return
+		}
+
+		b.pause = min(max(b.pause*2, b.options.InitialPause), b.options.MaxPause)
+		b.pausedUntil = time.Now().Add(b.pause)
+		b.limit = max(b.limit/2, min(b.options.MinWorkers, b.max))
+
+		log.Printf("Server asked to slow down; pausing %v and running %v of %v workers", b.pause, b.limit, b.max)
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	b.successes++
+	if b.successes < b.options.RecoverAfter {
+		return
+	}
+	b.successes = 0
+
+	// Recover gradually: halve the next pause and let one more worker run
+	b.pause /= 2
+	if b.limit < b.max {
+		b.limit++
+		b.wake()
+	}
+}
+
+// drain lifts the limit once the batch has handed out its last item, so the workers still holding items finish them
+// and the workers waiting for one find the queue closed.
+func (b *backpressure) drain() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.drained = true
+	b.wake()
+}
+
+// wake wakes every waiting worker to check the limit again. b.mu must be held.
+func (b *backpressure) wake() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
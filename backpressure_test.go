@@ -0,0 +1,136 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func TestIsSlowDown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		err    error
+		wanted bool
+	}{
+		{name: "nil", err: nil, wanted: false},
+		{name: "SlowDown", err: &smithy.GenericAPIError{Code: "SlowDown"}, wanted: true},
+		{name: "RequestLimitExceeded", err: &smithy.GenericAPIError{Code: "RequestLimitExceeded"}, wanted: true},
+		{name: "wrapped SlowDown", err: classifyError(&smithy.GenericAPIError{Code: "SlowDown"}), wanted: true},
+		{name: "503 without a body", err: &smithyhttp.ResponseError{Response: &smithyhttp.Response{Response: &http.Response{StatusCode: 503}}, Err: errors.New("unavailable")}, wanted: true},
+		{name: "retry quota", err: ratelimit.QuotaExceededError{Available: 0, Requested: 5}, wanted: true},
+		{name: "missing key", err: &smithy.GenericAPIError{Code: "NoSuchKey"}, wanted: false},
+		{name: "local error", err: errors.New("disk full"), wanted: false},
+	}
+
+	for _, test := range tests {
+		if got := isSlowDown(test.err); got != test.wanted {
+			t.Errorf("%v: isSlowDown(%v) = %v, want %v", test.name, test.err, got, test.wanted)
+		}
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	t.Parallel()
+
+	b := newBackpressure(BackpressureOptions{InitialPause: time.Millisecond, RecoverAfter: 2}, 8)
+	slowDown := &smithy.GenericAPIError{Code: "SlowDown"}
+
+	// A refusal pauses everyone and halves the workers; refusals during the pause don't count again
+	b.observe(slowDown)
+	b.observe(slowDown)
+	if b.limit != 4 {
+		t.Errorf("limit after a refusal = %v, want 4", b.limit)
+	}
+
+	// Workers above the limit wait until it rises
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx, 5); err == nil {
+		t.Errorf("wait(5) with a limit of 4 = nil, want the context's error")
+	}
+
+	if err := b.wait(context.Background(), 0); err != nil {
+		t.Errorf("wait(0) = %v, want nil", err)
+	}
+
+	// Successes restore one worker at a time, waking the waiters
+	woken := make(chan error)
+	go func() { woken <- b.wait(context.Background(), 4) }()
+
+	b.observe(nil)
+	b.observe(nil)
+	if err := <-woken; err != nil {
+		t.Errorf("wait(4) after recovering = %v, want nil", err)
+	}
+	if b.limit != 5 {
+		t.Errorf("limit after recovering = %v, want 5", b.limit)
+	}
+
+	// The limit never drops below MinWorkers
+	for range 5 {
+		time.Sleep(b.pause + time.Millisecond)
+		b.observe(slowDown)
+	}
+	if b.limit != 1 {
+		t.Errorf("limit after repeated refusals = %v, want 1", b.limit)
+	}
+}
+
+func TestRunBatchBackpressureFinishes(t *testing.T) {
+	t.Parallel()
+
+	// Every worker takes an item and is refused together, so the workers above the reduced limit hold items that
+	// only finish if the batch lets them go once nothing is left to restore the limit
+	items := []FileUpload{{Key: "a"}, {Key: "b"}, {Key: "c"}, {Key: "d"}}
+	config := batchConfig{
+		workerCount:  4,
+		policy:       RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		report:       &TransferReport{},
+		backpressure: BackpressureOptions{InitialPause: time.Millisecond},
+	}
+
+	var started sync.WaitGroup
+	started.Add(len(items))
+
+	var mu sync.Mutex
+	refused := make(map[string]bool)
+
+	done := make(chan error)
+	go func() {
+		done <- runBatch(context.Background(), items, config, func(ctx context.Context, item FileUpload) error {
+			mu.Lock()
+			first := !refused[item.Key]
+			refused[item.Key] = true
+			mu.Unlock()
+
+			if first {
+				started.Done()
+				started.Wait()
+				return &smithy.GenericAPIError{Code: "SlowDown"}
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("runBatch() = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runBatch() after a SlowDown never returned")
+	}
+
+	if succeeded := config.report.Succeeded(); len(succeeded) != len(items) {
+		t.Errorf("runBatch() succeeded %v items, want %v", len(succeeded), len(items))
+	}
+}
@@ -0,0 +1,237 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// BackupTimePlaceholder is replaced with the time of the run in a backup's prefix template.
+const BackupTimePlaceholder = "{time}"
+
+// BackupTimeFormat is the layout of the time in a backup's prefix. It sorts in time order and contains no "/".
+const BackupTimeFormat = "2006-01-02T15:04:05Z"
+
+// BackupCompleteMarker is the name of the object Backup writes into a backup's prefix once every file is uploaded.
+// PruneBackups only counts backups that have one. It isn't _SUCCESS, which a backed up directory may already hold.
+const BackupCompleteMarker = "_BACKUP_COMPLETE"
+
+type BackupOptions struct {
+	// RetryPolicy controls retries of each file. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Hooks, if set, are called as files start and finish
+	Hooks *Hooks
+}
+
+// backupTemplate returns the parts of a prefix template before and after the time. A template without the
+// placeholder gets the time as a folder at its end. The time must be followed by a "/" so each backup is a folder.
+func backupTemplate(prefixTemplate string) (string, string, error) {
+	if !strings.Contains(prefixTemplate, BackupTimePlaceholder) {
+		prefixTemplate += BackupTimePlaceholder + "/"
+	}
+
+	before, after, _ := strings.Cut(prefixTemplate, BackupTimePlaceholder)
+	if !strings.Contains(after, "/") {
+		return "", "", fmt.Errorf("backup prefix template %q needs a \"/\" after %v", prefixTemplate, BackupTimePlaceholder)
+	}
+
+	return before, after, nil
+}
+
+// Backup takes a local directory, a prefix template, and a bucket name and uploads every file in the directory
+// under a new prefix for this run, made by replacing "{time}" in the template with the current UTC time, for
+// example "backups/{time}/" becomes "backups/2024-06-01T02:00:00Z/". A template without "{time}" gets the time
+// as a folder at its end. Once every file is uploaded a BackupCompleteMarker is written into the prefix. It returns
+// the prefix written to. Old backups can be expired with PruneBackups.
+func (basics BucketBasics) Backup(localDir string, prefixTemplate string, bucketName string, options BackupOptions) (string, *TransferReport, error) {
+	return basics.BackupWithContext(context.Background(), localDir, prefixTemplate, bucketName, options)
+}
+
+// BackupWithContext is Backup with a context.
+func (basics BucketBasics) BackupWithContext(ctx context.Context, localDir string, prefixTemplate string, bucketName string, options BackupOptions) (string, *TransferReport, error) {
+	before, after, err := backupTemplate(prefixTemplate)
+	if err != nil {
+		return "", nil, err
+	}
+
+	prefix := before + time.Now().UTC().Format(BackupTimeFormat) + after
+
+	report, err := basics.SyncUpWithContext(ctx, localDir, prefix, bucketName, SyncOptions{
+		Skip:        SkipNothing,
+		RetryPolicy: options.RetryPolicy,
+		Hooks:       options.Hooks,
+	})
+	if err != nil || len(report.Failed()) > 0 {
+		return prefix, report, err
+	}
+
+	return prefix, report, basics.writeBackupMarker(ctx, prefix, bucketName)
+}
+
+// writeBackupMarker writes the BackupCompleteMarker into a backup's prefix, holding the time it was finished.
+func (basics BucketBasics) writeBackupMarker(ctx context.Context, prefix string, bucketName string) error {
+	key := prefix + BackupCompleteMarker
+
+	_, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		Body:        strings.NewReader(time.Now().UTC().Format(BackupTimeFormat) + "\n"),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't write backup marker %v to bucket %v: %v", key, bucketName, err)
+	}
+
+	return classifyError(err)
+}
+
+// backupComplete reports whether the backup in prefix has its BackupCompleteMarker.
+func (basics BucketBasics) backupComplete(ctx context.Context, prefix string, bucketName string) (bool, error) {
+	_, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(prefix + BackupCompleteMarker),
+	})
+
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		log.Printf("Couldn't check backup %v: %v", prefix, err)
+		return false, classifyError(err)
+	}
+
+	return true, nil
+}
+
+// backupSnapshot is one backup found under a template's base prefix.
+type backupSnapshot struct {
+	prefix string
+	time   time.Time
+}
+
+// backupsToPrune returns the prefixes of the snapshots that are neither among the newest keepLast nor younger than
+// keepDays days, oldest first. A zero keepLast or keepDays doesn't keep anything on its own.
+func backupsToPrune(snapshots []backupSnapshot, now time.Time, keepLast int, keepDays int) []string {
+	sorted := slices.Clone(snapshots)
+	slices.SortFunc(sorted, func(a, b backupSnapshot) int {
+		return b.time.Compare(a.time)
+	})
+
+	cutoff := now.AddDate(0, 0, -keepDays)
+
+	prune := make([]string, 0)
+	for i, snapshot := range sorted {
+		if i < keepLast || (keepDays > 0 && snapshot.time.After(cutoff)) {
+			continue
+		}
+		prune = append(prune, snapshot.prefix)
+	}
+
+	slices.Reverse(prune)
+	return prune
+}
+
+// backupTime returns the time of the backup in a folder listed under before, the part of a prefix template before
+// {time}, if the folder was written with the template. The rest of the folder's name must match after, the part
+// following {time}, up to its first "/", so "{time}-nightly/" and "{time}-weekly/" backups are told apart.
+func backupTime(prefix string, before string, after string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(prefix, before)
+	if !ok || len(rest) < len(BackupTimeFormat) {
+		return time.Time{}, false
+	}
+
+	suffix, _, _ := strings.Cut(after, "/")
+	if rest[len(BackupTimeFormat):] != suffix+"/" {
+		return time.Time{}, false
+	}
+
+	stamp, err := time.Parse(BackupTimeFormat, rest[:len(BackupTimeFormat)])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return stamp, true
+}
+
+// PruneBackups takes a prefix template and a bucket name and deletes the backups written by Backup with that
+// template, except the newest keepLast and any made in the last keepDays days. At least one of keepLast and keepDays
+// must be set. Only finished backups, those with a BackupCompleteMarker, are counted; backups from failed runs or
+// runs still going are left alone, so they can't push out the last good one. It returns the prefixes of the backups
+// deleted.
+func (basics BucketBasics) PruneBackups(prefixTemplate string, bucketName string, keepLast int, keepDays int) ([]string, error) {
+	return basics.PruneBackupsWithContext(context.Background(), prefixTemplate, bucketName, keepLast, keepDays)
+}
+
+// PruneBackupsWithContext is PruneBackups with a context.
+func (basics BucketBasics) PruneBackupsWithContext(ctx context.Context, prefixTemplate string, bucketName string, keepLast int, keepDays int) ([]string, error) {
+	if keepLast <= 0 && keepDays <= 0 {
+		return nil, errors.New("pruning backups needs keepLast or keepDays, or it would delete them all")
+	}
+
+	before, after, err := backupTemplate(prefixTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each backup is a folder directly under the part of the template before the time
+	params := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucketName),
+		Prefix:    aws.String(before),
+		Delimiter: aws.String("/"),
+	}
+
+	snapshots := make([]backupSnapshot, 0)
+	for page, err := range basics.listPages(ctx, params) {
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commonPrefix := range page.CommonPrefixes {
+			prefix := aws.ToString(commonPrefix.Prefix)
+
+			// Folders that aren't backups, such as ones made by hand or with another template, are left alone
+			stamp, ok := backupTime(prefix, before, after)
+			if !ok {
+				continue
+			}
+
+			complete, err := basics.backupComplete(ctx, before+stamp.Format(BackupTimeFormat)+after, bucketName)
+			if err != nil {
+				return nil, err
+			}
+			if !complete {
+				continue
+			}
+
+			snapshots = append(snapshots, backupSnapshot{prefix: prefix, time: stamp})
+		}
+	}
+
+	prune := backupsToPrune(snapshots, time.Now().UTC(), keepLast, keepDays)
+
+	for _, prefix := range prune {
+		keys := make([]string, 0)
+		for object, err := range basics.listObjectsSeq(ctx, bucketName, prefix) {
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, aws.ToString(object.Key))
+		}
+
+		if err := basics.deleteKeys(ctx, bucketName, keys); err != nil {
+			log.Printf("Couldn't prune backup %v: %v", prefix, err)
+			return nil, err
+		}
+	}
+
+	return prune, nil
+}
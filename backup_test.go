@@ -0,0 +1,207 @@
+package boto3manager
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBackupTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		template     string
+		wantedBefore string
+		wantedAfter  string
+		wantedErr    bool
+	}{
+		{template: "backups/{time}/", wantedBefore: "backups/", wantedAfter: "/"},
+		{template: "backups/", wantedBefore: "backups/", wantedAfter: "/"},
+		{template: "lab/{time}/home/", wantedBefore: "lab/", wantedAfter: "/home/"},
+		{template: "backups/{time}", wantedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			t.Parallel()
+
+			before, after, err := backupTemplate(tt.template)
+			if (err != nil) != tt.wantedErr {
+				t.Fatalf("backupTemplate(%v) error = %v, want error %v", tt.template, err, tt.wantedErr)
+			}
+			if before != tt.wantedBefore || after != tt.wantedAfter {
+				t.Errorf("backupTemplate(%v) = %q, %q, want %q, %q", tt.template, before, after, tt.wantedBefore, tt.wantedAfter)
+			}
+		})
+	}
+}
+
+func TestBackupsToPrune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	snapshots := make([]backupSnapshot, 0)
+	for day := 1; day <= 9; day++ {
+		stamp := time.Date(2024, 6, day, 2, 0, 0, 0, time.UTC)
+		snapshots = append(snapshots, backupSnapshot{prefix: stamp.Format(BackupTimeFormat), time: stamp})
+	}
+
+	tests := []struct {
+		name     string
+		keepLast int
+		keepDays int
+		wanted   []string
+	}{
+		{
+			name:     "keep last",
+			keepLast: 7,
+			wanted:   []string{"2024-06-01T02:00:00Z", "2024-06-02T02:00:00Z"},
+		},
+		{
+			name:     "keep days",
+			keepDays: 3,
+			wanted:   []string{"2024-06-01T02:00:00Z", "2024-06-02T02:00:00Z", "2024-06-03T02:00:00Z", "2024-06-04T02:00:00Z", "2024-06-05T02:00:00Z", "2024-06-06T02:00:00Z"},
+		},
+		{
+			name:     "either",
+			keepLast: 2,
+			keepDays: 5,
+			wanted:   []string{"2024-06-01T02:00:00Z", "2024-06-02T02:00:00Z", "2024-06-03T02:00:00Z", "2024-06-04T02:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := backupsToPrune(snapshots, now, tt.keepLast, tt.keepDays); !slices.Equal(got, tt.wanted) {
+				t.Errorf("backupsToPrune(%v, %v) = %v, want %v", tt.keepLast, tt.keepDays, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestBackupTime(t *testing.T) {
+	t.Parallel()
+
+	stamp := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		prefix string
+		before string
+		after  string
+		wanted bool
+	}{
+		{prefix: "backups/2024-06-01T02:00:00Z/", before: "backups/", after: "/", wanted: true},
+		{prefix: "lab/2024-06-01T02:00:00Z/", before: "lab/", after: "/home/", wanted: true},
+		{prefix: "backups/2024-06-01T02:00:00Z-nightly/", before: "backups/", after: "-nightly/", wanted: true},
+		{prefix: "backups/2024-06-01T02:00:00Z-weekly/", before: "backups/", after: "-nightly/", wanted: false},
+		{prefix: "backups/2024-06-01T02:00:00Z-nightly/", before: "backups/", after: "/", wanted: false},
+		{prefix: "backups/2024-06-01T02:00:00Z/", before: "backups/", after: "-nightly/", wanted: false},
+		{prefix: "backups/manual/", before: "backups/", after: "/", wanted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix+" "+tt.after, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := backupTime(tt.prefix, tt.before, tt.after)
+			if ok != tt.wanted || (ok && !got.Equal(stamp)) {
+				t.Errorf("backupTime(%q, %q, %q) = %v, %v, want %v", tt.prefix, tt.before, tt.after, got, ok, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestPruneBackupsByTemplate(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "backups")
+	for _, day := range []string{"01", "02", "03"} {
+		fake.put("backups", "lab/2024-06-"+day+"T02:00:00Z-nightly/a.csv", "a", nil)
+		fake.put("backups", "lab/2024-06-"+day+"T02:00:00Z-nightly/"+BackupCompleteMarker, "", nil)
+		fake.put("backups", "lab/2024-06-"+day+"T02:00:00Z-weekly/a.csv", "a", nil)
+		fake.put("backups", "lab/2024-06-"+day+"T02:00:00Z-weekly/"+BackupCompleteMarker, "", nil)
+	}
+
+	pruned, err := fake.basics().PruneBackups("lab/{time}-nightly/", "backups", 1, 0)
+	if err != nil {
+		t.Fatalf("PruneBackups() = %v, want nil", err)
+	}
+
+	wanted := []string{"lab/2024-06-01T02:00:00Z-nightly/", "lab/2024-06-02T02:00:00Z-nightly/"}
+	if !slices.Equal(pruned, wanted) {
+		t.Errorf("PruneBackups() = %v, want %v", pruned, wanted)
+	}
+
+	// The weekly backups are another template's, so they are all kept
+	for _, day := range []string{"01", "02", "03"} {
+		if _, ok := fake.object("backups", "lab/2024-06-"+day+"T02:00:00Z-weekly/a.csv"); !ok {
+			t.Errorf("PruneBackups(\"lab/{time}-nightly/\") deleted the weekly backup of June %v", day)
+		}
+	}
+}
+
+func TestPruneBackupsSkipsUnfinished(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "backups")
+	fake.put("backups", "backups/2024-06-01T02:00:00Z/home/a.csv", "a", nil)
+	fake.put("backups", "backups/2024-06-01T02:00:00Z/home/"+BackupCompleteMarker, "", nil)
+	fake.put("backups", "backups/2024-06-02T02:00:00Z/home/a.csv", "a", nil)
+	fake.put("backups", "backups/2024-06-02T02:00:00Z/home/"+BackupCompleteMarker, "", nil)
+
+	// The latest run failed halfway, so it has no marker
+	fake.put("backups", "backups/2024-06-03T02:00:00Z/home/a.csv", "a", nil)
+
+	pruned, err := fake.basics().PruneBackups("backups/{time}/home/", "backups", 1, 0)
+	if err != nil {
+		t.Fatalf("PruneBackups() = %v, want nil", err)
+	}
+
+	if wanted := []string{"backups/2024-06-01T02:00:00Z/"}; !slices.Equal(pruned, wanted) {
+		t.Errorf("PruneBackups() = %v, want %v", pruned, wanted)
+	}
+	if _, ok := fake.object("backups", "backups/2024-06-02T02:00:00Z/home/a.csv"); !ok {
+		t.Errorf("PruneBackups() deleted the last finished backup")
+	}
+	if _, ok := fake.object("backups", "backups/2024-06-03T02:00:00Z/home/a.csv"); !ok {
+		t.Errorf("PruneBackups() deleted the unfinished backup")
+	}
+}
+
+func TestBackupWritesMarker(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "backups")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.csv"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prefix, _, err := fake.basics().Backup(dir, "backups/{time}/", "backups", BackupOptions{})
+	if err != nil {
+		t.Fatalf("Backup() = %v, want nil", err)
+	}
+
+	wanted := []string{prefix + BackupCompleteMarker, prefix + "a.csv"}
+	if got := fake.keys("backups"); !slices.Equal(got, wanted) {
+		t.Errorf("Backup() wrote %v, want %v", got, wanted)
+	}
+
+	// A run whose files fail leaves no marker
+	fake.fail = func(r *http.Request) int {
+		if r.Method == http.MethodPut {
+			return http.StatusForbidden
+		}
+		return 0
+	}
+	prefix, _, _ = fake.basics().Backup(dir, "failed/{time}/", "backups", BackupOptions{RetryPolicy: &RetryPolicy{MaxAttempts: 1}})
+	if _, ok := fake.object("backups", prefix+BackupCompleteMarker); ok {
+		t.Errorf("Backup() with failed uploads wrote %v", prefix+BackupCompleteMarker)
+	}
+}
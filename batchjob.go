@@ -0,0 +1,264 @@
+package boto3manager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3control"
+	controltypes "github.com/aws/aws-sdk-go-v2/service/s3control/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// batchManifestPrefix is where CreateBatchJob uploads manifests when no key is given.
+const batchManifestPrefix = ".s3m-batch/"
+
+// BatchOperation is what an S3 Batch Operations job does to each object.
+type BatchOperation int
+
+const (
+	// BatchCopy copies each object to DestinationBucket under DestinationPrefix
+	BatchCopy BatchOperation = iota
+	// BatchTag replaces each object's tags with Tags
+	BatchTag
+	// BatchRestore restores each archived object for RestoreDays
+	BatchRestore
+)
+
+type BatchJobOptions struct {
+	// RoleARN is the ARN of an IAM role S3 Batch Operations can assume to read the manifest and operate on the
+	// objects. It is required
+	RoleARN string
+
+	// AccountID is the ID of the account the job runs in. If empty, it is looked up from the credentials
+	AccountID string
+
+	// ManifestKey is the key in the bucket the manifest of matched objects is uploaded to. If empty, a key under
+	// .s3m-batch/ named for the time is used
+	ManifestKey string
+
+	// DestinationBucket and DestinationPrefix are where BatchCopy copies objects to
+	DestinationBucket string
+	DestinationPrefix string
+
+	// StorageClass, if set, is the storage class of BatchCopy's copies
+	StorageClass string
+
+	// Tags are the tags BatchTag sets
+	Tags map[string]string
+
+	// RestoreDays is how long BatchRestore's restored copies are kept. Defaults to 1
+	RestoreDays int32
+
+	// RestoreBulk restores with the Bulk tier, which is slower but cheaper than the Standard tier
+	RestoreBulk bool
+
+	// ReportPrefix, if set, has S3 write a completion report of every task under it in the bucket
+	ReportPrefix string
+
+	// Priority orders the account's jobs; higher runs first. Defaults to 10
+	Priority int32
+
+	// ConfirmationRequired holds the job until it is confirmed in the console, to check the manifest first
+	ConfirmationRequired bool
+
+	Description string
+}
+
+// batchJobOperation returns the SDK's representation of an operation with its options.
+func batchJobOperation(operation BatchOperation, options BatchJobOptions) (*controltypes.JobOperation, error) {
+	switch operation {
+	case BatchCopy:
+		if options.DestinationBucket == "" {
+			return nil, errors.New("a batch copy needs a destination bucket")
+		}
+
+		copyOperation := &controltypes.S3CopyObjectOperation{
+			TargetResource: aws.String(bucketARN(options.DestinationBucket)),
+			StorageClass:   controltypes.S3StorageClass(options.StorageClass),
+		}
+		if options.DestinationPrefix != "" {
+			copyOperation.TargetKeyPrefix = aws.String(options.DestinationPrefix)
+		}
+
+		return &controltypes.JobOperation{S3PutObjectCopy: copyOperation}, nil
+	case BatchTag:
+		tags := make([]controltypes.S3Tag, 0, len(options.Tags))
+		for _, key := range slices.Sorted(maps.Keys(options.Tags)) {
+			tags = append(tags, controltypes.S3Tag{Key: aws.String(key), Value: aws.String(options.Tags[key])})
+		}
+
+		return &controltypes.JobOperation{S3PutObjectTagging: &controltypes.S3SetObjectTaggingOperation{TagSet: tags}}, nil
+	case BatchRestore:
+		days := options.RestoreDays
+		if days <= 0 {
+			days = 1
+		}
+
+		tier := controltypes.S3GlacierJobTierStandard
+		if options.RestoreBulk {
+			tier = controltypes.S3GlacierJobTierBulk
+		}
+
+		return &controltypes.JobOperation{S3InitiateRestoreObject: &controltypes.S3InitiateRestoreObjectOperation{ExpirationInDays: aws.Int32(days), GlacierJobTier: tier}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported batch operation %v", operation)
+	}
+}
+
+// batchManifest returns a Batch Operations CSV manifest of keys in a bucket. Keys are URL encoded, as the format
+// requires.
+func batchManifest(bucketName string, keys []string) string {
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%v,%v\n", bucketName, url.QueryEscape(key))
+	}
+
+	return b.String()
+}
+
+// controlClient returns an S3 Control client with the region, credentials, and HTTP client of a bucket's client.
+func (basics BucketBasics) controlClient(bucketName string) *s3control.Client {
+	options := basics.client(bucketName).Options()
+
+	return s3control.New(s3control.Options{
+		Region:      options.Region,
+		Credentials: options.Credentials,
+		HTTPClient:  options.HTTPClient,
+	})
+}
+
+// accountID looks up the ID of the account a bucket's client's credentials belong to.
+func (basics BucketBasics) accountID(ctx context.Context, bucketName string) (string, error) {
+	options := basics.client(bucketName).Options()
+
+	identity, err := sts.New(sts.Options{
+		Region:      options.Region,
+		Credentials: options.Credentials,
+		HTTPClient:  options.HTTPClient,
+	}).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+
+	if err != nil {
+		log.Printf("Couldn't look up the account ID: %v", err)
+		return "", err
+	}
+
+	return aws.ToString(identity.Account), nil
+}
+
+// CreateBatchJob takes a pattern, a bucket name, an operation, and options and submits an S3 Batch Operations job
+// running the operation on every object matching the pattern, so S3 does the work instead of a client-side loop over
+// hundreds of millions of objects. The manifest of matched objects is uploaded to the bucket first. The job's ID is
+// returned; follow its progress in the console or with the S3 Control API.
+func (basics BucketBasics) CreateBatchJob(pattern string, bucketName string, operation BatchOperation, options BatchJobOptions) (string, error) {
+	ctx := context.TODO()
+
+	if options.RoleARN == "" {
+		return "", errors.New("a batch job needs a role ARN")
+	}
+
+	jobOperation, err := batchJobOperation(operation, options)
+	if err != nil {
+		return "", err
+	}
+
+	accountID := options.AccountID
+	if accountID == "" {
+		if accountID, err = basics.accountID(ctx, bucketName); err != nil {
+			return "", err
+		}
+	}
+
+	// Write the manifest of matched objects
+	matches, err := basics.matchObjects(ctx, pattern, bucketName)
+	if err != nil {
+		return "", err
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no objects match %v", pattern)
+	}
+
+	keys := make([]string, 0, len(matches))
+	for _, object := range matches {
+		keys = append(keys, aws.ToString(object.Key))
+	}
+
+	manifestKey := options.ManifestKey
+	if manifestKey == "" {
+		manifestKey = batchManifestPrefix + time.Now().UTC().Format(trashStamp) + ".csv"
+	}
+
+	manifest, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(manifestKey),
+		Body:        strings.NewReader(batchManifest(bucketName, keys)),
+		ContentType: aws.String("text/csv"),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't upload batch manifest %v to bucket %v: %v", manifestKey, bucketName, err)
+		return "", classifyError(err)
+	}
+
+	report := &controltypes.JobReport{Enabled: false}
+	if options.ReportPrefix != "" {
+		report = &controltypes.JobReport{
+			Enabled:     true,
+			Bucket:      aws.String(bucketARN(bucketName)),
+			Prefix:      aws.String(options.ReportPrefix),
+			Format:      controltypes.JobReportFormatReportCsv20180820,
+			ReportScope: controltypes.JobReportScopeAllTasks,
+		}
+	}
+
+	priority := options.Priority
+	if priority == 0 {
+		priority = 10
+	}
+
+	token := make([]byte, 16)
+	rand.Read(token)
+
+	input := &s3control.CreateJobInput{
+		AccountId:            aws.String(accountID),
+		ClientRequestToken:   aws.String(hex.EncodeToString(token)),
+		Operation:            jobOperation,
+		Priority:             aws.Int32(priority),
+		Report:               report,
+		RoleArn:              aws.String(options.RoleARN),
+		ConfirmationRequired: aws.Bool(options.ConfirmationRequired),
+		Manifest: &controltypes.JobManifest{
+			Spec: &controltypes.JobManifestSpec{
+				Format: controltypes.JobManifestFormatS3BatchOperationsCsv20180820,
+				Fields: []controltypes.JobManifestFieldName{controltypes.JobManifestFieldNameBucket, controltypes.JobManifestFieldNameKey},
+			},
+			Location: &controltypes.JobManifestLocation{
+				ObjectArn: aws.String(objectARN(bucketName, manifestKey)),
+				ETag:      manifest.ETag,
+			},
+		},
+	}
+
+	if options.Description != "" {
+		input.Description = aws.String(options.Description)
+	}
+
+	job, err := basics.controlClient(bucketName).CreateJob(ctx, input)
+	if err != nil {
+		log.Printf("Couldn't create batch job for %v: %v", pattern, err)
+		return "", err
+	}
+
+	return aws.ToString(job.JobId), nil
+}
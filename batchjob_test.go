@@ -0,0 +1,170 @@
+package boto3manager
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	controltypes "github.com/aws/aws-sdk-go-v2/service/s3control/types"
+)
+
+func TestBatchManifest(t *testing.T) {
+	t.Parallel()
+
+	got := batchManifest("lab", []string{"data/a.csv", "data/b c+d.csv"})
+	if wanted := "lab,data%2Fa.csv\nlab,data%2Fb+c%2Bd.csv\n"; got != wanted {
+		t.Errorf("batchManifest() = %q, want %q", got, wanted)
+	}
+}
+
+func TestBatchJobOperation(t *testing.T) {
+	t.Parallel()
+
+	copyOperation, err := batchJobOperation(BatchCopy, BatchJobOptions{DestinationBucket: "backup", DestinationPrefix: "2024/"})
+	if err != nil || copyOperation.S3PutObjectCopy == nil || aws.ToString(copyOperation.S3PutObjectCopy.TargetResource) != "arn:aws:s3:::backup" || aws.ToString(copyOperation.S3PutObjectCopy.TargetKeyPrefix) != "2024/" {
+		t.Errorf("batchJobOperation(BatchCopy) = %+v, %v, want a copy to arn:aws:s3:::backup under 2024/", copyOperation, err)
+	}
+
+	if _, err := batchJobOperation(BatchCopy, BatchJobOptions{}); err == nil {
+		t.Errorf("batchJobOperation(BatchCopy) without a destination returned no error")
+	}
+
+	tagOperation, err := batchJobOperation(BatchTag, BatchJobOptions{Tags: map[string]string{"project": "kelp", "class": "raw"}})
+	if err != nil || tagOperation.S3PutObjectTagging == nil || len(tagOperation.S3PutObjectTagging.TagSet) != 2 || aws.ToString(tagOperation.S3PutObjectTagging.TagSet[0].Key) != "class" {
+		t.Errorf("batchJobOperation(BatchTag) = %+v, %v, want 2 tags in key order", tagOperation, err)
+	}
+
+	restoreOperation, err := batchJobOperation(BatchRestore, BatchJobOptions{RestoreBulk: true})
+	if err != nil || restoreOperation.S3InitiateRestoreObject == nil || aws.ToInt32(restoreOperation.S3InitiateRestoreObject.ExpirationInDays) != 1 || restoreOperation.S3InitiateRestoreObject.GlacierJobTier != controltypes.S3GlacierJobTierBulk {
+		t.Errorf("batchJobOperation(BatchRestore) = %+v, %v, want a 1 day bulk restore", restoreOperation, err)
+	}
+}
+
+// stubControl answers S3 Control's CreateJob and STS's GetCallerIdentity in place of AWS, sending every other
+// request on to the fake S3.
+type stubControl struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (stub *stubControl) RoundTrip(r *http.Request) (*http.Response, error) {
+	var response string
+	switch {
+	case strings.Contains(r.URL.Host, "s3-control."):
+		response = "<CreateJobResult><JobId>job-1</JobId></CreateJobResult>"
+	case strings.HasPrefix(r.URL.Host, "sts."):
+		response = "<GetCallerIdentityResponse><GetCallerIdentityResult><Account>123456789012</Account></GetCallerIdentityResult></GetCallerIdentityResponse>"
+	default:
+		return http.DefaultTransport.RoundTrip(r)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	stub.mu.Lock()
+	stub.requests = append(stub.requests, r)
+	stub.bodies = append(stub.bodies, string(body))
+	stub.mu.Unlock()
+
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "text/xml")
+	recorder.WriteString(response)
+	return recorder.Result(), nil
+}
+
+func TestCreateBatchJob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		accountID string
+		wantedSTS int
+	}{
+		{name: "account given", accountID: "210987654321", wantedSTS: 0},
+		{name: "account looked up", accountID: "", wantedSTS: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			fake.put("bucket", "data/a.csv", "a", nil)
+			fake.put("bucket", "data/b c.csv", "b", nil)
+			fake.put("bucket", "data/skip.txt", "c", nil)
+
+			stub := &stubControl{}
+			client := s3.New(fake.client().Options(), func(o *s3.Options) {
+				o.HTTPClient = &http.Client{Transport: stub}
+			})
+
+			options := BatchJobOptions{
+				RoleARN:     "arn:aws:iam::123456789012:role/batch",
+				AccountID:   tt.accountID,
+				ManifestKey: "manifests/tag.csv",
+				Tags:        map[string]string{"project": "kelp"},
+			}
+			id, err := BucketBasics{S3Client: client}.CreateBatchJob("data/*.csv", "bucket", BatchTag, options)
+			if err != nil {
+				t.Fatalf("CreateBatchJob() = %v, want nil", err)
+			}
+			if id != "job-1" {
+				t.Errorf("CreateBatchJob() = %q, want %q", id, "job-1")
+			}
+
+			manifest, ok := fake.object("bucket", "manifests/tag.csv")
+			if wanted := batchManifest("bucket", []string{"data/a.csv", "data/b c.csv"}); !ok || string(manifest.body) != wanted {
+				t.Errorf("CreateBatchJob() wrote manifest %q, want %q", manifest.body, wanted)
+			}
+
+			var sts, jobs int
+			accountID := tt.accountID
+			if accountID == "" {
+				accountID = "123456789012"
+			}
+			for i, r := range stub.requests {
+				if strings.HasPrefix(r.URL.Host, "sts.") {
+					sts++
+					continue
+				}
+
+				jobs++
+				if !strings.HasPrefix(r.URL.Host, accountID+".") {
+					t.Errorf("CreateBatchJob() created the job in %v, want account %v", r.URL.Host, accountID)
+				}
+				for _, wanted := range []string{options.RoleARN, objectARN("bucket", "manifests/tag.csv"), strings.Trim(manifest.etag, `"`), "kelp"} {
+					if !strings.Contains(stub.bodies[i], wanted) {
+						t.Errorf("CreateBatchJob() sent a job without %v: %v", wanted, stub.bodies[i])
+					}
+				}
+			}
+
+			if sts != tt.wantedSTS || jobs != 1 {
+				t.Errorf("CreateBatchJob() sent %v GetCallerIdentity and %v CreateJob requests, want %v and 1", sts, jobs, tt.wantedSTS)
+			}
+		})
+	}
+}
+
+func TestCreateBatchJobNoMatches(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "data/skip.txt", "c", nil)
+
+	options := BatchJobOptions{RoleARN: "arn:aws:iam::123456789012:role/batch", AccountID: "123456789012"}
+	if _, err := fake.basics().CreateBatchJob("data/*.csv", "bucket", BatchTag, options); err == nil {
+		t.Errorf("CreateBatchJob() with no matching objects returned no error")
+	}
+	if keys := fake.keys("bucket"); len(keys) != 1 {
+		t.Errorf("CreateBatchJob() with no matching objects left %v, want no manifest", keys)
+	}
+}
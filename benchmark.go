@@ -0,0 +1,260 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type BenchmarkOptions struct {
+	// Sizes are the object sizes to test. Defaults to 64 KiB, 1 MiB, 8 MiB, and 32 MiB
+	Sizes []int64
+
+	// Concurrency are the numbers of transfers at once to test. Defaults to 1, 4, 16, and 64
+	Concurrency []int
+
+	// Objects is how many objects each run transfers. Defaults to 16, or the concurrency if that is higher
+	Objects int
+
+	// Quiet turns off the line printed as each run finishes
+	Quiet bool
+}
+
+// BenchmarkRun is the measurement of one operation at one object size and concurrency.
+type BenchmarkRun struct {
+	Operation   string
+	Size        int64
+	Concurrency int
+	Objects     int
+	Elapsed     time.Duration
+
+	// Throughput is bytes per second across all the transfers
+	Throughput float64
+
+	// P50, P90, and P99 are percentiles of the time each request took
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+}
+
+func (run BenchmarkRun) String() string {
+	return fmt.Sprintf("%-8v %10v x%-3d %8.1f MB/s  p50 %v  p90 %v  p99 %v", run.Operation, formatSize(run.Size), run.Concurrency,
+		run.Throughput/1e6, run.P50.Round(time.Millisecond), run.P90.Round(time.Millisecond), run.P99.Round(time.Millisecond))
+}
+
+// BenchmarkResult is every run of a benchmark and the settings it suggests.
+type BenchmarkResult struct {
+	Runs []BenchmarkRun
+
+	// PartSize is the suggested multipart part size: the tested size with the best upload throughput, at least the
+	// smallest part S3 allows
+	PartSize int64
+
+	// Workers is the suggested number of transfers at once: the lowest tested concurrency within 10% of the best
+	// upload throughput at PartSize
+	Workers int
+}
+
+func (result BenchmarkResult) String() string {
+	var b strings.Builder
+	for _, run := range result.Runs {
+		b.WriteString(run.String())
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "suggested part size %v, %v workers", formatSize(result.PartSize), result.Workers)
+	return b.String()
+}
+
+// formatSize returns a size in whole KiB or MiB where it is one.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1024*1024 && n%(1024*1024) == 0:
+		return fmt.Sprintf("%d MiB", n/(1024*1024))
+	case n >= 1024 && n%1024 == 0:
+		return fmt.Sprintf("%d KiB", n/1024)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// percentile returns the p-th percentile, from 0 to 100, of the durations by the nearest-rank method.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	rank := int(float64(len(sorted))*p/100+0.5) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// suggestSettings picks the part size and worker count a benchmark's upload runs suggest.
+func suggestSettings(runs []BenchmarkRun) (int64, int) {
+	var best BenchmarkRun
+	for _, run := range runs {
+		if run.Operation == "upload" && run.Throughput > best.Throughput {
+			best = run
+		}
+	}
+
+	if best.Throughput == 0 {
+		return minPartSize, 1
+	}
+
+	workers := best.Concurrency
+	for _, run := range runs {
+		if run.Operation == "upload" && run.Size == best.Size && run.Concurrency < workers && run.Throughput >= 0.9*best.Throughput {
+			workers = run.Concurrency
+		}
+	}
+
+	return max(best.Size, minPartSize), workers
+}
+
+// Benchmark takes a bucket name and options and uploads and downloads synthetic objects of each size at each
+// concurrency, measuring throughput and request latency and suggesting a part size and worker count for the
+// endpoint. The objects are written under .s3m-probe/ and deleted afterwards. Each size is uploaded in single
+// requests, the same as one multipart part of that size.
+func (basics BucketBasics) Benchmark(bucketName string, options BenchmarkOptions) (BenchmarkResult, error) {
+	ctx := context.TODO()
+	options.Quiet = defaultQuiet(options.Quiet)
+
+	sizes := options.Sizes
+	if len(sizes) == 0 {
+		sizes = []int64{64 * 1024, 1024 * 1024, 8 * 1024 * 1024, 32 * 1024 * 1024}
+	}
+
+	concurrency := options.Concurrency
+	if len(concurrency) == 0 {
+		concurrency = []int{1, 4, 16, 64}
+	}
+
+	data := make([]byte, slices.Max(sizes))
+	rand.Read(data)
+
+	prefix := fmt.Sprintf("%vbench-%v/", probePrefix, time.Now().UnixNano())
+	var keys []string
+	defer func() {
+		basics.deleteKeys(context.Background(), bucketName, keys)
+	}()
+
+	var result BenchmarkResult
+	for _, size := range sizes {
+		for _, workers := range concurrency {
+			objects := max(options.Objects, workers)
+			if options.Objects == 0 {
+				objects = max(16, workers)
+			}
+
+			runKeys := make([]string, objects)
+			for i := range runKeys {
+				runKeys[i] = fmt.Sprintf("%v%d-%d-%d", prefix, size, workers, i)
+			}
+			keys = append(keys, runKeys...)
+
+			upload, err := benchmarkRun("upload", size, workers, runKeys, func(key string) error {
+				_, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+					Bucket: aws.String(bucketName),
+					Key:    aws.String(key),
+					Body:   bytes.NewReader(data[:size]),
+				})
+				return err
+			})
+			if err != nil {
+				log.Printf("Couldn't benchmark uploads to bucket %v: %v", bucketName, err)
+				return result, err
+			}
+
+			download, err := benchmarkRun("download", size, workers, runKeys, func(key string) error {
+				obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+					Bucket: aws.String(bucketName),
+					Key:    aws.String(key),
+				})
+				if err != nil {
+					return err
+				}
+				defer obj.Body.Close()
+
+				_, err = io.Copy(io.Discard, obj.Body)
+				return err
+			})
+			if err != nil {
+				log.Printf("Couldn't benchmark downloads from bucket %v: %v", bucketName, err)
+				return result, err
+			}
+
+			result.Runs = append(result.Runs, upload, download)
+			if !options.Quiet {
+				fmt.Println(upload)
+				fmt.Println(download)
+			}
+		}
+	}
+
+	result.PartSize, result.Workers = suggestSettings(result.Runs)
+
+	return result, nil
+}
+
+// benchmarkRun runs fn for every key with the given number of workers and measures the run.
+func benchmarkRun(operation string, size int64, workers int, keys []string, fn func(key string) error) (BenchmarkRun, error) {
+	queue := make(chan string)
+	latencies := make([]time.Duration, 0, len(keys))
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for key := range queue {
+				started := time.Now()
+				err := fn(key)
+				elapsed := time.Since(started)
+
+				mu.Lock()
+				latencies = append(latencies, elapsed)
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, key := range keys {
+		queue <- key
+	}
+	close(queue)
+	wg.Wait()
+
+	run := BenchmarkRun{
+		Operation:   operation,
+		Size:        size,
+		Concurrency: workers,
+		Objects:     len(keys),
+		Elapsed:     time.Since(start),
+		P50:         percentile(latencies, 50),
+		P90:         percentile(latencies, 90),
+		P99:         percentile(latencies, 99),
+	}
+	run.Throughput = float64(size) * float64(len(keys)) / run.Elapsed.Seconds()
+
+	return run, firstErr
+}
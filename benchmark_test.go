@@ -0,0 +1,87 @@
+package boto3manager
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	durations := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		p      float64
+		wanted time.Duration
+	}{
+		{p: 50, wanted: 50 * time.Millisecond},
+		{p: 90, wanted: 90 * time.Millisecond},
+		{p: 99, wanted: 99 * time.Millisecond},
+		{p: 100, wanted: 100 * time.Millisecond},
+		{p: 0, wanted: 1 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(durations, tt.p); got != tt.wanted {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.wanted)
+		}
+	}
+}
+
+func TestSuggestSettings(t *testing.T) {
+	t.Parallel()
+
+	runs := []BenchmarkRun{
+		{Operation: "upload", Size: 1 << 20, Concurrency: 16, Throughput: 50e6},
+		{Operation: "upload", Size: 32 << 20, Concurrency: 4, Throughput: 95e6},
+		{Operation: "upload", Size: 32 << 20, Concurrency: 16, Throughput: 100e6},
+		{Operation: "upload", Size: 32 << 20, Concurrency: 64, Throughput: 98e6},
+		{Operation: "download", Size: 1 << 20, Concurrency: 64, Throughput: 500e6},
+	}
+
+	partSize, workers := suggestSettings(runs)
+	if partSize != 32<<20 || workers != 4 {
+		t.Errorf("suggestSettings() = %v, %v, want %v, %v", partSize, workers, 32<<20, 4)
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "data/a.txt", "a", nil)
+
+	options := BenchmarkOptions{Sizes: []int64{1024, 4096}, Concurrency: []int{1, 4}, Objects: 3, Quiet: true}
+	result, err := fake.basics().Benchmark("bucket", options)
+	if err != nil {
+		t.Fatalf("Benchmark() = %v, want nil", err)
+	}
+
+	// An upload and a download run at every size and concurrency, each of at least the concurrency's objects
+	if len(result.Runs) != 8 {
+		t.Fatalf("Benchmark() ran %v, want 8 runs", result.Runs)
+	}
+	for _, run := range result.Runs {
+		if wanted := max(3, run.Concurrency); run.Objects != wanted || run.Throughput <= 0 {
+			t.Errorf("Benchmark() run %+v, want %v objects and a throughput", run, wanted)
+		}
+	}
+
+	if got, wanted := fake.count("PUT bucket/"+probePrefix), 3+4+3+4; got != wanted {
+		t.Errorf("Benchmark() uploaded %v objects, want %v", got, wanted)
+	}
+
+	// Part sizes below the smallest S3 allows are raised to it
+	if result.PartSize != minPartSize || !slices.Contains(options.Concurrency, result.Workers) {
+		t.Errorf("Benchmark() suggested %v, %v workers, want %v and one of %v", result.PartSize, result.Workers, minPartSize, options.Concurrency)
+	}
+
+	// The synthetic objects are cleaned up
+	if keys := fake.keys("bucket"); !slices.Equal(keys, []string{"data/a.txt"}) {
+		t.Errorf("Benchmark() left %v, want [data/a.txt]", keys)
+	}
+}
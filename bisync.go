@@ -0,0 +1,447 @@
+package boto3manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSyncConflict means a two-way sync found files changed on both sides and its policy is ConflictFail.
+var ErrSyncConflict = errors.New("sync conflict")
+
+// ConflictPolicy decides what a two-way sync does with a file changed on both sides since the last sync.
+type ConflictPolicy int
+
+const (
+	// ConflictNewestWins keeps whichever side was modified last
+	ConflictNewestWins ConflictPolicy = iota
+
+	// ConflictKeepBoth keeps the bucket's version under the file's name and the local version under the name with
+	// ConflictSuffix added, on both sides. If that name is taken on either side, such as by an earlier conflict, a
+	// number is added after the suffix
+	ConflictKeepBoth
+
+	// ConflictFail stops the sync with ErrSyncConflict before anything is changed
+	ConflictFail
+)
+
+// ConflictSuffix is added to the name of the local version of a file kept by ConflictKeepBoth.
+const ConflictSuffix = ".conflict"
+
+type TwoWaySyncOptions struct {
+	// StateFile is where the sync records both sides as they were after the last run, to tell which side changed.
+	// It is required and should be kept outside the synced directory
+	StateFile string
+
+	// Conflict decides what happens to files changed on both sides
+	Conflict ConflictPolicy
+
+	// RetryPolicy controls retries of each file. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Hooks, if set, are called as files start and finish
+	Hooks *Hooks
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+
+	// TrashPrefix moves objects deleted because their files were deleted under this prefix, in a folder named for
+	// the time of the sync, instead of deleting them outright. It must be outside the synced prefix
+	TrashPrefix string
+
+	// TrashDir moves files deleted because their objects were deleted into this directory, in a folder named for
+	// the time of the sync, instead of removing them. It must be outside the synced directory
+	TrashDir string
+
+	// ConfirmDelete, if set, is given the names a sync would delete on either side and must return true for them to
+	// be deleted. As with a mirror, deletions are refused unless they go to the side's trash or ConfirmDelete is
+	// set, and deleting from one side when the other is empty, as when a directory isn't mounted, is refused
+	// unless ConfirmDelete is set
+	ConfirmDelete func(names []string) bool
+}
+
+// mirrorOptions returns the options of a mirror with the same trash and ConfirmDelete, whose checks guard a two-way
+// sync's deletions.
+func (options TwoWaySyncOptions) mirrorOptions() SyncOptions {
+	return SyncOptions{Mirror: true, TrashPrefix: options.TrashPrefix, TrashDir: options.TrashDir, ConfirmDelete: options.ConfirmDelete}
+}
+
+// syncEntry is a file as it was on both sides after a two-way sync.
+type syncEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	ETag    string    `json:"etag"`
+}
+
+// syncState is the contents of a two-way sync's state file.
+type syncState struct {
+	Files map[string]syncEntry `json:"files"`
+}
+
+// readSyncState reads a state file. A missing file is an empty state, as on the first run.
+func readSyncState(path string) (syncState, error) {
+	state := syncState{Files: make(map[string]syncEntry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("couldn't read sync state %v: %w", path, err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]syncEntry)
+	}
+
+	return state, nil
+}
+
+// writeSyncState replaces a state file, writing it to a temporary file first so a crash can't leave it half written.
+func writeSyncState(path string, state syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// twoWayPlan is what a two-way sync will do, by name relative to the directory and prefix.
+type twoWayPlan struct {
+	uploads      []string
+	downloads    []string
+	deleteRemote []string
+	deleteLocal  []string
+
+	// keepBoth are conflicts resolved by keeping both versions
+	keepBoth []string
+
+	// conflicts are conflicts left unresolved by ConflictFail
+	conflicts []string
+
+	// declined are deletions ConfirmDelete didn't approve
+	declined []string
+}
+
+// sameContent reports whether a local file has the same contents as an object, by the MD5 checksum in a single-part
+// ETag. Multipart ETags aren't checksums of the contents, so files are never taken to match them.
+func sameContent(file FileInfo, object ObjectInfo) bool {
+	if file.Size != object.Size || object.ETag == "" || strings.Contains(object.ETag, "-") {
+		return false
+	}
+
+	sum, err := md5File(os.DirFS(filepath.Dir(file.Path)), filepath.Base(file.Path))
+	return err == nil && sum == object.ETag
+}
+
+// planTwoWay compares both sides with the state of the last sync. A change on one side is copied to the other,
+// including deletions. A change on one side beats a deletion on the other. Files new or changed on both sides that
+// same says have the same contents are left alone; otherwise they are conflicts resolved by the policy.
+func planTwoWay(local map[string]FileInfo, remote map[string]ObjectInfo, state map[string]syncEntry, policy ConflictPolicy, same func(FileInfo, ObjectInfo) bool) twoWayPlan {
+	var plan twoWayPlan
+
+	names := make(map[string]bool)
+	for name := range local {
+		names[name] = true
+	}
+	for name := range remote {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	slices.Sort(sorted)
+
+	for _, name := range sorted {
+		file, inLocal := local[name]
+		object, inRemote := remote[name]
+		last, known := state[name]
+
+		localChanged := inLocal && (!known || file.Size != last.Size || !file.ModTime.Equal(last.ModTime))
+		remoteChanged := inRemote && (!known || object.ETag != last.ETag)
+		localDeleted := known && !inLocal
+		remoteDeleted := known && !inRemote
+
+		switch {
+		case localChanged && remoteChanged:
+			if same(file, object) {
+				continue
+			}
+
+			switch policy {
+			case ConflictNewestWins:
+				if file.ModTime.After(object.LastModified) {
+					plan.uploads = append(plan.uploads, name)
+				} else {
+					plan.downloads = append(plan.downloads, name)
+				}
+			case ConflictKeepBoth:
+				plan.keepBoth = append(plan.keepBoth, name)
+			default:
+				plan.conflicts = append(plan.conflicts, name)
+			}
+		case localChanged:
+			plan.uploads = append(plan.uploads, name)
+		case remoteChanged:
+			plan.downloads = append(plan.downloads, name)
+		case localDeleted && inRemote:
+			plan.deleteRemote = append(plan.deleteRemote, name)
+		case remoteDeleted && inLocal:
+			plan.deleteLocal = append(plan.deleteLocal, name)
+		}
+	}
+
+	return plan
+}
+
+// TwoWaySync takes a local directory, a prefix, and a bucket name and brings both sides up to date with each other:
+// files added, changed, or deleted on one side since the last run are added, changed, or deleted on the other, and
+// files changed on both sides are resolved by the conflict policy. The state of both sides after each run is kept
+// in the options' StateFile. Files that fail to transfer are tried again on the next run. Deletions are guarded as a
+// mirror's are: they need a trash or ConfirmDelete, and ConfirmDelete alone when the other side is empty.
+func (basics BucketBasics) TwoWaySync(localDir string, prefix string, bucketName string, options TwoWaySyncOptions) (*TransferReport, error) {
+	return basics.TwoWaySyncWithContext(context.Background(), localDir, prefix, bucketName, options)
+}
+
+// TwoWaySyncWithContext is TwoWaySync with a context.
+func (basics BucketBasics) TwoWaySyncWithContext(ctx context.Context, localDir string, prefix string, bucketName string, options TwoWaySyncOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+	basics.detectRegions(ctx, bucketName)
+
+	if options.StateFile == "" {
+		return nil, errors.New("two-way sync needs a state file")
+	}
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	mirror := options.mirrorOptions()
+	if err := mirror.checkTrashPrefix(prefix); err != nil {
+		return nil, err
+	}
+	if err := mirror.checkTrashDir(localDir); err != nil {
+		return nil, err
+	}
+
+	state, err := readSyncState(options.StateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := localFiles(localDir)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := basics.remoteObjects(ctx, bucketName, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := planTwoWay(local, remote, state.Files, options.Conflict, sameContent)
+	if len(plan.conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %v files changed on both sides, first %v", ErrSyncConflict, len(plan.conflicts), plan.conflicts[0])
+	}
+
+	// Deletions are copied to the other side as a mirror's are, so they are guarded the same way. A stale state file
+	// and an empty side would otherwise delete everything on the other
+	if len(plan.deleteRemote) > 0 {
+		if err := mirror.checkMirror(localDir, len(local), options.TrashPrefix); err != nil {
+			return nil, err
+		}
+	}
+	if len(plan.deleteLocal) > 0 {
+		if err := mirror.checkMirror(bucketName+"/"+prefix, len(remote), options.TrashDir); err != nil {
+			return nil, err
+		}
+	}
+
+	// Move the local version of each kept conflict aside, to be uploaded under its new name
+	for _, name := range plan.keepBoth {
+		conflictName := conflictName(localDir, name, local, remote)
+		conflictPath := filepath.Join(localDir, filepath.FromSlash(conflictName))
+
+		if err := os.Rename(local[name].Path, conflictPath); err != nil {
+			log.Printf("Couldn't keep local version of %v: %v", name, err)
+			return nil, err
+		}
+
+		local[conflictName] = FileInfo{Path: conflictPath, Name: conflictName, Size: local[name].Size, ModTime: local[name].ModTime}
+		plan.uploads = append(plan.uploads, conflictName)
+		plan.downloads = append(plan.downloads, name)
+	}
+
+	uploads := make([]FileUpload, 0, len(plan.uploads))
+	var totalSize int64
+	for _, name := range plan.uploads {
+		uploads = append(uploads, FileUpload{Path: local[name].Path, Key: prefix + name, Size: local[name].Size})
+		totalSize += local[name].Size
+	}
+
+	downloads := make([]FileDownload, 0, len(plan.downloads))
+	for _, name := range plan.downloads {
+		path := filepath.Join(localDir, filepath.FromSlash(name))
+		downloads = append(downloads, FileDownload{Key: remote[name].Key, Destination: path, Size: remote[name].Size})
+		totalSize += remote[name].Size
+	}
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  25,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		hooks:        options.Hooks,
+		backpressure: basics.Backpressure,
+	}
+
+	progress := newTransferProgress("syncing", totalSize, false, options.Quiet)
+	defer progress.close()
+
+	// Each file transferred is recorded as it was sent or received, not as it is found afterwards, so a change
+	// made by someone else during the sync is still seen as a change by the next one
+	var mu sync.Mutex
+	synced := make(map[string]syncEntry)
+
+	uploader := basics.newUploader(bucketName)
+	err = runBatch(ctx, uploads, config, func(ctx context.Context, file FileUpload) error {
+		bar := progress.file(file.Key, file.Size)
+		defer progress.done(file.Key)
+
+		var etag string
+		if err := basics.UploadObjectWithContext(ctx, file.Path, file.Key, bucketName, UploadObjectOptions{bar: bar, uploader: uploader, etag: &etag}); err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(file.Key, prefix)
+		mu.Lock()
+		synced[name] = syncEntry{Size: local[name].Size, ModTime: local[name].ModTime, ETag: etag}
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	// DownloadObject names the file after the key, so download into the file's directory
+	downloader := basics.newDownloader(bucketName)
+	err = runBatch(ctx, downloads, config, func(ctx context.Context, file FileDownload) error {
+		bar := progress.file(file.Key, file.Size)
+		defer progress.done(file.Key)
+
+		if err := basics.DownloadObjectWithContext(ctx, file.Key, filepath.Dir(file.Destination), bucketName, DownloadObjectOptions{Quiet: options.Quiet, bar: bar, downloader: downloader, small: file.Size < smallObjectSize}); err != nil {
+			return err
+		}
+
+		info, err := os.Stat(file.Destination)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(file.Key, prefix)
+		mu.Lock()
+		synced[name] = syncEntry{Size: info.Size(), ModTime: info.ModTime(), ETag: remote[name].ETag}
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if mirror.confirmDeletions(plan.deleteRemote) {
+		if err := basics.deleteRemote(ctx, bucketName, remote, plan.deleteRemote, options.TrashPrefix, report, options.Quiet); err != nil {
+			return report, err
+		}
+	} else {
+		plan.declined = append(plan.declined, plan.deleteRemote...)
+	}
+
+	if mirror.confirmDeletions(plan.deleteLocal) {
+		if err := deleteLocal(local, plan.deleteLocal, options.TrashDir, report, options.Quiet); err != nil {
+			return report, err
+		}
+	} else {
+		plan.declined = append(plan.declined, plan.deleteLocal...)
+	}
+
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	state = nextSyncState(state, local, remote, plan, synced)
+	if err := writeSyncState(options.StateFile, state); err != nil {
+		log.Printf("Couldn't write sync state %v: %v", options.StateFile, err)
+		return report, err
+	}
+
+	return report, nil
+}
+
+// conflictName returns the name the local version of a conflicting file is kept under: the name with ConflictSuffix
+// added, numbered if that is already taken locally or in the bucket, so an earlier conflict's copy isn't overwritten.
+func conflictName(localDir string, name string, local map[string]FileInfo, remote map[string]ObjectInfo) string {
+	for n := 0; ; n++ {
+		candidate := name + ConflictSuffix
+		if n > 0 {
+			candidate += "-" + strconv.Itoa(n)
+		}
+
+		_, inLocal := local[candidate]
+		_, inRemote := remote[candidate]
+		if _, err := os.Lstat(filepath.Join(localDir, filepath.FromSlash(candidate))); !inLocal && !inRemote && errors.Is(err, fs.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+// nextSyncState returns the state after a sync that carried out plan, made from the listings of both sides. Names
+// the sync left alone that were on both sides are recorded as they were listed, and names it transferred as they
+// were sent or received. Names that failed or whose deletion was declined keep their old entries, so they are tried
+// again, and deleted names are dropped.
+func nextSyncState(old syncState, local map[string]FileInfo, remote map[string]ObjectInfo, plan twoWayPlan, synced map[string]syncEntry) syncState {
+	state := syncState{Files: make(map[string]syncEntry)}
+
+	for name, file := range local {
+		if object, ok := remote[name]; ok {
+			state.Files[name] = syncEntry{Size: file.Size, ModTime: file.ModTime, ETag: object.ETag}
+		}
+	}
+
+	for _, name := range slices.Concat(plan.uploads, plan.downloads) {
+		delete(state.Files, name)
+
+		if entry, ok := synced[name]; ok {
+			state.Files[name] = entry
+		} else if entry, ok := old.Files[name]; ok {
+			state.Files[name] = entry
+		}
+	}
+
+	for _, name := range plan.declined {
+		if entry, ok := old.Files[name]; ok {
+			state.Files[name] = entry
+		}
+	}
+
+	return state
+}
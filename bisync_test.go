@@ -0,0 +1,608 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSameContent(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file := FileInfo{Path: path, Name: "notes.txt", Size: 5}
+
+	tests := []struct {
+		name   string
+		object ObjectInfo
+		wanted bool
+	}{
+		{name: "same contents", object: ObjectInfo{Size: 5, ETag: "5d41402abc4b2a76b9719d911017c592"}, wanted: true},
+		{name: "same size", object: ObjectInfo{Size: 5, ETag: "7d793037a0760186574b0282f2f435e7"}, wanted: false},
+		{name: "multipart", object: ObjectInfo{Size: 5, ETag: "5d41402abc4b2a76b9719d911017c592-2"}, wanted: false},
+		{name: "different size", object: ObjectInfo{Size: 6, ETag: "5d41402abc4b2a76b9719d911017c592"}, wanted: false},
+	}
+
+	for _, tt := range tests {
+		if got := sameContent(file, tt.object); got != tt.wanted {
+			t.Errorf("sameContent(%v) = %v, want %v", tt.name, got, tt.wanted)
+		}
+	}
+}
+
+func TestPlanTwoWay(t *testing.T) {
+	t.Parallel()
+
+	then := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := then.Add(time.Hour)
+
+	state := map[string]syncEntry{
+		"same":          {Size: 1, ModTime: then, ETag: "a"},
+		"local-edit":    {Size: 1, ModTime: then, ETag: "a"},
+		"remote-edit":   {Size: 1, ModTime: then, ETag: "a"},
+		"local-delete":  {Size: 1, ModTime: then, ETag: "a"},
+		"remote-delete": {Size: 1, ModTime: then, ETag: "a"},
+		"both-delete":   {Size: 1, ModTime: then, ETag: "a"},
+		"conflict":      {Size: 1, ModTime: then, ETag: "a"},
+		"edit-delete":   {Size: 1, ModTime: then, ETag: "a"},
+	}
+
+	local := map[string]FileInfo{
+		"same":          {Size: 1, ModTime: then},
+		"local-edit":    {Size: 2, ModTime: later},
+		"remote-edit":   {Size: 1, ModTime: then},
+		"remote-delete": {Size: 1, ModTime: then},
+		"conflict":      {Size: 2, ModTime: later},
+		"edit-delete":   {Size: 2, ModTime: later},
+		"new-local":     {Size: 1, ModTime: then},
+		"new-both":      {Size: 3, ModTime: then},
+		"same-size":     {Size: 3, ModTime: later},
+	}
+
+	remote := map[string]ObjectInfo{
+		"same":         {Size: 1, ETag: "a"},
+		"local-edit":   {Size: 1, ETag: "a"},
+		"remote-edit":  {Size: 2, ETag: "b"},
+		"local-delete": {Size: 1, ETag: "a"},
+		"conflict":     {Size: 3, ETag: "b", LastModified: then},
+		"new-remote":   {Size: 1, ETag: "c"},
+		"new-both":     {Size: 3, ETag: "d"},
+		"same-size":    {Size: 3, ETag: "e", LastModified: then},
+	}
+
+	// new-both has the same contents on both sides, same-size only the same length
+	same := func(file FileInfo, object ObjectInfo) bool { return object.ETag == "d" }
+
+	tests := []struct {
+		name   string
+		policy ConflictPolicy
+		wanted twoWayPlan
+	}{
+		{
+			name:   "newest wins",
+			policy: ConflictNewestWins,
+			wanted: twoWayPlan{
+				uploads:      []string{"conflict", "edit-delete", "local-edit", "new-local", "same-size"},
+				downloads:    []string{"new-remote", "remote-edit"},
+				deleteRemote: []string{"local-delete"},
+				deleteLocal:  []string{"remote-delete"},
+			},
+		},
+		{
+			name:   "keep both",
+			policy: ConflictKeepBoth,
+			wanted: twoWayPlan{
+				uploads:      []string{"edit-delete", "local-edit", "new-local"},
+				downloads:    []string{"new-remote", "remote-edit"},
+				deleteRemote: []string{"local-delete"},
+				deleteLocal:  []string{"remote-delete"},
+				keepBoth:     []string{"conflict", "same-size"},
+			},
+		},
+		{
+			name:   "fail",
+			policy: ConflictFail,
+			wanted: twoWayPlan{
+				uploads:      []string{"edit-delete", "local-edit", "new-local"},
+				downloads:    []string{"new-remote", "remote-edit"},
+				deleteRemote: []string{"local-delete"},
+				deleteLocal:  []string{"remote-delete"},
+				conflicts:    []string{"conflict", "same-size"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := planTwoWay(local, remote, state, tt.policy, same); !reflect.DeepEqual(got, tt.wanted) {
+				t.Errorf("planTwoWay(%v) = %+v, want %+v", tt.policy, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestSyncStateRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := readSyncState(path)
+	if err != nil {
+		t.Fatalf("readSyncState() of missing file: %v", err)
+	}
+	if len(state.Files) != 0 {
+		t.Errorf("readSyncState() of missing file = %v, want empty", state.Files)
+	}
+
+	state.Files["a/b"] = syncEntry{Size: 5, ModTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ETag: "x"}
+	if err := writeSyncState(path, state); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readSyncState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Errorf("readSyncState() = %v, want %v", got, state)
+	}
+}
+
+func TestNextSyncState(t *testing.T) {
+	t.Parallel()
+
+	then := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := then.Add(time.Hour)
+
+	old := syncState{Files: map[string]syncEntry{
+		"same":     {Size: 1, ModTime: then, ETag: "a"},
+		"uploaded": {Size: 1, ModTime: then, ETag: "a"},
+		"failed":   {Size: 1, ModTime: then, ETag: "a"},
+		"deleted":  {Size: 1, ModTime: then, ETag: "a"},
+	}}
+
+	local := map[string]FileInfo{
+		"same":     {Size: 1, ModTime: then},
+		"uploaded": {Size: 2, ModTime: later},
+		"failed":   {Size: 2, ModTime: later},
+		"new-both": {Size: 3, ModTime: later},
+	}
+	remote := map[string]ObjectInfo{
+		"same":       {Size: 1, ETag: "a"},
+		"uploaded":   {Size: 1, ETag: "a"},
+		"failed":     {Size: 1, ETag: "a"},
+		"deleted":    {Size: 1, ETag: "a"},
+		"new-both":   {Size: 3, ETag: "d"},
+		"downloaded": {Size: 4, ETag: "e"},
+	}
+
+	plan := twoWayPlan{uploads: []string{"failed", "uploaded"}, downloads: []string{"downloaded"}, deleteRemote: []string{"deleted"}}
+	synced := map[string]syncEntry{
+		"uploaded":   {Size: 2, ModTime: later, ETag: "b"},
+		"downloaded": {Size: 4, ModTime: later, ETag: "e"},
+	}
+
+	wanted := syncState{Files: map[string]syncEntry{
+		"same":       {Size: 1, ModTime: then, ETag: "a"},
+		"uploaded":   {Size: 2, ModTime: later, ETag: "b"},
+		"failed":     {Size: 1, ModTime: then, ETag: "a"},
+		"new-both":   {Size: 3, ModTime: later, ETag: "d"},
+		"downloaded": {Size: 4, ModTime: later, ETag: "e"},
+	}}
+
+	if got := nextSyncState(old, local, remote, plan, synced); !reflect.DeepEqual(got, wanted) {
+		t.Errorf("nextSyncState() = %+v, want %+v", got, wanted)
+	}
+}
+
+func TestTwoWaySyncConcurrentChange(t *testing.T) {
+	fake := newFakeS3(t, "data")
+	fake.put("data", "sync/shared.txt", "shared", nil)
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	if err := os.MkdirAll(local, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(local, "mine.txt"), []byte("mine"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	options := TwoWaySyncOptions{StateFile: filepath.Join(dir, "state.json"), Quiet: true}
+
+	out := captureStdout(t, func() {
+		if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+			t.Fatalf("TwoWaySync() = %v, want nil", err)
+		}
+	})
+	if out != "" {
+		t.Errorf("TwoWaySync() while quiet printed %q, want nothing", out)
+	}
+
+	// Someone else rewrites shared.txt while the next sync is uploading a change to mine.txt
+	if err := os.WriteFile(filepath.Join(local, "mine.txt"), []byte("mine, edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	options.Hooks = &Hooks{OnObjectStart: func(key string, size int64) {
+		fake.put("data", "sync/shared.txt", "theirs", nil)
+	}}
+	if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+		t.Fatalf("TwoWaySync() = %v, want nil", err)
+	}
+	if mine, _ := fake.object("data", "sync/mine.txt"); string(mine.body) != "mine, edited" {
+		t.Errorf("TwoWaySync() uploaded %q, want %q", mine.body, "mine, edited")
+	}
+
+	// The change made during the last sync wasn't taken as synced, so this one brings it down
+	options.Hooks = nil
+	if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+		t.Fatalf("TwoWaySync() = %v, want nil", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(local, "shared.txt")); string(got) != "theirs" {
+		t.Errorf("TwoWaySync() left shared.txt as %q, want %q", got, "theirs")
+	}
+
+	state, err := readSyncState(options.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine, _ := fake.object("data", "sync/mine.txt")
+	if etag := state.Files["mine.txt"].ETag; etag != strings.Trim(mine.etag, `"`) {
+		t.Errorf("TwoWaySync() recorded mine.txt with ETag %q, want the uploaded %v", etag, mine.etag)
+	}
+}
+
+// writeFiles writes files under dir, by slash separated name, creating their directories.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTwoWaySyncDeletionGuards(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	confirm := func(names []string) bool { return true }
+	decline := func(names []string) bool { return false }
+
+	tests := []struct {
+		name    string
+		remove  []string
+		options TwoWaySyncOptions
+		wanted  bool
+		keys    []string
+	}{
+		{name: "no trash", remove: []string{"a.txt"}, wanted: false, keys: []string{"sync/a.txt", "sync/b.txt"}},
+		{name: "trash", remove: []string{"a.txt"}, options: TwoWaySyncOptions{TrashPrefix: "trash/"}, wanted: true, keys: []string{"sync/b.txt", "trash/a.txt"}},
+		{name: "trash under prefix", remove: []string{"a.txt"}, options: TwoWaySyncOptions{TrashPrefix: "sync/trash/"}, wanted: false, keys: []string{"sync/a.txt", "sync/b.txt"}},
+		{name: "empty directory", remove: []string{"a.txt", "b.txt"}, options: TwoWaySyncOptions{TrashPrefix: "trash/"}, wanted: false, keys: []string{"sync/a.txt", "sync/b.txt"}},
+		{name: "empty directory confirmed", remove: []string{"a.txt", "b.txt"}, options: TwoWaySyncOptions{ConfirmDelete: confirm}, wanted: true, keys: []string{}},
+		{name: "declined", remove: []string{"a.txt"}, options: TwoWaySyncOptions{ConfirmDelete: decline}, wanted: true, keys: []string{"sync/a.txt", "sync/b.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "data")
+			dir := t.TempDir()
+			local := filepath.Join(dir, "local")
+			writeFiles(t, local, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+			options := TwoWaySyncOptions{StateFile: filepath.Join(dir, "state.json"), Quiet: true}
+			if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+				t.Fatalf("TwoWaySync() = %v, want nil", err)
+			}
+
+			for _, name := range tt.remove {
+				if err := os.Remove(filepath.Join(local, name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tt.options.StateFile = options.StateFile
+			tt.options.Quiet = true
+			_, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", tt.options)
+			if got := err == nil; got != tt.wanted {
+				t.Errorf("TwoWaySync() = %v, want success %v", err, tt.wanted)
+			}
+
+			// Trashed objects are under a folder named for the time, so compare them without it
+			keys := make([]string, 0)
+			for _, key := range fake.keys("data") {
+				if rest, ok := strings.CutPrefix(key, "trash/"); ok {
+					_, key, _ = strings.Cut(rest, "/")
+					key = "trash/" + key
+				}
+				keys = append(keys, key)
+			}
+			if !slices.Equal(keys, tt.keys) {
+				t.Errorf("TwoWaySync() left %v, want %v", keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestTwoWaySyncDeclinedDeletionAskedAgain(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := newFakeS3(t, "data")
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	writeFiles(t, local, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	options := TwoWaySyncOptions{StateFile: filepath.Join(dir, "state.json"), Quiet: true}
+	if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+		t.Fatalf("TwoWaySync() = %v, want nil", err)
+	}
+	if err := os.Remove(filepath.Join(local, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	var asked [][]string
+	options.ConfirmDelete = func(names []string) bool {
+		asked = append(asked, names)
+		return len(asked) > 1
+	}
+
+	for range 2 {
+		if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+			t.Fatalf("TwoWaySync() = %v, want nil", err)
+		}
+	}
+
+	if !reflect.DeepEqual(asked, [][]string{{"a.txt"}, {"a.txt"}}) {
+		t.Errorf("TwoWaySync() asked to delete %v, want a.txt on both runs", asked)
+	}
+	if _, ok := fake.object("data", "sync/a.txt"); ok {
+		t.Errorf("TwoWaySync() kept sync/a.txt after the deletion was confirmed")
+	}
+	if _, err := os.Stat(filepath.Join(local, "a.txt")); err == nil {
+		t.Errorf("TwoWaySync() downloaded a.txt again after its deletion was declined")
+	}
+}
+
+func TestTwoWaySyncKeepBothTwice(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := newFakeS3(t, "data")
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	writeFiles(t, local, map[string]string{"notes.txt": "v1"})
+
+	options := TwoWaySyncOptions{StateFile: filepath.Join(dir, "state.json"), Conflict: ConflictKeepBoth, Quiet: true}
+	if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+		t.Fatalf("TwoWaySync() = %v, want nil", err)
+	}
+
+	// The second conflict mustn't overwrite the copy kept from the first
+	for _, edit := range []string{"1", "2"} {
+		writeFiles(t, local, map[string]string{"notes.txt": "mine " + edit})
+		fake.put("data", "sync/notes.txt", "theirs, edit "+edit, nil)
+
+		if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+			t.Fatalf("TwoWaySync() = %v, want nil", err)
+		}
+	}
+
+	wanted := map[string]string{
+		"notes.txt":            "theirs, edit 2",
+		"notes.txt.conflict":   "mine 1",
+		"notes.txt.conflict-1": "mine 2",
+	}
+	for name, body := range wanted {
+		if got, err := os.ReadFile(filepath.Join(local, name)); err != nil || string(got) != body {
+			t.Errorf("TwoWaySync() left local %v as %q (%v), want %q", name, got, err, body)
+		}
+		if object, _ := fake.object("data", "sync/"+name); string(object.body) != body {
+			t.Errorf("TwoWaySync() left sync/%v as %q, want %q", name, object.body, body)
+		}
+	}
+}
+
+func TestTwoWaySync(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := newFakeS3(t, "data")
+	fake.put("data", "sync/theirs.txt", "theirs", nil)
+	fake.put("data", "sync/gone-remote.txt", "gone", nil)
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	writeFiles(t, local, map[string]string{"mine.txt": "mine", "sub/gone-local.txt": "gone"})
+
+	options := TwoWaySyncOptions{
+		StateFile:   filepath.Join(dir, "state.json"),
+		TrashPrefix: "trash/",
+		TrashDir:    filepath.Join(dir, "trash"),
+		Quiet:       true,
+	}
+
+	// The first run copies each side's files to the other
+	if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+		t.Fatalf("TwoWaySync() = %v, want nil", err)
+	}
+	if object, _ := fake.object("data", "sync/mine.txt"); string(object.body) != "mine" {
+		t.Errorf("TwoWaySync() uploaded %q, want %q", object.body, "mine")
+	}
+	if got, _ := os.ReadFile(filepath.Join(local, "theirs.txt")); string(got) != "theirs" {
+		t.Errorf("TwoWaySync() downloaded %q, want %q", got, "theirs")
+	}
+
+	// The second copies deletions both ways, into the trash
+	if err := os.Remove(filepath.Join(local, "sub", "gone-local.txt")); err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	delete(fake.buckets["data"], "sync/gone-remote.txt")
+	fake.mu.Unlock()
+
+	report, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options)
+	if err != nil {
+		t.Fatalf("TwoWaySync() = %v, want nil", err)
+	}
+	if len(report.Deleted) != 2 {
+		t.Errorf("TwoWaySync() deleted %v, want both deletions copied", report.Deleted)
+	}
+	if _, ok := fake.object("data", "sync/sub/gone-local.txt"); ok {
+		t.Errorf("TwoWaySync() kept sync/sub/gone-local.txt, want it deleted")
+	}
+	if _, err := os.Stat(filepath.Join(local, "gone-remote.txt")); err == nil {
+		t.Errorf("TwoWaySync() kept gone-remote.txt, want it deleted")
+	}
+
+	trashed := 0
+	for _, key := range fake.keys("data") {
+		if strings.HasPrefix(key, "trash/") && strings.HasSuffix(key, "/sub/gone-local.txt") {
+			trashed++
+		}
+	}
+	if trashed != 1 {
+		t.Errorf("TwoWaySync() left %v, want sub/gone-local.txt in the trash", fake.keys("data"))
+	}
+	if moved, _ := filepath.Glob(filepath.Join(options.TrashDir, "*", "gone-remote.txt")); len(moved) != 1 {
+		t.Errorf("TwoWaySync() moved gone-remote.txt to %v, want one copy in the trash", moved)
+	}
+
+	state, err := readSyncState(options.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := slices.Sorted(maps.Keys(state.Files)); !slices.Equal(names, []string{"mine.txt", "theirs.txt"}) {
+		t.Errorf("TwoWaySync() recorded %v, want mine.txt and theirs.txt", names)
+	}
+}
+
+func TestTwoWaySyncConflicts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	old := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Now()
+
+	tests := []struct {
+		name    string
+		policy  ConflictPolicy
+		modTime time.Time
+		err     error
+		local   string
+		remote  string
+	}{
+		{name: "newest wins, local newer", policy: ConflictNewestWins, modTime: recent, local: "mine", remote: "mine"},
+		{name: "newest wins, bucket newer", policy: ConflictNewestWins, modTime: old, local: "theirs", remote: "theirs"},
+		{name: "keep both", policy: ConflictKeepBoth, modTime: recent, local: "theirs", remote: "theirs"},
+		{name: "fail", policy: ConflictFail, modTime: recent, err: ErrSyncConflict, local: "mine", remote: "theirs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "data")
+			dir := t.TempDir()
+			local := filepath.Join(dir, "local")
+			writeFiles(t, local, map[string]string{"notes.txt": "base"})
+
+			options := TwoWaySyncOptions{StateFile: filepath.Join(dir, "state.json"), Conflict: tt.policy, Quiet: true}
+			if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); err != nil {
+				t.Fatalf("TwoWaySync() = %v, want nil", err)
+			}
+
+			path := filepath.Join(local, "notes.txt")
+			writeFiles(t, local, map[string]string{"notes.txt": "mine"})
+			if err := os.Chtimes(path, tt.modTime, tt.modTime); err != nil {
+				t.Fatal(err)
+			}
+			fake.put("data", "sync/notes.txt", "theirs", nil)
+
+			if _, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options); !errors.Is(err, tt.err) {
+				t.Fatalf("TwoWaySync() = %v, want %v", err, tt.err)
+			}
+
+			if got, _ := os.ReadFile(path); string(got) != tt.local {
+				t.Errorf("TwoWaySync() left notes.txt as %q, want %q", got, tt.local)
+			}
+			if object, _ := fake.object("data", "sync/notes.txt"); string(object.body) != tt.remote {
+				t.Errorf("TwoWaySync() left sync/notes.txt as %q, want %q", object.body, tt.remote)
+			}
+
+			_, kept := fake.object("data", "sync/notes.txt"+ConflictSuffix)
+			if wanted := tt.policy == ConflictKeepBoth; kept != wanted {
+				t.Errorf("TwoWaySync() kept a conflict copy = %v, want %v", kept, wanted)
+			}
+		})
+	}
+}
+
+func TestTwoWaySyncPartialFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := newFakeS3(t, "data")
+	fake.fail = func(r *http.Request) int {
+		if r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/bad.txt") {
+			return http.StatusForbidden
+		}
+		return 0
+	}
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	writeFiles(t, local, map[string]string{"good.txt": "good", "bad.txt": "bad"})
+
+	options := TwoWaySyncOptions{StateFile: filepath.Join(dir, "state.json"), Quiet: true}
+	report, err := fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options)
+	if err != nil {
+		t.Fatalf("TwoWaySync() = %v, want nil", err)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Key != "sync/bad.txt" {
+		t.Errorf("TwoWaySync() failed %v, want sync/bad.txt", failed)
+	}
+
+	// The state records what was sent, and leaves out what wasn't so the next run tries it again
+	state, err := readSyncState(options.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := slices.Sorted(maps.Keys(state.Files)); !slices.Equal(names, []string{"good.txt"}) {
+		t.Errorf("TwoWaySync() recorded %v, want only good.txt", names)
+	}
+
+	fake.mu.Lock()
+	fake.fail = nil
+	fake.mu.Unlock()
+
+	report, err = fake.basics().TwoWaySyncWithContext(ctx, local, "sync/", "data", options)
+	if err != nil {
+		t.Fatalf("TwoWaySync() = %v, want nil", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Key != "sync/bad.txt" || report.Results[0].Err != nil {
+		t.Errorf("TwoWaySync() after the failure sent %+v, want only sync/bad.txt", report.Results)
+	}
+}
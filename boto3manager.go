@@ -2,13 +2,13 @@ package boto3manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strings"
-	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
@@ -20,24 +20,263 @@ import (
 
 type BucketBasics struct {
 	S3Client *s3.Client
+
+	// BucketClients are used instead of S3Client for the buckets they name, so operations that span buckets can use
+	// different credentials for each. See WithBucketClient
+	BucketClients map[string]*s3.Client
+
+	// Listing tunes the page size and pacing of every listing made through this BucketBasics
+	Listing ListingOptions
+
+	// Backpressure tunes how batch operations made through this BucketBasics slow down when the server is overloaded
+	Backpressure BackpressureOptions
+
+	// DetectRegions finds each bucket's region with DetectBucketRegion the first time it's used and sends its
+	// requests there, so buckets outside the client's region don't fail with redirect errors. NewBucketBasics turns
+	// it on for AWS's own endpoints
+	DetectRegions bool
 }
 
 type FileUpload struct {
 	Path string
 	Key  string
+	Size int64
 }
 
 type FileDownload struct {
 	Key         string
 	Destination string
+	Size        int64
 }
 
 type UploadObjectOptions struct {
+	// VerifyMD5 sends Content-MD5 with every request and checks the ETags returned, failing on a mismatch
+	VerifyMD5 bool
+
+	// IfNoneMatch makes the upload conditional: "*" uploads only if the key doesn't exist yet, so concurrent
+	// writers can't clobber each other. The upload fails with ErrPreconditionFailed if the condition doesn't hold
+	IfNoneMatch string
+
+	// IfMatch uploads only if the object's current ETag is this one, for compare-and-swap updates. The upload
+	// fails with ErrPreconditionFailed if the object has changed since the ETag was read
+	IfMatch string
+
+	// PreserveMetadata records the file's permissions, modification time, and owner in the object's metadata so
+	// downloads with PreserveMetadata can restore them
+	PreserveMetadata bool
+
+	// Retention locks the object against deletion and overwriting until its date, in a bucket with Object Lock
+	// enabled. Locked uploads are sent as with VerifyMD5, since S3 requires Content-MD5 on them
+	Retention *ObjectRetention
+
+	// LegalHold places a legal hold on the object, in a bucket with Object Lock enabled
+	LegalHold bool
+
 	bar *progressbar.ProgressBar
+
+	// fsys is the file system path is opened from. If nil, path is opened from the OS file system
+	fsys fs.FS
+
+	// uploader is the upload manager shared by a batch. If nil, a new one is created
+	uploader *manager.Uploader
+
+	// etag, if set, is given the ETag of the uploaded object
+	etag *string
 }
 
 type DownloadObjectOptions struct {
+	// PreserveMetadata restores the permissions, modification time, and owner recorded by an upload with
+	// PreserveMetadata. Changing the owner needs root, so it is skipped with a log message otherwise
+	PreserveMetadata bool
+
+	// Decompress decodes objects stored with Content-Encoding: gzip or zstd as they download, like a browser does,
+	// and names the file without its .gz or .zst suffix. Objects in an encoding that can't be decoded, such as br,
+	// are kept as they are. If false, the raw bytes are written
+	Decompress bool
+
+	// Quiet turns off the line printed once the object is downloaded
+	Quiet bool
+
 	bar *progressbar.ProgressBar
+
+	// name is the name of the file written in the destination. If empty, the key's base name is used
+	name string
+
+	// downloader is the download manager shared by a batch. If nil, a new one is created
+	downloader *manager.Downloader
+
+	// small is set when the object is known to be smaller than smallObjectSize, so it is fetched with a single
+	// GetObject instead of through the download manager
+	small bool
+}
+
+type UploadObjectsOptions struct {
+	// RetryPolicy controls retries of each file. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// ChecksumsFile is the name of an md5sum-style index, e.g. "MD5SUMS", written into the destination after
+	// the upload. No index is written if it is empty
+	ChecksumsFile string
+
+	// Order is the order files are started in
+	Order TransferOrder
+
+	// KeyMapper maps each file's path to its key under the destination, or skips it. If nil, files are keyed by
+	// their paths relative to the directory of the pattern's literal prefix, as RelativeKeys does
+	KeyMapper KeyMapper
+
+	// DirectoryMarkers uploads a zero-byte "<dir>/" marker object, as S3 browsers create for folders, for each empty
+	// directory the pattern could match files in, so the directory structure survives a round trip
+	DirectoryMarkers bool
+
+	// FS is the file system files are read from, such as an embed.FS. If nil, the current directory is used
+	FS fs.FS
+
+	// Root is the directory within FS the pattern is relative to. If empty, the root of FS is used
+	Root string
+
+	// SuccessMarker writes a _SUCCESS object into the destination once every file has been uploaded. If a
+	// ChecksumsFile is also written, the marker contains its name and MD5 checksum
+	SuccessMarker bool
+
+	// DeliveryManifest, if set, is the name of a manifest written into the destination once every file has been
+	// uploaded, listing each file's key, size, SHA-256 checksum, and modification time, such as
+	// DeliveryManifestName. Consumers can check the delivery is complete with VerifyDelivery
+	DeliveryManifest string
+
+	// Hashing controls how files are hashed for the DeliveryManifest
+	Hashing HashOptions
+
+	// RetryFile, if set, is the path of a local file the files that failed are written to when the upload
+	// finishes, so they can be tried again later with ResumeFromRetryFile. Nothing is written if none failed.
+	// It can't be used with FS
+	RetryFile string
+
+	// Heartbeat, if set, writes the upload's progress to a heartbeat object every few minutes
+	Heartbeat *HeartbeatOptions
+
+	// VerifyMD5 sends Content-MD5 with every request and checks the ETags returned, retrying a file on a mismatch
+	VerifyMD5 bool
+
+	// IfNoneMatch is passed to every file's upload; "*" uploads only the files whose keys don't exist yet. Files
+	// that already exist fail with ErrPreconditionFailed
+	IfNoneMatch string
+
+	// PreserveMetadata records each file's permissions, modification time, and owner in its object's metadata
+	PreserveMetadata bool
+
+	// Retention and LegalHold lock every file's object, as in UploadObjectOptions
+	Retention *ObjectRetention
+	LegalHold bool
+
+	// EstimateCompression samples every file to estimate how much gzip compression would save. The estimate is
+	// printed and kept in the report's Compression field
+	EstimateCompression bool
+
+	// Probe measures the endpoint before starting, prints an estimate of how long the upload will take, and
+	// runs fewer workers if the endpoint can't keep up with the default number
+	Probe bool
+
+	// Concurrency pins how many files and parts are sent at once. Fields left zero are chosen from the file sizes:
+	// many small files get more workers, a few huge files get fewer workers sending more parts each
+	Concurrency Concurrency
+
+	// Hooks, if set, are called as files start and finish
+	Hooks *Hooks
+
+	// Stats, if set, has live statistics of the transfer passed to its OnStats as it runs
+	Stats *StatsOptions
+
+	// FileProgress shows a bar for each of the largest files in flight, with how long it has been running, above
+	// the overall bar, so a stuck file stands out. It redraws in place, so it is ignored when stderr isn't a
+	// terminal; progress is then logged as a plain line every few seconds instead
+	FileProgress bool
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+}
+
+type DownloadObjectsOptions struct {
+	// RetryPolicy controls retries of each file. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Order is the order objects are started in
+	Order TransferOrder
+
+	// DirectoryMarkers recreates the directory each matching marker object stands for as an empty directory.
+	// Marker objects, whose keys end in "/", are never downloaded as files
+	DirectoryMarkers bool
+
+	// DestMapper maps each object's key to the file it is downloaded to, or skips it. If nil, each object is
+	// downloaded under the destination at its key
+	DestMapper DestMapper
+
+	// LinkDuplicates downloads objects with the same size and ETag once and hard links the others' files to the
+	// first, or copies it where the file system can't link, to save bandwidth on datasets with many copies of the
+	// same files. Linked files share their contents and attributes, so changing one changes all of them. It is
+	// ignored with Decompress, whose files may not match their objects
+	LinkDuplicates bool
+
+	// Sanitize, if set, escapes the characters in keys the local file system can't hold and settles keys that
+	// would be written to the same file. Each object is then written to its key under the destination, or where
+	// DestMapper puts it. The keys affected are listed in the report's Sanitized field
+	Sanitize *SanitizeOptions
+
+	// RetryFile, if set, is the path of a local file the objects that failed are written to when the download
+	// finishes, so they can be tried again later with ResumeFromRetryFile. Nothing is written if none failed. It
+	// can't be used with DestMapper or Sanitize
+	RetryFile string
+
+	// Heartbeat, if set, writes the download's progress to a heartbeat object every few minutes
+	Heartbeat *HeartbeatOptions
+
+	// Probe measures the endpoint before starting, prints an estimate of how long the download will take, and
+	// runs fewer workers if the endpoint can't keep up with the default number
+	Probe bool
+
+	// Concurrency pins how many objects and parts are fetched at once. Fields left zero are chosen from the object
+	// sizes, as for UploadObjectsOptions
+	Concurrency Concurrency
+
+	// IgnoreDiskSpace starts the download even if the objects are larger than the space free at the destination,
+	// logging a warning instead of failing with ErrInsufficientDiskSpace
+	IgnoreDiskSpace bool
+
+	// MaxBytes, if positive, fails the download with ErrOverBudget before it starts if the objects total more
+	MaxBytes int64
+
+	// PreserveMetadata restores the permissions, modification time, and owner recorded in each object's metadata
+	PreserveMetadata bool
+
+	// Decompress decodes objects stored with Content-Encoding: gzip or zstd as they download, as with DownloadObject
+	Decompress bool
+
+	// Inventory, if set, is matched against instead of listing the bucket, see ReadInventory. Objects created
+	// since the inventory was taken aren't downloaded
+	Inventory *Inventory
+
+	// Hooks, if set, are called as objects start and finish
+	Hooks *Hooks
+
+	// Stats, if set, has live statistics of the transfer passed to its OnStats as it runs
+	Stats *StatsOptions
+
+	// FileProgress shows a bar for each of the largest files in flight, with how long it has been running, above
+	// the overall bar, so a stuck file stands out. It redraws in place, so it is ignored when stderr isn't a
+	// terminal; progress is then logged as a plain line every few seconds instead
+	FileProgress bool
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+}
+
+// retryPolicy returns the given policy or DefaultRetryPolicy if it is nil.
+func retryPolicy(policy *RetryPolicy) RetryPolicy {
+	if policy == nil {
+		return DefaultRetryPolicy
+	}
+
+	return *policy
 }
 
 // ListObjects takes a bucket name and lists all objects in the bucket.
@@ -47,20 +286,12 @@ func (basics BucketBasics) ListObjects(bucketName string) ([]types.Object, error
 		Bucket: aws.String(bucketName),
 	}
 
-	// Create the Paginator for the ListObjectsV2 operation
-	p := s3.NewListObjectsV2Paginator(basics.S3Client, params)
-
 	results := make([]types.Object, 0)
 
 	// Iterate through S3 object pages
-	var i int
-	for p.HasMorePages() {
-		i++
-
-		// Next Page takes a new context for each page retrieval
-		page, err := p.NextPage(context.TODO())
+	for page, err := range basics.listPages(context.TODO(), params) {
 		if err != nil {
-			log.Fatalf("Failed to get page %v in bucket %v: %v", i, bucketName, err)
+			log.Fatalf("Failed to list bucket %v: %v", bucketName, err)
 			return nil, err
 		}
 
@@ -73,182 +304,387 @@ func (basics BucketBasics) ListObjects(bucketName string) ([]types.Object, error
 
 // UploadObject takes a path to a file, the key to name the object, and a bucket name and uploads the file to the bucket.
 func (basics BucketBasics) UploadObject(path string, key string, bucketName string, options UploadObjectOptions) error {
-	// Create a new upload manager
-	uploader := manager.NewUploader(basics.S3Client)
+	return basics.UploadObjectWithContext(context.Background(), path, key, bucketName, options)
+}
+
+// UploadObjectWithContext is UploadObject with a context. If the context is cancelled, a multipart upload in
+// progress is aborted so no orphaned parts are left in the bucket.
+func (basics BucketBasics) UploadObjectWithContext(ctx context.Context, path string, key string, bucketName string, options UploadObjectOptions) error {
+	// Use the batch's upload manager or create a new one
+	uploader := options.uploader
+	if uploader == nil {
+		uploader = basics.newUploader(bucketName)
+	}
 
 	// Open the file
-	f, err := os.Open(path)
+	var f fs.File
+	var err error
+	if options.fsys != nil {
+		f, err = options.fsys.Open(path)
+	} else {
+		f, err = os.Open(path)
+	}
 
 	if err != nil {
 		log.Printf("Couldn't read file %v: %v\n", path, err)
 		return err
 	}
 
-	// Upload the file to the bucket - set the key name to the name of the file
-	_, err = uploader.Upload(context.TODO(), &s3.PutObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(key),
-		Body:   f,
-	})
+	// Close the file after everything is finished
+	defer f.Close()
 
-	if options.bar != nil {
-		fileInfo, err := os.Stat(path)
+	conditions := writeConditions(options.IfNoneMatch, options.IfMatch)
+	if options.etag != nil {
+		conditions = append(conditions, captureETag(options.etag))
+	}
+	lock := objectLock{retention: options.Retention, legalHold: options.LegalHold}
 
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var metadata map[string]string
+	if options.PreserveMetadata {
+		metadata = fileMetadata(fileInfo)
+	}
+
+	// Object Lock needs a Content-MD5, which uploadVerified sends
+	if options.VerifyMD5 || lock.locked() {
+		err = basics.uploadVerified(ctx, f, fileInfo.Size(), key, bucketName, metadata, lock, options.bar, conditions...)
 		if err != nil {
-			log.Printf("Couldn't get size of uploaded file %v: %v", path, err)
+			log.Printf("Couldn't upload object %v to bucket %v: %v\n", path, bucketName, err)
 		}
-		options.bar.Add(int(fileInfo.Size()))
+		return classifyError(err)
 	}
 
-	// fmt.Println("Uploaded", path)
+	// Send small files in a single request without the upload manager
+	if fileInfo.Size() < smallObjectSize {
+		err = basics.putSmallObject(ctx, f, fileInfo.Size(), key, bucketName, metadata, options.bar, conditions...)
+		if err != nil {
+			log.Printf("Couldn't upload object %v to bucket %v: %v\n", path, bucketName, err)
+		}
+		return classifyError(err)
+	}
+
+	// Count bytes as they are read so the progress bar advances during the upload
+	var body io.Reader = f
+	var progress *progressReader
+	if options.bar != nil {
+		body, progress = newProgressReader(f, options.bar)
+	}
+
+	// Upload the file to the bucket - set the key name to the name of the file
+	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:   aws.String(bucketName),
+		Key:      aws.String(key),
+		Body:     body,
+		Metadata: metadata,
+	}, func(u *manager.Uploader) {
+		u.ClientOptions = append(u.ClientOptions, conditions...)
+	})
 
 	if err != nil {
 		log.Printf("Couldn't upload object %v to bucket %v: %v\n", path, bucketName, err)
+		if progress != nil {
+			progress.rollback()
+		}
+		return classifyError(err)
 	}
 
-	return err
+	// fmt.Println("Uploaded", path)
+
+	return nil
 }
 
 // UploadObjects takes a glob pattern for files, a destination path, and a bucket name and uploads all files matching the pattern
-// to the destination concurrently. dest must be empty or end with a "/" to signify a prefix
-func (basics BucketBasics) UploadObjects(pattern string, dest string, bucketName string) error {
-	// Get the files matching the pattern given
-	fs := os.DirFS(".")
-	matches, err := strutil.Glob(fs, pattern)
+// to the destination concurrently. dest must be empty or end with a "/" to signify a prefix. The pattern may be
+// absolute or start with "~", and ${VAR} in either is replaced with the environment variable VAR. The returned
+// report holds the result of every file, including the number of attempts it took.
+func (basics BucketBasics) UploadObjects(pattern string, dest string, bucketName string, options UploadObjectsOptions) (*TransferReport, error) {
+	return basics.UploadObjectsWithContext(context.Background(), pattern, dest, bucketName, options)
+}
 
-	for _, match := range matches {
-		fmt.Println(match)
+// UploadObjectsWithContext is UploadObjects with a context. If the context is cancelled, no new files are started,
+// uploads in flight are aborted, and the report of what completed is returned along with the context's error.
+func (basics BucketBasics) UploadObjectsWithContext(ctx context.Context, pattern string, dest string, bucketName string, options UploadObjectsOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+	options.Concurrency = options.Concurrency.withEnv()
+	basics.detectRegions(ctx, bucketName)
+
+	// The retry file names files by their paths on disk, which a custom file system doesn't have
+	if options.RetryFile != "" && options.FS != nil {
+		return nil, errors.New("RetryFile can't be used with FS")
 	}
 
+	fsys, root, pattern, dest, err := uploadSource(options.FS, options.Root, pattern, dest)
 	if err != nil {
-		log.Printf("Error parsing file pattern: %v\n", err)
-		return err
+		return nil, err
 	}
 
-	dirExcluded := make([]string, 0, len(matches))
-	// Filter the matches to only include files
-	for _, match := range matches {
-		// Get file info of each path
-		fileInfo, err := os.Stat(match)
+	mapper := options.KeyMapper
+	if mapper == nil {
+		mapper = RelativeKeys(pattern)
+	}
 
-		if err != nil {
-			return err
+	// Get the files matching the pattern given
+	uploads, err := uploadsForPattern(fsys, pattern, dest, mapper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Get total size of files to be uploaded
+	var totalSize int64
+	sizes := make([]int64, 0, len(uploads))
+	for _, upload := range uploads {
+		totalSize += upload.Size
+		sizes = append(sizes, upload.Size)
+	}
+	if !options.Quiet {
+		fmt.Println(totalSize)
+	}
+
+	// Put the files in the order they should be started
+	orderBySize(uploads, func(upload FileUpload) int64 { return upload.Size }, options.Order)
+
+	warnRequests("upload", uploadRequests(uploads, options))
+
+	// Make a progress display
+	progress := newTransferProgress("uploading", totalSize, options.FileProgress, options.Quiet)
+
+	// Size the workers to the files and the endpoint
+	concurrency := chooseConcurrency(sizes, 25, options.Concurrency)
+	if options.Probe && options.Concurrency.Workers == 0 {
+		concurrency.Workers = basics.probeBatch(bucketName, false, totalSize, len(uploads), concurrency.Workers)
+	}
+
+	report := &TransferReport{Concurrency: &concurrency}
+	config := batchConfig{
+		workerCount:  concurrency.Workers,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		heartbeat:    basics.newHeartbeat(options.Heartbeat, "upload", bucketName, len(uploads), totalSize),
+		hooks:        options.Hooks,
+		stats:        options.Stats,
+		backpressure: basics.Backpressure,
+	}
+
+	if options.EstimateCompression {
+		report.Compression = &CompressionEstimate{}
+	}
+
+	// Upload every file with a pool of workers sharing an upload manager
+	uploader := basics.newUploader(bucketName, concurrency.uploader)
+	err = runBatch(ctx, uploads, config, func(ctx context.Context, file FileUpload) error {
+		bar := progress.file(file.Key, file.Size)
+		defer progress.done(file.Key)
+
+		if report.Compression != nil {
+			if err := report.Compression.sampleFile(fsys, file.Path, file.Size); err != nil {
+				log.Printf("Couldn't sample %v for compression: %v", file.Path, err)
+			}
 		}
 
-		// Append file path if it isn't a directory
-		if !fileInfo.IsDir() {
-			dirExcluded = append(dirExcluded, filepath.ToSlash(match))
+		return basics.UploadObjectWithContext(ctx, file.Path, file.Key, bucketName, UploadObjectOptions{VerifyMD5: options.VerifyMD5, IfNoneMatch: options.IfNoneMatch, PreserveMetadata: options.PreserveMetadata, Retention: options.Retention, LegalHold: options.LegalHold, bar: bar, fsys: fsys, uploader: uploader})
+	})
+
+	progress.close()
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	if report.Compression != nil && !options.Quiet {
+		fmt.Println(report.Compression)
+	}
+
+	// Record what failed, including files cut off by cancellation, before anything else can fail
+	if options.RetryFile != "" {
+		if retryErr := writeRetryFile(options.RetryFile, bucketName, PlanUpload, root, report); retryErr != nil && err == nil {
+			err = retryErr
 		}
 	}
 
-	parentDir := pattern
+	if err != nil {
+		return report, err
+	}
 
-	globIndex := strings.Index(pattern, "*")
-	if globIndex != -1 {
-		parentDir = parentDir[:globIndex]
+	// Keep empty directories as markers
+	if options.DirectoryMarkers {
+		if err := basics.putDirectoryMarkers(ctx, fsys, pattern, dest, mapper, bucketName); err != nil {
+			return report, err
+		}
 	}
 
-	// Check that the destination is empty or ends in "/"
-	if !(len(dest) == 0 || string(dest[len(dest)-1]) == "/") {
-		log.Printf("Destination must be empty or end in '/'\n")
-		return err
+	// Publish checksums of the uploaded files alongside them
+	var checksumsSum string
+	if options.ChecksumsFile != "" {
+		checksumsSum, err = basics.publishChecksums(fsys, report, dest, options.ChecksumsFile, bucketName)
+		if err != nil {
+			return report, err
+		}
 	}
 
-	// Get total size of files to be uploaded
-	totalSize, err := totalFileSize(dirExcluded)
-	fmt.Println(totalSize)
+	// Write the receipt of the delivery only if nothing failed, before the marker saying it is complete
+	if options.DeliveryManifest != "" && len(report.Failed()) == 0 {
+		err = basics.writeDeliveryManifest(ctx, fsys, root, report, dest, options.DeliveryManifest, bucketName, options.Hashing)
+		if err != nil {
+			return report, err
+		}
+	}
 
-	if err != nil {
-		log.Printf("Error getting total file size: %v", err)
-		return err
+	// Mark the destination as complete only if nothing failed
+	if options.SuccessMarker && len(report.Failed()) == 0 {
+		var content string
+		if checksumsSum != "" {
+			content = fmt.Sprintf("%v  %v\n", checksumsSum, options.ChecksumsFile)
+		}
+
+		err = basics.writeSuccessMarker(dest, bucketName, content)
+		if err != nil {
+			return report, err
+		}
 	}
 
-	// Make a progress bar
-	bar := progressbar.DefaultBytes(totalSize, "uploading")
+	return report, nil
+}
 
-	// Make a queue for files to upload
-	queue := make(chan *FileUpload)
+// uploadSource expands ~ and ${VAR} in an upload pattern and ${VAR} in its destination, and returns the file system
+// to read the pattern from along with where it is on disk, if it is. If fsys is nil, the OS file system is used, with
+// absolute patterns such as C:\data\*.csv rooted at their directory. subdir is a directory within it to read from.
+func uploadSource(fsys fs.FS, subdir string, pattern string, dest string) (fs.FS, string, string, string, error) {
+	var err error
+	if fsys == nil {
+		pattern, err = expandLocalPath(pattern)
+	} else {
+		pattern, err = expandVars(pattern)
+	}
 
-	var wg sync.WaitGroup
-	workerCount := 25
+	if err == nil {
+		dest, err = expandVars(dest)
+	}
 
-	// Create a goroutine for each worker
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
+	if err != nil {
+		log.Printf("Couldn't expand pattern or destination: %v", err)
+		return nil, "", "", "", err
+	}
 
-		go func() {
-			defer wg.Done()
+	pattern = localPattern(pattern)
+	// root is where the file system is on disk, if it is
+	root := ""
+	if fsys == nil {
+		dir, rel := splitAbsPattern(pattern)
+		if dir == "" {
+			dir = "."
+		}
 
-			// Get file upload from queue
-			for file := range queue {
-				// fmt.Printf("Received %v from queue\n", file.Path)
-				basics.UploadObject(file.Path, file.Key, bucketName, UploadObjectOptions{bar: bar})
-			}
-		}()
+		fsys, pattern = localDirFS(dir), rel
+		root = filepath.Join(dir, subdir)
 	}
 
-	// For each file, create a FileUpload struct instance and send it to the queue
-	for _, path := range dirExcluded {
-		// Get the path of a given file excluding the parent directory - this will be the key of the file upload
-		relToParentDir, err := filepath.Rel(parentDir, path)
+	if subdir != "" && subdir != "." {
+		sub, err := fs.Sub(fsys, subdir)
+
 		if err != nil {
-			log.Printf("Couldn't get path of %v relative to %v: %v\n", parentDir, path, err)
+			log.Printf("Couldn't open root %v: %v", subdir, err)
+			return nil, "", "", "", err
 		}
 
-		upload := FileUpload{
-			Path: path,
-			Key:  relToParentDir,
-		}
+		fsys = sub
+	}
 
-		// fmt.Printf("Sending %v to queue\n", upload.Path)
+	return fsys, root, pattern, dest, nil
+}
 
-		queue <- &upload
+// uploadsForPattern takes a file system, a glob pattern for files, a destination path, and a KeyMapper and returns an
+// upload for each file matching the pattern that the mapper doesn't skip, keyed by its mapped key under the
+// destination. If mapper is nil, RelativeKeys is used.
+func uploadsForPattern(fsys fs.FS, pattern string, dest string, mapper KeyMapper) ([]FileUpload, error) {
+	// Check that the destination is empty or ends in "/"
+	if !(len(dest) == 0 || string(dest[len(dest)-1]) == "/") {
+		log.Printf("Destination must be empty or end in '/'\n")
+		return nil, fmt.Errorf("destination %q must be empty or end in '/'", dest)
 	}
 
-	close(queue)
+	// Get the files matching the pattern given
+	matches, err := strutil.Glob(fsys, pattern)
 
-	wg.Wait()
+	if err != nil {
+		log.Printf("Error parsing file pattern: %v\n", err)
+		return nil, err
+	}
 
-	return err
-}
+	if mapper == nil {
+		mapper = RelativeKeys(pattern)
+	}
 
-// totalFileSize gets the total size of a slice of paths to files.
-func totalFileSize(paths []string) (int64, error) {
-	var size int64
-	for _, path := range paths {
+	uploads := make([]FileUpload, 0, len(matches))
+	// Filter the matches to only include files
+	for _, match := range matches {
 		// Get file info of each path
-		fileInfo, err := os.Stat(path)
+		fileInfo, err := fs.Stat(fsys, match)
 
 		if err != nil {
-			return 0, err
+			return nil, err
+		}
+
+		// Skip directories
+		if fileInfo.IsDir() {
+			continue
 		}
 
-		// Get size of file if it isn't a directory
-		if !fileInfo.IsDir() {
-			size += fileInfo.Size()
+		path := filepath.ToSlash(match)
+
+		key, skip := mapper(path)
+		if skip {
+			continue
 		}
+
+		uploads = append(uploads, FileUpload{
+			Path: path,
+			Key:  dest + key,
+			Size: fileInfo.Size(),
+		})
 	}
 
-	return size, nil
+	return uploads, nil
 }
 
 // DownloadObject takes a key, a destination, and a bucket name and downloads the object with that key to the destination.
 func (basics BucketBasics) DownloadObject(key string, dest string, bucketName string, options DownloadObjectOptions) error {
-	// Create a new download manager
-	manager := manager.NewDownloader(basics.S3Client)
+	return basics.DownloadObjectWithContext(context.Background(), key, dest, bucketName, options)
+}
+
+// DownloadObjectWithContext is DownloadObject with a context. If the download fails or the context is cancelled,
+// the partially written file is removed.
+func (basics BucketBasics) DownloadObjectWithContext(ctx context.Context, key string, dest string, bucketName string, options DownloadObjectOptions) error {
+	options.Quiet = defaultQuiet(options.Quiet)
+
+	// Use the batch's download manager or create a new one
+	downloader := options.downloader
+	if downloader == nil {
+		downloader = basics.newDownloader(bucketName)
+	}
 
 	// Create the destination directory if it doesn't exist already
-	err := os.MkdirAll(dest, os.ModePerm)
+	err := os.MkdirAll(localPath(dest), os.ModePerm)
 
 	if err != nil {
 		log.Printf("Couldn't create directory %v: %v", dest, err)
 	}
 
-	// Get base name of file
-	baseName := filepath.Base(key)
+	if options.Decompress {
+		return basics.downloadDecompressed(ctx, key, dest, bucketName, options)
+	}
+
+	// Get base name of file, made valid on this system
+	baseName := localName(filepath.Base(key))
+	if options.name != "" {
+		baseName = options.name
+	}
 
 	// Create file name from destination path and base name of key in bucket
-	fileName := filepath.Join(dest, baseName)
+	fileName := localPath(filepath.Join(dest, baseName))
 
 	// Create the file
 	f, err := os.Create(fileName)
@@ -261,133 +697,233 @@ func (basics BucketBasics) DownloadObject(key string, dest string, bucketName st
 	// Close the file after everything is finished
 	defer f.Close()
 
-	// Download the file
-	_, err = manager.Download(context.Background(), f, &s3.GetObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(key),
-	})
+	// Count bytes as they are written so the progress bar advances during the download
+	var w io.WriterAt = f
+	var progress *progressWriterAt
+	if options.bar != nil {
+		progress = &progressWriterAt{w: f, bar: options.bar}
+		w = progress
+	}
+
+	// Download the file, fetching small objects in a single request without the download manager
+	var metadata map[string]string
+	if options.small {
+		_, metadata, err = basics.getSmallObject(ctx, key, bucketName, io.NewOffsetWriter(w, 0))
+	} else {
+		_, err = downloader.Download(ctx, w, &s3.GetObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(key),
+		})
+	}
 
 	if err != nil {
 		log.Printf("Couldn't download file %v: %v", key, err)
-		return err
+		if progress != nil {
+			progress.rollback()
+		}
+		os.Remove(fileName)
+		return classifyError(err)
 	}
 
-	fmt.Printf("Downloaded %v\n", key)
+	// Restore the file's attributes, looking up the object's metadata if the download didn't return it
+	if options.PreserveMetadata {
+		if metadata == nil {
+			head, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+				Bucket: aws.String(bucketName),
+				Key:    aws.String(key),
+			})
+
+			if err != nil {
+				log.Printf("Couldn't get metadata of %v: %v", key, err)
+				return classifyError(err)
+			}
 
-	// Get size of downloaded file and update progress bar
-	if options.bar != nil {
-		fileInfo, err := os.Stat(f.Name())
+			metadata = head.Metadata
+		}
 
-		if err != nil {
-			log.Printf("Couldn't get size of downloaded file %v: %v", f.Name(), err)
+		if err := restoreFileMetadata(fileName, metadata); err != nil {
+			log.Printf("Couldn't restore attributes of %v: %v", fileName, err)
+			return err
 		}
-		options.bar.Add(int(fileInfo.Size()))
+	}
+
+	if !options.Quiet {
+		fmt.Printf("Downloaded %v\n", key)
 	}
 
 	return nil
 }
 
 // DownloadObjects takes a pattern, a destination, and a bucket name and downloads all objects in the bucket matching
-// that pattern to the destination.
-func (basics BucketBasics) DownloadObjects(pattern string, dest string, bucketName string) error {
-	// Get the prefix of the pattern by stopping before the first wildcard
-	firstWildcard := strings.Index(pattern, "*")
-	prefix := pattern
-	if firstWildcard > -1 {
-		prefix = pattern[:firstWildcard]
-	}
+// that pattern to the destination. ${VAR} in either is replaced with the environment variable VAR, and the
+// destination may start with "~". The returned report holds the result of every object, including the number of
+// attempts it took.
+func (basics BucketBasics) DownloadObjects(pattern string, dest string, bucketName string, options DownloadObjectsOptions) (*TransferReport, error) {
+	return basics.DownloadObjectsWithContext(context.Background(), pattern, dest, bucketName, options)
+}
 
-	// Get every item in bucket
-	params := &s3.ListObjectsV2Input{
-		Bucket: aws.String(bucketName),
+// DownloadObjectsWithContext is DownloadObjects with a context. If the context is cancelled, no new objects are
+// started, downloads in flight are stopped and their partial files removed, and the report of what completed is
+// returned along with the context's error.
+func (basics BucketBasics) DownloadObjectsWithContext(ctx context.Context, pattern string, dest string, bucketName string, options DownloadObjectsOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+	options.Concurrency = options.Concurrency.withEnv()
+	basics.detectRegions(ctx, bucketName)
+
+	// The retry file names files by their directories, which a mapped file's name can't be recovered from
+	if options.RetryFile != "" && (options.DestMapper != nil || options.Sanitize != nil) {
+		return nil, errors.New("RetryFile can't be used with DestMapper or Sanitize")
 	}
 
-	// If the pattern has a prefix that can be identified, add it to the input struct instance.
-	// Otherwise, list all objects.
-	if len(prefix) > 0 {
-		params.Prefix = &prefix
+	matches, markers, pattern, dest, err := basics.downloadSource(ctx, pattern, dest, bucketName, options.Inventory)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create the Paginator for the ListObjectsV2 operation
-	p := s3.NewListObjectsV2Paginator(basics.S3Client, params)
-
-	results := make([]types.Object, 0)
+	if options.DirectoryMarkers {
+		if err := makeMarkerDirs(markers, dest, options.DestMapper); err != nil {
+			return nil, err
+		}
+	}
 
-	// Iterate through S3 object pages
-	var i int
-	for p.HasMorePages() {
-		i++
+	report := &TransferReport{}
+	mapped := options.DestMapper != nil || options.Sanitize != nil
 
-		// Next Page takes a new context for each page retrieval
-		page, err := p.NextPage(context.TODO())
+	var downloads []FileDownload
+	if options.Sanitize != nil {
+		downloads, err = sanitizedDownloads(matches, dest, options.DestMapper, *options.Sanitize, report)
 		if err != nil {
-			log.Fatalf("Failed to get page %v in bucket %v: %v", i, bucketName, err)
-			return err
+			log.Printf("Couldn't download %v: %v", pattern, err)
+			return nil, err
 		}
+	} else if options.DestMapper != nil {
+		downloads = mappedDownloads(matches, dest, options.DestMapper)
+	} else {
+		downloads = downloadsForObjects(matches, dest)
+	}
 
-		// Append to results
-		results = append(results, page.Contents...)
+	// Download each set of identical objects once
+	var duplicates []duplicateDownload
+	if options.LinkDuplicates && !options.Decompress {
+		downloads, duplicates = splitDuplicateDownloads(downloads, matches)
 	}
 
-	// Create a regular expression from the given pattern
-	re := regexp.MustCompile(strutil.WildCardToRegexp(pattern))
+	// Get the total size of the objects to download
+	var totalSize int64
+	for _, download := range downloads {
+		totalSize += download.Size
+	}
 
-	// Create a slice of strings to store matches
-	matches := make([]types.Object, 0, len(results))
+	// Put the objects in the order they should be started
+	orderBySize(downloads, func(download FileDownload) int64 { return download.Size }, options.Order)
 
-	// Loop through contents of bucket
-	for _, item := range results {
-		// Append to slice if the key of the object matches the given pattern
-		if re.MatchString(*item.Key) {
-			matches = append(matches, item)
-		}
+	// Fail before starting if the objects won't fit
+	if err := checkDownloadSpace(dest, totalSize, options.MaxBytes, options.IgnoreDiskSpace); err != nil {
+		log.Printf("Couldn't download %v: %v", pattern, err)
+		return nil, err
 	}
 
-	// Get the total size of the objects matching the pattern
-	totalSize := totalObjectSize(matches)
+	warnRequests("download", downloadRequests(downloads))
 
-	// Make a progress bar
-	bar := progressbar.DefaultBytes(totalSize, "uploading")
+	// Make a progress display
+	progress := newTransferProgress("downloading", totalSize, options.FileProgress, options.Quiet)
 
-	// Make a queue for files to download
-	queue := make(chan *FileDownload)
+	// Size the workers to the objects and the endpoint
+	sizes := make([]int64, 0, len(downloads))
+	for _, download := range downloads {
+		sizes = append(sizes, download.Size)
+	}
 
-	var wg sync.WaitGroup
-	workerCount := 50
+	concurrency := chooseConcurrency(sizes, 50, options.Concurrency)
+	if options.Probe && options.Concurrency.Workers == 0 {
+		concurrency.Workers = basics.probeBatch(bucketName, true, totalSize, len(downloads), concurrency.Workers)
+	}
 
-	// Create a goroutine for each worker
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
+	report.Concurrency = &concurrency
+	config := batchConfig{
+		workerCount:  concurrency.Workers,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		heartbeat:    basics.newHeartbeat(options.Heartbeat, "download", bucketName, len(downloads), totalSize),
+		hooks:        options.Hooks,
+		stats:        options.Stats,
+		backpressure: basics.Backpressure,
+	}
 
-		go func() {
-			defer wg.Done()
+	// Download every object with a pool of workers sharing a download manager
+	downloader := basics.newDownloader(bucketName, concurrency.downloader)
+	err = runBatch(ctx, downloads, config, func(ctx context.Context, file FileDownload) error {
+		bar := progress.file(file.Key, file.Size)
+		defer progress.done(file.Key)
 
-			// Get file download from queue
-			for file := range queue {
-				fmt.Printf("Received %v from queue\n", file.Key)
-				basics.DownloadObject(file.Key, file.Destination, bucketName, DownloadObjectOptions{bar: bar})
-			}
-		}()
-	}
+		// Mapped destinations are the files themselves rather than directories named after the keys
+		dir, name := file.Destination, ""
+		if mapped {
+			dir, name = filepath.Dir(file.Destination), filepath.Base(file.Destination)
+		}
 
-	// For each file, create a FileDownload struct instance and send it to the queue
-	for _, object := range matches {
+		return basics.DownloadObjectWithContext(ctx, file.Key, dir, bucketName, DownloadObjectOptions{PreserveMetadata: options.PreserveMetadata, Decompress: options.Decompress, Quiet: options.Quiet, bar: bar, name: name, downloader: downloader, small: file.Size < smallObjectSize})
+	})
 
-		download := FileDownload{
-			Key:         *object.Key,
-			Destination: filepath.Join(dest, *object.Key), // Write to file in destination directory with the name being the object's key
+	linkDuplicates(duplicates, mapped, report)
+
+	progress.close()
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	if options.RetryFile != "" {
+		if retryErr := writeRetryFile(options.RetryFile, bucketName, PlanDownload, "", report); retryErr != nil && err == nil {
+			err = retryErr
 		}
+	}
 
-		fmt.Printf("Sending %v to queue\n", download.Key)
+	return report, err
+}
 
-		queue <- &download
+// downloadSource expands ${VAR} in a download pattern, and ~ and ${VAR} in its destination, and returns the objects
+// matching the pattern, from the inventory if it isn't nil, split into files and the directory markers among them,
+// along with the expanded pattern and destination.
+func (basics BucketBasics) downloadSource(ctx context.Context, pattern string, dest string, bucketName string, inventory *Inventory) ([]types.Object, []types.Object, string, string, error) {
+	pattern, err := expandVars(pattern)
+	if err == nil {
+		dest, err = expandLocalPath(dest)
 	}
 
-	close(queue)
+	if err != nil {
+		log.Printf("Couldn't expand pattern or destination: %v", err)
+		return nil, nil, "", "", err
+	}
 
-	wg.Wait()
+	var matches []types.Object
+	if inventory != nil {
+		matches = inventory.Match(pattern)
+	} else {
+		matches, err = basics.matchObjects(ctx, pattern, bucketName)
 
-	return nil
+		if err != nil {
+			return nil, nil, "", "", err
+		}
+	}
+
+	// Marker objects stand for directories rather than files
+	files, markers := splitDirectoryMarkers(matches)
+	return files, markers, pattern, dest, nil
+}
+
+// downloadsForObjects returns a download for each object into the destination directory.
+func downloadsForObjects(objects []types.Object, dest string) []FileDownload {
+	downloads := make([]FileDownload, 0, len(objects))
+
+	for _, object := range objects {
+		downloads = append(downloads, FileDownload{
+			Key:         *object.Key,
+			Destination: filepath.Join(dest, localKeyPath(*object.Key)), // Write to file in destination directory with the name being the object's key
+			Size:        aws.ToInt64(object.Size),
+		})
+	}
+
+	return downloads
 }
 
 // totalObjectSize takes a list of items in an S3 bucket and returns the total size in bytes.
@@ -0,0 +1,176 @@
+package boto3manager
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestUploadsForPattern(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"data/a.txt":        {Data: []byte("a")},
+		"data/b.csv":        {Data: []byte("bb")},
+		"data/nested/c.txt": {Data: []byte("ccc")},
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		dest    string
+		mapper  KeyMapper
+		wanted  map[string]string
+	}{
+		{
+			name:    "single directory",
+			pattern: "data/*.txt",
+			dest:    "",
+			wanted:  map[string]string{"data/a.txt": "a.txt"},
+		},
+		{
+			name:    "recursive with destination",
+			pattern: "data/**/*.txt",
+			dest:    "out/",
+			wanted:  map[string]string{"data/a.txt": "out/a.txt", "data/nested/c.txt": "out/nested/c.txt"},
+		},
+		{
+			name:    "no wildcard",
+			pattern: "data/b.csv",
+			dest:    "out/",
+			wanted:  map[string]string{"data/b.csv": "out/b.csv"},
+		},
+		{
+			name:    "wildcard within a name",
+			pattern: "data/nested/c*",
+			wanted:  map[string]string{"data/nested/c.txt": "c.txt"},
+		},
+		{
+			name:    "mapper",
+			pattern: "data/**/*",
+			dest:    "out/",
+			mapper:  FlattenKeys,
+			wanted:  map[string]string{"data/a.txt": "out/a.txt", "data/b.csv": "out/b.csv", "data/nested/c.txt": "out/c.txt"},
+		},
+		{
+			name:    "mapper skipping",
+			pattern: "data/**/*",
+			mapper: func(localPath string) (string, bool) {
+				return localPath, strings.HasSuffix(localPath, ".txt")
+			},
+			wanted: map[string]string{"data/b.csv": "data/b.csv"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploads, err := uploadsForPattern(fsys, tt.pattern, tt.dest, tt.mapper)
+			if err != nil {
+				t.Fatalf("uploadsForPattern(%q) returned error %v", tt.pattern, err)
+			}
+
+			if len(uploads) != len(tt.wanted) {
+				t.Fatalf("uploadsForPattern(%q) = %v, want %v", tt.pattern, uploads, tt.wanted)
+			}
+
+			for _, upload := range uploads {
+				if key := tt.wanted[upload.Path]; key != upload.Key {
+					t.Errorf("uploadsForPattern(%q) key for %v = %v, want %v", tt.pattern, upload.Path, upload.Key, key)
+				}
+			}
+		})
+	}
+}
+
+func TestUploadsForPatternDestination(t *testing.T) {
+	t.Parallel()
+
+	if _, err := uploadsForPattern(fstest.MapFS{}, "*", "out", nil); err == nil {
+		t.Errorf("uploadsForPattern() with destination \"out\" didn't return an error")
+	}
+}
+
+// captureStdout returns what fn prints to stdout. It replaces os.Stdout, so tests using it can't run in parallel.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestDownloadObjectsQuiet(t *testing.T) {
+	fake := newFakeS3(t, "data")
+	fake.put("data", "logs/a.csv", "a", nil)
+	fake.put("data", "logs/b.csv.gz", "", http.Header{"Content-Encoding": {"gzip"}})
+
+	tests := []struct {
+		name    string
+		env     string
+		options DownloadObjectsOptions
+	}{
+		{name: "option", options: DownloadObjectsOptions{Quiet: true, Decompress: true}},
+		{name: "environment", env: "1", options: DownloadObjectsOptions{Decompress: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvQuiet, tt.env)
+
+			out := captureStdout(t, func() {
+				if _, err := fake.basics().DownloadObjects("logs/*", t.TempDir(), "data", tt.options); err != nil {
+					t.Errorf("DownloadObjects() = %v, want nil", err)
+				}
+			})
+
+			if out != "" {
+				t.Errorf("DownloadObjects() while quiet printed %q, want nothing", out)
+			}
+		})
+	}
+}
+
+func TestQuietListingAndDeletion(t *testing.T) {
+	fake := newFakeS3(t, "data")
+	fake.put("data", "sync/old.txt", "old", nil)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.csv"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() {
+		if uploads, err := uploadsForPattern(os.DirFS(dir), "*.csv", "", nil); err != nil || len(uploads) != 1 {
+			t.Errorf("uploadsForPattern() = %v, %v, want one upload", uploads, err)
+		}
+
+		objects := map[string]ObjectInfo{"old.txt": {Key: "sync/old.txt", Size: 3}}
+		if err := fake.basics().deleteRemote(context.Background(), "data", objects, []string{"old.txt"}, "", &TransferReport{}, true); err != nil {
+			t.Errorf("deleteRemote() = %v, want nil", err)
+		}
+	})
+
+	if out != "" {
+		t.Errorf("matching files and quiet deletion printed %q, want nothing", out)
+	}
+}
@@ -0,0 +1,156 @@
+package boto3manager
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// bucketRegionKey identifies a bucket by its name and the endpoint it's on, since buckets on different S3
+// compatible endpoints can share a name.
+type bucketRegionKey struct {
+	endpoint string
+	bucket   string
+}
+
+// bucketRegion is a cached result of detecting a bucket's region.
+type bucketRegion struct {
+	region string
+
+	// err is why the region couldn't be detected, and expires when to try again
+	err     error
+	expires time.Time
+}
+
+// bucketRegions caches the regions found by DetectBucketRegion for the life of the process, and failures for
+// regionFailureTTL.
+var bucketRegions sync.Map
+
+// regionFailureTTL is how long a failure to detect a bucket's region is remembered, so a missing bucket doesn't
+// cost a HeadBucket on every request of a batch but one created later is still found.
+const regionFailureTTL = time.Minute
+
+// regionDetectTimeout bounds detecting a region outside a batch, where no caller's context is at hand.
+const regionDetectTimeout = 10 * time.Second
+
+// regionalClientKey identifies a copy of a client for another region.
+type regionalClientKey struct {
+	client *s3.Client
+	region string
+}
+
+// regionalClients caches the copies of clients made for buckets in other regions.
+var regionalClients sync.Map
+
+// DetectBucketRegion takes a bucket name and returns the region it's in, from the x-amz-bucket-region header of a
+// HeadBucket response. S3 sends the header even when the request went to the wrong region or was denied, so only the
+// bucket needs to exist. The region is cached for the life of the process, and a failure for a minute. Endpoints that
+// don't send the header are taken to be in the client's region.
+func (basics BucketBasics) DetectBucketRegion(bucketName string) (string, error) {
+	return basics.DetectBucketRegionWithContext(context.TODO(), bucketName)
+}
+
+// DetectBucketRegionWithContext is DetectBucketRegion with a context.
+func (basics BucketBasics) DetectBucketRegionWithContext(ctx context.Context, bucketName string) (string, error) {
+	client, ok := basics.BucketClients[bucketName]
+	if !ok {
+		client = basics.S3Client
+	}
+
+	return detectBucketRegion(ctx, client, bucketName)
+}
+
+// detectBucketRegion returns the region of a bucket on the client's endpoint, from the cache if it has been found
+// before or failed within regionFailureTTL.
+func detectBucketRegion(ctx context.Context, client *s3.Client, bucketName string) (string, error) {
+	key := bucketRegionKey{endpoint: aws.ToString(client.Options().BaseEndpoint), bucket: bucketName}
+	if cached, ok := cachedBucketRegion(key); ok {
+		return cached.region, cached.err
+	}
+
+	region, err := manager.GetBucketRegion(ctx, client, bucketName)
+	if err != nil {
+		log.Printf("Couldn't detect the region of bucket %v: %v", bucketName, err)
+
+		// A cancelled caller says nothing about the bucket
+		if ctx.Err() == nil {
+			bucketRegions.Store(key, bucketRegion{err: classifyError(err), expires: time.Now().Add(regionFailureTTL)})
+		}
+		return "", classifyError(err)
+	}
+	if region == "" {
+		region = client.Options().Region
+	}
+
+	bucketRegions.Store(key, bucketRegion{region: region})
+	return region, nil
+}
+
+// cachedBucketRegion returns the cached region of a bucket, or the failure to detect it if that hasn't expired.
+func cachedBucketRegion(key bucketRegionKey) (bucketRegion, bool) {
+	value, ok := bucketRegions.Load(key)
+	if !ok {
+		return bucketRegion{}, false
+	}
+
+	cached := value.(bucketRegion)
+	if cached.err != nil && time.Now().After(cached.expires) {
+		bucketRegions.CompareAndDelete(key, value)
+		return bucketRegion{}, false
+	}
+
+	return cached, true
+}
+
+// detectRegions detects the regions of the buckets with the batch's context before the batch starts, so the
+// requests it makes go to the right region without client detecting them on first use. It does nothing unless
+// DetectRegions is set. Failures are left for the batch's requests to report.
+func (basics BucketBasics) detectRegions(ctx context.Context, bucketNames ...string) {
+	if !basics.DetectRegions {
+		return
+	}
+
+	for _, bucketName := range bucketNames {
+		if bucketName == "" || isBucketARN(bucketName) || IsDirectoryBucket(bucketName) {
+			continue
+		}
+
+		client, ok := basics.BucketClients[bucketName]
+		if !ok {
+			client = basics.S3Client
+		}
+
+		detectBucketRegion(ctx, client, bucketName)
+	}
+}
+
+// regionalClient returns a client for the region a bucket is in. Batches detect the region up front with their
+// context; otherwise it is detected the first time the bucket is used, within regionDetectTimeout. A copy of the
+// client is made once for each other region and reused. If the region can't be detected, the client is returned
+// unchanged and the request fails, or succeeds, on its own.
+func regionalClient(client *s3.Client, bucketName string) *s3.Client {
+	ctx, cancel := context.WithTimeout(context.Background(), regionDetectTimeout)
+	defer cancel()
+
+	region, err := detectBucketRegion(ctx, client, bucketName)
+	if err != nil || region == client.Options().Region {
+		return client
+	}
+
+	key := regionalClientKey{client: client, region: region}
+	if cached, ok := regionalClients.Load(key); ok {
+		return cached.(*s3.Client)
+	}
+
+	regional := s3.New(client.Options(), func(o *s3.Options) {
+		o.Region = region
+	})
+
+	cached, _ := regionalClients.LoadOrStore(key, regional)
+	return cached.(*s3.Client)
+}
@@ -0,0 +1,104 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestRegionalClient(t *testing.T) {
+	t.Parallel()
+
+	shared := s3.New(s3.Options{Region: "us-east-1"})
+	basics := BucketBasics{S3Client: shared, DetectRegions: true}
+
+	// Seed the cache so no request is sent
+	bucketRegions.Store(bucketRegionKey{bucket: "regional-test-west"}, bucketRegion{region: "us-west-2"})
+	bucketRegions.Store(bucketRegionKey{bucket: "regional-test-east"}, bucketRegion{region: "us-east-1"})
+
+	tests := []struct {
+		bucketName string
+		wanted     string
+	}{
+		{bucketName: "regional-test-west", wanted: "us-west-2"},
+		{bucketName: "regional-test-east", wanted: "us-east-1"},
+	}
+
+	for _, tt := range tests {
+		if got := basics.client(tt.bucketName).Options().Region; got != tt.wanted {
+			t.Errorf("client(%q) region = %v, want %v", tt.bucketName, got, tt.wanted)
+		}
+
+		region, err := basics.DetectBucketRegion(tt.bucketName)
+		if err != nil || region != tt.wanted {
+			t.Errorf("DetectBucketRegion(%q) = %v, %v, want %v", tt.bucketName, region, err, tt.wanted)
+		}
+	}
+
+	if got := basics.client("regional-test-east"); got != shared {
+		t.Errorf("client() in the client's region = %p, want %p", got, shared)
+	}
+	if first, second := basics.client("regional-test-west"), basics.client("regional-test-west"); first != second {
+		t.Errorf("client() for another region = %p, then %p, want the same client", first, second)
+	}
+}
+
+func TestDetectRegionsCachesFailures(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.region = "us-west-2"
+	basics := fake.basics()
+	basics.DetectRegions = true
+
+	// Detected once for the batch, then reused by every request
+	basics.detectRegions(context.Background(), "data", "missing")
+	for range 3 {
+		if got := basics.client("data").Options().Region; got != "us-west-2" {
+			t.Errorf("client(data) region = %v, want us-west-2", got)
+		}
+		basics.client("missing")
+	}
+
+	tests := []struct {
+		request string
+		wanted  int
+	}{
+		{"HEAD data", 1},
+		{"HEAD missing", 1},
+	}
+
+	for _, tt := range tests {
+		if got := fake.count(tt.request); got != tt.wanted {
+			t.Errorf("detectRegions() then client() sent %v %v times, want %v", tt.request, got, tt.wanted)
+		}
+	}
+
+	// An expired failure is tried again
+	key := bucketRegionKey{endpoint: fake.server.URL, bucket: "missing"}
+	bucketRegions.Store(key, bucketRegion{err: errors.New("no such bucket"), expires: time.Now().Add(-time.Second)})
+	basics.client("missing")
+	if got := fake.count("HEAD missing"); got != 2 {
+		t.Errorf("client() after the failure expired sent HEAD missing %v times, want 2", got)
+	}
+}
+
+func TestDetectRegionsCancelled(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	basics := fake.basics()
+	basics.DetectRegions = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A cancelled batch says nothing about the bucket, so nothing is remembered
+	basics.detectRegions(ctx, "data")
+	if cached, ok := cachedBucketRegion(bucketRegionKey{endpoint: fake.server.URL, bucket: "data"}); ok {
+		t.Errorf("detectRegions() with a cancelled context cached %+v, want nothing", cached)
+	}
+}
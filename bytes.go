@@ -0,0 +1,44 @@
+package boto3manager
+
+import (
+	"context"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DownloadObjectBytes takes a key and a bucket name and downloads the object into memory. It is meant for small
+// objects such as configs and manifests that don't need to be written to disk.
+func (basics BucketBasics) DownloadObjectBytes(key string, bucketName string) ([]byte, error) {
+	buf := manager.NewWriteAtBuffer([]byte{})
+
+	_, err := basics.DownloadObjectTo(key, bucketName, buf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DownloadObjectTo takes a key, a bucket name, and an io.WriterAt and downloads the object into w, returning the
+// number of bytes written. Parts may be written out of order and concurrently.
+func (basics BucketBasics) DownloadObjectTo(key string, bucketName string, w io.WriterAt) (int64, error) {
+	// Create a new download manager
+	downloader := basics.newDownloader(bucketName)
+
+	// Download the object
+	n, err := downloader.Download(context.TODO(), w, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't download object %v: %v", key, err)
+	}
+
+	return n, classifyError(err)
+}
@@ -0,0 +1,43 @@
+package boto3manager
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDownloadObjectBytes(t *testing.T) {
+	t.Parallel()
+
+	// Large enough to be fetched in several ranged parts
+	large := bytes.Repeat([]byte("0123456789abcdef"), 800*1024)
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "config.json", `{"a": 1}`, nil)
+	fake.put("data", "large.bin", string(large), nil)
+	fake.put("data", "empty", "", nil)
+
+	tests := []struct {
+		key    string
+		wanted []byte
+	}{
+		{key: "config.json", wanted: []byte(`{"a": 1}`)},
+		{key: "large.bin", wanted: large},
+		{key: "empty", wanted: []byte{}},
+	}
+
+	for _, test := range tests {
+		got, err := fake.basics().DownloadObjectBytes(test.key, "data")
+		if err != nil || !bytes.Equal(got, test.wanted) {
+			t.Errorf("DownloadObjectBytes(%v) = %v bytes, %v, want %v bytes", test.key, len(got), err, len(test.wanted))
+		}
+	}
+
+	if got := fake.count("GET data/large.bin"); got < 2 {
+		t.Errorf("DownloadObjectBytes(large.bin) sent %v GETs, want one per part", got)
+	}
+
+	if _, err := fake.basics().DownloadObjectBytes("missing", "data"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("DownloadObjectBytes(missing) = %v, want %v", err, ErrObjectNotFound)
+	}
+}
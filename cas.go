@@ -0,0 +1,183 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type ContentAddressedOptions struct {
+	// ManifestKey is where the sha256sum-style manifest mapping each file's path to its checksum is written. If
+	// empty, it is written under prefix+"manifests/", named for the time of the upload
+	ManifestKey string
+
+	// RetryPolicy controls retries of each file. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Hooks, if set, are called as files start and finish. Skipped files are not passed to the object hooks
+	Hooks *Hooks
+
+	// Hashing controls how the files are hashed before they are uploaded
+	Hashing HashOptions
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+}
+
+// ContentAddressedKey returns the key a file with the hex encoded SHA-256 checksum sum is stored under in a
+// content-addressed store at prefix, e.g. prefix+"ab/cd/abcd...", so no single folder grows too large.
+func ContentAddressedKey(prefix string, sum string) string {
+	return prefix + sum[:2] + "/" + sum[2:4] + "/" + sum
+}
+
+// UploadContentAddressed takes a glob pattern for files, a prefix, and a bucket name and uploads each file under a
+// key derived from its SHA-256 checksum (see ContentAddressedKey). Contents already in the store, or repeated
+// within the upload, are skipped, so the same data is stored once however many datasets include it. Once every
+// file is stored, a sha256sum-style manifest mapping the files' paths, relative to the pattern's parent directory,
+// to their checksums is written, and its key is returned. The pattern is expanded as in UploadObjects.
+func (basics BucketBasics) UploadContentAddressed(pattern string, prefix string, bucketName string, options ContentAddressedOptions) (string, *TransferReport, error) {
+	return basics.UploadContentAddressedWithContext(context.Background(), pattern, prefix, bucketName, options)
+}
+
+// UploadContentAddressedWithContext is UploadContentAddressed with a context.
+func (basics BucketBasics) UploadContentAddressedWithContext(ctx context.Context, pattern string, prefix string, bucketName string, options ContentAddressedOptions) (string, *TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+	basics.detectRegions(ctx, bucketName)
+
+	fsys, root, pattern, _, err := uploadSource(nil, "", pattern, "")
+	if err != nil {
+		return "", nil, err
+	}
+
+	files, err := uploadsForPattern(fsys, pattern, "", nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	options.Hashing.quiet = options.Quiet
+	sums, err := hashFiles(fsys, root, files, options.Hashing)
+	if err != nil {
+		return "", nil, err
+	}
+
+	report := &TransferReport{}
+
+	// Keep the first file with each checksum, skipping the rest
+	uploads := make([]FileUpload, 0)
+	seen := make(map[string]bool)
+	for _, file := range files {
+		key := ContentAddressedKey(prefix, sums[file.Key])
+
+		if seen[key] {
+			report.record(TransferResult{Key: key, Path: file.Path, Size: file.Size, SkipReason: "duplicate"})
+			continue
+		}
+		seen[key] = true
+
+		uploads = append(uploads, FileUpload{Path: file.Path, Key: key, Size: file.Size})
+	}
+
+	existing, err := basics.existingKeys(ctx, uploads, bucketName)
+	if err != nil {
+		return "", report, err
+	}
+
+	missing := make([]FileUpload, 0, len(uploads))
+	var totalSize int64
+	for _, upload := range uploads {
+		if existing[upload.Key] {
+			report.record(TransferResult{Key: upload.Key, Path: upload.Path, Size: upload.Size, SkipReason: "exists"})
+			continue
+		}
+
+		missing = append(missing, upload)
+		totalSize += upload.Size
+	}
+
+	bar := newBytesBar(totalSize, "uploading")
+	if options.Quiet {
+		bar = silentBar(totalSize)
+	}
+
+	config := batchConfig{
+		workerCount:  25,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		hooks:        options.Hooks,
+		backpressure: basics.Backpressure,
+	}
+
+	uploader := basics.newUploader(bucketName)
+	err = runBatch(ctx, missing, config, func(ctx context.Context, file FileUpload) error {
+		return basics.UploadObjectWithContext(ctx, file.Path, file.Key, bucketName, UploadObjectOptions{bar: bar, fsys: fsys, uploader: uploader})
+	})
+
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	// A manifest is only written once everything it refers to is stored
+	if err != nil || len(report.Failed()) > 0 {
+		return "", report, err
+	}
+
+	manifestKey := options.ManifestKey
+	if manifestKey == "" {
+		manifestKey = prefix + "manifests/" + time.Now().UTC().Format(BackupTimeFormat) + ".sha256"
+	}
+
+	if err := basics.writeSumsFile(ctx, manifestKey, bucketName, sums); err != nil {
+		log.Printf("Couldn't write manifest %v: %v", manifestKey, err)
+		return "", report, err
+	}
+
+	return manifestKey, report, nil
+}
+
+// existingKeys checks which of the uploads' keys already exist in the bucket with concurrent HEAD requests.
+func (basics BucketBasics) existingKeys(ctx context.Context, uploads []FileUpload, bucketName string) (map[string]bool, error) {
+	var mu sync.Mutex
+	existing := make(map[string]bool)
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  25,
+		policy:       DefaultRetryPolicy,
+		report:       report,
+		backpressure: basics.Backpressure,
+	}
+
+	err := runBatch(ctx, uploads, config, func(ctx context.Context, file FileUpload) error {
+		_, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(file.Key),
+		})
+
+		if isNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		existing[file.Key] = true
+		mu.Unlock()
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		log.Printf("Couldn't check whether %v exists: %v", failed[0].Key, failed[0].Err)
+		return nil, failed[0].Err
+	}
+
+	return existing, nil
+}
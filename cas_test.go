@@ -0,0 +1,60 @@
+package boto3manager
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestContentAddressedKey(t *testing.T) {
+	t.Parallel()
+
+	sum := "2d711642b726b04401627ca9fbac32f5c8530fb1903cc4db02258717921a4881"
+	wanted := "cas/2d/71/2d711642b726b04401627ca9fbac32f5c8530fb1903cc4db02258717921a4881"
+
+	if got := ContentAddressedKey("cas/", sum); got != wanted {
+		t.Errorf("ContentAddressedKey(cas/, %v) = %v, want %v", sum, got, wanted)
+	}
+}
+
+func TestUploadContentAddressed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"data/a.txt": "same", "data/sub/b.txt": "same", "data/c.txt": "stored"})
+	t.Setenv("CAS_DIR", filepath.ToSlash(dir))
+
+	fake := newFakeS3(t, "bucket")
+	stored := ContentAddressedKey("cas/", sha256Hex("stored"))
+	fake.put("bucket", stored, "stored", nil)
+
+	manifestKey, report, err := fake.basics().UploadContentAddressed("${CAS_DIR}/data/**/*", "cas/", "bucket", ContentAddressedOptions{ManifestKey: "cas/manifest.sha256", Quiet: true})
+	if err != nil {
+		t.Fatalf("UploadContentAddressed() = %v, want nil", err)
+	}
+	if manifestKey != "cas/manifest.sha256" {
+		t.Errorf("UploadContentAddressed() = %v, want cas/manifest.sha256", manifestKey)
+	}
+
+	// The repeated contents are stored once, and the contents already in the store not at all
+	same := ContentAddressedKey("cas/", sha256Hex("same"))
+	if got := fake.count("PUT bucket/" + same); got != 1 {
+		t.Errorf("UploadContentAddressed() put %v %v times, want 1", same, got)
+	}
+	if got := fake.count("PUT bucket/" + stored); got != 0 {
+		t.Errorf("UploadContentAddressed() put %v %v times, want 0", stored, got)
+	}
+	if object, _ := fake.object("bucket", same); string(object.body) != "same" {
+		t.Errorf("UploadContentAddressed() stored %q at %v, want %q", object.body, same, "same")
+	}
+
+	skipped := make(map[string]int)
+	for _, result := range report.Results {
+		skipped[result.SkipReason]++
+	}
+	if skipped["duplicate"] != 1 || skipped["exists"] != 1 {
+		t.Errorf("UploadContentAddressed() skipped %v, want one duplicate and one existing", skipped)
+	}
+
+	wanted := sha256Hex("same") + "  a.txt\n" + sha256Hex("stored") + "  c.txt\n" + sha256Hex("same") + "  sub/b.txt\n"
+	if manifest, _ := fake.object("bucket", manifestKey); string(manifest.body) != wanted {
+		t.Errorf("UploadContentAddressed() wrote manifest %q, want %q", manifest.body, wanted)
+	}
+}
@@ -0,0 +1,86 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// CatObject takes a key and a bucket name and streams the whole object to w, returning the number of bytes written.
+func (basics BucketBasics) CatObject(key string, bucketName string, w io.Writer) (int64, error) {
+	return basics.catRange(key, bucketName, "", w)
+}
+
+// HeadBytes takes a key, a bucket name, and a count and returns the first n bytes of the object. Fewer bytes are
+// returned if the object is smaller, and none if it is empty.
+func (basics BucketBasics) HeadBytes(key string, bucketName string, n int64) ([]byte, error) {
+	return basics.readRange(key, bucketName, fmt.Sprintf("bytes=0-%d", n-1), n)
+}
+
+// TailBytes takes a key, a bucket name, and a count and returns the last n bytes of the object. Fewer bytes are
+// returned if the object is smaller, and none if it is empty.
+func (basics BucketBasics) TailBytes(key string, bucketName string, n int64) ([]byte, error) {
+	return basics.readRange(key, bucketName, fmt.Sprintf("bytes=-%d", n), n)
+}
+
+// readRange reads a range of up to n bytes of an object into memory.
+func (basics BucketBasics) readRange(key string, bucketName string, byteRange string, n int64) ([]byte, error) {
+	if n <= 0 {
+		return []byte{}, nil
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, n))
+
+	if _, err := basics.catRange(key, bucketName, byteRange, buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isInvalidRange reports whether an error means a requested range is outside the object.
+func isInvalidRange(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange"
+}
+
+// catRange streams a range of an object to w. An empty range streams the whole object.
+func (basics BucketBasics) catRange(key string, bucketName string, byteRange string, w io.Writer) (int64, error) {
+	params := &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	}
+
+	if byteRange != "" {
+		params.Range = aws.String(byteRange)
+	}
+
+	obj, err := basics.client(bucketName).GetObject(context.TODO(), params)
+
+	// Every range of an empty object is unsatisfiable, but reads as nothing
+	if byteRange != "" && isInvalidRange(err) {
+		return 0, nil
+	}
+
+	if err != nil {
+		log.Printf("Couldn't get object %v: %v", key, err)
+		return 0, classifyError(err)
+	}
+
+	defer obj.Body.Close()
+
+	n, err := io.Copy(w, obj.Body)
+
+	if err != nil {
+		log.Printf("Couldn't read object %v: %v", key, err)
+	}
+
+	return n, err
+}
@@ -0,0 +1,73 @@
+package boto3manager
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHeadTailBytes(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "digits.txt", "0123456789", nil)
+	fake.put("data", "empty.txt", "", nil)
+
+	tests := []struct {
+		name   string
+		read   func(basics BucketBasics) ([]byte, error)
+		wanted string
+	}{
+		{
+			name:   "head",
+			read:   func(basics BucketBasics) ([]byte, error) { return basics.HeadBytes("digits.txt", "data", 3) },
+			wanted: "012",
+		},
+		{
+			name:   "head past the end",
+			read:   func(basics BucketBasics) ([]byte, error) { return basics.HeadBytes("digits.txt", "data", 20) },
+			wanted: "0123456789",
+		},
+		{
+			name:   "head of an empty object",
+			read:   func(basics BucketBasics) ([]byte, error) { return basics.HeadBytes("empty.txt", "data", 3) },
+			wanted: "",
+		},
+		{
+			name:   "tail",
+			read:   func(basics BucketBasics) ([]byte, error) { return basics.TailBytes("digits.txt", "data", 3) },
+			wanted: "789",
+		},
+		{
+			name:   "tail of an empty object",
+			read:   func(basics BucketBasics) ([]byte, error) { return basics.TailBytes("empty.txt", "data", 3) },
+			wanted: "",
+		},
+		{
+			name:   "no bytes",
+			read:   func(basics BucketBasics) ([]byte, error) { return basics.HeadBytes("digits.txt", "data", 0) },
+			wanted: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.read(fake.basics())
+			if err != nil || string(got) != tt.wanted {
+				t.Errorf("%v = %q, %v, want %q", tt.name, got, err, tt.wanted)
+			}
+		})
+	}
+
+	// A missing object is still an error, whatever the range
+	if _, err := fake.basics().HeadBytes("missing.txt", "data", 3); err == nil {
+		t.Error("HeadBytes() of a missing object = nil error, want an error")
+	}
+
+	// Reading the whole of an empty object needs no range
+	var buf bytes.Buffer
+	if n, err := fake.basics().CatObject("empty.txt", "data", &buf); err != nil || n != 0 {
+		t.Errorf("CatObject() of an empty object = %v, %v, want 0, nil", n, err)
+	}
+}
@@ -0,0 +1,216 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// maxChannelHistory is how many previous targets a channel remembers for rollback.
+const maxChannelHistory = 20
+
+// ChannelPointer is the content of a channel pointer object. Target is the immutable release prefix the channel
+// currently points at.
+type ChannelPointer struct {
+	Target  string    `json:"target"`
+	Updated time.Time `json:"updated"`
+
+	// History holds the previous targets, most recent first
+	History []string `json:"history,omitempty"`
+}
+
+// Channels manages pointer objects such as stable, latest, or v1.2 that each reference an immutable release prefix,
+// e.g. releases/v1.2.3/. Pointers are stored as JSON objects under root + "channels/".
+type Channels struct {
+	basics     BucketBasics
+	bucketName string
+	root       string
+}
+
+// Channels takes a bucket name and a root prefix and returns a Channels for the pointers under it.
+func (basics BucketBasics) Channels(bucketName string, root string) Channels {
+	return Channels{basics: basics, bucketName: bucketName, root: root}
+}
+
+// pointerKey returns the key of a channel's pointer object.
+func (c Channels) pointerKey(name string) string {
+	return c.root + "channels/" + name + ".json"
+}
+
+// Get returns the pointer of a channel.
+func (c Channels) Get(name string) (ChannelPointer, error) {
+	pointer, _, err := c.get(context.TODO(), name)
+	return pointer, err
+}
+
+// get returns the pointer of a channel and the ETag of its object. The ETag is empty if the channel doesn't exist.
+func (c Channels) get(ctx context.Context, name string) (ChannelPointer, string, error) {
+	var pointer ChannelPointer
+
+	obj, err := c.basics.client(c.bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucketName),
+		Key:    aws.String(c.pointerKey(name)),
+	})
+
+	if err != nil {
+		if isNotFound(err) {
+			return pointer, "", fmt.Errorf("channel %v doesn't exist: %w", name, err)
+		}
+		log.Printf("Couldn't get channel %v: %v", name, err)
+		return pointer, "", err
+	}
+
+	defer obj.Body.Close()
+
+	if err := json.NewDecoder(obj.Body).Decode(&pointer); err != nil {
+		return pointer, "", fmt.Errorf("channel %v is corrupt: %w", name, err)
+	}
+
+	return pointer, aws.ToString(obj.ETag), nil
+}
+
+// Resolve returns the key of an object within the release a channel points at.
+func (c Channels) Resolve(name string, key string) (string, error) {
+	pointer, err := c.Get(name)
+
+	if err != nil {
+		return "", err
+	}
+
+	return pointer.Target + key, nil
+}
+
+// Promote points a channel at a release prefix, creating the channel if needed. The release must contain at least one
+// object. The pointer is replaced atomically with a conditional write, so concurrent promotions can't lose each other's
+// history.
+func (c Channels) Promote(name string, target string) error {
+	if !strings.HasSuffix(target, "/") {
+		return fmt.Errorf("release prefix %q must end in '/'", target)
+	}
+
+	ctx := context.TODO()
+
+	// Refuse to point a channel at a release that doesn't exist
+	output, err := c.basics.client(c.bucketName).ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(c.bucketName),
+		Prefix:  aws.String(target),
+		MaxKeys: aws.Int32(1),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't list release %v: %v", target, err)
+		return err
+	}
+
+	if len(output.Contents) == 0 {
+		return fmt.Errorf("release %v is empty or doesn't exist", target)
+	}
+
+	return c.update(ctx, name, func(pointer ChannelPointer, exists bool) (ChannelPointer, error) {
+		if exists && pointer.Target != target {
+			pointer.History = append([]string{pointer.Target}, pointer.History...)
+		}
+		pointer.Target = target
+		return pointer, nil
+	})
+}
+
+// Rollback points a channel back at its previous target and returns the new pointer.
+func (c Channels) Rollback(name string) (ChannelPointer, error) {
+	var result ChannelPointer
+
+	err := c.update(context.TODO(), name, func(pointer ChannelPointer, exists bool) (ChannelPointer, error) {
+		if !exists || len(pointer.History) == 0 {
+			return pointer, fmt.Errorf("channel %v has no previous release to roll back to", name)
+		}
+
+		pointer.Target, pointer.History = pointer.History[0], pointer.History[1:]
+		result = pointer
+		return pointer, nil
+	})
+
+	return result, err
+}
+
+// update applies fn to a channel's pointer and writes it back with compare-and-swap semantics, retrying if another
+// writer changed the pointer in the meantime.
+func (c Channels) update(ctx context.Context, name string, fn func(pointer ChannelPointer, exists bool) (ChannelPointer, error)) error {
+	for attempt := 0; attempt < 5; attempt++ {
+		pointer, etag, err := c.get(ctx, name)
+		exists := err == nil
+
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+
+		pointer, err = fn(pointer, exists)
+		if err != nil {
+			return err
+		}
+
+		pointer.Updated = time.Now().UTC()
+		if len(pointer.History) > maxChannelHistory {
+			pointer.History = pointer.History[:maxChannelHistory]
+		}
+
+		body, err := json.MarshalIndent(pointer, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		// Only write if the pointer is unchanged since it was read, or still doesn't exist
+		condition := withHeader("If-None-Match", "*")
+		if exists {
+			condition = withHeader("If-Match", etag)
+		}
+
+		_, err = c.basics.client(c.bucketName).PutObject(ctx, &s3.PutObjectInput{
+			Bucket:       aws.String(c.bucketName),
+			Key:          aws.String(c.pointerKey(name)),
+			Body:         bytes.NewReader(body),
+			ContentType:  aws.String("application/json"),
+			CacheControl: aws.String("no-cache"),
+		}, condition)
+
+		if isPreconditionFailed(err) {
+			continue
+		}
+
+		if err != nil {
+			log.Printf("Couldn't update channel %v: %v", name, err)
+		}
+
+		return err
+	}
+
+	return fmt.Errorf("channel %v kept changing while it was being updated", name)
+}
+
+// withHeader returns an s3.Options function that sets a header on a single request.
+func withHeader(header string, value string) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue(header, value))
+	}
+}
+
+// isPreconditionFailed reports whether an error means a conditional request's condition didn't hold.
+func isPreconditionFailed(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code := apiErr.ErrorCode()
+		return code == "PreconditionFailed" || code == "ConditionalRequestConflict"
+	}
+
+	var respErr *smithyhttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == 412
+}
@@ -0,0 +1,87 @@
+package boto3manager
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestChannels(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "releases")
+	fake.put("releases", "app/releases/v1/app.bin", "1", nil)
+	fake.put("releases", "app/releases/v2/app.bin", "2", nil)
+
+	channels := fake.basics().Channels("releases", "app/")
+
+	if _, err := channels.Get("stable"); err == nil {
+		t.Errorf("Get(stable) before any promotion returned no error")
+	}
+
+	for _, target := range []string{"app/releases/v1/", "app/releases/v2/"} {
+		if err := channels.Promote("stable", target); err != nil {
+			t.Fatalf("Promote(stable, %v) = %v, want nil", target, err)
+		}
+	}
+
+	key, err := channels.Resolve("stable", "app.bin")
+	if err != nil || key != "app/releases/v2/app.bin" {
+		t.Errorf("Resolve(stable, app.bin) = %v, %v, want app/releases/v2/app.bin", key, err)
+	}
+
+	pointer, err := channels.Rollback("stable")
+	if err != nil || pointer.Target != "app/releases/v1/" || len(pointer.History) != 0 {
+		t.Errorf("Rollback(stable) = %+v, %v, want app/releases/v1/ with no history", pointer, err)
+	}
+
+	if _, err := channels.Rollback("stable"); err == nil {
+		t.Errorf("Rollback(stable) past the first release returned no error")
+	}
+
+	// Releases must be prefixes that hold something
+	for _, target := range []string{"app/releases/v3/", "app/releases/v2"} {
+		if err := channels.Promote("stable", target); err == nil {
+			t.Errorf("Promote(stable, %v) returned no error", target)
+		}
+	}
+
+	if pointer, _ := channels.Get("stable"); pointer.Target != "app/releases/v1/" {
+		t.Errorf("Get(stable) after refused promotions = %v, want app/releases/v1/", pointer.Target)
+	}
+}
+
+func TestChannelsConcurrentPromote(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "releases")
+	for _, release := range []string{"v1", "v2", "v3"} {
+		fake.put("releases", "releases/"+release+"/app.bin", release, nil)
+	}
+
+	channels := fake.basics().Channels("releases", "")
+	if err := channels.Promote("stable", "releases/v1/"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another writer promotes v2 between this promotion's read and its write
+	raced := false
+	fake.fail = func(r *http.Request) int {
+		if r.Method == http.MethodPut && !raced {
+			raced = true
+			body, _ := json.Marshal(ChannelPointer{Target: "releases/v2/", History: []string{"releases/v1/"}})
+			fake.buckets["releases"]["channels/stable.json"] = &fakeObject{body: body, etag: fakeETag(body), header: make(http.Header), lastModified: fake.tick()}
+		}
+		return 0
+	}
+
+	if err := channels.Promote("stable", "releases/v3/"); err != nil {
+		t.Fatalf("Promote(stable, releases/v3/) = %v, want nil", err)
+	}
+
+	pointer, _ := channels.Get("stable")
+	if wanted := []string{"releases/v2/", "releases/v1/"}; pointer.Target != "releases/v3/" || !slices.Equal(pointer.History, wanted) {
+		t.Errorf("Get(stable) after a concurrent promotion = %+v, want releases/v3/ with history %v", pointer, wanted)
+	}
+}
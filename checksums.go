@@ -0,0 +1,76 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// md5File returns the hex encoded MD5 checksum of a file in a file system.
+func md5File(fsys fs.FS, path string) (string, error) {
+	f, err := fsys.Open(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// publishChecksums writes an md5sum-style index of the files that were uploaded successfully to the object
+// dest+name, with paths relative to dest so `md5sum -c` works on a download of the prefix. The files are read
+// from fsys. It returns the MD5 checksum of the index itself.
+func (basics BucketBasics) publishChecksums(fsys fs.FS, report *TransferReport, dest string, name string, bucketName string) (string, error) {
+	lines := make([]string, 0, len(report.Results))
+
+	for _, result := range report.Succeeded() {
+		sum, err := md5File(fsys, result.Path)
+
+		if err != nil {
+			log.Printf("Couldn't compute checksum of %v: %v", result.Path, err)
+			return "", err
+		}
+
+		lines = append(lines, fmt.Sprintf("%v  %v\n", sum, strings.TrimPrefix(result.Key, dest)))
+	}
+
+	// Sort by path so the index is stable between runs
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i][34:] < lines[j][34:]
+	})
+
+	index := []byte(strings.Join(lines, ""))
+
+	key := dest + name
+	_, err := basics.client(bucketName).PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(index),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't upload checksums file %v to bucket %v: %v", key, bucketName, err)
+		return "", err
+	}
+
+	sum := md5.Sum(index)
+	return hex.EncodeToString(sum[:]), nil
+}
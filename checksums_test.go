@@ -0,0 +1,70 @@
+package boto3manager
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUploadObjectsChecksums(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"data/b.txt":        {Data: []byte("bb")},
+		"data/a.txt":        {Data: []byte("a")},
+		"data/nested/c.txt": {Data: []byte("ccc")},
+	}
+
+	fake := newFakeS3(t, "bucket")
+	options := UploadObjectsOptions{FS: fsys, ChecksumsFile: "MD5SUMS", SuccessMarker: true, Quiet: true}
+	if _, err := fake.basics().UploadObjects("data/**/*", "out/", "bucket", options); err != nil {
+		t.Fatalf("UploadObjects() = %v, want nil", err)
+	}
+
+	// Paths are relative to the destination and sorted, as md5sum -c expects
+	index := fmt.Sprintf("%v  a.txt\n%v  b.txt\n%v  nested/c.txt\n", md5Hex("a"), md5Hex("bb"), md5Hex("ccc"))
+	sums, ok := fake.object("bucket", "out/MD5SUMS")
+	if !ok || string(sums.body) != index {
+		t.Errorf("UploadObjects() wrote MD5SUMS %q, want %q", sums.body, index)
+	}
+
+	marker, _ := fake.object("bucket", "out/"+SuccessMarkerName)
+	if wanted := md5Hex(index) + "  MD5SUMS\n"; string(marker.body) != wanted {
+		t.Errorf("UploadObjects() wrote %v %q, want %q", SuccessMarkerName, marker.body, wanted)
+	}
+}
+
+func TestUploadObjectsChecksumsFailed(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"data/a.txt": {Data: []byte("a")},
+		"data/b.txt": {Data: []byte("bb")},
+	}
+
+	fake := newFakeS3(t, "bucket")
+	fake.fail = func(r *http.Request) int {
+		if r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/b.txt") {
+			return http.StatusForbidden
+		}
+		return 0
+	}
+
+	options := UploadObjectsOptions{FS: fsys, ChecksumsFile: "MD5SUMS", Quiet: true, RetryPolicy: &RetryPolicy{MaxAttempts: 1}}
+	fake.basics().UploadObjects("data/*", "out/", "bucket", options)
+
+	// Only the files that arrived are listed
+	sums, _ := fake.object("bucket", "out/MD5SUMS")
+	if wanted := md5Hex("a") + "  a.txt\n"; string(sums.body) != wanted {
+		t.Errorf("UploadObjects() with a failed file wrote MD5SUMS %q, want %q", sums.body, wanted)
+	}
+}
@@ -0,0 +1,275 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ChunkManifestSuffix is appended to a chunked file's key to name its manifest object.
+const ChunkManifestSuffix = ".manifest.json"
+
+// ChunkManifest describes a file stored as several chunk objects.
+type ChunkManifest struct {
+	Name      string  `json:"name"`
+	Size      int64   `json:"size"`
+	ChunkSize int64   `json:"chunk_size"`
+	Chunks    []Chunk `json:"chunks"`
+}
+
+// Chunk is one object holding bytes [Offset, Offset+Size) of a chunked file.
+type Chunk struct {
+	Key    string `json:"key"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+}
+
+func (chunk Chunk) result() TransferResult {
+	return TransferResult{Key: chunk.Key, Size: chunk.Size}
+}
+
+type ChunkedOptions struct {
+	// ChunkSize is the largest object written. Defaults to 1 GiB
+	ChunkSize int64
+
+	// RetryPolicy controls retries of each chunk. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Workers is the number of chunks transferred at once. Defaults to 4, or to EnvConcurrency if it is set
+	Workers int
+
+	// Quiet turns off progress output
+	Quiet bool
+}
+
+// workers returns the number of chunks to transfer at once.
+func (options ChunkedOptions) workers() int {
+	if options.Workers > 0 {
+		return options.Workers
+	}
+
+	return defaultWorkers(4)
+}
+
+// chunksForSize splits a file of the given size into chunks named key.part0000, key.part0001, and so on.
+func chunksForSize(key string, size int64, chunkSize int64) []Chunk {
+	chunks := make([]Chunk, 0, (size+chunkSize-1)/chunkSize)
+	for offset := int64(0); offset < size; offset += chunkSize {
+		chunks = append(chunks, Chunk{
+			Key:    fmt.Sprintf("%v.part%04d", key, len(chunks)),
+			Offset: offset,
+			Size:   min(chunkSize, size-offset),
+		})
+	}
+
+	return chunks
+}
+
+// UploadChunked takes a path to a file, a key, and a bucket name and uploads the file as chunk objects of at most
+// ChunkSize bytes plus a JSON manifest at key + ChunkManifestSuffix, for endpoints that cap object size below the
+// size of the file. The manifest is written last, so a manifest only exists for a complete set of chunks.
+func (basics BucketBasics) UploadChunked(path string, key string, bucketName string, options ChunkedOptions) (*ChunkManifest, error) {
+	ctx := context.TODO()
+	options.Quiet = defaultQuiet(options.Quiet)
+
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 1024 * 1024 * 1024
+	}
+
+	f, err := os.Open(path)
+
+	if err != nil {
+		log.Printf("Couldn't read file %v: %v", path, err)
+		return nil, err
+	}
+
+	defer f.Close()
+
+	fileInfo, err := f.Stat()
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ChunkManifest{
+		Name:      filepath.Base(path),
+		Size:      fileInfo.Size(),
+		ChunkSize: chunkSize,
+		Chunks:    chunksForSize(key, fileInfo.Size(), chunkSize),
+	}
+
+	bar := silentBar(manifest.Size)
+	if !options.Quiet {
+		bar = newBytesBar(manifest.Size, "uploading")
+	}
+	uploader := basics.newUploader(bucketName)
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  options.workers(),
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		backpressure: basics.Backpressure,
+	}
+
+	// Upload each chunk straight from its section of the file
+	err = runBatch(ctx, manifest.Chunks, config, func(ctx context.Context, chunk Chunk) error {
+		body, progress := newProgressReader(io.NewSectionReader(f, chunk.Offset, chunk.Size), bar)
+
+		_, err := uploader.Upload(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(chunk.Key),
+			Body:   body,
+		})
+
+		if err != nil {
+			log.Printf("Couldn't upload chunk %v to bucket %v: %v", chunk.Key, bucketName, err)
+			progress.rollback()
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return nil, fmt.Errorf("%v of %v chunks failed to upload: %w", len(failed), len(manifest.Chunks), failed[0].Err)
+	}
+
+	body, err := json.MarshalIndent(manifest, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key + ChunkManifestSuffix),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't upload manifest for %v to bucket %v: %v", key, bucketName, err)
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// DownloadChunked takes the key of a chunked file, a destination path, and a bucket name and reassembles the file
+// from its chunks at the destination. The file only appears at the destination once every chunk has been written.
+func (basics BucketBasics) DownloadChunked(key string, dest string, bucketName string, options ChunkedOptions) (*ChunkManifest, error) {
+	ctx := context.TODO()
+	options.Quiet = defaultQuiet(options.Quiet)
+
+	manifest, err := basics.readChunkManifest(ctx, key, bucketName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Write into a temporary file next to the destination and move it into place when it's complete
+	partial := dest + ".partial"
+	f, err := os.Create(partial)
+
+	if err != nil {
+		log.Printf("Couldn't create file %v: %v", partial, err)
+		return nil, err
+	}
+
+	if err := f.Truncate(manifest.Size); err != nil {
+		f.Close()
+		os.Remove(partial)
+		return nil, err
+	}
+
+	bar := silentBar(manifest.Size)
+	if !options.Quiet {
+		bar = newBytesBar(manifest.Size, "downloading")
+	}
+	downloader := basics.newDownloader(bucketName)
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  options.workers(),
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		backpressure: basics.Backpressure,
+	}
+
+	err = runBatch(ctx, manifest.Chunks, config, func(ctx context.Context, chunk Chunk) error {
+		w := &progressWriterAt{w: io.NewOffsetWriter(f, chunk.Offset), bar: bar}
+
+		n, err := downloader.Download(ctx, w, &s3.GetObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(chunk.Key),
+		})
+
+		if err == nil && n != chunk.Size {
+			err = fmt.Errorf("chunk %v is %v bytes, manifest says %v", chunk.Key, n, chunk.Size)
+		}
+
+		if err != nil {
+			log.Printf("Couldn't download chunk %v: %v", chunk.Key, err)
+			w.rollback()
+		}
+
+		return err
+	})
+
+	closeErr := f.Close()
+
+	if err == nil && len(report.Failed()) > 0 {
+		err = fmt.Errorf("%v of %v chunks failed to download: %w", len(report.Failed()), len(manifest.Chunks), report.Failed()[0].Err)
+	}
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(partial)
+		return nil, err
+	}
+
+	if err := os.Rename(partial, dest); err != nil {
+		os.Remove(partial)
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// readChunkManifest downloads and decodes the manifest of a chunked file.
+func (basics BucketBasics) readChunkManifest(ctx context.Context, key string, bucketName string) (*ChunkManifest, error) {
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key + ChunkManifestSuffix),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get manifest for %v: %v", key, err)
+		return nil, err
+	}
+
+	defer obj.Body.Close()
+
+	manifest := &ChunkManifest{}
+	if err := json.NewDecoder(obj.Body).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("manifest for %v is corrupt: %w", key, err)
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,122 @@
+package boto3manager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestChunksForSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		size      int64
+		chunkSize int64
+		wanted    []Chunk
+	}{
+		{
+			name:      "uneven",
+			size:      25,
+			chunkSize: 10,
+			wanted: []Chunk{
+				{Key: "data.bin.part0000", Offset: 0, Size: 10},
+				{Key: "data.bin.part0001", Offset: 10, Size: 10},
+				{Key: "data.bin.part0002", Offset: 20, Size: 5},
+			},
+		},
+		{
+			name:      "exact",
+			size:      10,
+			chunkSize: 10,
+			wanted:    []Chunk{{Key: "data.bin.part0000", Offset: 0, Size: 10}},
+		},
+		{
+			name:      "empty",
+			size:      0,
+			chunkSize: 10,
+			wanted:    []Chunk{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chunksForSize("data.bin", tt.size, tt.chunkSize); !reflect.DeepEqual(got, tt.wanted) {
+				t.Errorf("chunksForSize(\"data.bin\", %v, %v) = %+v, want %+v", tt.size, tt.chunkSize, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestChunkedRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	data := "0123456789abcdefghijklmno"
+	path := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeS3(t, "bucket")
+	options := ChunkedOptions{ChunkSize: 10, Workers: 2, Quiet: true}
+
+	manifest, err := fake.basics().UploadChunked(path, "big/data.bin", "bucket", options)
+	if err != nil {
+		t.Fatalf("UploadChunked() = %v, want nil", err)
+	}
+	if len(manifest.Chunks) != 3 {
+		t.Errorf("UploadChunked() wrote %v chunks, want 3", len(manifest.Chunks))
+	}
+
+	wanted := []string{"big/data.bin.manifest.json", "big/data.bin.part0000", "big/data.bin.part0001", "big/data.bin.part0002"}
+	if keys := fake.keys("bucket"); !slices.Equal(keys, wanted) {
+		t.Errorf("UploadChunked() wrote %v, want %v", keys, wanted)
+	}
+	if last, _ := fake.object("bucket", "big/data.bin.part0002"); string(last.body) != "klmno" {
+		t.Errorf("UploadChunked() wrote the last chunk as %q, want %q", last.body, "klmno")
+	}
+
+	dest := filepath.Join(dir, "copy.bin")
+	if _, err := fake.basics().DownloadChunked("big/data.bin", dest, "bucket", options); err != nil {
+		t.Fatalf("DownloadChunked() = %v, want nil", err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != data {
+		t.Errorf("DownloadChunked() wrote %q, want %q", got, data)
+	}
+	if _, err := os.Stat(dest + ".partial"); err == nil {
+		t.Errorf("DownloadChunked() left its partial file behind")
+	}
+}
+
+func TestDownloadChunkedMissingChunk(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(path, []byte("0123456789abcde"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeS3(t, "bucket")
+	options := ChunkedOptions{ChunkSize: 10, RetryPolicy: &RetryPolicy{MaxAttempts: 1}, Quiet: true}
+	if _, err := fake.basics().UploadChunked(path, "data.bin", "bucket", options); err != nil {
+		t.Fatalf("UploadChunked() = %v, want nil", err)
+	}
+
+	fake.mu.Lock()
+	delete(fake.buckets["bucket"], "data.bin.part0001")
+	fake.mu.Unlock()
+
+	dest := filepath.Join(dir, "copy.bin")
+	if _, err := fake.basics().DownloadChunked("data.bin", dest, "bucket", options); err == nil {
+		t.Errorf("DownloadChunked() with a chunk missing = nil error, want an error")
+	}
+	for _, leftover := range []string{dest, dest + ".partial"} {
+		if _, err := os.Stat(leftover); err == nil {
+			t.Errorf("DownloadChunked() with a chunk missing left %v", leftover)
+		}
+	}
+}
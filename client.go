@@ -0,0 +1,257 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// ClientOptions describe how to connect to an S3 endpoint and which credentials to use.
+type ClientOptions struct {
+	// Endpoint is the URL of an S3 compatible endpoint, such as https://s3-tide.nrp-nautilus.io, addressed with
+	// path-style bucket names. If empty, it comes from SecretDir, then BOTO3MANAGER_ENDPOINT, then
+	// DefaultEndpoint, and otherwise AWS's own endpoints are used
+	Endpoint string
+
+	// UseAWS uses AWS's own endpoints even when SecretDir, BOTO3MANAGER_ENDPOINT, or DefaultEndpoint give
+	// another. It can't be used with Endpoint
+	UseAWS bool
+
+	// DefaultEndpoint is the endpoint used when neither Endpoint, SecretDir, nor BOTO3MANAGER_ENDPOINT give one,
+	// for programs with an endpoint of their own that the environment should still be able to change
+	DefaultEndpoint string
+
+	// Region is the region to sign requests for. If empty, it comes from BOTO3MANAGER_REGION, the environment or
+	// shared config, and falls back to us-east-1, which most S3 compatible endpoints accept
+	Region string
+
+	// Profile is a profile from the shared config and credentials files. If empty, the default credential chain
+	// is used
+	Profile string
+
+	// SecretDir is a directory a secret is mounted at, such as a Kubernetes secret volume, with the access key ID,
+	// secret access key, and optionally the session token, endpoint, and region each in a file named for it. The
+	// credentials are reread every SecretRefresh, so rotated keys are picked up without restarting; the endpoint
+	// and region are read once and only used when Endpoint and Region are empty. If empty, it comes from
+	// BOTO3MANAGER_SECRET_DIR
+	SecretDir string
+
+	// SecretRefresh is how often credentials are reread from SecretDir. If zero, every minute
+	SecretRefresh time.Duration
+
+	// Anonymous sends requests unsigned, without any credentials, for reading public buckets
+	Anonymous bool
+
+	// RoleARN, if set, is assumed through STS with the credentials above. The role's temporary credentials are
+	// refreshed automatically before they expire
+	RoleARN string
+
+	// RoleSessionName names the assumed role's session. If empty, a unique name is generated
+	RoleSessionName string
+
+	// ExternalID is passed when assuming RoleARN, for roles that require one
+	ExternalID string
+
+	// WebIdentityTokenFile, if set with RoleARN, assumes the role with the OIDC token in this file, such as a
+	// Kubernetes service account token, instead of other credentials. The file is reread on every refresh
+	WebIdentityTokenFile string
+
+	// STSEndpoint is the URL of the STS endpoint roles are assumed through. If empty, AWS STS is used
+	STSEndpoint string
+
+	// Accelerate sends requests through S3 Transfer Acceleration's edge locations, which must be enabled on the
+	// bucket. It can't be used with Endpoint or FIPS
+	Accelerate bool
+
+	// DualStack uses endpoints reachable over IPv6 as well as IPv4
+	DualStack bool
+
+	// FIPS uses FIPS 140 validated endpoints, in the regions that have them
+	FIPS bool
+
+	// HTTP configures proxies, TLS, connection pooling, and timeouts
+	HTTP HTTPOptions
+}
+
+// NewBucketBasics returns a BucketBasics with a client for the endpoint and credentials the options describe.
+func NewBucketBasics(options ClientOptions) (BucketBasics, error) {
+	client, err := newS3Client(context.TODO(), options)
+	if err != nil {
+		return BucketBasics{}, err
+	}
+
+	// Buckets on AWS can be in any region, while S3 compatible endpoints generally ignore it
+	return BucketBasics{S3Client: client, DetectRegions: client.Options().BaseEndpoint == nil}, nil
+}
+
+// WithBucketClient returns a copy of basics that uses a client built from the options for one bucket, for
+// operations that span buckets needing different credentials. Other buckets keep using the existing clients.
+func (basics BucketBasics) WithBucketClient(bucketName string, options ClientOptions) (BucketBasics, error) {
+	client, err := newS3Client(context.TODO(), options)
+	if err != nil {
+		return basics, err
+	}
+
+	clients := maps.Clone(basics.BucketClients)
+	if clients == nil {
+		clients = make(map[string]*s3.Client)
+	}
+	clients[bucketName] = client
+
+	basics.BucketClients = clients
+	return basics, nil
+}
+
+// client returns the client for a bucket: its entry in BucketClients, or S3Client if it has none. Access point ARNs
+// and directory buckets get a virtual-hosted client, since they can't be addressed path-style. With DetectRegions,
+// other buckets get a client for the region they're in.
+func (basics BucketBasics) client(bucketName string) *s3.Client {
+	client, ok := basics.BucketClients[bucketName]
+	if !ok {
+		client = basics.S3Client
+	}
+
+	if isBucketARN(bucketName) || IsDirectoryBucket(bucketName) {
+		return virtualHostedClient(client)
+	}
+
+	if basics.DetectRegions && bucketName != "" {
+		return regionalClient(client, bucketName)
+	}
+
+	return client
+}
+
+// newS3Client loads the shared configuration for the options' profile and region, replaces its credentials with
+// those in a secret directory if one is given, wraps them in an assumed role if one is given, and returns a client
+// for the options' endpoint.
+func newS3Client(ctx context.Context, options ClientOptions) (*s3.Client, error) {
+	if options.SecretDir == "" {
+		options.SecretDir = os.Getenv(EnvSecretDir)
+	}
+	if options.SecretDir != "" {
+		if options.Anonymous {
+			return nil, fmt.Errorf("can't use secret %v with anonymous access", options.SecretDir)
+		}
+
+		secret, err := readSecretDir(options.SecretDir)
+		if err != nil {
+			return nil, err
+		}
+		if options.Endpoint == "" && !options.Accelerate && !options.UseAWS {
+			options.Endpoint = secret.Endpoint
+		}
+		if options.Region == "" {
+			options.Region = secret.Region
+		}
+	}
+
+	if options.UseAWS && options.Endpoint != "" {
+		return nil, fmt.Errorf("can't use AWS's endpoints with endpoint %v", options.Endpoint)
+	}
+	if options.Endpoint == "" && !options.Accelerate && !options.UseAWS {
+		options.Endpoint = os.Getenv(EnvEndpoint)
+	}
+	if options.Endpoint == "" && !options.Accelerate && !options.UseAWS {
+		options.Endpoint = options.DefaultEndpoint
+	}
+	if options.Region == "" {
+		options.Region = os.Getenv(EnvRegion)
+	}
+
+	if options.Anonymous && options.RoleARN != "" {
+		return nil, fmt.Errorf("can't assume role %v with anonymous access", options.RoleARN)
+	}
+	if options.Accelerate && options.Endpoint != "" {
+		return nil, fmt.Errorf("transfer acceleration isn't available on endpoint %v", options.Endpoint)
+	}
+	if options.Accelerate && options.FIPS {
+		return nil, errors.New("transfer acceleration has no FIPS endpoints")
+	}
+
+	loadOptions := make([]func(*config.LoadOptions) error, 0)
+	if options.Profile != "" {
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(options.Profile))
+	}
+	if options.Region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(options.Region))
+	}
+
+	// Set in the configuration rather than on the client so STS uses the same kind of endpoint
+	if options.DualStack {
+		loadOptions = append(loadOptions, config.WithUseDualStackEndpoint(aws.DualStackEndpointStateEnabled))
+	}
+	if options.FIPS {
+		loadOptions = append(loadOptions, config.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled))
+	}
+
+	httpClient, err := options.HTTP.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		loadOptions = append(loadOptions, config.WithHTTPClient(httpClient))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load configuration: %w", err)
+	}
+
+	// S3 compatible endpoints ignore the region, but the SDK won't sign requests without one
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	if options.Anonymous {
+		cfg.Credentials = aws.AnonymousCredentials{}
+	}
+
+	if options.SecretDir != "" {
+		cfg.Credentials = aws.NewCredentialsCache(SecretDirProvider{Dir: options.SecretDir, RefreshInterval: options.SecretRefresh})
+	}
+
+	if options.RoleARN != "" {
+		cfg.Credentials = aws.NewCredentialsCache(assumeRoleProvider(cfg, options))
+	}
+
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if options.Endpoint != "" {
+			o.BaseEndpoint = aws.String(options.Endpoint)
+			o.UsePathStyle = true
+		}
+		o.UseAccelerate = options.Accelerate
+	}), nil
+}
+
+// assumeRoleProvider returns a credentials provider assuming the options' role, with a web identity token if
+// one is given and the configuration's credentials otherwise.
+func assumeRoleProvider(cfg aws.Config, options ClientOptions) aws.CredentialsProvider {
+	stsClient := sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if options.STSEndpoint != "" {
+			o.BaseEndpoint = aws.String(options.STSEndpoint)
+		}
+	})
+
+	if options.WebIdentityTokenFile != "" {
+		return stscreds.NewWebIdentityRoleProvider(stsClient, options.RoleARN, stscreds.IdentityTokenFile(options.WebIdentityTokenFile), func(o *stscreds.WebIdentityRoleOptions) {
+			o.RoleSessionName = options.RoleSessionName
+		})
+	}
+
+	return stscreds.NewAssumeRoleProvider(stsClient, options.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = options.RoleSessionName
+		if options.ExternalID != "" {
+			o.ExternalID = aws.String(options.ExternalID)
+		}
+	})
+}
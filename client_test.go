@@ -0,0 +1,110 @@
+package boto3manager
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestBucketBasicsClient(t *testing.T) {
+	t.Parallel()
+
+	shared := s3.New(s3.Options{Region: "us-east-1"})
+	scoped := s3.New(s3.Options{Region: "us-east-1"})
+	basics := BucketBasics{S3Client: shared, BucketClients: map[string]*s3.Client{"scoped": scoped}}
+
+	tests := []struct {
+		bucketName string
+		wanted     *s3.Client
+	}{
+		{
+			bucketName: "scoped",
+			wanted:     scoped,
+		},
+		{
+			bucketName: "other",
+			wanted:     shared,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bucketName, func(t *testing.T) {
+			if got := basics.client(tt.bucketName); got != tt.wanted {
+				t.Errorf("client(%q) = %p, want %p", tt.bucketName, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestNewS3ClientConflicts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options ClientOptions
+	}{
+		{name: "anonymous role", options: ClientOptions{Anonymous: true, RoleARN: "arn:aws:iam::123456789012:role/reader"}},
+		{name: "accelerated endpoint", options: ClientOptions{Accelerate: true, Endpoint: "https://s3-tide.nrp-nautilus.io"}},
+		{name: "accelerated FIPS", options: ClientOptions{Accelerate: true, FIPS: true}},
+		{name: "anonymous secret", options: ClientOptions{Anonymous: true, SecretDir: "/var/run/secrets/s3"}},
+		{name: "AWS endpoint", options: ClientOptions{UseAWS: true, Endpoint: "https://s3-tide.nrp-nautilus.io"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newS3Client(context.TODO(), tt.options); err == nil {
+				t.Errorf("newS3Client(%+v) = nil error, want an error", tt.options)
+			}
+		})
+	}
+}
+
+func TestNewBucketBasicsAnonymous(t *testing.T) {
+	// Credentials in the environment must not be sent
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv(EnvSecretDir, "")
+	t.Setenv(EnvEndpoint, "")
+
+	fake := newFakeS3(t, "public")
+	fake.put("public", "data.csv", "a,b\n", nil)
+
+	var authorization []string
+	fake.fail = func(r *http.Request) int {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		return 0
+	}
+
+	tests := []struct {
+		anonymous bool
+		signed    bool
+	}{
+		{anonymous: true, signed: false},
+		{anonymous: false, signed: true},
+	}
+
+	for _, tt := range tests {
+		authorization = nil
+
+		basics, err := NewBucketBasics(ClientOptions{Endpoint: fake.server.URL, Anonymous: tt.anonymous})
+		if err != nil {
+			t.Fatalf("NewBucketBasics(Anonymous: %v) = %v, want nil", tt.anonymous, err)
+		}
+
+		got, err := basics.DownloadObjectBytes("data.csv", "public")
+		if err != nil || string(got) != "a,b\n" {
+			t.Errorf("DownloadObjectBytes() with Anonymous: %v = %q, %v, want %q", tt.anonymous, got, err, "a,b\n")
+		}
+
+		for _, header := range authorization {
+			if signed := header != ""; signed != tt.signed {
+				t.Errorf("NewBucketBasics(Anonymous: %v) sent Authorization %q, want signed %v", tt.anonymous, header, tt.signed)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	boto3manager "gitlab.nrp-nautilus.io/humboldt/boto3-manager"
+)
+
+// runSums writes a SHA-256 manifest of local files to stdout or an object.
+func runSums(args []string) error {
+	flags := flag.NewFlagSet("sums", flag.ExitOnError)
+	cacheFile := flags.String("hash-cache", "", "JSON file to keep checksums in between runs, so unchanged files aren't hashed again")
+	workers := flags.Int("hash-workers", 0, "number of files hashed at once (default 8)")
+	flags.Parse(args)
+
+	hashing := boto3manager.HashOptions{Workers: *workers, CacheFile: *cacheFile}
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return fmt.Errorf("sums takes a pattern and an optional destination")
+	}
+	pattern := flags.Arg(0)
+
+	if flags.NArg() == 1 || flags.Arg(1) == "-" {
+		return boto3manager.GenerateChecksumManifest(pattern, os.Stdout, hashing)
+	}
+
+	bucketName, key, ok := parseS3URL(flags.Arg(1))
+	if !ok || key == "" {
+		return fmt.Errorf("destination %q is not an s3:// URL of an object", flags.Arg(1))
+	}
+
+	basics, err := newBucketBasics()
+	if err != nil {
+		return err
+	}
+
+	return basics.PutChecksumManifest(pattern, key, bucketName, hashing)
+}
+
+// runVerify checks objects against a SHA-256 manifest read from a file or an object, failing if any don't match.
+func runVerify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	rehash := flags.Bool("rehash", false, "download and hash every object, ignoring checksums in metadata")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("verify takes a manifest and an s3:// prefix")
+	}
+
+	bucketName, prefix, ok := parseS3URL(flags.Arg(1))
+	if !ok {
+		return fmt.Errorf("%q is not an s3:// URL", flags.Arg(1))
+	}
+
+	basics, err := newBucketBasics()
+	if err != nil {
+		return err
+	}
+
+	// Read the manifest from the bucket or a local file
+	var manifest io.Reader
+	if manifestBucket, manifestKey, ok := parseS3URL(flags.Arg(0)); ok {
+		var buf bytes.Buffer
+		if _, err := basics.CatObject(manifestKey, manifestBucket, &buf); err != nil {
+			return err
+		}
+		manifest = &buf
+	} else {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		manifest = f
+	}
+
+	report, err := basics.VerifyAgainstManifest(manifest, prefix, bucketName, boto3manager.VerifyOptions{Rehash: *rehash})
+	if err != nil {
+		return err
+	}
+
+	failed := report.Failed()
+	for _, result := range failed {
+		fmt.Printf("FAILED %v: %v\n", result.Key, result.Err)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%v of %v objects failed verification", len(failed), len(report.Results))
+	}
+
+	fmt.Printf("%v objects OK\n", len(report.Results))
+	return nil
+}
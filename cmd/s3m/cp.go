@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	boto3manager "gitlab.nrp-nautilus.io/humboldt/boto3-manager"
+)
+
+// runCp copies stdin or a file to an object, or an object to stdout.
+func runCp(args []string) error {
+	flags := flag.NewFlagSet("cp", flag.ExitOnError)
+	partSize := flags.Int64("part-size", 0, "part size in MiB for streamed uploads (default 64)")
+	contentType := flags.String("content-type", "", "content type of the uploaded object")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("cp takes a source and a destination")
+	}
+	src, dst := flags.Arg(0), flags.Arg(1)
+
+	basics, err := newBucketBasics()
+	if err != nil {
+		return err
+	}
+
+	// Object to stdout
+	if bucketName, key, ok := parseS3URL(src); ok {
+		if dst != "-" {
+			return fmt.Errorf("objects can only be copied to stdout (-)")
+		}
+
+		_, err := basics.CatObject(key, bucketName, os.Stdout)
+		return err
+	}
+
+	bucketName, key, ok := parseS3URL(dst)
+	if !ok {
+		return fmt.Errorf("destination %q is not an s3:// URL", dst)
+	}
+
+	// Stdin to object, streamed one part at a time since its length is unknown
+	if src == "-" {
+		if key == "" {
+			return fmt.Errorf("a key is needed to upload stdin")
+		}
+
+		_, err := basics.UploadStream(os.Stdin, key, bucketName, boto3manager.UploadStreamOptions{
+			PartSize:    *partSize * 1024 * 1024,
+			ContentType: *contentType,
+			Progress:    true,
+		})
+		return err
+	}
+
+	// File to object, keeping the file's name when the destination is a prefix
+	if key == "" || key[len(key)-1] == '/' {
+		key += filepath.Base(src)
+	}
+
+	return basics.UploadObject(src, key, bucketName, boto3manager.UploadObjectOptions{})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// runDoctor checks the endpoint, credentials, clock, and permissions against a bucket and prints what to fix.
+func runDoctor(args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("doctor takes an s3:// URL of a bucket")
+	}
+
+	bucketName, _, ok := parseS3URL(flags.Arg(0))
+	if !ok {
+		return fmt.Errorf("%q is not an s3:// URL", flags.Arg(0))
+	}
+
+	basics, err := newBucketBasics()
+	if err != nil {
+		return err
+	}
+
+	report := basics.Doctor(bucketName)
+	fmt.Print(report)
+
+	if !report.OK() {
+		return fmt.Errorf("some checks failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+//go:embed templates/main.go.tmpl
+var templates embed.FS
+
+// scaffold is the data the starter program template is filled in with.
+type scaffold struct {
+	Preset   string
+	Endpoint string
+	Bucket   string
+}
+
+// runInit writes a starter program using the library's recommended options into a directory.
+func runInit(args []string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	preset := flags.String("preset", "nautilus", "endpoint preset or URL")
+	bucketName := flags.String("bucket", "my-bucket", "bucket the program uses")
+	flags.Parse(args)
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	endpoint, err := resolveEndpoint(*preset)
+	if err != nil {
+		return err
+	}
+
+	source, err := renderScaffold(scaffold{Preset: *preset, Endpoint: endpoint, Bucket: *bucketName})
+	if err != nil {
+		return err
+	}
+
+	// Never overwrite an existing program
+	path := filepath.Join(dir, "main.go")
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%v already exists", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, source, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %v. To build it:\n\n", path)
+	fmt.Printf("\tcd %v\n", dir)
+	fmt.Println("\tgo mod init example")
+	fmt.Println("\tgo get gitlab.nrp-nautilus.io/humboldt/boto3-manager")
+	fmt.Println("\tgo run .")
+
+	return nil
+}
+
+// renderScaffold fills in the starter program template and formats the result.
+func renderScaffold(data scaffold) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, "templates/main.go.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderScaffold(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		preset   string
+		endpoint string
+	}{
+		{preset: "nautilus-west", endpoint: "https://s3-west.nrp-nautilus.io"},
+		{preset: "aws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			source, err := renderScaffold(scaffold{Preset: tt.preset, Endpoint: tt.endpoint, Bucket: "data"})
+			if err != nil {
+				t.Fatalf("renderScaffold() = %v, want nil", err)
+			}
+
+			// The program is built with the library's own client options, so the environment and secrets apply
+			for _, wanted := range []string{"boto3manager.NewBucketBasics(", "basics.UploadObjects(", "basics.SyncUp(", `const bucketName = "data"`} {
+				if !strings.Contains(string(source), wanted) {
+					t.Errorf("renderScaffold() wrote a program without %v:\n%s", wanted, source)
+				}
+			}
+
+			if got := strings.Contains(string(source), "DefaultEndpoint: endpoint"); got != (tt.endpoint != "") {
+				t.Errorf("renderScaffold() set a default endpoint %v, want %v:\n%s", got, tt.endpoint != "", source)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+
+	boto3manager "gitlab.nrp-nautilus.io/humboldt/boto3-manager"
+)
+
+// runJobs runs the named jobs from a jobs file once, or every job on its schedule with -daemon.
+func runJobs(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	file := flags.String("f", "jobs.yaml", "jobs file to read")
+	daemon := flags.Bool("daemon", false, "run every scheduled job on its schedule until interrupted")
+	flags.Parse(args)
+
+	jobs, err := boto3manager.LoadJobs(*file)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *daemon {
+		if flags.NArg() > 0 {
+			return fmt.Errorf("run -daemon runs every scheduled job and takes no job names")
+		}
+
+		scheduler := boto3manager.NewScheduler()
+		if err := scheduler.AddJobs(jobs); err != nil {
+			return err
+		}
+
+		scheduler.Run(ctx)
+		return nil
+	}
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("run takes the names of jobs in %v, or -daemon", *file)
+	}
+
+	for _, name := range flags.Args() {
+		i := slices.IndexFunc(jobs, func(job boto3manager.JobConfig) bool { return job.Name == name })
+		if i < 0 {
+			return fmt.Errorf("no job named %q in %v", name, *file)
+		}
+
+		report, err := boto3manager.RunJob(ctx, jobs[i])
+		if err != nil {
+			return fmt.Errorf("job %v: %w", name, err)
+		}
+
+		if failed := report.Failed(); len(failed) > 0 {
+			return fmt.Errorf("job %v: %v of %v files failed", name, len(failed), len(report.Results))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Command s3m is a small command line front end for boto3manager.
+//
+//	s3m cp - s3://bucket/key                  upload stdin
+//	s3m cp FILE s3://bucket/key               upload a file
+//	s3m cp s3://bucket/key -                  write an object to stdout
+//	s3m doctor s3://bucket                    diagnose the endpoint, credentials, and permissions
+//	s3m init [-preset name] DIR               generate a starter program
+//	s3m run [-f jobs.yaml] JOB...             run jobs from a jobs file
+//	s3m run [-f jobs.yaml] -daemon            run every job in a jobs file on its schedule
+//	s3m sums PATTERN [s3://bucket/key]        write a SHA-256 manifest of local files
+//	s3m verify MANIFEST s3://bucket/prefix/   check objects against a manifest
+//
+// The endpoint can be set with S3_ENDPOINT, to a URL or a preset name such as nautilus-west, or aws for AWS's own
+// endpoints. Without it, the endpoint in BOTO3MANAGER_SECRET_DIR or BOTO3MANAGER_ENDPOINT is used, and otherwise
+// the Nautilus S3 gateway.
+// Set S3_ANONYMOUS=1 to read public buckets without credentials.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	boto3manager "gitlab.nrp-nautilus.io/humboldt/boto3-manager"
+)
+
+const defaultEndpoint = "https://s3-tide.nrp-nautilus.io"
+
+// commands maps each subcommand to the function running it with the remaining arguments.
+var commands = map[string]func(args []string) error{
+	"cp":     runCp,
+	"doctor": runDoctor,
+	"init":   runInit,
+	"run":    runJobs,
+	"sums":   runSums,
+	"verify": runVerify,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	if err := run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "s3m:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: s3m cp SRC DST")
+	fmt.Fprintln(os.Stderr, "       s3m doctor s3://bucket")
+	fmt.Fprintln(os.Stderr, "       s3m init [-preset name] [-bucket name] DIR")
+	fmt.Fprintln(os.Stderr, "       s3m run [-f jobs.yaml] [-daemon] [JOB...]")
+	fmt.Fprintln(os.Stderr, "       s3m sums [-hash-cache file] [-hash-workers n] PATTERN [s3://bucket/key]")
+	fmt.Fprintln(os.Stderr, "       s3m verify [-rehash] MANIFEST s3://bucket/prefix/")
+	os.Exit(2)
+}
+
+// newBucketBasics returns a BucketBasics for the configured endpoint. S3_ENDPOINT overrides the library's own
+// defaults, which fall back to defaultEndpoint, and its aws preset skips them for AWS's own endpoints.
+func newBucketBasics() (boto3manager.BucketBasics, error) {
+	endpoint, useAWS := "", false
+	if env := os.Getenv("S3_ENDPOINT"); env != "" {
+		var err error
+		endpoint, err = resolveEndpoint(env)
+		if err != nil {
+			return boto3manager.BucketBasics{}, err
+		}
+		useAWS = endpoint == ""
+	}
+
+	return boto3manager.NewBucketBasics(boto3manager.ClientOptions{
+		Endpoint:        endpoint,
+		UseAWS:          useAWS,
+		DefaultEndpoint: defaultEndpoint,
+		Anonymous:       os.Getenv("S3_ANONYMOUS") == "1",
+	})
+}
+
+// parseS3URL splits s3://bucket/key into its bucket and key.
+func parseS3URL(s string) (bucketName string, key string, ok bool) {
+	rest, ok := strings.CutPrefix(s, "s3://")
+	if !ok {
+		return "", "", false
+	}
+
+	bucketName, key = boto3manager.SplitBucketPath(rest)
+	return bucketName, key, bucketName != ""
+}
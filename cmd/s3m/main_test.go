@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	boto3manager "gitlab.nrp-nautilus.io/humboldt/boto3-manager"
+)
+
+func TestNewBucketBasicsEndpoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset string
+		wanted string
+	}{
+		{name: "unset", wanted: "https://s3-east.nrp-nautilus.io"},
+		{name: "preset", preset: "nautilus-west", wanted: "https://s3-west.nrp-nautilus.io"},
+		{name: "url", preset: "https://s3.example.com", wanted: "https://s3.example.com"},
+		{name: "aws", preset: "aws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("S3_ENDPOINT", tt.preset)
+			t.Setenv(boto3manager.EnvEndpoint, "https://s3-east.nrp-nautilus.io")
+			t.Setenv(boto3manager.EnvSecretDir, "")
+
+			basics, err := newBucketBasics()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := basics.S3Client.Options().BaseEndpoint
+			if tt.wanted == "" {
+				if got != nil {
+					t.Errorf("BaseEndpoint = %v, want nil", *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.wanted {
+				t.Errorf("BaseEndpoint = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// endpointPresets maps preset names to S3 endpoints. An empty endpoint means AWS's own endpoints.
+var endpointPresets = map[string]string{
+	"nautilus":         defaultEndpoint,
+	"nautilus-west":    "https://s3-west.nrp-nautilus.io",
+	"nautilus-central": "https://s3-central.nrp-nautilus.io",
+	"nautilus-east":    "https://s3-east.nrp-nautilus.io",
+	"aws":              "",
+}
+
+// resolveEndpoint returns the endpoint for a preset name or URL.
+func resolveEndpoint(nameOrURL string) (string, error) {
+	if strings.Contains(nameOrURL, "://") {
+		return nameOrURL, nil
+	}
+
+	endpoint, ok := endpointPresets[nameOrURL]
+	if !ok {
+		return "", fmt.Errorf("unknown endpoint preset %q; choose one of %v or give a URL", nameOrURL, strings.Join(slices.Sorted(maps.Keys(endpointPresets)), ", "))
+	}
+
+	return endpoint, nil
+}
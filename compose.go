@@ -0,0 +1,164 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// composeSegment is bytes [start, end) of the source at index source.
+type composeSegment struct {
+	source int
+	start  int64
+	end    int64
+}
+
+// composePart is one part of a composed object. A copied part has a single segment copied server-side with
+// UploadPartCopy; any other part is downloaded and uploaded as new data.
+type composePart struct {
+	copy     bool
+	segments []composeSegment
+}
+
+// planCompose splits the concatenation of sources of the given sizes into parts. Sources of at least minPartSize are
+// copied server-side. Smaller sources are joined with their neighbours until they are large enough to be a part,
+// since every part except the last must be at least minPartSize.
+func planCompose(sizes []int64) []composePart {
+	parts := make([]composePart, 0)
+	var pending composePart
+	var pendingSize int64
+
+	for i, size := range sizes {
+		offset := int64(0)
+
+		// Top up a pending part from the start of this source
+		if pendingSize > 0 {
+			take := min(minPartSize-pendingSize, size)
+			if take > 0 {
+				pending.segments = append(pending.segments, composeSegment{source: i, start: 0, end: take})
+				pendingSize += take
+				offset = take
+			}
+
+			if pendingSize >= minPartSize {
+				parts = append(parts, pending)
+				pending, pendingSize = composePart{}, 0
+			}
+		}
+
+		if offset == size {
+			continue
+		}
+
+		if size-offset >= minPartSize {
+			parts = append(parts, composePart{copy: true, segments: []composeSegment{{source: i, start: offset, end: size}}})
+		} else {
+			pending.segments = append(pending.segments, composeSegment{source: i, start: offset, end: size})
+			pendingSize += size - offset
+		}
+	}
+
+	if pendingSize > 0 {
+		parts = append(parts, pending)
+	}
+
+	return parts
+}
+
+// ComposeObject takes a destination key, a bucket name, and source keys and concatenates the sources, in order, into
+// the destination without downloading them, e.g. to merge sharded output files in place. Sources smaller than 5 MiB
+// are the exception: they are downloaded and uploaded as part of a larger part. The destination takes the content
+// type of the first source.
+func (basics BucketBasics) ComposeObject(dstKey string, bucketName string, srcKeys ...string) error {
+	ctx := context.TODO()
+
+	if len(srcKeys) == 0 {
+		return fmt.Errorf("no objects to compose into %v", dstKey)
+	}
+
+	// Look up the size of every source
+	sizes := make([]int64, 0, len(srcKeys))
+	var contentType *string
+	for i, key := range srcKeys {
+		head, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(key),
+		})
+
+		if err != nil {
+			log.Printf("Couldn't get object %v: %v", key, err)
+			return err
+		}
+
+		if i == 0 {
+			contentType = head.ContentType
+		}
+		sizes = append(sizes, aws.ToInt64(head.ContentLength))
+	}
+
+	parts := planCompose(sizes)
+
+	// A multipart upload needs at least one part, so empty sources make an empty object
+	if len(parts) == 0 {
+		_, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(bucketName),
+			Key:         aws.String(dstKey),
+			Body:        strings.NewReader(""),
+			ContentType: contentType,
+		})
+
+		if err != nil {
+			log.Printf("Couldn't upload object %v to bucket %v: %v", dstKey, bucketName, err)
+		}
+
+		return err
+	}
+
+	splice, err := basics.newMultipartSplice(ctx, dstKey, bucketName, contentType, nil)
+
+	if err != nil {
+		return err
+	}
+
+	for _, part := range parts {
+		if part.copy {
+			segment := part.segments[0]
+			err = splice.copyRange(bucketName, srcKeys[segment.source], segment.start, segment.end)
+		} else {
+			err = basics.uploadSegments(ctx, splice, bucketName, srcKeys, part.segments)
+		}
+
+		if err != nil {
+			splice.abort()
+			return err
+		}
+	}
+
+	if err := splice.complete(); err != nil {
+		splice.abort()
+		return err
+	}
+
+	return nil
+}
+
+// uploadSegments downloads segments of the sources and uploads them together as one part.
+func (basics BucketBasics) uploadSegments(ctx context.Context, splice *multipartSplice, bucketName string, srcKeys []string, segments []composeSegment) error {
+	data := make([]byte, 0, minPartSize)
+
+	for _, segment := range segments {
+		chunk, err := basics.getRange(ctx, srcKeys[segment.source], bucketName, segment.start, segment.end, nil)
+
+		if err != nil {
+			return err
+		}
+
+		data = append(data, chunk...)
+	}
+
+	return splice.uploadBytes(data)
+}
@@ -0,0 +1,127 @@
+package boto3manager
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanCompose(t *testing.T) {
+	t.Parallel()
+
+	const mib = 1024 * 1024
+
+	tests := []struct {
+		name   string
+		sizes  []int64
+		wanted []composePart
+	}{
+		{
+			name:  "large sources are copied",
+			sizes: []int64{10 * mib, 6 * mib},
+			wanted: []composePart{
+				{copy: true, segments: []composeSegment{{source: 0, start: 0, end: 10 * mib}}},
+				{copy: true, segments: []composeSegment{{source: 1, start: 0, end: 6 * mib}}},
+			},
+		},
+		{
+			name:  "small sources are joined",
+			sizes: []int64{1 * mib, 2 * mib, 1 * mib},
+			wanted: []composePart{
+				{segments: []composeSegment{{source: 0, start: 0, end: 1 * mib}, {source: 1, start: 0, end: 2 * mib}, {source: 2, start: 0, end: 1 * mib}}},
+			},
+		},
+		{
+			name:  "small source borrows from the next",
+			sizes: []int64{1 * mib, 20 * mib},
+			wanted: []composePart{
+				{segments: []composeSegment{{source: 0, start: 0, end: 1 * mib}, {source: 1, start: 0, end: 4 * mib}}},
+				{copy: true, segments: []composeSegment{{source: 1, start: 4 * mib, end: 20 * mib}}},
+			},
+		},
+		{
+			name:  "short remainder stays pending",
+			sizes: []int64{1 * mib, 7 * mib, 1 * mib},
+			wanted: []composePart{
+				{segments: []composeSegment{{source: 0, start: 0, end: 1 * mib}, {source: 1, start: 0, end: 4 * mib}}},
+				{segments: []composeSegment{{source: 1, start: 4 * mib, end: 7 * mib}, {source: 2, start: 0, end: 1 * mib}}},
+			},
+		},
+		{
+			name:   "empty sources",
+			sizes:  []int64{0, 0},
+			wanted: []composePart{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planCompose(tt.sizes); !reflect.DeepEqual(got, tt.wanted) {
+				t.Errorf("planCompose(%v) = %+v, want %+v", tt.sizes, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestComposeObject(t *testing.T) {
+	t.Parallel()
+
+	// The big sources are copied server-side, except for the start of big.bin that tops up small.bin's part
+	sources := []struct {
+		key  string
+		body string
+	}{
+		{key: "first.bin", body: strings.Repeat("a", minPartSize+1)},
+		{key: "small.bin", body: strings.Repeat("b", 100)},
+		{key: "big.bin", body: strings.Repeat("c", 2*minPartSize)},
+		{key: "tail.bin", body: "dddddddddd"},
+	}
+
+	fake := newFakeS3(t, "bucket")
+	keys := make([]string, 0, len(sources))
+	var wanted strings.Builder
+	for _, source := range sources {
+		fake.put("bucket", source.key, source.body, http.Header{"Content-Type": {"application/x-shard"}})
+		keys = append(keys, source.key)
+		wanted.WriteString(source.body)
+	}
+
+	copies := 0
+	fake.fail = func(r *http.Request) int {
+		if r.Header.Get("X-Amz-Copy-Source") != "" {
+			copies++
+		}
+		return 0
+	}
+
+	if err := fake.basics().ComposeObject("joined.bin", "bucket", keys...); err != nil {
+		t.Fatalf("ComposeObject() = %v, want nil", err)
+	}
+
+	joined, _ := fake.object("bucket", "joined.bin")
+	if string(joined.body) != wanted.String() {
+		t.Errorf("ComposeObject() wrote %v bytes, want the %v bytes of the sources in order", len(joined.body), wanted.Len())
+	}
+	if got := joined.header.Get("Content-Type"); got != "application/x-shard" {
+		t.Errorf("ComposeObject() wrote Content-Type %q, want the first source's", got)
+	}
+	if copies != 2 {
+		t.Errorf("ComposeObject() copied %v parts server-side, want 2", copies)
+	}
+}
+
+func TestComposeObjectEmpty(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "a", "", nil)
+	fake.put("bucket", "b", "", nil)
+
+	if err := fake.basics().ComposeObject("joined", "bucket", "a", "b"); err != nil {
+		t.Fatalf("ComposeObject() = %v, want nil", err)
+	}
+	if joined, ok := fake.object("bucket", "joined"); !ok || len(joined.body) != 0 {
+		t.Errorf("ComposeObject() of empty sources wrote %q (%v), want an empty object", joined.body, ok)
+	}
+}
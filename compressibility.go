@@ -0,0 +1,123 @@
+package boto3manager
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+const (
+	// compressionSampleSize is the size of each sample compressed to estimate compressibility
+	compressionSampleSize = 64 * 1024
+
+	// compressionSamples is how many samples are taken from each file, spread evenly through it
+	compressionSamples = 4
+)
+
+// CompressionEstimate is an estimate of how much smaller a transfer would be with gzip compression, made by
+// compressing samples of every file.
+type CompressionEstimate struct {
+	mu sync.Mutex
+
+	// SampledBytes were compressed to CompressedBytes
+	SampledBytes    int64
+	CompressedBytes int64
+
+	// TotalBytes is the size of every file sampled
+	TotalBytes int64
+
+	// sampled holds the paths already sampled, so files that are retried are only counted once
+	sampled map[string]bool
+}
+
+// Ratio returns the compressed size of the samples as a fraction of their original size.
+func (estimate *CompressionEstimate) Ratio() float64 {
+	if estimate.SampledBytes == 0 {
+		return 1
+	}
+
+	return float64(estimate.CompressedBytes) / float64(estimate.SampledBytes)
+}
+
+// EstimatedSavings returns the number of bytes compression would be expected to save across every file.
+func (estimate *CompressionEstimate) EstimatedSavings() int64 {
+	return int64(float64(estimate.TotalBytes) * (1 - estimate.Ratio()))
+}
+
+func (estimate *CompressionEstimate) String() string {
+	return fmt.Sprintf("compression would save about %.0f%% (%d of %d bytes)", 100*(1-estimate.Ratio()), estimate.EstimatedSavings(), estimate.TotalBytes)
+}
+
+// sampleFile compresses samples of a file in a file system and adds them to the estimate. Files that can be read at
+// an offset are sampled throughout; others only from the start.
+func (estimate *CompressionEstimate) sampleFile(fsys fs.FS, path string, size int64) error {
+	estimate.mu.Lock()
+	if estimate.sampled == nil {
+		estimate.sampled = make(map[string]bool)
+	}
+	seen := estimate.sampled[path]
+	estimate.sampled[path] = true
+	estimate.mu.Unlock()
+
+	if seen {
+		return nil
+	}
+
+	f, err := fsys.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	// Pick evenly spaced samples, or the whole file if it's small
+	samples := make([]io.Reader, 0, compressionSamples)
+	if readerAt, ok := f.(io.ReaderAt); ok && size > compressionSamples*compressionSampleSize {
+		step := size / compressionSamples
+		for i := int64(0); i < compressionSamples; i++ {
+			samples = append(samples, io.NewSectionReader(readerAt, i*step, compressionSampleSize))
+		}
+	} else {
+		samples = append(samples, io.LimitReader(f, compressionSamples*compressionSampleSize))
+	}
+
+	var sampled, compressed int64
+	for _, sample := range samples {
+		counter := &countingWriter{}
+		gz := gzip.NewWriter(counter)
+
+		n, err := io.Copy(gz, sample)
+		if err != nil {
+			return err
+		}
+
+		if err := gz.Close(); err != nil {
+			return err
+		}
+
+		sampled += n
+		compressed += counter.n
+	}
+
+	estimate.mu.Lock()
+	defer estimate.mu.Unlock()
+
+	estimate.SampledBytes += sampled
+	estimate.CompressedBytes += compressed
+	estimate.TotalBytes += size
+
+	return nil
+}
+
+// countingWriter counts the bytes written to it and discards them.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,59 @@
+package boto3manager
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompressionEstimateSampleFile(t *testing.T) {
+	t.Parallel()
+
+	random := make([]byte, 512*1024)
+	rand.Read(random)
+
+	fsys := fstest.MapFS{
+		"text.csv":   {Data: bytes.Repeat([]byte("1.0,2.0,3.0\n"), 50000)},
+		"random.bin": {Data: random},
+	}
+
+	tests := []struct {
+		name     string
+		minRatio float64
+		maxRatio float64
+	}{
+		{
+			name:     "text.csv",
+			minRatio: 0,
+			maxRatio: 0.1,
+		},
+		{
+			name:     "random.bin",
+			minRatio: 0.95,
+			maxRatio: 1.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate := &CompressionEstimate{}
+			size := int64(len(fsys[tt.name].Data))
+
+			// Sampling a file twice counts it once
+			for range 2 {
+				if err := estimate.sampleFile(fsys, tt.name, size); err != nil {
+					t.Fatalf("sampleFile(%v) = %v", tt.name, err)
+				}
+			}
+
+			if got := estimate.Ratio(); got < tt.minRatio || got > tt.maxRatio {
+				t.Errorf("Ratio() = %v, want between %v and %v", got, tt.minRatio, tt.maxRatio)
+			}
+
+			if estimate.TotalBytes != size {
+				t.Errorf("TotalBytes = %v, want %v", estimate.TotalBytes, size)
+			}
+		})
+	}
+}
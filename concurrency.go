@@ -0,0 +1,118 @@
+package boto3manager
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+)
+
+// Concurrency is how a batch transfer spreads its work over connections: how many files move at once, and how many
+// parts of each multipart file move at once. In options, fields left zero are chosen from the sizes of the files
+// and fields that are set are pinned to their value. The choice made is kept in the report's Concurrency field.
+type Concurrency struct {
+	// Workers is the number of files transferred at once
+	Workers int `json:"workers"`
+
+	// PartConcurrency is the number of parts of each multipart file transferred at once
+	PartConcurrency int `json:"part_concurrency"`
+
+	// PartSize is the size in bytes of each part of a multipart file
+	PartSize int64 `json:"part_size"`
+
+	// Reason explains how the values were chosen. It is ignored in options
+	Reason string `json:"reason"`
+}
+
+// String returns the concurrency as a line for people to read.
+func (concurrency Concurrency) String() string {
+	return fmt.Sprintf("%v workers, %v parts of %v at once per file (%v)", concurrency.Workers, concurrency.PartConcurrency, indexSize(concurrency.PartSize), concurrency.Reason)
+}
+
+const (
+	// smallFileWorkers is the number of files at once for batches of mostly small files. Each small file is a
+	// single request on a single connection, so many more can be in flight than multipart files
+	smallFileWorkers = 64
+
+	// largeFileSize is the median file size from which a batch counts as a few huge files
+	largeFileSize = 1024 * 1024 * 1024
+
+	// largeFileWorkers and largeFileParts are the files at once and parts at once per file for batches of a few
+	// huge files, which go faster spread over parts than over files
+	largeFileWorkers = 4
+	largeFileParts   = 16
+
+	// largeFilePartSize is the part size for batches of a few huge files, to keep the number of requests down
+	largeFilePartSize = 64 * 1024 * 1024
+)
+
+// chooseConcurrency chooses the concurrency of a batch from the sizes of its files, starting from the batch's
+// default number of workers. The fields set in pinned are kept as they are.
+func chooseConcurrency(sizes []int64, defaultWorkers int, pinned Concurrency) Concurrency {
+	sorted := slices.Sorted(slices.Values(sizes))
+
+	var median, largest int64
+	if len(sorted) > 0 {
+		median = sorted[len(sorted)/2]
+		largest = sorted[len(sorted)-1]
+	}
+
+	concurrency := Concurrency{
+		Workers:         defaultWorkers,
+		PartConcurrency: manager.DefaultUploadConcurrency,
+		PartSize:        manager.DefaultUploadPartSize,
+		Reason:          "mixed file sizes",
+	}
+
+	switch {
+	case len(sorted) == 0:
+		concurrency.Reason = "no files"
+	case median < smallObjectSize:
+		concurrency.Workers = smallFileWorkers
+		concurrency.Reason = fmt.Sprintf("mostly small files, median %v", indexSize(median))
+	case median >= largeFileSize:
+		concurrency.Workers = largeFileWorkers
+		concurrency.PartConcurrency = largeFileParts
+		concurrency.PartSize = largeFilePartSize
+		concurrency.Reason = fmt.Sprintf("few huge files, median %v", indexSize(median))
+	}
+
+	// No more workers than files, and parts big enough that the largest file fits in the most parts S3 allows
+	concurrency.Workers = max(1, min(concurrency.Workers, len(sorted)))
+	if minimum := (largest + int64(manager.MaxUploadParts) - 1) / int64(manager.MaxUploadParts); concurrency.PartSize < minimum {
+		concurrency.PartSize = minimum
+	}
+
+	pins := make([]string, 0, 3)
+	if pinned.Workers > 0 {
+		concurrency.Workers = pinned.Workers
+		pins = append(pins, "workers")
+	}
+	if pinned.PartConcurrency > 0 {
+		concurrency.PartConcurrency = pinned.PartConcurrency
+		pins = append(pins, "part concurrency")
+	}
+	if pinned.PartSize > 0 {
+		concurrency.PartSize = pinned.PartSize
+		pins = append(pins, "part size")
+	}
+
+	if len(pins) > 0 {
+		concurrency.Reason += "; pinned " + strings.Join(pins, ", ")
+	}
+
+	return concurrency
+}
+
+// uploader applies the concurrency's part settings to an upload manager.
+func (concurrency Concurrency) uploader(u *manager.Uploader) {
+	u.Concurrency = concurrency.PartConcurrency
+	u.PartSize = concurrency.PartSize
+}
+
+// downloader applies the concurrency's part settings to a download manager.
+func (concurrency Concurrency) downloader(d *manager.Downloader) {
+	d.Concurrency = concurrency.PartConcurrency
+	d.PartSize = concurrency.PartSize
+}
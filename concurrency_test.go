@@ -0,0 +1,37 @@
+package boto3manager
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+)
+
+func TestChooseConcurrency(t *testing.T) {
+	t.Parallel()
+
+	const mb = 1024 * 1024
+
+	tests := []struct {
+		name                  string
+		sizes                 []int64
+		pinned                Concurrency
+		wantedWorkers         int
+		wantedPartConcurrency int
+		wantedPartSize        int64
+	}{
+		{name: "no files", sizes: nil, wantedWorkers: 1, wantedPartConcurrency: manager.DefaultUploadConcurrency, wantedPartSize: manager.DefaultUploadPartSize},
+		{name: "small files", sizes: slices.Repeat([]int64{10 * 1024}, 1000), wantedWorkers: smallFileWorkers, wantedPartConcurrency: manager.DefaultUploadConcurrency, wantedPartSize: manager.DefaultUploadPartSize},
+		{name: "mixed files", sizes: slices.Repeat([]int64{1024, 50 * mb, 200 * mb}, 100), wantedWorkers: 25, wantedPartConcurrency: manager.DefaultUploadConcurrency, wantedPartSize: manager.DefaultUploadPartSize},
+		{name: "huge files", sizes: []int64{20 * 1024 * mb, 30 * 1024 * mb}, wantedWorkers: 2, wantedPartConcurrency: largeFileParts, wantedPartSize: largeFilePartSize},
+		{name: "more parts than allowed", sizes: []int64{1024 * 1024 * mb}, wantedWorkers: 1, wantedPartConcurrency: largeFileParts, wantedPartSize: (1024*1024*mb + int64(manager.MaxUploadParts) - 1) / int64(manager.MaxUploadParts)},
+		{name: "pinned", sizes: slices.Repeat([]int64{10 * 1024}, 1000), pinned: Concurrency{Workers: 8, PartSize: 16 * mb}, wantedWorkers: 8, wantedPartConcurrency: manager.DefaultUploadConcurrency, wantedPartSize: 16 * mb},
+	}
+
+	for _, test := range tests {
+		got := chooseConcurrency(test.sizes, 25, test.pinned)
+		if got.Workers != test.wantedWorkers || got.PartConcurrency != test.wantedPartConcurrency || got.PartSize != test.wantedPartSize {
+			t.Errorf("%v: chooseConcurrency() = %v, want %v workers, %v parts of %v at once", test.name, got, test.wantedWorkers, test.wantedPartConcurrency, test.wantedPartSize)
+		}
+	}
+}
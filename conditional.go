@@ -0,0 +1,72 @@
+package boto3manager
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// writeCondition returns an s3.Options function that sets a conditional header on the requests that create an
+// object, PutObject and CompleteMultipartUpload. The other requests of a multipart upload are left alone, since the
+// condition only applies once the object is written.
+func writeCondition(header string, value string) func(*s3.Options) {
+	condition := middleware.BuildMiddlewareFunc("WriteCondition"+header, func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+		switch awsmiddleware.GetOperationName(ctx) {
+		case "PutObject", "CompleteMultipartUpload":
+			if req, ok := in.Request.(*smithyhttp.Request); ok {
+				req.Header.Set(header, value)
+			}
+		}
+
+		return next.HandleBuild(ctx, in)
+	})
+
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Build.Add(condition, middleware.After)
+		})
+	}
+}
+
+// writeConditions returns the s3.Options functions for an upload's If-None-Match and If-Match conditions, skipping
+// any that are empty.
+func writeConditions(ifNoneMatch string, ifMatch string) []func(*s3.Options) {
+	conditions := make([]func(*s3.Options), 0, 2)
+
+	if ifNoneMatch != "" {
+		conditions = append(conditions, writeCondition("If-None-Match", ifNoneMatch))
+	}
+	if ifMatch != "" {
+		conditions = append(conditions, writeCondition("If-Match", ifMatch))
+	}
+
+	return conditions
+}
+
+// captureETag returns an s3.Options function that stores the ETag of the object written by a PutObject or
+// CompleteMultipartUpload in etag, whichever path an upload takes.
+func captureETag(etag *string) func(*s3.Options) {
+	capture := middleware.InitializeMiddlewareFunc("CaptureETag", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleInitialize(ctx, in)
+
+		switch output := out.Result.(type) {
+		case *s3.PutObjectOutput:
+			*etag = strings.Trim(aws.ToString(output.ETag), `"`)
+		case *s3.CompleteMultipartUploadOutput:
+			*etag = strings.Trim(aws.ToString(output.ETag), `"`)
+		}
+
+		return out, metadata, err
+	})
+
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(capture, middleware.After)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/schollz/progressbar/v3"
+)
+
+// objectCopy is an object to copy to a key in another bucket.
+type objectCopy struct {
+	Key            string
+	DestinationKey string
+	Size           int64
+}
+
+func (item objectCopy) result() TransferResult {
+	return TransferResult{Key: item.Key, Size: item.Size}
+}
+
+type CopyBetweenClientsOptions struct {
+	// Prefix is put in front of each object's key at the destination. Keys are otherwise kept as they are
+	Prefix string
+
+	// Workers is the number of objects copied at once. Defaults to 25. Each holds up to PartConcurrency parts of
+	// PartSize in memory
+	Workers int
+
+	// PartConcurrency and PartSize control the multipart upload of each object to the destination. They default
+	// to the upload manager's defaults
+	PartConcurrency int
+	PartSize        int64
+
+	// RetryPolicy controls retries of each object. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Hooks, if set, are called as objects start and finish
+	Hooks *Hooks
+
+	// Stats, if set, has live statistics of the copy passed to its OnStats as it runs
+	Stats *StatsOptions
+
+	// FileProgress shows a bar for each of the largest objects in flight, as in UploadObjectsOptions
+	FileProgress bool
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+}
+
+// CopyBetweenClients takes a BucketBasics and bucket name to copy from, a pattern, and a BucketBasics and bucket name
+// to copy to, each made with NewBucketBasics for its endpoint, and copies every object matching the pattern from one
+// endpoint to the other, for migrations between providers where a server-side copy isn't possible. Each object is
+// streamed through this machine, from a GET on the source into a multipart upload to the destination, without landing
+// on disk. Content type, cache control, content encoding, and user metadata are carried over. An object whose body ends
+// before its Content-Length fails rather than being uploaded short. The batch slows down when either endpoint is
+// overloaded, as set by dst's Backpressure.
+func CopyBetweenClients(src BucketBasics, srcBucket string, pattern string, dst BucketBasics, dstBucket string, options CopyBetweenClientsOptions) (*TransferReport, error) {
+	return CopyBetweenClientsWithContext(context.Background(), src, srcBucket, pattern, dst, dstBucket, options)
+}
+
+// CopyBetweenClientsWithContext is CopyBetweenClients with a context. If the context is cancelled, no new objects
+// are started and uploads in flight are aborted.
+func CopyBetweenClientsWithContext(ctx context.Context, src BucketBasics, srcBucket string, pattern string, dst BucketBasics, dstBucket string, options CopyBetweenClientsOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+	src.detectRegions(ctx, srcBucket)
+	dst.detectRegions(ctx, dstBucket)
+
+	matches, err := src.matchObjects(ctx, pattern, srcBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make([]objectCopy, 0, len(matches))
+	for _, object := range matches {
+		key := aws.ToString(object.Key)
+		copies = append(copies, objectCopy{Key: key, DestinationKey: options.Prefix + key, Size: aws.ToInt64(object.Size)})
+	}
+
+	totalSize := totalObjectSize(matches)
+	progress := newTransferProgress("copying", totalSize, options.FileProgress, options.Quiet)
+
+	workerCount := options.Workers
+	if workerCount <= 0 {
+		workerCount = defaultWorkers(25)
+	}
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  workerCount,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		hooks:        options.Hooks,
+		stats:        options.Stats,
+		backpressure: dst.Backpressure,
+	}
+
+	parts := Concurrency{PartConcurrency: options.PartConcurrency, PartSize: options.PartSize}.withEnv()
+	uploader := dst.newUploader(dstBucket, func(u *manager.Uploader) {
+		if parts.PartConcurrency > 0 {
+			u.Concurrency = parts.PartConcurrency
+		}
+		if parts.PartSize > 0 {
+			u.PartSize = parts.PartSize
+		}
+	})
+
+	err = runBatch(ctx, copies, config, func(ctx context.Context, item objectCopy) error {
+		bar := progress.file(item.Key, item.Size)
+		defer progress.done(item.Key)
+
+		return src.streamObject(ctx, item, srcBucket, dstBucket, uploader, bar)
+	})
+
+	progress.close()
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	return report, err
+}
+
+// streamObject copies an object from the source bucket into an upload to the destination bucket through uploader.
+func (basics BucketBasics) streamObject(ctx context.Context, item objectCopy, srcBucket string, dstBucket string, uploader *manager.Uploader, bar *progressbar.ProgressBar) error {
+	// Cancel the GET if the upload fails, so the source connection isn't left open
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	obj, err := basics.client(srcBucket).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(item.Key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get object %v from bucket %v: %v", item.Key, srcBucket, err)
+		return classifyError(err)
+	}
+
+	defer obj.Body.Close()
+
+	sized := &sizedReader{r: obj.Body, remaining: aws.ToInt64(obj.ContentLength)}
+	body, counted := newProgressReader(sized, bar)
+
+	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:          aws.String(dstBucket),
+		Key:             aws.String(item.DestinationKey),
+		Body:            body,
+		ContentType:     obj.ContentType,
+		CacheControl:    obj.CacheControl,
+		ContentEncoding: obj.ContentEncoding,
+		Metadata:        obj.Metadata,
+	})
+
+	if err != nil {
+		log.Printf("Couldn't upload object %v to bucket %v: %v", item.DestinationKey, dstBucket, err)
+		counted.rollback()
+		return classifyError(err)
+	}
+
+	// The upload manager reads to the end, so this only catches a reader that stopped early without saying so
+	if sized.remaining != 0 {
+		log.Printf("Couldn't copy all of object %v: it was %v bytes off its content length", item.Key, sized.remaining)
+		counted.rollback()
+		return fmt.Errorf("object %v: %w", item.Key, io.ErrUnexpectedEOF)
+	}
+
+	return nil
+}
+
+// sizedReader reads an object's body, failing at the end if the body was shorter or longer than the object's
+// Content-Length, so a truncated response aborts the upload instead of completing it.
+type sizedReader struct {
+	r io.Reader
+
+	// remaining is how many bytes of the body are still to come
+	remaining int64
+}
+
+func (reader *sizedReader) Read(p []byte) (int, error) {
+	n, err := reader.r.Read(p)
+	reader.remaining -= int64(n)
+
+	if err == io.EOF && reader.remaining != 0 {
+		return n, fmt.Errorf("%w: body was %v bytes off its content length", io.ErrUnexpectedEOF, reader.remaining)
+	}
+
+	return n, err
+}
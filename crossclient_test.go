@@ -0,0 +1,130 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/schollz/progressbar/v3"
+)
+
+func TestSizedReader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		body   string
+		length int64
+		wanted bool
+	}{
+		{"whole body", "hello", 5, false},
+		{"empty body", "", 0, false},
+		{"truncated body", "hel", 5, true},
+		{"long body", "hello!", 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := io.ReadAll(&sizedReader{r: strings.NewReader(tt.body), remaining: tt.length})
+			if got := errors.Is(err, io.ErrUnexpectedEOF); got != tt.wanted {
+				t.Errorf("sizedReader(%q, %v) error = %v, want unexpected EOF %v", tt.body, tt.length, err, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestCopyBetweenClients(t *testing.T) {
+	// HOME is set so NewBucketBasics doesn't read the real shared config
+	t.Setenv("HOME", t.TempDir())
+
+	source := newFakeS3(t, "old")
+	dest := newFakeS3(t, "new")
+
+	source.put("old", "data/a.csv", "a,b\n1,2\n", http.Header{"Content-Type": {"text/csv"}, "X-Amz-Meta-Owner": {"lab"}})
+	source.put("old", "data/b.csv", "", nil)
+	source.put("old", "other/c.csv", "c", nil)
+
+	src, err := NewBucketBasics(ClientOptions{Endpoint: source.server.URL, Anonymous: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := NewBucketBasics(ClientOptions{Endpoint: dest.server.URL, Anonymous: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := CopyBetweenClients(src, "old", "data/*.csv", dst, "new", CopyBetweenClientsOptions{Prefix: "moved/", Quiet: true})
+	if err != nil {
+		t.Fatalf("CopyBetweenClients() = %v, want nil", err)
+	}
+
+	if got := len(report.Succeeded()); got != 2 {
+		t.Errorf("CopyBetweenClients() succeeded %v objects, want 2", got)
+	}
+
+	if got, wanted := dest.keys("new"), []string{"moved/data/a.csv", "moved/data/b.csv"}; !slices.Equal(got, wanted) {
+		t.Errorf("CopyBetweenClients() copied %q, want %q", got, wanted)
+	}
+
+	object, _ := dest.object("new", "moved/data/a.csv")
+	if string(object.body) != "a,b\n1,2\n" || object.header.Get("Content-Type") != "text/csv" || object.header.Get("X-Amz-Meta-Owner") != "lab" {
+		t.Errorf("CopyBetweenClients() wrote %q with %v, want the source's body, content type, and metadata", object.body, object.header)
+	}
+
+	// Only the listing and the reads went to the source
+	for _, request := range source.served() {
+		if !strings.HasPrefix(request, "GET ") {
+			t.Errorf("CopyBetweenClients() sent %v to the source, want only reads", request)
+		}
+	}
+}
+
+// truncatingTransport cuts every object body short after n bytes while leaving its Content-Length alone, as a
+// misbehaving proxy might.
+type truncatingTransport struct {
+	n int64
+}
+
+func (transport truncatingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil || r.Method != http.MethodGet || r.URL.Query().Has("list-type") {
+		return resp, err
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, transport.n), resp.Body}
+
+	return resp, nil
+}
+
+func TestStreamObjectTruncated(t *testing.T) {
+	t.Parallel()
+
+	source := newFakeS3(t, "old")
+	dest := newFakeS3(t, "new")
+	source.put("old", "a.csv", "0123456789", nil)
+
+	client := s3.New(source.client().Options(), func(o *s3.Options) {
+		o.HTTPClient = &http.Client{Transport: truncatingTransport{n: 4}}
+	})
+	src := BucketBasics{S3Client: client}
+
+	err := src.streamObject(context.Background(), objectCopy{Key: "a.csv", DestinationKey: "a.csv", Size: 10}, "old", "new", manager.NewUploader(dest.client()), progressbar.DefaultSilent(10))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("streamObject() of a truncated body = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+
+	if object, ok := dest.object("new", "a.csv"); ok {
+		t.Errorf("streamObject() of a truncated body uploaded %q, want nothing", object.body)
+	}
+}
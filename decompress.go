@@ -0,0 +1,156 @@
+package boto3manager
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/klauspost/compress/zstd"
+)
+
+// encodingSuffixes are the file name suffixes of the content encodings a download can be decompressed from.
+var encodingSuffixes = map[string]string{
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+// decodedName returns the name a file downloaded with a content encoding is saved under once decompressed: the
+// name without the encoding's suffix, like a browser saving data.csv.gz served with Content-Encoding: gzip.
+func decodedName(name string, encoding string) string {
+	suffix, ok := encodingSuffixes[encoding]
+	if !ok || !strings.HasSuffix(strings.ToLower(name), suffix) || len(name) == len(suffix) {
+		return name
+	}
+
+	return name[:len(name)-len(suffix)]
+}
+
+// contentEncoding returns the single, lowercase coding of a Content-Encoding header.
+func contentEncoding(header string) string {
+	return strings.ToLower(strings.TrimSpace(header))
+}
+
+// decoder returns a reader decompressing r from the encoding, and whether the encoding could be decoded. Encodings
+// that can't be decoded leave r as it is. An empty body decodes to nothing, as some servers send one for an empty
+// object. The reader must be closed.
+func decoder(r io.Reader, encoding string) (io.ReadCloser, bool, error) {
+	switch encoding {
+	case "gzip":
+		gz, err := gzip.NewReader(r)
+		if err == io.EOF {
+			return io.NopCloser(strings.NewReader("")), true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return gz, true, nil
+	case "zstd":
+		// Downloads already run in parallel, so each decodes on a single goroutine
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, false, err
+		}
+		return zr.IOReadCloser(), true, nil
+	default:
+		return io.NopCloser(r), false, nil
+	}
+}
+
+// downloadDecompressed downloads an object into dest with a single streaming GetObject, decompressing it on the way
+// if its Content-Encoding is one that can be decoded and naming the file without the encoding's suffix. A compressed
+// stream can't be fetched in ranges, so the download manager isn't used.
+func (basics BucketBasics) downloadDecompressed(ctx context.Context, key string, dest string, bucketName string, options DownloadObjectOptions) error {
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't download file %v: %v", key, err)
+		return classifyError(err)
+	}
+
+	defer obj.Body.Close()
+
+	// Count the bytes fetched, which are what the object's size and so the progress bar's total measure
+	var body io.Reader = obj.Body
+	var progress *progressReader
+	if options.bar != nil {
+		body, progress = newProgressReader(obj.Body, options.bar)
+	}
+
+	encoding := contentEncoding(aws.ToString(obj.ContentEncoding))
+	r, decoded, err := decoder(body, encoding)
+	if err != nil {
+		log.Printf("Couldn't decompress %v: %v", key, err)
+		if progress != nil {
+			progress.rollback()
+		}
+		return err
+	}
+
+	defer r.Close()
+
+	baseName := localName(filepath.Base(key))
+	if decoded {
+		baseName = decodedName(baseName, encoding)
+	} else if _, ok := encodingSuffixes[encoding]; ok {
+		log.Printf("Keeping %v compressed, since %v can't be decompressed", key, encoding)
+	}
+
+	// A name chosen by the caller is kept as it is
+	if options.name != "" {
+		baseName = options.name
+	}
+
+	// Write beside the file and rename it into place, so a failure part way leaves no partial file behind
+	fileName := localPath(filepath.Join(dest, baseName))
+	partial := fileName + ".partial"
+	f, err := os.Create(partial)
+
+	if err != nil {
+		log.Printf("Couldn't open file %v: %v", partial, err)
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		log.Printf("Couldn't download file %v: %v", key, err)
+		if progress != nil {
+			progress.rollback()
+		}
+		os.Remove(partial)
+		return classifyError(err)
+	}
+
+	if options.PreserveMetadata {
+		if err := restoreFileMetadata(partial, obj.Metadata); err != nil {
+			log.Printf("Couldn't restore attributes of %v: %v", fileName, err)
+			os.Remove(partial)
+			return err
+		}
+	}
+
+	if err := os.Rename(partial, fileName); err != nil {
+		log.Printf("Couldn't move %v into place: %v", fileName, err)
+		os.Remove(partial)
+		return err
+	}
+
+	if !options.Quiet {
+		fmt.Printf("Downloaded %v\n", key)
+	}
+
+	return nil
+}
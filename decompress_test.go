@@ -0,0 +1,127 @@
+package boto3manager
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecodedName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		encoding string
+		wanted   string
+	}{
+		{name: "data.csv.gz", encoding: "gzip", wanted: "data.csv"},
+		{name: "data.csv.GZ", encoding: "gzip", wanted: "data.csv"},
+		{name: "data.csv", encoding: "gzip", wanted: "data.csv"},
+		{name: ".gz", encoding: "gzip", wanted: ".gz"},
+		{name: "data.csv.zst", encoding: "zstd", wanted: "data.csv"},
+		{name: "data.csv.gz", encoding: "br", wanted: "data.csv.gz"},
+		{name: "data.csv.gz", encoding: "", wanted: "data.csv.gz"},
+	}
+
+	for _, test := range tests {
+		if got := decodedName(test.name, test.encoding); got != test.wanted {
+			t.Errorf("decodedName(%v, %v) = %v, want %v", test.name, test.encoding, got, test.wanted)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte("a,b\n1,2\n"))
+	gz.Close()
+
+	var zstdCompressed bytes.Buffer
+	zw, err := zstd.NewWriter(&zstdCompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write([]byte("a,b\n1,2\n"))
+	zw.Close()
+
+	tests := []struct {
+		encoding string
+		body     []byte
+		decoded  bool
+		wanted   string
+	}{
+		{encoding: "gzip", body: compressed.Bytes(), decoded: true, wanted: "a,b\n1,2\n"},
+		{encoding: "gzip", body: nil, decoded: true, wanted: ""},
+		{encoding: "zstd", body: zstdCompressed.Bytes(), decoded: true, wanted: "a,b\n1,2\n"},
+		{encoding: "zstd", body: nil, decoded: true, wanted: ""},
+		{encoding: "br", body: []byte("raw"), decoded: false, wanted: "raw"},
+		{encoding: "", body: []byte("raw"), decoded: false, wanted: "raw"},
+	}
+
+	for _, test := range tests {
+		r, decoded, err := decoder(bytes.NewReader(test.body), test.encoding)
+		if err != nil {
+			t.Errorf("decoder(%v) returned %v", test.encoding, err)
+			continue
+		}
+
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil || decoded != test.decoded || string(got) != test.wanted {
+			t.Errorf("decoder(%v) = %q, %v, %v, want %q, %v", test.encoding, got, decoded, err, test.wanted, test.decoded)
+		}
+	}
+}
+
+func TestDownloadDecompressedEmpty(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "empty.csv.gz", "", http.Header{"Content-Encoding": {"gzip"}})
+
+	dest := t.TempDir()
+	if err := fake.basics().DownloadObject("empty.csv.gz", dest, "data", DownloadObjectOptions{Decompress: true}); err != nil {
+		t.Fatalf("DownloadObject() of an empty gzip object = %v, want nil", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "empty.csv"))
+	if err != nil || info.Size() != 0 {
+		t.Errorf("DownloadObject() of an empty gzip object wrote %v, %v, want an empty empty.csv", info, err)
+	}
+}
+
+func TestDownloadDecompressedCorrupt(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(bytes.Repeat([]byte("a,b\n1,2\n"), 10000))
+	gz.Close()
+
+	// The stream breaks off part way, after some of it has been decoded
+	fake := newFakeS3(t, "data")
+	fake.put("data", "data.csv.gz", compressed.String()[:compressed.Len()/2], http.Header{"Content-Encoding": {"gzip"}})
+
+	dest := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dest, "data.csv"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fake.basics().DownloadObject("data.csv.gz", dest, "data", DownloadObjectOptions{Decompress: true}); err == nil {
+		t.Fatalf("DownloadObject() of a truncated gzip object returned no error")
+	}
+
+	entries, _ := os.ReadDir(dest)
+	got, _ := os.ReadFile(filepath.Join(dest, "data.csv"))
+	if len(entries) != 1 || string(got) != "old" {
+		t.Errorf("DownloadObject() of a truncated gzip object left %v entries and data.csv = %q, want only data.csv = %q", len(entries), got, "old")
+	}
+}
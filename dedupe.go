@@ -0,0 +1,139 @@
+package boto3manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// duplicateDownload is a download of an object with the same contents as an earlier one in the batch, made from
+// that download's file instead of from the bucket.
+type duplicateDownload struct {
+	FileDownload
+	source FileDownload
+}
+
+// splitDuplicateDownloads separates the downloads of objects with the same size and ETag as an earlier download
+// from the downloads to make. Empty objects aren't worth linking and are left alone.
+func splitDuplicateDownloads(downloads []FileDownload, objects []types.Object) ([]FileDownload, []duplicateDownload) {
+	type identity struct {
+		size int64
+		etag string
+	}
+
+	identities := make(map[string]identity, len(objects))
+	for _, object := range objects {
+		identities[aws.ToString(object.Key)] = identity{size: aws.ToInt64(object.Size), etag: strings.Trim(aws.ToString(object.ETag), `"`)}
+	}
+
+	first := make(map[identity]FileDownload)
+	primaries := make([]FileDownload, 0, len(downloads))
+	duplicates := make([]duplicateDownload, 0)
+
+	for _, download := range downloads {
+		id, ok := identities[download.Key]
+		if !ok || id.size == 0 || id.etag == "" {
+			primaries = append(primaries, download)
+			continue
+		}
+
+		if source, ok := first[id]; ok {
+			duplicates = append(duplicates, duplicateDownload{FileDownload: download, source: source})
+			continue
+		}
+
+		first[id] = download
+		primaries = append(primaries, download)
+	}
+
+	return primaries, duplicates
+}
+
+// downloadedFile returns the path of the file a download writes. Unless the download's destination was mapped, it
+// is the directory the file is written in.
+func downloadedFile(download FileDownload, mapped bool) string {
+	if mapped {
+		return download.Destination
+	}
+
+	return filepath.Join(download.Destination, localName(filepath.Base(download.Key)))
+}
+
+// linkDuplicates makes each duplicate's file from its source's, recording the outcome in the report. Duplicates of
+// downloads that failed fail too.
+func linkDuplicates(duplicates []duplicateDownload, mapped bool, report *TransferReport) {
+	failed := make(map[string]error)
+	for _, result := range report.Failed() {
+		failed[result.Key] = result.Err
+	}
+
+	for _, duplicate := range duplicates {
+		started := time.Now()
+		result := duplicate.result()
+
+		if err, ok := failed[duplicate.source.Key]; ok {
+			result.Err = fmt.Errorf("couldn't download %v, which has the same contents: %w", duplicate.source.Key, err)
+		} else {
+			result.Err = linkOrCopy(downloadedFile(duplicate.source, mapped), downloadedFile(duplicate.FileDownload, mapped))
+			result.SkipReason = "duplicate"
+		}
+
+		if result.Err != nil {
+			result.SkipReason = ""
+		}
+
+		result.Attempts = 1
+		result.Duration = time.Since(started)
+		report.record(result)
+	}
+}
+
+// linkOrCopy hard links dst to src, replacing any file at dst, or copies src to dst where the file system can't
+// link them.
+func linkOrCopy(src string, dst string) error {
+	if err := os.MkdirAll(localPath(filepath.Dir(dst)), os.ModePerm); err != nil {
+		log.Printf("Couldn't create directory %v: %v", filepath.Dir(dst), err)
+		return err
+	}
+
+	src, dst = localPath(src), localPath(dst)
+	if err := os.Remove(dst); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Couldn't replace %v: %v", dst, err)
+		return err
+	}
+
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		log.Printf("Couldn't open %v: %v", src, err)
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		log.Printf("Couldn't open file %v: %v", dst, err)
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		log.Printf("Couldn't copy %v to %v: %v", src, dst, err)
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,71 @@
+package boto3manager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestSplitDuplicateDownloads(t *testing.T) {
+	t.Parallel()
+
+	objects := []types.Object{
+		{Key: aws.String("a.csv"), Size: aws.Int64(5), ETag: aws.String(`"abc"`)},
+		{Key: aws.String("b.csv"), Size: aws.Int64(5), ETag: aws.String(`"abc"`)},
+		{Key: aws.String("c.csv"), Size: aws.Int64(6), ETag: aws.String(`"abc"`)},
+		{Key: aws.String("empty"), Size: aws.Int64(0), ETag: aws.String(`"d41d8"`)},
+		{Key: aws.String("empty2"), Size: aws.Int64(0), ETag: aws.String(`"d41d8"`)},
+		{Key: aws.String("d.csv"), Size: aws.Int64(5), ETag: aws.String(`"abc"`)},
+	}
+	downloads := downloadsForObjects(objects, "out")
+
+	primaries, duplicates := splitDuplicateDownloads(downloads, objects)
+
+	wantedPrimaries := []FileDownload{downloads[0], downloads[2], downloads[3], downloads[4]}
+	wantedDuplicates := []duplicateDownload{
+		{FileDownload: downloads[1], source: downloads[0]},
+		{FileDownload: downloads[5], source: downloads[0]},
+	}
+
+	if !reflect.DeepEqual(primaries, wantedPrimaries) || !reflect.DeepEqual(duplicates, wantedDuplicates) {
+		t.Errorf("splitDuplicateDownloads() = %v, %v, want %v, %v", primaries, duplicates, wantedPrimaries, wantedDuplicates)
+	}
+}
+
+func TestLinkDuplicates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.csv"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := FileDownload{Key: "a.csv", Destination: filepath.Join(dir, "a.csv"), Size: 5}
+	failedSource := FileDownload{Key: "x.csv", Destination: filepath.Join(dir, "x.csv"), Size: 5}
+	report := &TransferReport{Results: []TransferResult{source.result(), {Key: "x.csv", Err: os.ErrNotExist}}}
+
+	linkDuplicates([]duplicateDownload{
+		{FileDownload: FileDownload{Key: "nested/b.csv", Destination: filepath.Join(dir, "nested", "b.csv"), Size: 5}, source: source},
+		{FileDownload: FileDownload{Key: "y.csv", Destination: filepath.Join(dir, "y.csv"), Size: 5}, source: failedSource},
+	}, true, report)
+
+	tests := []struct {
+		result     TransferResult
+		wantedData string
+		wantedErr  bool
+	}{
+		{result: report.Results[2], wantedData: "hello"},
+		{result: report.Results[3], wantedErr: true},
+	}
+
+	for _, test := range tests {
+		data, _ := os.ReadFile(test.result.Path)
+		if (test.result.Err != nil) != test.wantedErr || string(data) != test.wantedData {
+			t.Errorf("linkDuplicates() %v = %q, %v, want %q, error %v", test.result.Key, data, test.result.Err, test.wantedData, test.wantedErr)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxDeleteKeys is the most keys a single DeleteObjects request can remove.
+const maxDeleteKeys = 1000
+
+// deleteKeys deletes keys from a bucket in batches of up to 1000.
+func (basics BucketBasics) deleteKeys(ctx context.Context, bucketName string, keys []string) error {
+	for start := 0; start < len(keys); start += maxDeleteKeys {
+		end := min(start+maxDeleteKeys, len(keys))
+
+		objects := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		output, err := basics.client(bucketName).DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucketName),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+
+		if err != nil {
+			log.Printf("Couldn't delete objects from bucket %v: %v", bucketName, err)
+			return err
+		}
+
+		// A request can succeed while individual keys fail
+		if len(output.Errors) > 0 {
+			first := output.Errors[0]
+			return fmt.Errorf("couldn't delete %v objects from bucket %v, first %v: %v", len(output.Errors), bucketName, aws.ToString(first.Key), aws.ToString(first.Message))
+		}
+	}
+
+	return nil
+}
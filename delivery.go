@@ -0,0 +1,200 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DeliveryManifestName is a conventional name for the manifest written by an upload or sync with DeliveryManifest
+// set, next to SuccessMarkerName.
+const DeliveryManifestName = "_MANIFEST.json"
+
+// modulePath is the module path of this package, to find its version in the build information.
+const modulePath = "gitlab.nrp-nautilus.io/humboldt/boto3-manager"
+
+// DeliveryManifest is a receipt for a dataset delivered to a prefix: every file in it with its size and checksum,
+// when the delivery ran, and what wrote it. Downstream consumers read it with ReadDeliveryManifest or check the
+// prefix against it with VerifyDelivery.
+type DeliveryManifest struct {
+	Tool        string    `json:"tool"`
+	ToolVersion string    `json:"tool_version"`
+	Bucket      string    `json:"bucket"`
+	Prefix      string    `json:"prefix"`
+	Started     time.Time `json:"started"`
+	Finished    time.Time `json:"finished"`
+
+	// Objects and Bytes total the files
+	Objects int   `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+
+	Files []DeliveryFile `json:"files"`
+}
+
+// DeliveryFile is a file in a delivery manifest.
+type DeliveryFile struct {
+	// Key is relative to the manifest's prefix
+	Key      string    `json:"key"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Modified time.Time `json:"modified"`
+}
+
+// toolVersion returns the version of this module the program was built with.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+
+	return "unknown"
+}
+
+// newDeliveryManifest builds the manifest of the files a report holds, transferred or skipped as unchanged, hashing
+// each from fsys, or from the OS file system if fsys is nil. The file system is rooted at root on disk, as for
+// hashFiles.
+func newDeliveryManifest(fsys fs.FS, root string, report *TransferReport, prefix string, bucketName string, options HashOptions) (*DeliveryManifest, error) {
+	manifest := &DeliveryManifest{
+		Tool:        "boto3-manager",
+		ToolVersion: toolVersion(),
+		Bucket:      bucketName,
+		Prefix:      prefix,
+		Started:     report.Started,
+		Finished:    report.Finished,
+		Files:       make([]DeliveryFile, 0, len(report.Results)),
+	}
+
+	if fsys == nil {
+		fsys = osFS{}
+	}
+
+	files := make([]FileUpload, 0, len(report.Results))
+	for _, result := range report.Results {
+		if result.Err == nil {
+			files = append(files, FileUpload{Path: result.Path, Key: result.Key, Size: result.Size})
+		}
+	}
+
+	options.quiet = true
+	sums, err := hashFiles(fsys, root, files, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		info, err := fs.Stat(fsys, file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Files = append(manifest.Files, DeliveryFile{
+			Key:      strings.TrimPrefix(file.Key, prefix),
+			Size:     info.Size(),
+			SHA256:   sums[file.Key],
+			Modified: info.ModTime().UTC(),
+		})
+		manifest.Objects++
+		manifest.Bytes += info.Size()
+	}
+
+	// Sort by key so the manifest is stable between runs
+	slices.SortFunc(manifest.Files, func(a, b DeliveryFile) int { return strings.Compare(a.Key, b.Key) })
+
+	return manifest, nil
+}
+
+// osFS opens paths, relative or absolute, from the OS file system, unlike os.DirFS.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// writeDeliveryManifest writes the manifest of the files in a report to prefix + name.
+func (basics BucketBasics) writeDeliveryManifest(ctx context.Context, fsys fs.FS, root string, report *TransferReport, prefix string, name string, bucketName string, options HashOptions) error {
+	manifest, err := newDeliveryManifest(fsys, root, report, prefix, bucketName, options)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	key := prefix + name
+	_, err = basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't upload delivery manifest %v to bucket %v: %v", key, bucketName, err)
+		return classifyError(err)
+	}
+
+	return nil
+}
+
+// ReadDeliveryManifest takes the key of a delivery manifest and a bucket name and returns the manifest.
+func (basics BucketBasics) ReadDeliveryManifest(key string, bucketName string) (*DeliveryManifest, error) {
+	obj, err := basics.client(bucketName).GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get delivery manifest %v: %v", key, err)
+		return nil, classifyError(err)
+	}
+
+	defer obj.Body.Close()
+
+	var manifest DeliveryManifest
+	if err := json.NewDecoder(obj.Body).Decode(&manifest); err != nil {
+		log.Printf("Couldn't parse delivery manifest %v: %v", key, err)
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// VerifyDelivery takes the key of a delivery manifest and a bucket name and checks that every file the manifest
+// lists exists next to it with the listed checksum, as VerifyAgainstManifest does. Keys are taken relative to the
+// manifest's own prefix, so a delivery that has been copied elsewhere can still be checked.
+func (basics BucketBasics) VerifyDelivery(key string, bucketName string, options VerifyOptions) (*TransferReport, error) {
+	manifest, err := basics.ReadDeliveryManifest(key, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make(map[string]string, len(manifest.Files))
+	for _, file := range manifest.Files {
+		sums[file.Key] = file.SHA256
+	}
+
+	prefix := key[:strings.LastIndex(key, "/")+1]
+	return basics.verifySums(context.Background(), sums, prefix, bucketName, options)
+}
@@ -0,0 +1,98 @@
+package boto3manager
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestNewDeliveryManifest(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"run/b.csv": {Data: []byte("b"), ModTime: modified},
+		"run/a.csv": {Data: []byte("hello"), ModTime: modified},
+	}
+
+	report := &TransferReport{Results: []TransferResult{
+		{Key: "data/b.csv", Path: "run/b.csv", Size: 1, SkipReason: "unchanged"},
+		{Key: "data/a.csv", Path: "run/a.csv", Size: 5},
+		{Key: "data/c.csv", Path: "run/c.csv", Size: 3, Err: errors.New("failed")},
+	}}
+
+	manifest, err := newDeliveryManifest(fsys, "", report, "data/", "lab", HashOptions{})
+	if err != nil {
+		t.Fatalf("newDeliveryManifest() = %v", err)
+	}
+
+	tests := []struct {
+		file   DeliveryFile
+		wanted DeliveryFile
+	}{
+		{file: manifest.Files[0], wanted: DeliveryFile{Key: "a.csv", Size: 5, SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", Modified: modified}},
+		{file: manifest.Files[1], wanted: DeliveryFile{Key: "b.csv", Size: 1, SHA256: "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d", Modified: modified}},
+	}
+
+	if len(manifest.Files) != len(tests) || manifest.Objects != 2 || manifest.Bytes != 6 {
+		t.Fatalf("newDeliveryManifest() = %v files, %v objects, %v bytes, want 2, 2, 6", len(manifest.Files), manifest.Objects, manifest.Bytes)
+	}
+
+	for _, test := range tests {
+		if !test.file.Modified.Equal(test.wanted.Modified) || test.file.Key != test.wanted.Key || test.file.Size != test.wanted.Size || test.file.SHA256 != test.wanted.SHA256 {
+			t.Errorf("manifest file = %+v, want %+v", test.file, test.wanted)
+		}
+	}
+}
+
+func TestVerifyDelivery(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"data/a.csv":     {Data: []byte("a,1\n")},
+		"data/sub/b.csv": {Data: []byte("b,2\n")},
+	}
+
+	fake := newFakeS3(t, "bucket")
+	options := UploadObjectsOptions{FS: fsys, DeliveryManifest: DeliveryManifestName, Quiet: true}
+	if _, err := fake.basics().UploadObjects("data/**/*", "delivery/", "bucket", options); err != nil {
+		t.Fatalf("UploadObjects() = %v, want nil", err)
+	}
+
+	key := "delivery/" + DeliveryManifestName
+	manifest, err := fake.basics().ReadDeliveryManifest(key, "bucket")
+	if err != nil {
+		t.Fatalf("ReadDeliveryManifest() = %v, want nil", err)
+	}
+
+	files := make(map[string]string)
+	for _, file := range manifest.Files {
+		files[file.Key] = file.SHA256
+	}
+	wanted := map[string]string{"a.csv": sha256Hex("a,1\n"), "sub/b.csv": sha256Hex("b,2\n")}
+	if !reflect.DeepEqual(files, wanted) || manifest.Objects != 2 || manifest.Bytes != 8 || manifest.Prefix != "delivery/" {
+		t.Errorf("ReadDeliveryManifest() = %+v, want a.csv and sub/b.csv under delivery/", manifest)
+	}
+
+	report, err := fake.basics().VerifyDelivery(key, "bucket", VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyDelivery() = %v, want nil", err)
+	}
+	if failed := report.Failed(); len(failed) > 0 {
+		t.Errorf("VerifyDelivery() of an intact delivery failed %v", failed)
+	}
+
+	// Someone replaces a file after the delivery
+	fake.put("bucket", "delivery/sub/b.csv", "b,3\n", nil)
+
+	report, err = fake.basics().VerifyDelivery(key, "bucket", VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyDelivery() = %v, want nil", err)
+	}
+	failed := failedKeys(report)
+	if len(failed) != 1 || !errors.Is(failed["delivery/sub/b.csv"], ErrChecksumMismatch) {
+		t.Errorf("VerifyDelivery() of a tampered delivery failed %v, want delivery/sub/b.csv with a checksum mismatch", failed)
+	}
+}
@@ -0,0 +1,49 @@
+package boto3manager
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// DestMapper maps an object's key to the path of the file it is downloaded to. Relative paths are taken under the
+// download's destination directory. Objects it returns skip for aren't downloaded.
+type DestMapper func(key string) (localPath string, skip bool)
+
+// StripPrefixDest returns a DestMapper that writes each object to its key without prefix, skipping objects whose
+// keys don't start with it, so "data/2024/" downloads data/2024/a.csv to a.csv.
+func StripPrefixDest(prefix string) DestMapper {
+	return func(key string) (string, bool) {
+		rest, ok := strings.CutPrefix(key, prefix)
+		return filepath.FromSlash(rest), !ok || rest == ""
+	}
+}
+
+// FlattenDest writes each object to its key's base name, dropping its directories.
+func FlattenDest(key string) (string, bool) {
+	return filepath.Base(filepath.FromSlash(key)), false
+}
+
+// mappedDownloads returns a download for each object the mapper doesn't skip, with the full path of the file it is
+// written to as its destination.
+func mappedDownloads(objects []types.Object, dest string, mapper DestMapper) []FileDownload {
+	downloads := make([]FileDownload, 0, len(objects))
+
+	for _, object := range objects {
+		key := aws.ToString(object.Key)
+		localPath, skip := mapper(key)
+		if skip {
+			continue
+		}
+
+		if !filepath.IsAbs(localPath) {
+			localPath = filepath.Join(dest, localPath)
+		}
+
+		downloads = append(downloads, FileDownload{Key: key, Destination: localPath, Size: aws.ToInt64(object.Size)})
+	}
+
+	return downloads
+}
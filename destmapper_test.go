@@ -0,0 +1,59 @@
+package boto3manager
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestMappedDownloads(t *testing.T) {
+	t.Parallel()
+
+	objects := []types.Object{
+		{Key: aws.String("data/2024/a.csv"), Size: aws.Int64(5)},
+		{Key: aws.String("data/2024/nested/b.csv"), Size: aws.Int64(3)},
+		{Key: aws.String("other/c.csv"), Size: aws.Int64(1)},
+	}
+
+	tests := []struct {
+		name   string
+		mapper DestMapper
+		wanted []FileDownload
+	}{
+		{
+			name:   "strip prefix",
+			mapper: StripPrefixDest("data/2024/"),
+			wanted: []FileDownload{
+				{Key: "data/2024/a.csv", Destination: filepath.Join("out", "a.csv"), Size: 5},
+				{Key: "data/2024/nested/b.csv", Destination: filepath.Join("out", "nested", "b.csv"), Size: 3},
+			},
+		},
+		{
+			name:   "flatten",
+			mapper: FlattenDest,
+			wanted: []FileDownload{
+				{Key: "data/2024/a.csv", Destination: filepath.Join("out", "a.csv"), Size: 5},
+				{Key: "data/2024/nested/b.csv", Destination: filepath.Join("out", "b.csv"), Size: 3},
+				{Key: "other/c.csv", Destination: filepath.Join("out", "c.csv"), Size: 1},
+			},
+		},
+		{
+			name: "absolute",
+			mapper: func(key string) (string, bool) {
+				return filepath.Join(string(filepath.Separator)+"srv", filepath.Base(key)), key != "other/c.csv"
+			},
+			wanted: []FileDownload{
+				{Key: "other/c.csv", Destination: filepath.Join(string(filepath.Separator)+"srv", "c.csv"), Size: 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if got := mappedDownloads(objects, "out", test.mapper); !reflect.DeepEqual(got, test.wanted) {
+			t.Errorf("mappedDownloads(%v) = %v, want %v", test.name, got, test.wanted)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package boto3manager
+
+import (
+	"context"
+	"iter"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gitlab.nrp-nautilus.io/humboldt/boto3-manager/internal/merge"
+)
+
+type DiffKind int
+
+const (
+	// OnlyInSource means the key exists only under the source prefix
+	OnlyInSource DiffKind = iota
+	// OnlyInDestination means the key exists only under the destination prefix
+	OnlyInDestination
+	// Different means the key exists on both sides but the size or ETag differs
+	Different
+)
+
+func (kind DiffKind) String() string {
+	switch kind {
+	case OnlyInSource:
+		return "only-in-source"
+	case OnlyInDestination:
+		return "only-in-destination"
+	case Different:
+		return "different"
+	default:
+		return "unknown"
+	}
+}
+
+// DiffEntry is a single difference between two prefixes. Key is relative to the prefixes being compared.
+type DiffEntry struct {
+	Key         string
+	Kind        DiffKind
+	Source      *types.Object
+	Destination *types.Object
+}
+
+// DiffRemote compares the objects under srcPrefix in srcBucket with those under dstPrefix in dstBucket and calls fn
+// for every key that is only on one side or differs in size or ETag. Both listings are streamed page by page and
+// merged in key order, so memory use doesn't grow with the number of keys. Returning an error from fn stops the diff.
+func (basics BucketBasics) DiffRemote(srcBucket string, srcPrefix string, dstBucket string, dstPrefix string, fn func(DiffEntry) error) error {
+	src := basics.listObjectsSeq(context.TODO(), srcBucket, srcPrefix)
+	dst := basics.listObjectsSeq(context.TODO(), dstBucket, dstPrefix)
+
+	return diffObjects(src, srcPrefix, dst, dstPrefix, fn)
+}
+
+// diffObjects merges two streams of objects sorted by key and calls fn for every key, relative to its stream's
+// prefix, that is only in one stream or differs in size or ETag.
+func diffObjects(src iter.Seq2[types.Object, error], srcPrefix string, dst iter.Seq2[types.Object, error], dstPrefix string, fn func(DiffEntry) error) error {
+	// Compare keys relative to each prefix
+	compare := func(a types.Object, b types.Object) int {
+		return strings.Compare(strings.TrimPrefix(aws.ToString(a.Key), srcPrefix), strings.TrimPrefix(aws.ToString(b.Key), dstPrefix))
+	}
+
+	for pair, err := range merge.Join(src, dst, compare) {
+		if err != nil {
+			return err
+		}
+
+		var entry DiffEntry
+
+		switch {
+		case !pair.HasRight:
+			entry = DiffEntry{Key: strings.TrimPrefix(aws.ToString(pair.Left.Key), srcPrefix), Kind: OnlyInSource, Source: &pair.Left}
+		case !pair.HasLeft:
+			entry = DiffEntry{Key: strings.TrimPrefix(aws.ToString(pair.Right.Key), dstPrefix), Kind: OnlyInDestination, Destination: &pair.Right}
+		case !sameObject(pair.Left, pair.Right):
+			entry = DiffEntry{Key: strings.TrimPrefix(aws.ToString(pair.Left.Key), srcPrefix), Kind: Different, Source: &pair.Left, Destination: &pair.Right}
+		default:
+			continue
+		}
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sameObject reports whether two listed objects have the same size and ETag.
+func sameObject(a types.Object, b types.Object) bool {
+	return aws.ToInt64(a.Size) == aws.ToInt64(b.Size) && aws.ToString(a.ETag) == aws.ToString(b.ETag)
+}
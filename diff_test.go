@@ -0,0 +1,54 @@
+package boto3manager
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDiffRemote(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "src", "dst")
+	fake.put("src", "v1/a.csv", "a", nil)
+	fake.put("src", "v1/b.csv", "b", nil)
+	fake.put("src", "v1/c.csv", "c", nil)
+	fake.put("src", "v1/e.csv", "e", nil)
+	fake.put("dst", "backup/v1/a.csv", "a", nil)
+	fake.put("dst", "backup/v1/b.csv", "changed", nil)
+	fake.put("dst", "backup/v1/d.csv", "d", nil)
+	fake.put("dst", "backup/v1/e.csv", "e", nil)
+
+	// Small pages make the merge cross page boundaries on both sides
+	fake.maxKeys = 2
+
+	type diff struct {
+		key  string
+		kind DiffKind
+	}
+
+	var got []diff
+	err := fake.basics().DiffRemote("src", "v1/", "dst", "backup/v1/", func(entry DiffEntry) error {
+		got = append(got, diff{entry.Key, entry.Kind})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DiffRemote() = %v, want nil", err)
+	}
+
+	wanted := []diff{{"b.csv", Different}, {"c.csv", OnlyInSource}, {"d.csv", OnlyInDestination}}
+	if !slices.Equal(got, wanted) {
+		t.Errorf("DiffRemote() = %v, want %v", got, wanted)
+	}
+
+	// An error from fn stops the diff
+	stop := errors.New("stop")
+	calls := 0
+	err = fake.basics().DiffRemote("src", "v1/", "dst", "backup/v1/", func(entry DiffEntry) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("DiffRemote() with fn failing = %v after %v calls, want %v after 1", err, calls, stop)
+	}
+}
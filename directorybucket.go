@@ -0,0 +1,137 @@
+package boto3manager
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Directory buckets, the S3 Express One Zone storage class, keep objects in a single Availability Zone for
+// low-latency, high-throughput scratch storage. They are used through the same BucketBasics methods as any other
+// bucket. The SDK authenticates requests to them with sessions from CreateSession, which it creates and refreshes
+// itself, and their ListObjectsV2 differences are handled when listing:
+//
+//   - results aren't in key order, so listings are sorted before they are returned
+//   - a prefix listed with a delimiter must end in "/", so the directory holding the prefix is listed instead and
+//     filtered
+//   - StartAfter isn't supported, so ParallelList lists directory buckets in one pass
+
+// DirectoryBucketSuffix ends the name of every directory bucket.
+const DirectoryBucketSuffix = "--x-s3"
+
+// IsDirectoryBucket reports whether a bucket name is the name of a directory bucket.
+func IsDirectoryBucket(bucketName string) bool {
+	return strings.HasSuffix(bucketName, DirectoryBucketSuffix)
+}
+
+// DirectoryBucketName returns the full name of a directory bucket from its base name and the ID of the
+// Availability Zone it is in, such as usw2-az1.
+func DirectoryBucketName(baseName string, zoneID string) string {
+	return baseName + "--" + zoneID + DirectoryBucketSuffix
+}
+
+// CreateDirectoryBucket takes a base name and an Availability Zone ID, such as usw2-az1, and creates a directory
+// bucket in that zone, returning its full name. The client's region must be the zone's region.
+func (basics BucketBasics) CreateDirectoryBucket(baseName string, zoneID string) (string, error) {
+	bucketName := DirectoryBucketName(baseName, zoneID)
+
+	_, err := basics.client(bucketName).CreateBucket(context.TODO(), &s3.CreateBucketInput{
+		Bucket: aws.String(bucketName),
+		CreateBucketConfiguration: &types.CreateBucketConfiguration{
+			Location: &types.LocationInfo{
+				Type: types.LocationTypeAvailabilityZone,
+				Name: aws.String(zoneID),
+			},
+			Bucket: &types.BucketInfo{
+				DataRedundancy: types.DataRedundancySingleAvailabilityZone,
+				Type:           types.BucketTypeDirectory,
+			},
+		},
+	})
+
+	if err != nil {
+		log.Printf("Couldn't create directory bucket %v: %v", bucketName, err)
+		return "", classifyError(err)
+	}
+
+	return bucketName, nil
+}
+
+// ListDirectoryBuckets returns the names of the account's directory buckets in the client's region.
+func (basics BucketBasics) ListDirectoryBuckets() ([]string, error) {
+	names := make([]string, 0)
+
+	p := s3.NewListDirectoryBucketsPaginator(basics.S3Client, &s3.ListDirectoryBucketsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.TODO())
+		if err != nil {
+			log.Printf("Couldn't list directory buckets: %v", err)
+			return nil, classifyError(err)
+		}
+
+		for _, bucket := range page.Buckets {
+			names = append(names, aws.ToString(bucket.Name))
+		}
+	}
+
+	return names, nil
+}
+
+// directoryListParams returns params for listing a directory bucket and the prefix the results must be filtered
+// to, if any. A delimited listing of a prefix not ending in "/" lists the directory holding the prefix instead.
+func directoryListParams(params *s3.ListObjectsV2Input) (*s3.ListObjectsV2Input, string) {
+	prefix := aws.ToString(params.Prefix)
+	if params.Delimiter == nil || prefix == "" || strings.HasSuffix(prefix, "/") {
+		return params, ""
+	}
+
+	directory := *params
+	directory.Prefix = nil
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		directory.Prefix = aws.String(prefix[:i+1])
+	}
+
+	return &directory, prefix
+}
+
+// filterPage drops the objects and common prefixes of a page that don't start with prefix.
+func filterPage(page *s3.ListObjectsV2Output, prefix string) {
+	page.Contents = slices.DeleteFunc(page.Contents, func(object types.Object) bool {
+		return !strings.HasPrefix(aws.ToString(object.Key), prefix)
+	})
+	page.CommonPrefixes = slices.DeleteFunc(page.CommonPrefixes, func(commonPrefix types.CommonPrefix) bool {
+		return !strings.HasPrefix(aws.ToString(commonPrefix.Prefix), prefix)
+	})
+}
+
+// sortedObjects lists the objects under a prefix of a directory bucket, whose listings aren't in key order, and
+// returns them sorted by key.
+func (basics BucketBasics) sortedObjects(ctx context.Context, bucketName string, prefix string) ([]types.Object, error) {
+	params := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+	}
+
+	if len(prefix) > 0 {
+		params.Prefix = aws.String(prefix)
+	}
+
+	objects := make([]types.Object, 0)
+	for page, err := range basics.listPages(ctx, params) {
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, page.Contents...)
+	}
+
+	slices.SortFunc(objects, func(a, b types.Object) int {
+		return strings.Compare(aws.ToString(a.Key), aws.ToString(b.Key))
+	})
+
+	return objects, nil
+}
@@ -0,0 +1,86 @@
+package boto3manager
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestIsDirectoryBucket(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		bucketName string
+		wanted     bool
+	}{
+		{bucketName: DirectoryBucketName("scratch", "usw2-az1"), wanted: true},
+		{bucketName: "scratch--usw2-az1--x-s3", wanted: true},
+		{bucketName: "scratch", wanted: false},
+		{bucketName: "x-s3", wanted: false},
+	}
+
+	for _, test := range tests {
+		if got := IsDirectoryBucket(test.bucketName); got != test.wanted {
+			t.Errorf("IsDirectoryBucket(%v) = %v, want %v", test.bucketName, got, test.wanted)
+		}
+	}
+}
+
+func TestDirectoryListParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		prefix       string
+		delimiter    string
+		wantedPrefix string
+		wantedKeep   string
+	}{
+		{name: "undelimited", prefix: "data/run-", wantedPrefix: "data/run-"},
+		{name: "directory", prefix: "data/", delimiter: "/", wantedPrefix: "data/"},
+		{name: "partial name", prefix: "data/run-", delimiter: "/", wantedPrefix: "data/", wantedKeep: "data/run-"},
+		{name: "top level partial name", prefix: "run-", delimiter: "/", wantedPrefix: "", wantedKeep: "run-"},
+	}
+
+	for _, test := range tests {
+		params := &s3.ListObjectsV2Input{Bucket: aws.String("scratch--usw2-az1--x-s3"), Prefix: aws.String(test.prefix)}
+		if test.delimiter != "" {
+			params.Delimiter = aws.String(test.delimiter)
+		}
+
+		got, keep := directoryListParams(params)
+		if aws.ToString(got.Prefix) != test.wantedPrefix || keep != test.wantedKeep {
+			t.Errorf("%v: directoryListParams(%v) = %v, %v, want %v, %v", test.name, test.prefix, aws.ToString(got.Prefix), keep, test.wantedPrefix, test.wantedKeep)
+		}
+
+		if aws.ToString(params.Prefix) != test.prefix {
+			t.Errorf("%v: directoryListParams changed the caller's prefix to %v", test.name, aws.ToString(params.Prefix))
+		}
+	}
+}
+
+func TestFilterPage(t *testing.T) {
+	t.Parallel()
+
+	page := &s3.ListObjectsV2Output{
+		Contents: []types.Object{
+			{Key: aws.String("data/run-1.csv")},
+			{Key: aws.String("data/notes.txt")},
+		},
+		CommonPrefixes: []types.CommonPrefix{
+			{Prefix: aws.String("data/run-2/")},
+			{Prefix: aws.String("data/other/")},
+		},
+	}
+
+	filterPage(page, "data/run-")
+
+	if len(page.Contents) != 1 || aws.ToString(page.Contents[0].Key) != "data/run-1.csv" {
+		t.Errorf("filterPage() kept objects %v, want [data/run-1.csv]", page.Contents)
+	}
+	if len(page.CommonPrefixes) != 1 || aws.ToString(page.CommonPrefixes[0].Prefix) != "data/run-2/" {
+		t.Errorf("filterPage() kept common prefixes %v, want [data/run-2/]", page.CommonPrefixes)
+	}
+}
@@ -0,0 +1,126 @@
+package boto3manager
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gitlab.nrp-nautilus.io/humboldt/boto3-manager/strutil"
+)
+
+// directoryMarkerType is the content type S3 browsers give the zero-byte objects they create for folders.
+const directoryMarkerType = "application/x-directory"
+
+// emptyDirs returns the empty directories under the directory of a pattern's literal prefix that the pattern
+// could match files in, so "data/**/*.csv" finds every empty directory under data and "data/*.csv" finds none.
+func emptyDirs(fsys fs.FS, pattern string) ([]string, error) {
+	root := strings.TrimSuffix(patternDir(pattern), "/")
+	if root == "" {
+		root = "."
+	}
+
+	re := regexp.MustCompile(strutil.WildCardToRegexp(path.Dir(pattern) + "/"))
+
+	dirs := make([]string, 0)
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || name == root {
+			return nil
+		}
+
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) == 0 && re.MatchString(name+"/") {
+			dirs = append(dirs, name)
+		}
+
+		return nil
+	})
+
+	return dirs, err
+}
+
+// putDirectoryMarkers uploads a zero-byte "<dir>/" marker object for each empty directory the pattern could match
+// files in, keyed by the mapper under the destination.
+func (basics BucketBasics) putDirectoryMarkers(ctx context.Context, fsys fs.FS, pattern string, dest string, mapper KeyMapper, bucketName string) error {
+	dirs, err := emptyDirs(fsys, pattern)
+	if err != nil {
+		log.Printf("Couldn't find empty directories for %v: %v", pattern, err)
+		return err
+	}
+
+	for _, dir := range dirs {
+		key, skip := mapper(dir)
+		if skip || key == "" {
+			continue
+		}
+
+		key = dest + key + "/"
+		_, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(bucketName),
+			Key:         aws.String(key),
+			Body:        strings.NewReader(""),
+			ContentType: aws.String(directoryMarkerType),
+		})
+
+		if err != nil {
+			log.Printf("Couldn't write directory marker %v to bucket %v: %v", key, bucketName, err)
+			return classifyError(err)
+		}
+	}
+
+	return nil
+}
+
+// splitDirectoryMarkers separates the directory marker objects, whose keys end in "/", from the other objects.
+func splitDirectoryMarkers(objects []types.Object) ([]types.Object, []types.Object) {
+	files := make([]types.Object, 0, len(objects))
+	markers := make([]types.Object, 0)
+
+	for _, object := range objects {
+		if strings.HasSuffix(aws.ToString(object.Key), "/") {
+			markers = append(markers, object)
+		} else {
+			files = append(files, object)
+		}
+	}
+
+	return files, markers
+}
+
+// makeMarkerDirs creates the directory each marker object stands for under the destination, or where the mapper
+// puts it if mapper isn't nil.
+func makeMarkerDirs(markers []types.Object, dest string, mapper DestMapper) error {
+	for _, marker := range markers {
+		dir := filepath.Join(dest, localKeyPath(aws.ToString(marker.Key)))
+
+		if mapper != nil {
+			localPath, skip := mapper(strings.TrimSuffix(aws.ToString(marker.Key), "/"))
+			if skip {
+				continue
+			}
+
+			dir = localPath
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(dest, dir)
+			}
+		}
+
+		if err := os.MkdirAll(localPath(dir), os.ModePerm); err != nil {
+			log.Printf("Couldn't create directory %v: %v", dir, err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package boto3manager
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmptyDirs(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"data/a.csv":         {Data: []byte("a")},
+		"data/empty":         {Mode: fs.ModeDir},
+		"data/nested/b.csv":  {Data: []byte("b")},
+		"data/nested/empty":  {Mode: fs.ModeDir},
+		"data/parent/empty":  {Mode: fs.ModeDir},
+		"other/empty":        {Mode: fs.ModeDir},
+		"data/nested/c/file": {Data: []byte("c")},
+	}
+
+	tests := []struct {
+		pattern string
+		wanted  []string
+	}{
+		{pattern: "data/**/*.csv", wanted: []string{"data/empty", "data/nested/empty", "data/parent/empty"}},
+		{pattern: "data/*/*.csv", wanted: []string{"data/empty"}},
+		{pattern: "data/*.csv", wanted: []string{}},
+		{pattern: "**/*", wanted: []string{"data/empty", "data/nested/empty", "data/parent/empty", "other/empty"}},
+		{pattern: "missing/**/*", wanted: []string{}},
+	}
+
+	for _, test := range tests {
+		if got, err := emptyDirs(fsys, test.pattern); err != nil || !reflect.DeepEqual(got, test.wanted) {
+			t.Errorf("emptyDirs(%v) = %v, %v, want %v", test.pattern, got, err, test.wanted)
+		}
+	}
+}
+
+func TestUploadObjectsDirectoryMarkers(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"data/a.csv":        {Data: []byte("a")},
+		"data/empty":        {Mode: fs.ModeDir},
+		"data/nested/empty": {Mode: fs.ModeDir},
+	}
+
+	fake := newFakeS3(t, "bucket")
+	options := UploadObjectsOptions{FS: fsys, DirectoryMarkers: true, Quiet: true}
+	if _, err := fake.basics().UploadObjects("data/**/*", "up/", "bucket", options); err != nil {
+		t.Fatalf("UploadObjects() = %v, want nil", err)
+	}
+
+	wanted := []string{"up/a.csv", "up/empty/", "up/nested/empty/"}
+	if keys := fake.keys("bucket"); !slices.Equal(keys, wanted) {
+		t.Errorf("UploadObjects() wrote %v, want %v", keys, wanted)
+	}
+
+	marker, _ := fake.object("bucket", "up/empty/")
+	if len(marker.body) != 0 || marker.header.Get("Content-Type") != directoryMarkerType {
+		t.Errorf("UploadObjects() wrote marker %q of type %q, want an empty %v", marker.body, marker.header.Get("Content-Type"), directoryMarkerType)
+	}
+}
+
+func TestDownloadObjectsDirectoryMarkers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		mapper DestMapper
+		dirs   []string
+	}{
+		{name: "keys", dirs: []string{"data/empty", "data/nested/empty"}},
+		{name: "mapped", mapper: StripPrefixDest("data/"), dirs: []string{"empty", "nested/empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			fake.put("bucket", "data/a.csv", "a", nil)
+			fake.put("bucket", "data/empty/", "", nil)
+			fake.put("bucket", "data/nested/empty/", "", nil)
+
+			dest := t.TempDir()
+			options := DownloadObjectsOptions{DirectoryMarkers: true, DestMapper: tt.mapper, Quiet: true}
+			if _, err := fake.basics().DownloadObjects("data/**/*", dest, "bucket", options); err != nil {
+				t.Fatalf("DownloadObjects() = %v, want nil", err)
+			}
+
+			for _, dir := range tt.dirs {
+				info, err := os.Stat(filepath.Join(dest, filepath.FromSlash(dir)))
+				if err != nil || !info.IsDir() {
+					t.Errorf("DownloadObjects() didn't make directory %v: %v", dir, err)
+					continue
+				}
+				if entries, _ := os.ReadDir(filepath.Join(dest, filepath.FromSlash(dir))); len(entries) != 0 {
+					t.Errorf("DownloadObjects() wrote %v into %v, want it empty", entries, dir)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package boto3manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrInsufficientDiskSpace means a download is larger than the space free on its destination's file system
+	ErrInsufficientDiskSpace = errors.New("not enough disk space")
+
+	// ErrOverBudget means a download is larger than the MaxBytes it was allowed
+	ErrOverBudget = errors.New("download exceeds byte budget")
+)
+
+// existingDir returns the closest directory to dir that exists, which is where its free space is measured when it
+// hasn't been created yet.
+func existingDir(dir string) string {
+	dir = filepath.Clean(dir)
+
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// checkDownloadSpace checks that totalSize bytes fit within the download's budget and the space free at dest.
+// Running short of space fails unless ignoreSpace is set, in which case it is only logged. Free space that can't
+// be measured on this platform is not checked.
+func checkDownloadSpace(dest string, totalSize int64, maxBytes int64, ignoreSpace bool) error {
+	if maxBytes > 0 && totalSize > maxBytes {
+		return fmt.Errorf("%w: %v bytes to download, budget is %v", ErrOverBudget, totalSize, maxBytes)
+	}
+
+	dir := existingDir(dest)
+	free, err := freeSpace(dir)
+
+	if err != nil {
+		log.Printf("Couldn't check free space in %v: %v", dir, err)
+		return nil
+	}
+
+	if totalSize > free {
+		err := fmt.Errorf("%w: %v bytes to download, %v free in %v", ErrInsufficientDiskSpace, totalSize, free, dir)
+		if !ignoreSpace {
+			return err
+		}
+
+		log.Printf("Warning: %v", err)
+	}
+
+	return nil
+}
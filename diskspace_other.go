@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package boto3manager
+
+import "errors"
+
+// freeSpace isn't supported on this platform, so downloads go ahead without checking free space.
+func freeSpace(dir string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
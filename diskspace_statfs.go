@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package boto3manager
+
+import "syscall"
+
+// freeSpace returns the number of bytes available to unprivileged users on the file system holding dir.
+func freeSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+
+	return int64(uint64(stat.Bavail) * uint64(stat.Bsize)), nil
+}
@@ -0,0 +1,61 @@
+package boto3manager
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckDownloadSpace(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "not", "created", "yet")
+
+	tests := []struct {
+		name        string
+		totalSize   int64
+		maxBytes    int64
+		ignoreSpace bool
+		wanted      error
+	}{
+		{
+			name:      "fits",
+			totalSize: 1,
+		},
+		{
+			name:      "over budget",
+			totalSize: 100,
+			maxBytes:  10,
+			wanted:    ErrOverBudget,
+		},
+		{
+			name:      "too large for the disk",
+			totalSize: 1 << 62,
+			wanted:    ErrInsufficientDiskSpace,
+		},
+		{
+			name:        "too large but ignored",
+			totalSize:   1 << 62,
+			ignoreSpace: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := freeSpace(dir); err != nil && tt.wanted == ErrInsufficientDiskSpace {
+				t.Skip("free space can't be measured on this platform")
+			}
+
+			if err := checkDownloadSpace(missing, tt.totalSize, tt.maxBytes, tt.ignoreSpace); !errors.Is(err, tt.wanted) {
+				t.Errorf("checkDownloadSpace(%v, %v, %v) = %v, want %v", tt.totalSize, tt.maxBytes, tt.ignoreSpace, err, tt.wanted)
+			}
+		})
+	}
+
+	if got := existingDir(missing); got != dir {
+		t.Errorf("existingDir(%v) = %v, want %v", missing, got, dir)
+	}
+}
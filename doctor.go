@@ -0,0 +1,246 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// maxClockSkew is the difference from the server's clock beyond which S3 rejects signed requests.
+const maxClockSkew = 15 * time.Minute
+
+// Ping takes a bucket name and sends a HeadBucket request, returning how long it took. An error means the bucket
+// can't be reached with the current endpoint and credentials.
+func (basics BucketBasics) Ping(bucketName string) (time.Duration, error) {
+	latency, _, err := basics.ping(context.TODO(), bucketName)
+	return latency, err
+}
+
+// ping sends a HeadBucket request and returns how long it took and the server's clock from the response, which is
+// zero if the server didn't send one.
+func (basics BucketBasics) ping(ctx context.Context, bucketName string) (time.Duration, time.Time, error) {
+	start := time.Now()
+	output, err := basics.client(bucketName).HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	latency := time.Since(start)
+
+	// Error responses carry the server's clock too
+	var metadata middleware.Metadata
+	var respErr *awshttp.ResponseError
+	if err == nil {
+		metadata = output.ResultMetadata
+	} else if errors.As(err, &respErr) && respErr.Response != nil {
+		return latency, serverDate(respErr.Response.Response), classifyError(err)
+	}
+
+	var serverTime time.Time
+	if raw, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok {
+		serverTime = serverDate(raw.Response)
+	}
+
+	return latency, serverTime, classifyError(err)
+}
+
+// serverDate returns the time in a response's Date header, or zero if it has none.
+func serverDate(resp *http.Response) time.Time {
+	if resp == nil {
+		return time.Time{}
+	}
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+// DoctorCheck is the outcome of one of Doctor's checks. Advice says what to fix when it fails.
+type DoctorCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+	Advice string
+	Err    error
+}
+
+// DoctorReport is the outcome of every check Doctor ran, in order.
+type DoctorReport struct {
+	Checks []DoctorCheck
+}
+
+// OK reports whether every check passed.
+func (report DoctorReport) OK() bool {
+	for _, check := range report.Checks {
+		if !check.OK {
+			return false
+		}
+	}
+	return true
+}
+
+func (report DoctorReport) String() string {
+	var b strings.Builder
+	for _, check := range report.Checks {
+		status := "ok"
+		if !check.OK {
+			status = "FAIL"
+		}
+
+		fmt.Fprintf(&b, "%-4v %v", status, check.Name)
+		if check.Detail != "" {
+			fmt.Fprintf(&b, ": %v", check.Detail)
+		}
+		b.WriteString("\n")
+
+		if !check.OK && check.Advice != "" {
+			fmt.Fprintf(&b, "     %v\n", check.Advice)
+		}
+	}
+	return b.String()
+}
+
+// add records a check's outcome, with advice for the error if it failed.
+func (report *DoctorReport) add(name string, detail string, err error) bool {
+	check := DoctorCheck{Name: name, OK: err == nil, Detail: detail, Err: err}
+	if err != nil {
+		check.Detail = err.Error()
+		check.Advice = doctorAdvice(name, err)
+	}
+
+	report.Checks = append(report.Checks, check)
+	return check.OK
+}
+
+// doctorAdvice returns what to fix for a failed check.
+func doctorAdvice(name string, err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "InvalidAccessKeyId":
+			return "The access key isn't known to this endpoint; check the credentials and that the endpoint is the one they were issued for"
+		case "SignatureDoesNotMatch":
+			return "The secret key is wrong for this access key; check the credentials"
+		case "RequestTimeTooSkewed":
+			return "The local clock is too far from the server's; sync it with NTP"
+		case "PermanentRedirect", "AuthorizationHeaderMalformed", "IllegalLocationConstraintException":
+			return "The bucket is in a different region; set the region to the bucket's"
+		}
+	}
+
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return "The endpoint's host name doesn't resolve; check the endpoint URL"
+	case errors.As(err, &netErr):
+		return "The endpoint can't be reached; check the endpoint URL, proxy settings, and firewall"
+	case errors.Is(err, ErrBucketNotFound):
+		return "The bucket doesn't exist at this endpoint; check the bucket name and endpoint"
+	case errors.Is(err, ErrAccessDenied):
+		switch name {
+		case "list":
+			return "The credentials can't list the bucket; grant s3:ListBucket"
+		case "put":
+			return "The credentials can't write to the bucket; grant s3:PutObject"
+		case "get":
+			return "The credentials can't read from the bucket; grant s3:GetObject"
+		case "delete":
+			return "The credentials can't delete from the bucket; grant s3:DeleteObject"
+		}
+		return "The credentials aren't allowed to use the bucket; check the bucket policy and the credentials' permissions"
+	}
+
+	return ""
+}
+
+// Doctor takes a bucket name and checks everything a transfer needs, in order: that credentials can be loaded,
+// that the endpoint and bucket can be reached, that the local clock is close enough to the server's, and that the
+// credentials can list, put, get, and delete objects, using a test object under .s3m-probe/. The report says what
+// to fix for every check that fails. Later checks are skipped once the endpoint can't be reached.
+func (basics BucketBasics) Doctor(bucketName string) DoctorReport {
+	ctx := context.TODO()
+	var report DoctorReport
+
+	// Credentials
+	credentials := basics.client(bucketName).Options().Credentials
+	if credentials == nil || aws.IsCredentialsProvider(credentials, aws.AnonymousCredentials{}) {
+		report.add("credentials", "none, requests are unsigned", nil)
+	} else if creds, err := credentials.Retrieve(ctx); err != nil {
+		report.add("credentials", "", err)
+		report.Checks[len(report.Checks)-1].Advice = "No credentials could be loaded; set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or a profile"
+	} else {
+		report.add("credentials", "access key "+creds.AccessKeyID, nil)
+	}
+
+	// Endpoint and bucket
+	latency, serverTime, err := basics.ping(ctx, bucketName)
+	if !report.add("reach bucket", fmt.Sprintf("HeadBucket in %v", latency.Round(time.Millisecond)), err) && serverTime.IsZero() {
+		return report
+	}
+
+	// Clock skew
+	if !serverTime.IsZero() {
+		skew := time.Since(serverTime).Round(time.Second)
+		var skewErr error
+		if skew.Abs() > maxClockSkew {
+			skewErr = fmt.Errorf("local clock is %v off the server's", skew)
+		}
+		if !report.add("clock", fmt.Sprintf("%v off the server's", skew), skewErr) {
+			report.Checks[len(report.Checks)-1].Advice = "S3 rejects requests signed more than 15 minutes off; sync the local clock with NTP"
+		}
+	}
+
+	// Permissions
+	_, err = basics.client(bucketName).ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucketName),
+		MaxKeys: aws.Int32(1),
+	})
+	report.add("list", "", classifyError(err))
+
+	key := fmt.Sprintf("%vdoctor-%v", probePrefix, time.Now().UnixNano())
+	body := []byte("s3m doctor")
+
+	_, err = basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	})
+	if !report.add("put", "", classifyError(err)) {
+		return report
+	}
+
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		var got []byte
+		got, err = io.ReadAll(obj.Body)
+		obj.Body.Close()
+
+		if err == nil && !bytes.Equal(got, body) {
+			err = fmt.Errorf("%w: read back %q, wrote %q", ErrChecksumMismatch, got, body)
+		}
+	}
+	report.add("get", "", classifyError(err))
+
+	err = basics.deleteKeys(ctx, bucketName, []string{key})
+	report.add("delete", "", classifyError(err))
+
+	return report
+}
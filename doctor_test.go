@@ -0,0 +1,166 @@
+package boto3manager
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestDoctorAdvice(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		check  string
+		err    error
+		wanted string
+	}{
+		{name: "bad access key", check: "reach bucket", err: &smithy.GenericAPIError{Code: "InvalidAccessKeyId"}, wanted: "access key"},
+		{name: "bad secret", check: "reach bucket", err: &smithy.GenericAPIError{Code: "SignatureDoesNotMatch"}, wanted: "secret key"},
+		{name: "denied put", check: "put", err: fmt.Errorf("%w: x", ErrAccessDenied), wanted: "s3:PutObject"},
+		{name: "missing bucket", check: "reach bucket", err: fmt.Errorf("%w: x", ErrBucketNotFound), wanted: "bucket name"},
+		{name: "dns", check: "reach bucket", err: &net.DNSError{Err: "no such host", Name: "s3.example"}, wanted: "resolve"},
+		{name: "unknown", check: "get", err: errors.New("boom"), wanted: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := doctorAdvice(tt.check, tt.err)
+			if tt.wanted == "" && got != "" || !strings.Contains(got, tt.wanted) {
+				t.Errorf("doctorAdvice(%v, %v) = %q, want it to mention %q", tt.check, tt.err, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestDoctorReportOK(t *testing.T) {
+	t.Parallel()
+
+	var report DoctorReport
+	report.add("list", "", nil)
+	if !report.OK() {
+		t.Errorf("OK() = false with every check passing")
+	}
+
+	report.add("put", "", fmt.Errorf("%w: x", ErrAccessDenied))
+	if report.OK() {
+		t.Errorf("OK() = true with a failed check")
+	}
+	if advice := report.Checks[1].Advice; advice == "" {
+		t.Errorf("failed check has no advice")
+	}
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+
+	if _, err := fake.basics().Ping("bucket"); err != nil {
+		t.Errorf("Ping(bucket) = %v, want nil", err)
+	}
+	if _, err := fake.basics().Ping("missing"); err == nil {
+		t.Errorf("Ping(missing) returned no error")
+	}
+	if got := fake.count("HEAD bucket/"); got != 1 {
+		t.Errorf("Ping(bucket) sent HEAD bucket/ %v times, want 1", got)
+	}
+}
+
+// checkNames returns the name of every check in a report, in order.
+func checkNames(report DoctorReport) []string {
+	names := make([]string, 0, len(report.Checks))
+	for _, check := range report.Checks {
+		names = append(names, check.Name)
+	}
+	return names
+}
+
+func TestDoctor(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "data/a.txt", "a", nil)
+
+	report := fake.basics().Doctor("bucket")
+	if !report.OK() {
+		t.Errorf("Doctor() = %v, want every check passing", report)
+	}
+
+	wanted := []string{"credentials", "reach bucket", "clock", "list", "put", "get", "delete"}
+	if got := checkNames(report); !slices.Equal(got, wanted) {
+		t.Errorf("Doctor() ran %v, want %v", got, wanted)
+	}
+
+	// The test object is cleaned up
+	if keys := fake.keys("bucket"); !slices.Equal(keys, []string{"data/a.txt"}) {
+		t.Errorf("Doctor() left %v, want [data/a.txt]", keys)
+	}
+}
+
+func TestDoctorFailures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		bucket string
+		fail   func(r *http.Request) int
+		failed string
+		wanted []string
+	}{
+		{
+			name:   "denied put",
+			bucket: "bucket",
+			fail: func(r *http.Request) int {
+				if r.Method == http.MethodPut {
+					return http.StatusForbidden
+				}
+				return 0
+			},
+			failed: "put",
+			wanted: []string{"credentials", "reach bucket", "clock", "list", "put"},
+		},
+		{
+			name:   "missing bucket",
+			bucket: "missing",
+			failed: "reach bucket",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			fake.fail = tt.fail
+
+			report := fake.basics().Doctor(tt.bucket)
+			if report.OK() {
+				t.Fatalf("Doctor() = %v, want a failed check", report)
+			}
+
+			var check DoctorCheck
+			for _, c := range report.Checks {
+				if !c.OK {
+					check = c
+					break
+				}
+			}
+			if check.Name != tt.failed || check.Err == nil {
+				t.Errorf("Doctor() first failed %+v, want %v", check, tt.failed)
+			}
+
+			if tt.wanted != nil && !slices.Equal(checkNames(report), tt.wanted) {
+				t.Errorf("Doctor() ran %v, want %v", checkNames(report), tt.wanted)
+			}
+		})
+	}
+}
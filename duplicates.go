@@ -0,0 +1,83 @@
+package boto3manager
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// DuplicateCluster is a group of objects with the same size and ETag, and so the same contents.
+type DuplicateCluster struct {
+	Size int64
+	ETag string
+	Keys []string
+}
+
+// Savings returns the bytes that would be freed by keeping only one of the objects.
+func (cluster DuplicateCluster) Savings() int64 {
+	return cluster.Size * int64(len(cluster.Keys)-1)
+}
+
+// DuplicateReport is the result of FindDuplicates.
+type DuplicateReport struct {
+	// Clusters are the groups of identical objects, largest savings first
+	Clusters []DuplicateCluster
+
+	// Savings is the total bytes that would be freed by keeping one object of every cluster
+	Savings int64
+}
+
+// groupDuplicates groups objects by size and ETag and returns the groups with more than one object. Empty objects
+// and directory markers are left out.
+func groupDuplicates(objects iter.Seq2[types.Object, error]) (DuplicateReport, error) {
+	type identity struct {
+		size int64
+		etag string
+	}
+
+	groups := make(map[identity][]string)
+	for object, err := range objects {
+		if err != nil {
+			return DuplicateReport{}, err
+		}
+
+		key := aws.ToString(object.Key)
+		size := aws.ToInt64(object.Size)
+		if size == 0 || strings.HasSuffix(key, "/") {
+			continue
+		}
+
+		id := identity{size: size, etag: strings.Trim(aws.ToString(object.ETag), `"`)}
+		groups[id] = append(groups[id], key)
+	}
+
+	var report DuplicateReport
+	for id, keys := range groups {
+		if len(keys) < 2 {
+			continue
+		}
+
+		slices.Sort(keys)
+		cluster := DuplicateCluster{Size: id.size, ETag: id.etag, Keys: keys}
+		report.Clusters = append(report.Clusters, cluster)
+		report.Savings += cluster.Savings()
+	}
+
+	slices.SortFunc(report.Clusters, func(a, b DuplicateCluster) int {
+		return cmp.Or(cmp.Compare(b.Savings(), a.Savings()), strings.Compare(a.Keys[0], b.Keys[0]))
+	})
+
+	return report, nil
+}
+
+// FindDuplicates takes a bucket name and a prefix and finds the objects under the prefix with identical contents,
+// grouped into clusters with the space each would save. Objects are compared by size and ETag without being read,
+// so copies uploaded with different multipart part sizes have different ETags and aren't found.
+func (basics BucketBasics) FindDuplicates(bucketName string, prefix string) (DuplicateReport, error) {
+	return groupDuplicates(basics.listObjectsSeq(context.TODO(), bucketName, prefix))
+}
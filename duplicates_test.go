@@ -0,0 +1,84 @@
+package boto3manager
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestGroupDuplicates(t *testing.T) {
+	t.Parallel()
+
+	object := func(key string, size int64, etag string) types.Object {
+		return types.Object{Key: aws.String(key), Size: aws.Int64(size), ETag: aws.String(`"` + etag + `"`)}
+	}
+
+	objects := []types.Object{
+		object("a/data.csv", 100, "x"),
+		object("b/data.csv", 100, "x"),
+		object("c/copy.csv", 100, "x"),
+		object("a/big.bin", 1000, "y"),
+		object("b/big.bin", 1000, "y"),
+		object("a/other.csv", 100, "z"),
+		object("a/empty", 0, "e"),
+		object("b/empty", 0, "e"),
+		object("a/", 0, "e"),
+	}
+
+	got, err := groupDuplicates(func(yield func(types.Object, error) bool) {
+		for _, o := range objects {
+			if !yield(o, nil) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := DuplicateReport{
+		Clusters: []DuplicateCluster{
+			{Size: 1000, ETag: "y", Keys: []string{"a/big.bin", "b/big.bin"}},
+			{Size: 100, ETag: "x", Keys: []string{"a/data.csv", "b/data.csv", "c/copy.csv"}},
+		},
+		Savings: 1200,
+	}
+
+	if !reflect.DeepEqual(got, wanted) {
+		t.Errorf("groupDuplicates() = %+v, want %+v", got, wanted)
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "data/a.bin", "same contents", nil)
+	fake.put("bucket", "data/sub/a-copy.bin", "same contents", nil)
+	fake.put("bucket", "data/sub/a-copy-2.bin", "same contents", nil)
+	fake.put("bucket", "data/b.bin", "other", nil)
+	fake.put("bucket", "data/b-copy.bin", "other", nil)
+	fake.put("bucket", "data/unique.bin", "unique", nil)
+	fake.put("bucket", "data/empty-1", "", nil)
+	fake.put("bucket", "data/empty-2", "", nil)
+	fake.put("bucket", "elsewhere/a.bin", "same contents", nil)
+
+	report, err := fake.basics().FindDuplicates("bucket", "data/")
+	if err != nil {
+		t.Fatalf("FindDuplicates() = %v, want nil", err)
+	}
+
+	wanted := DuplicateReport{
+		Clusters: []DuplicateCluster{
+			{Size: 13, ETag: strings.Trim(fakeETag([]byte("same contents")), `"`), Keys: []string{"data/a.bin", "data/sub/a-copy-2.bin", "data/sub/a-copy.bin"}},
+			{Size: 5, ETag: strings.Trim(fakeETag([]byte("other")), `"`), Keys: []string{"data/b-copy.bin", "data/b.bin"}},
+		},
+		Savings: 2*13 + 5,
+	}
+	if !reflect.DeepEqual(report, wanted) {
+		t.Errorf("FindDuplicates() = %+v, want %+v", report, wanted)
+	}
+}
@@ -0,0 +1,122 @@
+package boto3manager
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read as defaults for options a program leaves unset, so operators can tune a job, such as
+// in a Kubernetes pod spec, without changing its code. Options set in code always win.
+const (
+	// EnvConcurrency is the number of files transferred at once
+	EnvConcurrency = "BOTO3MANAGER_CONCURRENCY"
+	// EnvPartConcurrency is the number of parts of each file transferred at once
+	EnvPartConcurrency = "BOTO3MANAGER_PART_CONCURRENCY"
+	// EnvPartSize is the size of each part, in bytes or with a unit such as "64MiB" or "100MB"
+	EnvPartSize = "BOTO3MANAGER_PART_SIZE"
+	// EnvEndpoint is the URL of the S3 compatible endpoint, as ClientOptions.Endpoint
+	EnvEndpoint = "BOTO3MANAGER_ENDPOINT"
+	// EnvRegion is the region requests are signed for, as ClientOptions.Region
+	EnvRegion = "BOTO3MANAGER_REGION"
+	// EnvSecretDir is the directory a secret with credentials is mounted at, as ClientOptions.SecretDir
+	EnvSecretDir = "BOTO3MANAGER_SECRET_DIR"
+	// EnvQuiet turns off progress output and summaries when set to "1" or "true"
+	EnvQuiet = "BOTO3MANAGER_QUIET"
+)
+
+// byteUnits are the multipliers of the units a size may be written with.
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kib": 1 << 10,
+	"kb":  1000,
+	"m":   1 << 20,
+	"mib": 1 << 20,
+	"mb":  1000 * 1000,
+	"g":   1 << 30,
+	"gib": 1 << 30,
+	"gb":  1000 * 1000 * 1000,
+}
+
+// parseByteSize parses a size in bytes, optionally followed by a unit such as "MiB" or "MB".
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	number := strings.TrimRight(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ ")
+
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[len(number):]))]
+	n, err := strconv.ParseInt(number, 10, 64)
+	if !ok || err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return n * unit, nil
+}
+
+// envInt returns the positive integer in an environment variable, or 0 if it is unset or invalid.
+func envInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Ignoring %v=%q, which isn't a positive number", name, value)
+		return 0
+	}
+
+	return n
+}
+
+// envByteSize returns the size in an environment variable, or 0 if it is unset or invalid.
+func envByteSize(name string) int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+
+	n, err := parseByteSize(value)
+	if err != nil {
+		log.Printf("Ignoring %v: %v", name, err)
+		return 0
+	}
+
+	return n
+}
+
+// defaultWorkers returns the number of workers from EnvConcurrency, or n if it isn't set.
+func defaultWorkers(n int) int {
+	if workers := envInt(EnvConcurrency); workers > 0 {
+		return workers
+	}
+
+	return n
+}
+
+// defaultQuiet returns whether output is turned off, by the option or by EnvQuiet.
+func defaultQuiet(quiet bool) bool {
+	value := strings.ToLower(os.Getenv(EnvQuiet))
+	return quiet || value == "1" || value == "true"
+}
+
+// withEnv returns the concurrency with the fields left zero set from the environment, so they are pinned rather
+// than chosen from the file sizes.
+func (concurrency Concurrency) withEnv() Concurrency {
+	if concurrency.Workers == 0 {
+		concurrency.Workers = envInt(EnvConcurrency)
+	}
+
+	if concurrency.PartConcurrency == 0 {
+		concurrency.PartConcurrency = envInt(EnvPartConcurrency)
+	}
+
+	if concurrency.PartSize == 0 {
+		concurrency.PartSize = envByteSize(EnvPartSize)
+	}
+
+	return concurrency
+}
@@ -0,0 +1,58 @@
+package boto3manager
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		size    string
+		wanted  int64
+		invalid bool
+	}{
+		{size: "1024", wanted: 1024},
+		{size: "64MiB", wanted: 64 << 20},
+		{size: "100MB", wanted: 100 * 1000 * 1000},
+		{size: "8 k", wanted: 8 << 10},
+		{size: "2GiB", wanted: 2 << 30},
+		{size: "", invalid: true},
+		{size: "MiB", invalid: true},
+		{size: "10PB", invalid: true},
+		{size: "-5", invalid: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.size)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("parseByteSize(%q) = %v, want error", tt.size, got)
+			}
+			continue
+		}
+
+		if err != nil || got != tt.wanted {
+			t.Errorf("parseByteSize(%q) = %v, %v, want %v", tt.size, got, err, tt.wanted)
+		}
+	}
+}
+
+func TestConcurrencyWithEnv(t *testing.T) {
+	t.Setenv(EnvConcurrency, "12")
+	t.Setenv(EnvPartConcurrency, "not a number")
+	t.Setenv(EnvPartSize, "16MiB")
+	t.Setenv(EnvQuiet, "true")
+
+	got := Concurrency{Workers: 4}.withEnv()
+	wanted := Concurrency{Workers: 4, PartSize: 16 << 20}
+	if got != wanted {
+		t.Errorf("withEnv() = %+v, want %+v", got, wanted)
+	}
+
+	if workers := defaultWorkers(25); workers != 12 {
+		t.Errorf("defaultWorkers(25) = %v, want 12", workers)
+	}
+
+	if !defaultQuiet(false) {
+		t.Errorf("defaultQuiet(false) = false, want true")
+	}
+}
@@ -0,0 +1,71 @@
+package boto3manager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Errors returned by the package wrap one of these when the cause is known, so callers can check for them with
+// errors.Is instead of matching S3 error codes. The original SDK error stays available with errors.As.
+var (
+	ErrObjectNotFound   = errors.New("object not found")
+	ErrBucketNotFound   = errors.New("bucket not found")
+	ErrAccessDenied     = errors.New("access denied")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrPreconditionFailed means a conditional write's If-None-Match or If-Match condition didn't hold
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// sentinels are the package's error sentinels, for spotting errors that were already classified.
+var sentinels = []error{ErrObjectNotFound, ErrBucketNotFound, ErrAccessDenied, ErrChecksumMismatch, ErrPreconditionFailed}
+
+// classifyError wraps an SDK error with the sentinel matching its cause. Other errors are returned unchanged.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel) {
+			return err
+		}
+	}
+
+	var noSuchBucket *types.NoSuchBucket
+	if errors.As(err, &noSuchBucket) {
+		return fmt.Errorf("%w: %w", ErrBucketNotFound, err)
+	}
+
+	if isPreconditionFailed(err) {
+		return fmt.Errorf("%w: %w", ErrPreconditionFailed, err)
+	}
+
+	if isNotFound(err) {
+		return fmt.Errorf("%w: %w", ErrObjectNotFound, err)
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchBucket":
+			return fmt.Errorf("%w: %w", ErrBucketNotFound, err)
+		case "AccessDenied", "Forbidden", "AllAccessDisabled":
+			return fmt.Errorf("%w: %w", ErrAccessDenied, err)
+		case "BadDigest", "InvalidDigest", "XAmzContentSHA256Mismatch":
+			return fmt.Errorf("%w: %w", ErrChecksumMismatch, err)
+		}
+	}
+
+	// HEAD responses have no body, so a denied HEAD only has its status code
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == 403 {
+		return fmt.Errorf("%w: %w", ErrAccessDenied, err)
+	}
+
+	return err
+}
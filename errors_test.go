@@ -0,0 +1,69 @@
+package boto3manager
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		err    error
+		wanted error
+	}{
+		{
+			name:   "missing key",
+			err:    &types.NoSuchKey{},
+			wanted: ErrObjectNotFound,
+		},
+		{
+			name:   "missing bucket",
+			err:    &types.NoSuchBucket{},
+			wanted: ErrBucketNotFound,
+		},
+		{
+			name:   "access denied",
+			err:    &smithy.GenericAPIError{Code: "AccessDenied"},
+			wanted: ErrAccessDenied,
+		},
+		{
+			name:   "bad digest",
+			err:    &smithy.GenericAPIError{Code: "BadDigest"},
+			wanted: ErrChecksumMismatch,
+		},
+		{
+			name:   "precondition failed",
+			err:    &smithy.GenericAPIError{Code: "PreconditionFailed"},
+			wanted: ErrPreconditionFailed,
+		},
+		{
+			name:   "already classified",
+			err:    fmt.Errorf("%w: ETag differs", ErrChecksumMismatch),
+			wanted: ErrChecksumMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+
+			if !errors.Is(got, tt.wanted) {
+				t.Errorf("classifyError(%v) = %v, want it to wrap %v", tt.err, got, tt.wanted)
+			}
+
+			if !errors.Is(got, tt.err) {
+				t.Errorf("classifyError(%v) = %v, want it to wrap the original error", tt.err, got)
+			}
+		})
+	}
+
+	if err := errors.New("other"); classifyError(err) != err {
+		t.Errorf("classifyError(%v) = %v, want it unchanged", err, classifyError(err))
+	}
+}
@@ -44,6 +44,6 @@ func main() {
 
 	bucketBasics := boto3manager.BucketBasics{S3Client: s3Client}
 
-	// bucketBasics.UploadObjects("**/*", "", "humboldt-s3-test")
-	bucketBasics.DownloadObjects("**/*", "output", "humboldt-s3-test")
+	// bucketBasics.UploadObjects("**/*", "", "humboldt-s3-test", boto3manager.UploadObjectsOptions{})
+	bucketBasics.DownloadObjects("**/*", "output", "humboldt-s3-test", boto3manager.DownloadObjectsOptions{})
 }
@@ -0,0 +1,53 @@
+package boto3manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// variablePattern matches a ${NAME} reference to an environment variable.
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandVars replaces each ${NAME} in s with the value of the environment variable NAME. Unlike os.ExpandEnv, a
+// bare $NAME is left alone, since "$" is common in keys, and a variable that isn't set is an error rather than an
+// empty string, so a missing variable can't silently send files to the wrong place.
+func expandVars(s string) (string, error) {
+	var missing []string
+	expanded := variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := variablePattern.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %v in %q isn't set", strings.Join(missing, ", "), s)
+	}
+
+	return expanded, nil
+}
+
+// expandLocalPath expands the environment variables in a local path or pattern, as expandVars does, and a leading
+// "~" to the user's home directory.
+func expandLocalPath(path string) (string, error) {
+	path, err := expandVars(path)
+	if err != nil {
+		return "", err
+	}
+
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(home) + path[1:], nil
+}
@@ -0,0 +1,41 @@
+package boto3manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandLocalPath(t *testing.T) {
+	t.Setenv("BOTO3_MANAGER_RUN", "2024-05-01")
+	t.Setenv("BOTO3_MANAGER_EMPTY", "")
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	home = filepath.ToSlash(home)
+
+	tests := []struct {
+		path      string
+		wanted    string
+		wantedErr bool
+	}{
+		{path: "data/*.csv", wanted: "data/*.csv"},
+		{path: "runs/${BOTO3_MANAGER_RUN}/**/*", wanted: "runs/2024-05-01/**/*"},
+		{path: "runs/${BOTO3_MANAGER_EMPTY}x", wanted: "runs/x"},
+		{path: "cost$5/$BOTO3_MANAGER_RUN", wanted: "cost$5/$BOTO3_MANAGER_RUN"},
+		{path: "~", wanted: home},
+		{path: "~/data/${BOTO3_MANAGER_RUN}", wanted: home + "/data/2024-05-01"},
+		{path: "~other/data", wanted: "~other/data"},
+		{path: "data/~/a", wanted: "data/~/a"},
+		{path: "runs/${BOTO3_MANAGER_UNSET}/", wantedErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := expandLocalPath(test.path)
+		if got != test.wanted || (err != nil) != test.wantedErr {
+			t.Errorf("expandLocalPath(%q) = %q, %v, want %q, error %v", test.path, got, err, test.wanted, test.wantedErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package boto3manager
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// User metadata keys (x-amz-meta-*) recording a file's attributes when uploads preserve them.
+const (
+	ModeMetadataKey  = "file-mode"
+	MtimeMetadataKey = "file-mtime"
+	UIDMetadataKey   = "file-uid"
+	GIDMetadataKey   = "file-gid"
+)
+
+// fileMetadata returns the user metadata recording a file's permissions, modification time, and, where the
+// platform has them, its owner and group.
+func fileMetadata(info fs.FileInfo) map[string]string {
+	metadata := map[string]string{
+		ModeMetadataKey: strconv.FormatUint(uint64(info.Mode().Perm()), 8),
+	}
+
+	// Files in some file systems, such as an embed.FS, have no modification time
+	if !info.ModTime().IsZero() {
+		metadata[MtimeMetadataKey] = info.ModTime().UTC().Format(time.RFC3339Nano)
+	}
+
+	if uid, gid, ok := fileOwner(info); ok {
+		metadata[UIDMetadataKey] = strconv.Itoa(uid)
+		metadata[GIDMetadataKey] = strconv.Itoa(gid)
+	}
+
+	return metadata
+}
+
+// restoreFileMetadata applies the attributes recorded by fileMetadata to a downloaded file. Attributes missing from
+// the metadata are left alone. Failing to change the owner, which usually needs root, is logged rather than
+// returned.
+func restoreFileMetadata(path string, metadata map[string]string) error {
+	uid, uidErr := strconv.Atoi(metadata[UIDMetadataKey])
+	gid, gidErr := strconv.Atoi(metadata[GIDMetadataKey])
+	if uidErr == nil && gidErr == nil {
+		if err := os.Lchown(path, uid, gid); err != nil {
+			log.Printf("Couldn't restore owner of %v: %v", path, err)
+		}
+	}
+
+	if s, ok := metadata[ModeMetadataKey]; ok {
+		mode, err := strconv.ParseUint(s, 8, 32)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Chmod(path, fs.FileMode(mode).Perm()); err != nil {
+			return err
+		}
+	}
+
+	if s, ok := metadata[MtimeMetadataKey]; ok {
+		mtime, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Chtimes(path, time.Time{}, mtime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
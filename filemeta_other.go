@@ -0,0 +1,10 @@
+//go:build !unix
+
+package boto3manager
+
+import "io/fs"
+
+// fileOwner returns false, since files on this platform have no POSIX owner.
+func fileOwner(info fs.FileInfo) (uid int, gid int, ok bool) {
+	return 0, 0, false
+}
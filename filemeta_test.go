@@ -0,0 +1,52 @@
+package boto3manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileMetadataRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+
+	mtime := time.Date(2020, 5, 17, 12, 30, 0, 123456789, time.UTC)
+	for _, path := range []string{src, dst} {
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(src, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(src, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := fileMetadata(info)
+	if err := restoreFileMetadata(dst, metadata); err != nil {
+		t.Fatalf("restoreFileMetadata(%v) = %v, want nil", metadata, err)
+	}
+
+	got, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Mode().Perm() != info.Mode().Perm() {
+		t.Errorf("restoreFileMetadata(%v) mode = %v, want %v", metadata, got.Mode().Perm(), info.Mode().Perm())
+	}
+
+	if !got.ModTime().Equal(info.ModTime()) {
+		t.Errorf("restoreFileMetadata(%v) mtime = %v, want %v", metadata, got.ModTime(), info.ModTime())
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package boto3manager
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the user and group IDs owning a file, if its file system has them.
+func fileOwner(info fs.FileInfo) (uid int, gid int, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return int(stat.Uid), int(stat.Gid), true
+}
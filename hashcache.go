@@ -0,0 +1,148 @@
+package boto3manager
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// HashOptions controls how local files are hashed, for manifests, content-addressed uploads, and delivery
+// manifests.
+type HashOptions struct {
+	// Workers is the number of files hashed at once, apart from the workers transferring files. Defaults to 8
+	Workers int
+
+	// CacheFile, if set, is a local JSON file checksums are kept in between runs, keyed by each file's absolute
+	// path, size, and modification time, so files that haven't changed aren't hashed again
+	CacheFile string
+
+	// quiet hides the hashing progress bar
+	quiet bool
+}
+
+// checksumCacheEntry is a file's checksum as recorded in a checksum cache, with the size and modification time it
+// was computed at.
+type checksumCacheEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	SHA256  string    `json:"sha256"`
+}
+
+// checksumCache holds the SHA-256 checksums of local files between runs. A nil cache holds nothing.
+type checksumCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]checksumCacheEntry
+	changed bool
+}
+
+// openChecksumCache reads the checksum cache at path, starting an empty one if the file doesn't exist. It returns a
+// nil cache if path is empty.
+func openChecksumCache(path string) (*checksumCache, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	cache := &checksumCache{path: path, entries: make(map[string]checksumCacheEntry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+
+	if err != nil {
+		log.Printf("Couldn't read checksum cache %v: %v", path, err)
+		return nil, err
+	}
+
+	// A cache that can't be parsed is only a missed optimization, so start over
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		log.Printf("Ignoring checksum cache %v, which couldn't be parsed: %v", path, err)
+		cache.entries = make(map[string]checksumCacheEntry)
+	}
+
+	return cache, nil
+}
+
+// lookup returns the cached checksum of the file at path if the file's size and modification time still match.
+func (cache *checksumCache) lookup(path string, info fs.FileInfo) (string, bool) {
+	if cache == nil {
+		return "", false
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry, ok := cache.entries[path]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return "", false
+	}
+
+	return entry.SHA256, true
+}
+
+// store records the checksum of the file at path.
+func (cache *checksumCache) store(path string, info fs.FileInfo, sum string) {
+	if cache == nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.entries[path] = checksumCacheEntry{Size: info.Size(), ModTime: info.ModTime(), SHA256: sum}
+	cache.changed = true
+}
+
+// save writes the cache back to its file if anything was added.
+func (cache *checksumCache) save() error {
+	if cache == nil || !cache.changed {
+		return nil
+	}
+
+	cache.mu.Lock()
+	data, err := json.Marshal(cache.entries)
+	cache.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves half a cache
+	tmp := cache.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		log.Printf("Couldn't write checksum cache %v: %v", cache.path, err)
+		return err
+	}
+
+	if err := os.Rename(tmp, cache.path); err != nil {
+		log.Printf("Couldn't write checksum cache %v: %v", cache.path, err)
+		return err
+	}
+
+	return nil
+}
+
+// cachePath returns the absolute path a file in a file system rooted at root on disk is cached under, or "" if
+// root is empty because the file system isn't on disk.
+func cachePath(root string, path string) string {
+	if root == "" {
+		return ""
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, filepath.FromSlash(path))
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+
+	return abs
+}
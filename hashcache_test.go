@@ -0,0 +1,109 @@
+package boto3manager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHashFilesCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cacheFile := filepath.Join(t.TempDir(), "sums.json")
+	if err := os.WriteFile(filepath.Join(dir, "a.csv"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	const helloSum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	const fakeSum = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	fsys := os.DirFS(dir)
+	files := []FileUpload{{Path: "a.csv", Key: "a.csv", Size: 5}}
+	options := HashOptions{Workers: 2, CacheFile: cacheFile, quiet: true}
+
+	tests := []struct {
+		name   string
+		change func(cache *checksumCache, info os.FileInfo)
+		wanted string
+	}{
+		{
+			name:   "first run",
+			change: func(cache *checksumCache, info os.FileInfo) {},
+			wanted: helloSum,
+		},
+		{
+			// A cached checksum is used as it is, so a planted one shows the file wasn't read
+			name: "unchanged",
+			change: func(cache *checksumCache, info os.FileInfo) {
+				cache.store(cachePath(dir, "a.csv"), info, fakeSum)
+			},
+			wanted: fakeSum,
+		},
+		{
+			name: "modified",
+			change: func(cache *checksumCache, info os.FileInfo) {
+				cache.store(cachePath(dir, "a.csv"), info, fakeSum)
+				os.Chtimes(filepath.Join(dir, "a.csv"), time.Now(), info.ModTime().Add(time.Hour))
+			},
+			wanted: helloSum,
+		},
+	}
+
+	for _, test := range tests {
+		cache, err := openChecksumCache(cacheFile)
+		if err != nil {
+			t.Fatalf("openChecksumCache() = %v", err)
+		}
+
+		info, err := os.Stat(filepath.Join(dir, "a.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.change(cache, info)
+		if err := cache.save(); err != nil {
+			t.Fatalf("save() = %v", err)
+		}
+
+		sums, err := hashFiles(fsys, dir, files, options)
+		if err != nil || sums["a.csv"] != test.wanted {
+			t.Errorf("hashFiles() %v = %v, %v, want %v", test.name, sums["a.csv"], err, test.wanted)
+		}
+	}
+}
+
+func TestGenerateChecksumManifestCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cacheFile := filepath.Join(t.TempDir(), "sums.json")
+	if err := os.WriteFile(filepath.Join(dir, "a.csv"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest strings.Builder
+	if err := GenerateChecksumManifest(filepath.Join(dir, "*.csv"), &manifest, HashOptions{CacheFile: cacheFile, quiet: true}); err != nil {
+		t.Fatalf("GenerateChecksumManifest() = %v, want nil", err)
+	}
+
+	const helloSum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if got := manifest.String(); !strings.Contains(got, helloSum) {
+		t.Errorf("GenerateChecksumManifest() = %q, want the checksum of a.csv", got)
+	}
+
+	// The checksum is kept for the next run
+	cache, err := openChecksumCache(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "a.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum, ok := cache.lookup(cachePath(dir, "a.csv"), info); !ok || sum != helloSum {
+		t.Errorf("GenerateChecksumManifest() cached %q, %v, want %v", sum, ok, helloSum)
+	}
+}
@@ -0,0 +1,160 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// HeartbeatOptions turns on heartbeat objects for a batch operation, so operators can follow a transfer running
+// inside a cluster job without access to its logs.
+type HeartbeatOptions struct {
+	// Prefix is where heartbeat objects are written, e.g. "status/"
+	Prefix string
+
+	// Interval is how often the heartbeat is updated. Defaults to 5 minutes
+	Interval time.Duration
+
+	// Name names the heartbeat object, Prefix + Name + ".json". Defaults to the host name
+	Name string
+}
+
+// Heartbeat is the content of a heartbeat object.
+type Heartbeat struct {
+	Operation string    `json:"operation"`
+	Host      string    `json:"host"`
+	PID       int       `json:"pid"`
+	State     string    `json:"state"`
+	Started   time.Time `json:"started"`
+	Updated   time.Time `json:"updated"`
+
+	Total      int   `json:"total"`
+	Done       int   `json:"done"`
+	Failed     int   `json:"failed"`
+	TotalBytes int64 `json:"total_bytes"`
+	DoneBytes  int64 `json:"done_bytes"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Heartbeat states.
+const (
+	HeartbeatRunning  = "running"
+	HeartbeatFinished = "finished"
+	HeartbeatFailed   = "failed"
+)
+
+// heartbeat writes a batch's progress to a heartbeat object until it is stopped.
+type heartbeat struct {
+	basics     BucketBasics
+	bucketName string
+	key        string
+	interval   time.Duration
+	status     Heartbeat
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// newHeartbeat returns a heartbeat for a batch of total items and totalBytes bytes, or nil if options is nil.
+func (basics BucketBasics) newHeartbeat(options *HeartbeatOptions, operation string, bucketName string, total int, totalBytes int64) *heartbeat {
+	if options == nil {
+		return nil
+	}
+
+	host, _ := os.Hostname()
+
+	name := options.Name
+	if name == "" {
+		name = host
+	}
+
+	interval := options.Interval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	return &heartbeat{
+		basics:     basics,
+		bucketName: bucketName,
+		key:        options.Prefix + name + ".json",
+		interval:   interval,
+		status: Heartbeat{
+			Operation:  operation,
+			Host:       host,
+			PID:        os.Getpid(),
+			Total:      total,
+			TotalBytes: totalBytes,
+		},
+	}
+}
+
+// start writes the first heartbeat and keeps updating it from the report every interval.
+func (hb *heartbeat) start(report *TransferReport) {
+	hb.status.Started = time.Now().UTC()
+	hb.status.State = HeartbeatRunning
+	hb.write(report)
+
+	hb.stop = make(chan struct{})
+	hb.done.Add(1)
+
+	go func() {
+		defer hb.done.Done()
+
+		ticker := time.NewTicker(hb.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				hb.write(report)
+			case <-hb.stop:
+				return
+			}
+		}
+	}()
+}
+
+// finish stops the updates and writes a final heartbeat recording how the batch ended.
+func (hb *heartbeat) finish(report *TransferReport, err error) {
+	close(hb.stop)
+	hb.done.Wait()
+
+	hb.status.State = HeartbeatFinished
+	if err != nil {
+		hb.status.State = HeartbeatFailed
+		hb.status.Error = err.Error()
+	}
+
+	hb.write(report)
+}
+
+// write uploads the current progress. Failures are logged but never stop the batch.
+func (hb *heartbeat) write(report *TransferReport) {
+	hb.status.Done, hb.status.Failed, hb.status.DoneBytes = report.progress()
+	hb.status.Updated = time.Now().UTC()
+
+	body, err := json.MarshalIndent(hb.status, "", "  ")
+	if err != nil {
+		return
+	}
+
+	_, err = hb.basics.client(hb.bucketName).PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:       aws.String(hb.bucketName),
+		Key:          aws.String(hb.key),
+		Body:         bytes.NewReader(body),
+		ContentType:  aws.String("application/json"),
+		CacheControl: aws.String("no-cache"),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't write heartbeat %v: %v", hb.key, err)
+	}
+}
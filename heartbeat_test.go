@@ -0,0 +1,79 @@
+package boto3manager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestUploadObjectsHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"data/a.txt": {Data: []byte("aaa")},
+		"data/b.txt": {Data: []byte("bbbbb")},
+	}
+
+	fake := newFakeS3(t, "bucket")
+	fake.fail = func(r *http.Request) int {
+		if r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/b.txt") {
+			return http.StatusForbidden
+		}
+		return 0
+	}
+
+	options := UploadObjectsOptions{FS: fsys, Quiet: true, Heartbeat: &HeartbeatOptions{Prefix: "status/", Name: "job-1"}}
+	if _, err := fake.basics().UploadObjects("data/*", "out/", "bucket", options); err != nil {
+		t.Fatalf("UploadObjects() = %v, want nil", err)
+	}
+
+	object, ok := fake.object("bucket", "status/job-1.json")
+	if !ok {
+		t.Fatalf("UploadObjects() wrote no heartbeat, objects %v", fake.keys("bucket"))
+	}
+	if got := object.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("UploadObjects() wrote a heartbeat of type %q, want application/json", got)
+	}
+
+	var heartbeat Heartbeat
+	if err := json.Unmarshal(object.body, &heartbeat); err != nil {
+		t.Fatalf("UploadObjects() wrote heartbeat %s: %v", object.body, err)
+	}
+
+	// The last heartbeat has the final progress
+	if heartbeat.Operation != "upload" || heartbeat.State != HeartbeatFinished || heartbeat.PID != os.Getpid() {
+		t.Errorf("heartbeat = %+v, want a finished upload by this process", heartbeat)
+	}
+	if heartbeat.Total != 2 || heartbeat.Done != 1 || heartbeat.Failed != 1 || heartbeat.TotalBytes != 8 || heartbeat.DoneBytes != 3 {
+		t.Errorf("heartbeat = %+v, want 1 of 2 files and 3 of 8 bytes done, 1 failed", heartbeat)
+	}
+	if heartbeat.Started.IsZero() || heartbeat.Updated.Before(heartbeat.Started) {
+		t.Errorf("heartbeat started %v and updated %v, want an update after the start", heartbeat.Started, heartbeat.Updated)
+	}
+}
+
+func TestUploadObjectsHeartbeatCanceled(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"data/a.txt": {Data: []byte("aaa")}}
+	fake := newFakeS3(t, "bucket")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	options := UploadObjectsOptions{FS: fsys, Quiet: true, Heartbeat: &HeartbeatOptions{Prefix: "status/", Name: "job-2"}}
+	fake.basics().UploadObjectsWithContext(ctx, "data/*", "out/", "bucket", options)
+
+	object, _ := fake.object("bucket", "status/job-2.json")
+	var heartbeat Heartbeat
+	if err := json.Unmarshal(object.body, &heartbeat); err != nil {
+		t.Fatalf("UploadObjectsWithContext() wrote heartbeat %s: %v", object.body, err)
+	}
+	if heartbeat.State != HeartbeatFailed || heartbeat.Error != context.Canceled.Error() {
+		t.Errorf("heartbeat = %+v, want failed with %v", heartbeat, context.Canceled)
+	}
+}
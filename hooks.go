@@ -0,0 +1,50 @@
+package boto3manager
+
+// Hooks are callbacks run as a batch operation progresses, for example to post notifications or update a job
+// database. Any of them may be nil. The object callbacks are called from the operation's workers, so they run
+// concurrently and should return quickly.
+type Hooks struct {
+	// OnObjectStart is called before the first attempt at each file
+	OnObjectStart func(key string, size int64)
+
+	// OnObjectComplete is called when a file has been transferred
+	OnObjectComplete func(key string, size int64)
+
+	// OnObjectError is called when a file has failed after every attempt
+	OnObjectError func(key string, size int64, err error)
+
+	// OnBatchComplete is called once with the report after every file has finished
+	OnBatchComplete func(report *TransferReport)
+}
+
+// objectStart calls OnObjectStart if the hooks have one.
+func (hooks *Hooks) objectStart(result TransferResult) {
+	if hooks != nil && hooks.OnObjectStart != nil {
+		hooks.OnObjectStart(result.Key, result.Size)
+	}
+}
+
+// objectDone calls OnObjectComplete or OnObjectError for a file's result.
+func (hooks *Hooks) objectDone(result TransferResult) {
+	if hooks == nil {
+		return
+	}
+
+	if result.Err != nil {
+		if hooks.OnObjectError != nil {
+			hooks.OnObjectError(result.Key, result.Size, result.Err)
+		}
+		return
+	}
+
+	if hooks.OnObjectComplete != nil {
+		hooks.OnObjectComplete(result.Key, result.Size)
+	}
+}
+
+// batchComplete calls OnBatchComplete if the hooks have one.
+func (hooks *Hooks) batchComplete(report *TransferReport) {
+	if hooks != nil && hooks.OnBatchComplete != nil {
+		hooks.OnBatchComplete(report)
+	}
+}
@@ -0,0 +1,71 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRunBatchHooks(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	started := make(map[string]int64)
+	completed := make(map[string]int64)
+	failed := make(map[string]error)
+	var batchReport *TransferReport
+
+	hooks := &Hooks{
+		OnObjectStart: func(key string, size int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			started[key] = size
+		},
+		OnObjectComplete: func(key string, size int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			completed[key] = size
+		},
+		OnObjectError: func(key string, size int64, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed[key] = err
+		},
+		OnBatchComplete: func(report *TransferReport) {
+			batchReport = report
+		},
+	}
+
+	errBroken := errors.New("broken")
+	items := []FileUpload{{Key: "a", Size: 1}, {Key: "b", Size: 2}}
+	report := &TransferReport{}
+	config := batchConfig{workerCount: 2, policy: RetryPolicy{MaxAttempts: 1}, report: report, hooks: hooks}
+
+	err := runBatch(context.Background(), items, config, func(ctx context.Context, item FileUpload) error {
+		if item.Key == "b" {
+			return errBroken
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("runBatch() = %v, want nil", err)
+	}
+
+	if len(started) != 2 || started["a"] != 1 || started["b"] != 2 {
+		t.Errorf("OnObjectStart got %v, want a and b with their sizes", started)
+	}
+
+	if len(completed) != 1 || completed["a"] != 1 {
+		t.Errorf("OnObjectComplete got %v, want only a", completed)
+	}
+
+	if len(failed) != 1 || !errors.Is(failed["b"], errBroken) {
+		t.Errorf("OnObjectError got %v, want only b", failed)
+	}
+
+	if batchReport != report {
+		t.Errorf("OnBatchComplete got %p, want the batch's report %p", batchReport, report)
+	}
+}
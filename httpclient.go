@@ -0,0 +1,116 @@
+package boto3manager
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// HTTPOptions tune the HTTP client requests are sent with. The zero value uses the SDK's defaults.
+type HTTPOptions struct {
+	// ProxyURL is the proxy every request goes through, such as http://proxy.example.com:3128. If empty, the
+	// HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables are used
+	ProxyURL string
+
+	// CABundle is the path of a PEM file of certificate authorities trusted on top of the system's, for endpoints
+	// with certificates signed by a private CA
+	CABundle string
+
+	// InsecureSkipVerify accepts any certificate the endpoint presents. Only use it for lab endpoints with
+	// self-signed certificates
+	InsecureSkipVerify bool
+
+	// MaxConnsPerHost limits the connections open to the endpoint. Zero means no limit
+	MaxConnsPerHost int
+
+	// MaxIdleConnsPerHost is the number of idle connections kept for reuse. Raise it to match the worker count
+	// of large batches so connections aren't reopened for every file
+	MaxIdleConnsPerHost int
+
+	// ConnectTimeout limits how long opening a connection may take
+	ConnectTimeout time.Duration
+
+	// ResponseTimeout limits how long to wait for a response's headers after sending a request
+	ResponseTimeout time.Duration
+
+	// Timeout limits whole requests, including reading the body. Leave it zero for large transfers
+	Timeout time.Duration
+}
+
+// httpClient builds an HTTP client from the options, or returns nil if they are all defaults.
+func (options HTTPOptions) httpClient() (*awshttp.BuildableClient, error) {
+	if options == (HTTPOptions{}) {
+		return nil, nil
+	}
+
+	var proxy func(*http.Request) (*url.URL, error)
+	if options.ProxyURL != "" {
+		proxyURL, err := url.Parse(options.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse proxy URL %v: %w", options.ProxyURL, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	var roots *x509.CertPool
+	if options.CABundle != "" {
+		pem, err := os.ReadFile(options.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read CA bundle: %w", err)
+		}
+
+		roots, err = x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %v", options.CABundle)
+		}
+	}
+
+	client := awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		if proxy != nil {
+			tr.Proxy = proxy
+		}
+
+		if roots != nil || options.InsecureSkipVerify {
+			if tr.TLSClientConfig == nil {
+				tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+			if roots != nil {
+				tr.TLSClientConfig.RootCAs = roots
+			}
+			tr.TLSClientConfig.InsecureSkipVerify = options.InsecureSkipVerify
+		}
+
+		if options.MaxConnsPerHost > 0 {
+			tr.MaxConnsPerHost = options.MaxConnsPerHost
+		}
+		if options.MaxIdleConnsPerHost > 0 {
+			tr.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+		}
+		if options.ResponseTimeout > 0 {
+			tr.ResponseHeaderTimeout = options.ResponseTimeout
+		}
+	})
+
+	if options.ConnectTimeout > 0 {
+		client = client.WithDialerOptions(func(d *net.Dialer) {
+			d.Timeout = options.ConnectTimeout
+		})
+	}
+
+	if options.Timeout > 0 {
+		client = client.WithTimeout(options.Timeout)
+	}
+
+	return client, nil
+}
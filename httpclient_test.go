@@ -0,0 +1,48 @@
+package boto3manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHTTPOptionsClient(t *testing.T) {
+	t.Parallel()
+
+	if client, err := (HTTPOptions{}).httpClient(); client != nil || err != nil {
+		t.Errorf("HTTPOptions{}.httpClient() = %v, %v, want nil, nil", client, err)
+	}
+
+	options := HTTPOptions{
+		ProxyURL:            "http://proxy.example.com:3128",
+		InsecureSkipVerify:  true,
+		MaxConnsPerHost:     10,
+		MaxIdleConnsPerHost: 50,
+		ConnectTimeout:      2 * time.Second,
+		ResponseTimeout:     30 * time.Second,
+	}
+
+	client, err := options.httpClient()
+	if err != nil {
+		t.Fatalf("httpClient() = %v, want nil", err)
+	}
+
+	tr := client.GetTransport()
+	if tr.Proxy == nil || !tr.TLSClientConfig.InsecureSkipVerify || tr.MaxConnsPerHost != 10 || tr.MaxIdleConnsPerHost != 50 || tr.ResponseHeaderTimeout != 30*time.Second {
+		t.Errorf("httpClient() transport = %+v, want the options applied", tr)
+	}
+
+	if got := client.GetDialer().Timeout; got != 2*time.Second {
+		t.Errorf("httpClient() dialer timeout = %v, want %v", got, 2*time.Second)
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (HTTPOptions{CABundle: bundle}).httpClient(); err == nil {
+		t.Errorf("httpClient() with an invalid CA bundle = nil, want an error")
+	}
+}
@@ -0,0 +1,236 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"maps"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// IndexPageName is the name of the listing pages WriteIndexPages generates for each prefix.
+const IndexPageName = "index.html"
+
+// indexPageMetadataKey is the user metadata key marking an index page as generated, so index pages written by hand
+// are never replaced.
+const indexPageMetadataKey = "s3m-index"
+
+var indexPageTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index of /{{.Prefix}}</title>
+<style>body{font-family:sans-serif}td{padding:0 1em}td.size{text-align:right}</style>
+</head>
+<body>
+<h1>Index of /{{.Prefix}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Last modified</th></tr>
+{{- if .Parent}}
+<tr><td><a href="../index.html">../</a></td><td></td><td></td></tr>
+{{- end}}
+{{- range .Entries}}
+<tr><td><a href="{{.Href}}">{{.Name}}</a></td><td class="size">{{.Size}}</td><td>{{.Modified}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// indexPage is the data an index page is rendered from.
+type indexPage struct {
+	Prefix  string
+	Parent  bool
+	Entries []indexEntry
+}
+
+// indexEntry is a row of an index page: a subdirectory, whose size and date are empty, or an object.
+type indexEntry struct {
+	Name     string
+	Href     string
+	Size     string
+	Modified string
+}
+
+// indexDir is a prefix's contents, relative to the prefix.
+type indexDir struct {
+	dirs    map[string]bool
+	objects []ObjectInfo
+
+	// hasPage is set if the prefix already has an index page
+	hasPage bool
+}
+
+// indexTree groups objects, named relative to a root prefix, by the directory they are in. Every directory between
+// the root and an object is included, so each can link to the next.
+func indexTree(objects []ObjectInfo) map[string]*indexDir {
+	tree := map[string]*indexDir{"": {dirs: make(map[string]bool)}}
+
+	dir := func(name string) *indexDir {
+		if tree[name] == nil {
+			tree[name] = &indexDir{dirs: make(map[string]bool)}
+		}
+		return tree[name]
+	}
+
+	for _, object := range objects {
+		// Add each directory on the way to the object to its parent
+		parent := ""
+		for {
+			i := strings.Index(object.Name[len(parent):], "/")
+			if i < 0 {
+				break
+			}
+
+			child := object.Name[:len(parent)+i+1]
+			dir(parent).dirs[child[len(parent):]] = true
+			dir(child)
+			parent = child
+		}
+
+		// Directory markers only create their directory
+		base := object.Name[len(parent):]
+		switch base {
+		case "":
+		case IndexPageName:
+			dir(parent).hasPage = true
+		default:
+			d := dir(parent)
+			d.objects = append(d.objects, ObjectInfo{Key: object.Key, Name: base, Size: object.Size, LastModified: object.LastModified})
+		}
+	}
+
+	return tree
+}
+
+// renderIndexPage renders the index page of a directory of the tree. prefix is the directory's full prefix.
+func renderIndexPage(prefix string, dir *indexDir, root bool) ([]byte, error) {
+	page := indexPage{Prefix: prefix, Parent: !root}
+
+	for _, name := range slices.Sorted(maps.Keys(dir.dirs)) {
+		page.Entries = append(page.Entries, indexEntry{Name: name, Href: url.PathEscape(strings.TrimSuffix(name, "/")) + "/" + IndexPageName})
+	}
+
+	objects := slices.SortedFunc(slices.Values(dir.objects), func(a, b ObjectInfo) int { return strings.Compare(a.Name, b.Name) })
+	for _, object := range objects {
+		page.Entries = append(page.Entries, indexEntry{
+			Name:     object.Name,
+			Href:     url.PathEscape(object.Name),
+			Size:     indexSize(object.Size),
+			Modified: object.LastModified.UTC().Format(time.DateTime),
+		})
+	}
+
+	var buf bytes.Buffer
+	err := indexPageTemplate.Execute(&buf, page)
+	return buf.Bytes(), err
+}
+
+// indexSize returns a size in bytes, kB, MB, or GB for people to read.
+func indexSize(n int64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.1f GB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1f MB", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1f kB", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// WriteIndexPages takes a prefix and a bucket name and uploads an index.html listing each subdirectory and object,
+// with its size and date, to the prefix and every prefix under it, so a directory of results can be shared and
+// browsed over HTTP. Pages are replaced on each call to keep them current; an index.html that wasn't generated is
+// left alone, along with its prefix. prefix should be empty or end in "/". The keys of the pages written are returned.
+func (basics BucketBasics) WriteIndexPages(prefix string, bucketName string) ([]string, error) {
+	return basics.writeIndexPages(context.TODO(), prefix, bucketName)
+}
+
+func (basics BucketBasics) writeIndexPages(ctx context.Context, prefix string, bucketName string) ([]string, error) {
+	objects := make([]ObjectInfo, 0)
+	for object, err := range basics.listObjectsSeq(ctx, bucketName, prefix) {
+		if err != nil {
+			return nil, err
+		}
+
+		key := aws.ToString(object.Key)
+		objects = append(objects, ObjectInfo{
+			Key:          key,
+			Name:         strings.TrimPrefix(key, prefix),
+			Size:         aws.ToInt64(object.Size),
+			LastModified: aws.ToTime(object.LastModified),
+		})
+	}
+
+	tree := indexTree(objects)
+
+	written := make([]string, 0)
+	for _, name := range slices.Sorted(maps.Keys(tree)) {
+		dir := tree[name]
+		key := prefix + name + IndexPageName
+
+		if dir.hasPage {
+			generated, err := basics.isGeneratedIndexPage(ctx, key, bucketName)
+			if err != nil {
+				return written, err
+			}
+			if !generated {
+				continue
+			}
+		}
+
+		page, err := renderIndexPage(prefix+name, dir, name == "")
+		if err != nil {
+			return written, err
+		}
+
+		_, err = basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+			Bucket:       aws.String(bucketName),
+			Key:          aws.String(key),
+			Body:         bytes.NewReader(page),
+			ContentType:  aws.String("text/html; charset=utf-8"),
+			CacheControl: aws.String("public, max-age=60, must-revalidate"),
+			Metadata:     map[string]string{indexPageMetadataKey: "generated"},
+		})
+
+		if err != nil {
+			log.Printf("Couldn't upload index page %v to bucket %v: %v", key, bucketName, err)
+			return written, classifyError(err)
+		}
+
+		written = append(written, key)
+	}
+
+	return written, nil
+}
+
+// isGeneratedIndexPage reports whether an index page was written by WriteIndexPages.
+func (basics BucketBasics) isGeneratedIndexPage(ctx context.Context, key string, bucketName string) (bool, error) {
+	head, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get index page %v: %v", key, err)
+		return false, classifyError(err)
+	}
+
+	return head.Metadata[indexPageMetadataKey] != "", nil
+}
+
+// isIndexPage reports whether a name relative to a synced prefix is an index page.
+func isIndexPage(name string) bool {
+	return path.Base(name) == IndexPageName
+}
@@ -0,0 +1,115 @@
+package boto3manager
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndexTree(t *testing.T) {
+	t.Parallel()
+
+	objects := []ObjectInfo{
+		{Key: "r/a.txt", Name: "a.txt", Size: 1},
+		{Key: "r/index.html", Name: "index.html"},
+		{Key: "r/x/y/b.txt", Name: "x/y/b.txt", Size: 2},
+		{Key: "r/empty/", Name: "empty/"},
+	}
+
+	tree := indexTree(objects)
+
+	tests := []struct {
+		dir           string
+		wantedDirs    []string
+		wantedObjects []string
+		wantedPage    bool
+	}{
+		{dir: "", wantedDirs: []string{"empty/", "x/"}, wantedObjects: []string{"a.txt"}, wantedPage: true},
+		{dir: "x/", wantedDirs: []string{"y/"}},
+		{dir: "x/y/", wantedObjects: []string{"b.txt"}},
+		{dir: "empty/"},
+	}
+
+	if got := slices.Sorted(maps.Keys(tree)); !slices.Equal(got, []string{"", "empty/", "x/", "x/y/"}) {
+		t.Errorf("indexTree() has directories %v, want , empty/, x/, x/y/", got)
+	}
+
+	for _, test := range tests {
+		dir := tree[test.dir]
+		if dir == nil {
+			t.Errorf("indexTree() is missing %q", test.dir)
+			continue
+		}
+
+		var objects []string
+		for _, object := range dir.objects {
+			objects = append(objects, object.Name)
+		}
+
+		if dirs := slices.Sorted(maps.Keys(dir.dirs)); !slices.Equal(dirs, test.wantedDirs) || !slices.Equal(objects, test.wantedObjects) || dir.hasPage != test.wantedPage {
+			t.Errorf("indexTree()[%q] = %v, %v, %v, want %v, %v, %v", test.dir, dirs, objects, dir.hasPage, test.wantedDirs, test.wantedObjects, test.wantedPage)
+		}
+	}
+}
+
+func TestRenderIndexPage(t *testing.T) {
+	t.Parallel()
+
+	dir := &indexDir{
+		dirs:    map[string]bool{"sub dir/": true},
+		objects: []ObjectInfo{{Name: "<b>.csv", Size: 1500, LastModified: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}},
+	}
+
+	page, err := renderIndexPage("results/", dir, false)
+	if err != nil {
+		t.Fatalf("renderIndexPage() returned %v", err)
+	}
+
+	for _, wanted := range []string{
+		"<title>Index of /results/</title>",
+		`<a href="../index.html">../</a>`,
+		`<a href="sub%20dir/index.html">sub dir/</a>`,
+		`<a href="%3Cb%3E.csv">&lt;b&gt;.csv</a></td><td class="size">1.5 kB</td><td>2024-05-01 12:00:00</td>`,
+	} {
+		if !strings.Contains(string(page), wanted) {
+			t.Errorf("renderIndexPage() = %s, want it to contain %v", page, wanted)
+		}
+	}
+}
+
+func TestWriteIndexPages(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "site/a.txt", "a", nil)
+	fake.put("bucket", "site/sub/b.txt", "b", nil)
+	fake.put("bucket", "site/manual/c.txt", "c", nil)
+	fake.put("bucket", "site/manual/index.html", "<p>written by hand</p>", nil)
+
+	// The second run replaces the pages the first generated, but still not the one written by hand
+	for run := range 2 {
+		written, err := fake.basics().WriteIndexPages("site/", "bucket")
+		if err != nil {
+			t.Fatalf("WriteIndexPages() = %v, want nil", err)
+		}
+		if wanted := []string{"site/index.html", "site/sub/index.html"}; !slices.Equal(written, wanted) {
+			t.Errorf("WriteIndexPages() run %v wrote %v, want %v", run+1, written, wanted)
+		}
+	}
+
+	root, _ := fake.object("bucket", "site/index.html")
+	for _, want := range []string{`href="a.txt"`, `href="sub/index.html"`, `href="manual/index.html"`} {
+		if !strings.Contains(string(root.body), want) {
+			t.Errorf("WriteIndexPages() wrote a root page without %v:\n%s", want, root.body)
+		}
+	}
+	if got := root.header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("WriteIndexPages() wrote Content-Type %q, want text/html", got)
+	}
+
+	if manual, _ := fake.object("bucket", "site/manual/index.html"); string(manual.body) != "<p>written by hand</p>" {
+		t.Errorf("WriteIndexPages() replaced the page written by hand with %q", manual.body)
+	}
+}
@@ -0,0 +1,127 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/schollz/progressbar/v3"
+)
+
+// verifiedPartSize is the part size of uploads sent with Content-MD5. Each part is hashed in memory before it is sent.
+const verifiedPartSize = 16 * 1024 * 1024
+
+// contentMD5 returns the MD5 checksum of data in the base64 form of the Content-MD5 header and as hex, which is the
+// ETag S3 returns for a single PutObject or UploadPart.
+func contentMD5(data []byte) (header string, etag string) {
+	sum := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:]), hex.EncodeToString(sum[:])
+}
+
+// checkETag compares the ETag S3 returned for a single PutObject or UploadPart with the MD5 checksum of what was sent.
+func checkETag(key string, returned *string, wanted string) error {
+	if got := strings.Trim(aws.ToString(returned), `"`); got != wanted {
+		return fmt.Errorf("%w: ETag of %v is %v, but the data sent has MD5 %v", ErrChecksumMismatch, key, got, wanted)
+	}
+
+	return nil
+}
+
+// uploadVerified uploads size bytes from r to key with Content-MD5 set on every PutObject or UploadPart, so the
+// server rejects data corrupted in transit, and checks each returned ETag against the checksum so data corrupted
+// by the server is caught too. A single object with a mismatched ETag is deleted. Parts are sent one at a time.
+// The object is given the metadata and lock, and optFns are passed to the requests that start and write it.
+func (basics BucketBasics) uploadVerified(ctx context.Context, r io.Reader, size int64, key string, bucketName string, metadata map[string]string, lock objectLock, bar *progressbar.ProgressBar, optFns ...func(*s3.Options)) error {
+	if size <= verifiedPartSize {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		header, etag := contentMD5(data)
+		input := &s3.PutObjectInput{
+			Bucket:     aws.String(bucketName),
+			Key:        aws.String(key),
+			Body:       bytes.NewReader(data),
+			ContentMD5: aws.String(header),
+			Metadata:   metadata,
+		}
+		lock.putObject(input)
+
+		output, err := basics.client(bucketName).PutObject(ctx, input, optFns...)
+
+		if err != nil {
+			return err
+		}
+
+		if err := checkETag(key, output.ETag, etag); err != nil {
+			basics.deleteKeys(context.Background(), bucketName, []string{key})
+			return err
+		}
+
+		if bar != nil {
+			bar.Add(len(data))
+		}
+
+		return nil
+	}
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:   aws.String(bucketName),
+		Key:      aws.String(key),
+		Metadata: metadata,
+	}
+	lock.createMultipartUpload(input)
+
+	splice, err := basics.startMultipartSplice(ctx, input, optFns...)
+	if err != nil {
+		return err
+	}
+	splice.verifyMD5 = true
+	splice.completeOptions = optFns
+
+	// Count progress as parts complete and take it back if the upload fails
+	var sent int64
+	fail := func(err error) error {
+		splice.abort()
+		if bar != nil {
+			bar.Add64(-sent)
+		}
+		return err
+	}
+
+	buf := make([]byte, verifiedPartSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fail(err)
+		}
+
+		if n == 0 {
+			break
+		}
+
+		if err := splice.uploadBytes(buf[:n]); err != nil {
+			return fail(err)
+		}
+
+		sent += int64(n)
+		if bar != nil {
+			bar.Add(n)
+		}
+	}
+
+	if err := splice.complete(); err != nil {
+		return fail(err)
+	}
+
+	return nil
+}
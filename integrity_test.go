@@ -0,0 +1,59 @@
+package boto3manager
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestContentMD5(t *testing.T) {
+	t.Parallel()
+
+	header, etag := contentMD5([]byte("hello"))
+	if header != "XUFAKrxLKna5cZ2REBfFkg==" || etag != "5d41402abc4b2a76b9719d911017c592" {
+		t.Errorf("contentMD5(\"hello\") = %v, %v", header, etag)
+	}
+
+	if err := checkETag("key", aws.String(`"5d41402abc4b2a76b9719d911017c592"`), etag); err != nil {
+		t.Errorf("checkETag() = %v, want nil", err)
+	}
+
+	if err := checkETag("key", aws.String(`"00000000000000000000000000000000"`), etag); err == nil {
+		t.Errorf("checkETag() = nil, want an error")
+	}
+}
+
+func TestUploadObjectsVerifyMD5RetriesMismatch(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"data/a.txt": {Data: []byte("intact")}}
+
+	// The first PUT is stored corrupted, so its ETag doesn't match, and the retry arrives intact
+	fake := newFakeS3(t, "bucket")
+	corrupted := false
+	fake.corrupt = func(r *http.Request) bool {
+		if corrupted {
+			return false
+		}
+		corrupted = true
+		return true
+	}
+
+	options := UploadObjectsOptions{FS: fsys, VerifyMD5: true, Quiet: true, RetryPolicy: &RetryPolicy{MaxAttempts: 2}}
+	report, err := fake.basics().UploadObjects("data/*", "out/", "bucket", options)
+	if err != nil {
+		t.Fatalf("UploadObjects() = %v, want nil", err)
+	}
+
+	if failed := report.Failed(); len(failed) != 0 {
+		t.Errorf("UploadObjects() failed %+v, want none", failed)
+	}
+	if got := fake.count("PUT bucket/out/a.txt"); got != 2 {
+		t.Errorf("UploadObjects() sent PUT bucket/out/a.txt %v times, want 2", got)
+	}
+	if object, ok := fake.object("bucket", "out/a.txt"); !ok || string(object.body) != "intact" {
+		t.Errorf("UploadObjects() left out/a.txt = %q, %v, want %q", object.body, ok, "intact")
+	}
+}
@@ -0,0 +1,78 @@
+// Package merge joins two key-ordered streams, holding one item from each at a time. DiffRemote uses it to compare
+// two listings. The sync, mirror, and two-way sync planners don't: they compare whole listings already loaded into
+// maps by relative path, where local walk order doesn't match S3's key order.
+package merge
+
+import (
+	"iter"
+)
+
+// Pair is one step of a join. Left and Right are only set if HasLeft and HasRight are true, respectively.
+// Both are set when the two streams have an item with the same key.
+type Pair[A, B any] struct {
+	Left     A
+	Right    B
+	HasLeft  bool
+	HasRight bool
+}
+
+// Join merges two streams that are each sorted by key into a single stream of pairs in key order. compare
+// returns a negative number if the left item's key sorts first, a positive number if the right item's key
+// sorts first, and zero if the keys are equal. Only one item from each stream is held at a time, so memory
+// use doesn't depend on the length of the streams. The first error from either stream ends the join.
+func Join[A, B any](left iter.Seq2[A, error], right iter.Seq2[B, error], compare func(A, B) int) iter.Seq2[Pair[A, B], error] {
+	return func(yield func(Pair[A, B], error) bool) {
+		nextLeft, stopLeft := iter.Pull2(left)
+		defer stopLeft()
+
+		nextRight, stopRight := iter.Pull2(right)
+		defer stopRight()
+
+		l, lErr, lOk := nextLeft()
+		r, rErr, rOk := nextRight()
+
+		for lOk || rOk {
+			if lOk && lErr != nil {
+				yield(Pair[A, B]{}, lErr)
+				return
+			}
+			if rOk && rErr != nil {
+				yield(Pair[A, B]{}, rErr)
+				return
+			}
+
+			var pair Pair[A, B]
+
+			switch {
+			case lOk && (!rOk || compare(l, r) < 0):
+				// The left stream is behind, so its item has no match
+				pair = Pair[A, B]{Left: l, HasLeft: true}
+				l, lErr, lOk = nextLeft()
+			case rOk && (!lOk || compare(l, r) > 0):
+				// The right stream is behind, so its item has no match
+				pair = Pair[A, B]{Right: r, HasRight: true}
+				r, rErr, rOk = nextRight()
+			default:
+				// Both streams have the key
+				pair = Pair[A, B]{Left: l, Right: r, HasLeft: true, HasRight: true}
+				l, lErr, lOk = nextLeft()
+				r, rErr, rOk = nextRight()
+			}
+
+			if !yield(pair, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Slice returns a stream over a slice with no errors, which is useful for joining against data already in memory.
+func Slice[T any](items []T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
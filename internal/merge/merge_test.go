@@ -0,0 +1,89 @@
+package merge
+
+import (
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		left   []string
+		right  []string
+		wanted string
+	}{
+		{
+			name:   "both empty",
+			left:   nil,
+			right:  nil,
+			wanted: "",
+		},
+		{
+			name:   "identical",
+			left:   []string{"a", "b"},
+			right:  []string{"a", "b"},
+			wanted: "a=a b=b",
+		},
+		{
+			name:   "interleaved",
+			left:   []string{"a", "c", "d"},
+			right:  []string{"b", "c", "e"},
+			wanted: "a< >b c=c d< >e",
+		},
+		{
+			name:   "left only",
+			left:   []string{"a", "b"},
+			right:  nil,
+			wanted: "a< b<",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := make([]string, 0)
+			for pair, err := range Join(Slice(tt.left), Slice(tt.right), strings.Compare) {
+				if err != nil {
+					t.Fatalf("Join() returned error %v", err)
+				}
+
+				switch {
+				case pair.HasLeft && pair.HasRight:
+					steps = append(steps, pair.Left+"="+pair.Right)
+				case pair.HasLeft:
+					steps = append(steps, pair.Left+"<")
+				default:
+					steps = append(steps, ">"+pair.Right)
+				}
+			}
+
+			if got := strings.Join(steps, " "); got != tt.wanted {
+				t.Errorf("Join(%v, %v) = %q, want %q", tt.left, tt.right, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestJoinError(t *testing.T) {
+	t.Parallel()
+
+	failing := iter.Seq2[string, error](func(yield func(string, error) bool) {
+		if yield("a", nil) {
+			yield("", errors.New("page failed"))
+		}
+	})
+
+	var err error
+	for _, err = range Join(failing, Slice([]string{"a", "b"}), strings.Compare) {
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		t.Errorf("Join() didn't return the stream's error")
+	}
+}
@@ -0,0 +1,404 @@
+package boto3manager
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/parquet-go/parquet-go"
+	"gitlab.nrp-nautilus.io/humboldt/boto3-manager/strutil"
+)
+
+// Inventory is a listing of a bucket read from an S3 Inventory report or a Ceph RGW bucket index dump rather than
+// from live LIST calls, which are slow and expensive on buckets of millions of objects. Pass it as the Inventory of
+// DownloadObjectsOptions or DeleteObjectsOptions to match objects against it instead of listing the bucket.
+type Inventory struct {
+	// Objects are the objects in the inventory in key order
+	Objects []types.Object
+}
+
+// newInventory returns an inventory of objects, sorting them by key.
+func newInventory(objects []types.Object) *Inventory {
+	slices.SortFunc(objects, func(a, b types.Object) int {
+		return strings.Compare(aws.ToString(a.Key), aws.ToString(b.Key))
+	})
+
+	return &Inventory{Objects: objects}
+}
+
+// Match returns the objects in the inventory whose keys match a pattern, in key order.
+func (inventory *Inventory) Match(pattern string) []types.Object {
+	re := regexp.MustCompile(strutil.WildCardToRegexp(pattern))
+
+	// Only the objects sharing the pattern's literal prefix can match, and they are together in key order
+	prefix := literalPrefix(pattern)
+	start, _ := slices.BinarySearchFunc(inventory.Objects, prefix, func(object types.Object, prefix string) int {
+		return strings.Compare(aws.ToString(object.Key), prefix)
+	})
+
+	matches := make([]types.Object, 0)
+	for _, object := range inventory.Objects[start:] {
+		key := aws.ToString(object.Key)
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+
+		if re.MatchString(key) {
+			matches = append(matches, object)
+		}
+	}
+
+	return matches
+}
+
+// InventoryStats totals the objects under a prefix of an inventory.
+type InventoryStats struct {
+	Objects int64
+	Bytes   int64
+
+	// ByStorageClass totals the bytes in each storage class
+	ByStorageClass map[string]int64
+}
+
+// Stats totals the objects under a prefix of the inventory.
+func (inventory *Inventory) Stats(prefix string) InventoryStats {
+	stats := InventoryStats{ByStorageClass: make(map[string]int64)}
+
+	for _, object := range inventory.Objects {
+		if !strings.HasPrefix(aws.ToString(object.Key), prefix) {
+			continue
+		}
+
+		stats.Objects++
+		stats.Bytes += aws.ToInt64(object.Size)
+
+		storageClass := string(object.StorageClass)
+		if storageClass == "" {
+			storageClass = string(types.ObjectStorageClassStandard)
+		}
+		stats.ByStorageClass[storageClass] += aws.ToInt64(object.Size)
+	}
+
+	return stats
+}
+
+// inventoryManifest is the manifest.json an S3 Inventory report is described by.
+type inventoryManifest struct {
+	SourceBucket      string `json:"sourceBucket"`
+	DestinationBucket string `json:"destinationBucket"`
+	FileFormat        string `json:"fileFormat"`
+	FileSchema        string `json:"fileSchema"`
+	Files             []struct {
+		Key  string `json:"key"`
+		Size int64  `json:"size"`
+	} `json:"files"`
+}
+
+// ReadInventory takes the key of an S3 Inventory report's manifest.json and the name of the bucket the report was
+// delivered to and reads the objects it lists. CSV and Parquet reports can be read, but not ORC reports.
+func (basics BucketBasics) ReadInventory(manifestKey string, bucketName string) (*Inventory, error) {
+	ctx := context.TODO()
+
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(manifestKey),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get inventory manifest %v: %v", manifestKey, err)
+		return nil, classifyError(err)
+	}
+
+	var manifest inventoryManifest
+	err = json.NewDecoder(obj.Body).Decode(&manifest)
+	obj.Body.Close()
+
+	if err != nil {
+		log.Printf("Couldn't parse inventory manifest %v: %v", manifestKey, err)
+		return nil, err
+	}
+
+	if manifest.FileFormat != "CSV" && manifest.FileFormat != "Parquet" {
+		return nil, fmt.Errorf("inventory format %v isn't supported; configure the inventory as CSV or Parquet", manifest.FileFormat)
+	}
+
+	schema := strings.Split(manifest.FileSchema, ",")
+	for i := range schema {
+		schema[i] = strings.TrimSpace(schema[i])
+	}
+
+	objects := make([]types.Object, 0)
+	for _, file := range manifest.Files {
+		var fileObjects []types.Object
+		if manifest.FileFormat == "Parquet" {
+			fileObjects, err = basics.readInventoryParquetFile(file.Key, bucketName)
+		} else {
+			fileObjects, err = basics.readInventoryFile(ctx, file.Key, bucketName, schema)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, fileObjects...)
+	}
+
+	return newInventory(objects), nil
+}
+
+// readInventoryFile reads a gzipped CSV data file of an inventory report.
+func (basics BucketBasics) readInventoryFile(ctx context.Context, key string, bucketName string, schema []string) ([]types.Object, error) {
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get inventory file %v: %v", key, err)
+		return nil, classifyError(err)
+	}
+
+	defer obj.Body.Close()
+
+	gz, err := gzip.NewReader(obj.Body)
+	if err != nil {
+		log.Printf("Couldn't decompress inventory file %v: %v", key, err)
+		return nil, err
+	}
+
+	objects, err := parseInventoryCSV(gz, schema)
+	if err != nil {
+		log.Printf("Couldn't parse inventory file %v: %v", key, err)
+	}
+
+	return objects, err
+}
+
+// parseInventoryCSV parses the rows of an inventory CSV file, whose columns are named by the manifest's schema.
+// Delete markers and noncurrent versions are left out.
+func parseInventoryCSV(r io.Reader, schema []string) ([]types.Object, error) {
+	column := make(map[string]int, len(schema))
+	for i, name := range schema {
+		column[name] = i
+	}
+
+	if _, ok := column["Key"]; !ok {
+		return nil, errors.New("inventory schema has no Key column")
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := column[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	objects := make([]types.Object, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if field(row, "IsDeleteMarker") == "true" || field(row, "IsLatest") == "false" {
+			continue
+		}
+
+		// Keys are URL encoded
+		key, err := url.QueryUnescape(field(row, "Key"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", field(row, "Key"), err)
+		}
+
+		object := types.Object{
+			Key:          aws.String(key),
+			ETag:         aws.String(field(row, "ETag")),
+			StorageClass: types.ObjectStorageClass(field(row, "StorageClass")),
+		}
+
+		if size, err := strconv.ParseInt(field(row, "Size"), 10, 64); err == nil {
+			object.Size = aws.Int64(size)
+		}
+
+		if modified, err := time.Parse(time.RFC3339, field(row, "LastModifiedDate")); err == nil {
+			object.LastModified = aws.Time(modified)
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// inventoryParquetRow is a row of an inventory Parquet file. Columns other than the key are only there if the
+// inventory is configured to include them.
+type inventoryParquetRow struct {
+	Key              string  `parquet:"key"`
+	IsLatest         *bool   `parquet:"is_latest,optional"`
+	IsDeleteMarker   *bool   `parquet:"is_delete_marker,optional"`
+	Size             *int64  `parquet:"size,optional"`
+	LastModifiedDate *int64  `parquet:"last_modified_date,optional"`
+	ETag             *string `parquet:"e_tag,optional"`
+	StorageClass     *string `parquet:"storage_class,optional"`
+}
+
+// readInventoryParquetFile reads a Parquet data file of an inventory report. Parquet is read from the end, so the
+// file is read with ranged GETs rather than downloaded.
+func (basics BucketBasics) readInventoryParquetFile(key string, bucketName string) ([]types.Object, error) {
+	reader, err := basics.OpenObject(key, bucketName, OpenOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	objects, err := parseInventoryParquet(reader, reader.Size())
+	if err != nil {
+		log.Printf("Couldn't parse inventory file %v: %v", key, err)
+	}
+
+	return objects, err
+}
+
+// parseInventoryParquet parses the rows of an inventory Parquet file. Unlike in CSV files, keys aren't URL encoded.
+// Delete markers and noncurrent versions are left out.
+func parseInventoryParquet(r io.ReaderAt, size int64) ([]types.Object, error) {
+	file, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := file.Schema().Lookup("key"); !ok {
+		return nil, errors.New("inventory schema has no key column")
+	}
+
+	reader := parquet.NewGenericReader[inventoryParquetRow](file)
+	defer reader.Close()
+
+	objects := make([]types.Object, 0, reader.NumRows())
+	rows := make([]inventoryParquetRow, 1024)
+	for {
+		n, err := reader.Read(rows)
+
+		for _, row := range rows[:n] {
+			if aws.ToBool(row.IsDeleteMarker) || (row.IsLatest != nil && !*row.IsLatest) {
+				continue
+			}
+
+			object := types.Object{
+				Key:          aws.String(row.Key),
+				ETag:         aws.String(aws.ToString(row.ETag)),
+				StorageClass: types.ObjectStorageClass(aws.ToString(row.StorageClass)),
+				Size:         row.Size,
+			}
+
+			// Modification times are milliseconds since the epoch
+			if row.LastModifiedDate != nil {
+				object.LastModified = aws.Time(time.UnixMilli(*row.LastModifiedDate).UTC())
+			}
+
+			objects = append(objects, object)
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// rgwBucketEntry is an entry of radosgw-admin bucket list's output.
+type rgwBucketEntry struct {
+	Name   string `json:"name"`
+	Exists bool   `json:"exists"`
+	Meta   struct {
+		Size         int64     `json:"size"`
+		MTime        time.Time `json:"mtime"`
+		ETag         string    `json:"etag"`
+		StorageClass string    `json:"storage_class"`
+	} `json:"meta"`
+}
+
+// ReadRGWBucketList reads the objects in the output of Ceph's radosgw-admin bucket list, a dump of a bucket's index
+// that can be taken on the cluster without going through the S3 API.
+func ReadRGWBucketList(r io.Reader) (*Inventory, error) {
+	var entries []rgwBucketEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		log.Printf("Couldn't parse bucket list: %v", err)
+		return nil, err
+	}
+
+	objects := make([]types.Object, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Exists {
+			continue
+		}
+
+		objects = append(objects, types.Object{
+			Key:          aws.String(entry.Name),
+			Size:         aws.Int64(entry.Meta.Size),
+			LastModified: aws.Time(entry.Meta.MTime),
+			ETag:         aws.String(entry.Meta.ETag),
+			StorageClass: types.ObjectStorageClass(entry.Meta.StorageClass),
+		})
+	}
+
+	return newInventory(objects), nil
+}
+
+type DeleteObjectsOptions struct {
+	// Inventory, if set, is matched against instead of listing the bucket. Objects created since the inventory was
+	// taken aren't deleted, but objects overwritten since then are, newer data and all, since only their keys are
+	// compared. Use an inventory of a prefix nothing else writes to, or list the bucket instead
+	Inventory *Inventory
+}
+
+// DeleteObjects takes a pattern and a bucket name and deletes every object whose key matches the pattern, returning
+// the keys deleted.
+func (basics BucketBasics) DeleteObjects(pattern string, bucketName string, options DeleteObjectsOptions) ([]string, error) {
+	ctx := context.TODO()
+
+	var matches []types.Object
+	if options.Inventory != nil {
+		matches = options.Inventory.Match(pattern)
+	} else {
+		var err error
+		if matches, err = basics.matchObjects(ctx, pattern, bucketName); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(matches))
+	for _, object := range matches {
+		keys = append(keys, aws.ToString(object.Key))
+	}
+
+	if err := basics.deleteKeys(ctx, bucketName, keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,217 @@
+package boto3manager
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParseInventoryCSV(t *testing.T) {
+	t.Parallel()
+
+	schema := []string{"Bucket", "Key", "VersionId", "IsLatest", "IsDeleteMarker", "Size", "LastModifiedDate", "ETag", "StorageClass"}
+	csv := `"lab","data/a%20b.csv","v2","true","false","120","2024-05-01T12:00:00.000Z","abc","STANDARD"
+"lab","data/a%20b.csv","v1","false","false","100","2024-04-01T12:00:00.000Z","def","STANDARD"
+"lab","data/gone.csv","v3","true","true","","2024-05-02T12:00:00.000Z","",""
+"lab","raw/c.h5","v4","true","false","5000","2024-05-03T12:00:00.000Z","ghi","GLACIER"
+`
+
+	objects, err := parseInventoryCSV(strings.NewReader(csv), schema)
+	if err != nil {
+		t.Fatalf("parseInventoryCSV() returned %v", err)
+	}
+
+	if len(objects) != 2 {
+		t.Fatalf("parseInventoryCSV() = %v objects, want 2", len(objects))
+	}
+
+	first := objects[0]
+	if aws.ToString(first.Key) != "data/a b.csv" || aws.ToInt64(first.Size) != 120 || aws.ToTime(first.LastModified).Month() != 5 || aws.ToString(first.ETag) != "abc" {
+		t.Errorf("parseInventoryCSV() first object = %+v, want data/a b.csv, 120 bytes, May, abc", first)
+	}
+
+	if objects[1].StorageClass != types.ObjectStorageClassGlacier {
+		t.Errorf("parseInventoryCSV() second storage class = %v, want GLACIER", objects[1].StorageClass)
+	}
+}
+
+// parquetInventoryRow is a row of an inventory Parquet file as S3 writes it, without the storage class column.
+type parquetInventoryRow struct {
+	Bucket           string    `parquet:"bucket"`
+	Key              string    `parquet:"key"`
+	VersionID        *string   `parquet:"version_id,optional"`
+	IsLatest         *bool     `parquet:"is_latest,optional"`
+	IsDeleteMarker   *bool     `parquet:"is_delete_marker,optional"`
+	Size             *int64    `parquet:"size,optional"`
+	LastModifiedDate time.Time `parquet:"last_modified_date,optional,timestamp(millisecond)"`
+	ETag             *string   `parquet:"e_tag,optional"`
+}
+
+func TestReadInventoryParquet(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := []parquetInventoryRow{
+		{"lab", "data/a b.csv", aws.String("v2"), aws.Bool(true), aws.Bool(false), aws.Int64(120), modified, aws.String("abc")},
+		{"lab", "data/a b.csv", aws.String("v1"), aws.Bool(false), aws.Bool(false), aws.Int64(100), modified, aws.String("def")},
+		{"lab", "data/gone.csv", aws.String("v3"), aws.Bool(true), aws.Bool(true), nil, modified, nil},
+		{"lab", "raw/c.h5", aws.String("v4"), aws.Bool(true), aws.Bool(false), aws.Int64(5000), modified, aws.String("ghi")},
+	}
+
+	var data bytes.Buffer
+	if err := parquet.Write(&data, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeS3(t, "inventory")
+	fake.put("inventory", "lab/config/data/part-0.parquet", data.String(), nil)
+	fake.put("inventory", "lab/config/manifest.json", `{
+		"sourceBucket": "lab",
+		"fileFormat": "Parquet",
+		"fileSchema": "message s3.inventory { required binary bucket (STRING); required binary key (STRING); }",
+		"files": [{"key": "lab/config/data/part-0.parquet", "size": 1}]
+	}`, nil)
+
+	inventory, err := fake.basics().ReadInventory("lab/config/manifest.json", "inventory")
+	if err != nil {
+		t.Fatalf("ReadInventory() returned %v", err)
+	}
+
+	if len(inventory.Objects) != 2 {
+		t.Fatalf("ReadInventory() = %v objects, want 2", len(inventory.Objects))
+	}
+
+	first := inventory.Objects[0]
+	if aws.ToString(first.Key) != "data/a b.csv" || aws.ToInt64(first.Size) != 120 || !aws.ToTime(first.LastModified).Equal(modified) || aws.ToString(first.ETag) != "abc" {
+		t.Errorf("ReadInventory() first object = %+v, want data/a b.csv, 120 bytes, %v, abc", first, modified)
+	}
+
+	if key := aws.ToString(inventory.Objects[1].Key); key != "raw/c.h5" {
+		t.Errorf("ReadInventory() second key = %v, want raw/c.h5", key)
+	}
+}
+
+func TestReadInventoryORC(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "inventory")
+	fake.put("inventory", "manifest.json", `{"fileFormat": "ORC", "files": []}`, nil)
+
+	if _, err := fake.basics().ReadInventory("manifest.json", "inventory"); err == nil {
+		t.Errorf("ReadInventory() of an ORC report returned no error")
+	}
+}
+
+func TestReadRGWBucketList(t *testing.T) {
+	t.Parallel()
+
+	dump := `[
+	{"name": "b.txt", "exists": true, "meta": {"size": 7, "mtime": "2024-05-01T12:00:00.000000Z", "etag": "e2", "storage_class": ""}},
+	{"name": "deleted.txt", "exists": false, "meta": {"size": 0}},
+	{"name": "a.txt", "exists": true, "meta": {"size": 3, "mtime": "2024-05-01T12:00:00.000000Z", "etag": "e1", "storage_class": "STANDARD"}}
+]`
+
+	inventory, err := ReadRGWBucketList(strings.NewReader(dump))
+	if err != nil {
+		t.Fatalf("ReadRGWBucketList() returned %v", err)
+	}
+
+	var keys []string
+	for _, object := range inventory.Objects {
+		keys = append(keys, aws.ToString(object.Key))
+	}
+
+	if !slices.Equal(keys, []string{"a.txt", "b.txt"}) {
+		t.Errorf("ReadRGWBucketList() keys = %v, want [a.txt b.txt]", keys)
+	}
+}
+
+func TestInventoryMatchAndStats(t *testing.T) {
+	t.Parallel()
+
+	inventory := newInventory([]types.Object{
+		{Key: aws.String("raw/2024/b.csv"), Size: aws.Int64(20), StorageClass: types.ObjectStorageClassGlacier},
+		{Key: aws.String("raw/2024/a.csv"), Size: aws.Int64(10)},
+		{Key: aws.String("raw/2024/a.json"), Size: aws.Int64(5)},
+		{Key: aws.String("processed/a.csv"), Size: aws.Int64(1)},
+	})
+
+	tests := []struct {
+		pattern string
+		wanted  []string
+	}{
+		{pattern: "raw/2024/*.csv", wanted: []string{"raw/2024/a.csv", "raw/2024/b.csv"}},
+		{pattern: "**/a.csv", wanted: []string{"processed/a.csv", "raw/2024/a.csv"}},
+		{pattern: "missing/*", wanted: nil},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, object := range inventory.Match(test.pattern) {
+			got = append(got, aws.ToString(object.Key))
+		}
+
+		if !slices.Equal(got, test.wanted) {
+			t.Errorf("Match(%v) = %v, want %v", test.pattern, got, test.wanted)
+		}
+	}
+
+	stats := inventory.Stats("raw/")
+	if stats.Objects != 3 || stats.Bytes != 35 || stats.ByStorageClass["GLACIER"] != 20 || stats.ByStorageClass["STANDARD"] != 15 {
+		t.Errorf("Stats(raw/) = %+v, want 3 objects, 35 bytes, 20 GLACIER, 15 STANDARD", stats)
+	}
+}
+
+func TestDeleteObjects(t *testing.T) {
+	t.Parallel()
+
+	// The inventory was taken before logs/new.txt was written
+	inventory := newInventory([]types.Object{
+		{Key: aws.String("logs/a.txt"), Size: aws.Int64(1)},
+		{Key: aws.String("logs/b.txt"), Size: aws.Int64(1)},
+		{Key: aws.String("logs/keep.csv"), Size: aws.Int64(1)},
+	})
+
+	tests := []struct {
+		name    string
+		options DeleteObjectsOptions
+		deleted []string
+		kept    []string
+	}{
+		{name: "listing", deleted: []string{"logs/a.txt", "logs/b.txt", "logs/new.txt"}, kept: []string{"logs/keep.csv"}},
+		{name: "inventory", options: DeleteObjectsOptions{Inventory: inventory}, deleted: []string{"logs/a.txt", "logs/b.txt"}, kept: []string{"logs/keep.csv", "logs/new.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			for _, key := range []string{"logs/a.txt", "logs/b.txt", "logs/keep.csv", "logs/new.txt"} {
+				fake.put("bucket", key, "x", nil)
+			}
+
+			deleted, err := fake.basics().DeleteObjects("logs/*.txt", "bucket", tt.options)
+			if err != nil {
+				t.Fatalf("DeleteObjects() = %v, want nil", err)
+			}
+
+			slices.Sort(deleted)
+			if !slices.Equal(deleted, tt.deleted) {
+				t.Errorf("DeleteObjects() = %v, want %v", deleted, tt.deleted)
+			}
+			if keys := fake.keys("bucket"); !slices.Equal(keys, tt.kept) {
+				t.Errorf("DeleteObjects() left %v, want %v", keys, tt.kept)
+			}
+			if listed := fake.count("GET bucket/?") > 0; listed != (tt.options.Inventory == nil) {
+				t.Errorf("DeleteObjects() listed the bucket = %v, want %v", listed, tt.options.Inventory == nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.nrp-nautilus.io/humboldt/boto3-manager/strutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Directions a JobConfig can transfer in.
+const (
+	DirectionUpload   = "upload"
+	DirectionDownload = "download"
+	DirectionSyncUp   = "sync-up"
+	DirectionSyncDown = "sync-down"
+)
+
+// JobsFile is a file of declarative transfer jobs, read with LoadJobs, such as:
+//
+//	profiles:
+//	  nautilus:
+//	    endpoint: https://s3-west.nrp-nautilus.io
+//	    aws_profile: lab
+//	jobs:
+//	  - name: nightly-results
+//	    profile: nautilus
+//	    bucket: lab-data
+//	    direction: upload
+//	    patterns: ["~/results/**/*.csv"]
+//	    excludes: ["**/scratch/**/*"]
+//	    dest: results/${HOSTNAME}/
+//	    workers: 16
+//	    schedule: "0 2 * * *"
+type JobsFile struct {
+	Profiles map[string]ProfileConfig `yaml:"profiles"`
+	Jobs     []JobConfig              `yaml:"jobs"`
+}
+
+// ProfileConfig is a named endpoint and credentials in a jobs file, shared by the jobs that name it. The fields
+// are those of ClientOptions.
+type ProfileConfig struct {
+	Endpoint             string `yaml:"endpoint"`
+	Region               string `yaml:"region"`
+	AWSProfile           string `yaml:"aws_profile"`
+	Anonymous            bool   `yaml:"anonymous"`
+	RoleARN              string `yaml:"role_arn"`
+	ExternalID           string `yaml:"external_id"`
+	WebIdentityTokenFile string `yaml:"web_identity_token_file"`
+}
+
+// clientOptions returns the ClientOptions the profile describes.
+func (profile ProfileConfig) clientOptions() ClientOptions {
+	return ClientOptions{
+		Endpoint:             profile.Endpoint,
+		Region:               profile.Region,
+		Profile:              profile.AWSProfile,
+		Anonymous:            profile.Anonymous,
+		RoleARN:              profile.RoleARN,
+		ExternalID:           profile.ExternalID,
+		WebIdentityTokenFile: profile.WebIdentityTokenFile,
+	}
+}
+
+// JobConfig is a transfer described in a jobs file.
+type JobConfig struct {
+	Name string `yaml:"name"`
+
+	// Profile names the profile in the jobs file to connect with. If empty, the default AWS endpoints and
+	// credentials are used
+	Profile string `yaml:"profile"`
+
+	Bucket string `yaml:"bucket"`
+
+	// Direction is one of DirectionUpload, DirectionDownload, DirectionSyncUp, and DirectionSyncDown
+	Direction string `yaml:"direction"`
+
+	// Patterns are glob patterns for local files to upload or keys to download, each transferred to Dest as
+	// UploadObjects and DownloadObjects do. Downloads write each object to its key under Dest. A sync takes a
+	// single pattern, the local directory or prefix to sync from
+	Patterns []string `yaml:"patterns"`
+	Dest     string   `yaml:"dest"`
+
+	// Excludes are glob patterns for local paths or keys to leave out of an upload or download, such as
+	// "**/*.tmp". The paths of files matched by an absolute pattern are relative to the pattern's directory
+	Excludes []string `yaml:"excludes"`
+
+	// Workers, PartConcurrency, and PartSize pin the concurrency of an upload or download, as Concurrency does.
+	// PartSize is in bytes. Syncs don't take them
+	Workers         int   `yaml:"workers"`
+	PartConcurrency int   `yaml:"part_concurrency"`
+	PartSize        int64 `yaml:"part_size"`
+
+	// Mirror deletes what is missing from the source after a sync, as SyncOptions.Mirror does
+	Mirror bool `yaml:"mirror"`
+
+	// Trash is where a mirror moves what it deletes: a prefix for a sync up, as SyncOptions.TrashPrefix, or a
+	// directory for a sync down, as SyncOptions.TrashDir. A mirror needs one, since nobody is there to confirm
+	// the deletions
+	Trash string `yaml:"trash"`
+
+	// Schedule is when a Scheduler runs the job: a cron expression as ParseCron takes, or "@every" and a
+	// duration such as "@every 30m". Jobs without one are only run by hand
+	Schedule string `yaml:"schedule"`
+
+	// LockFile keeps runs of the job from overlapping, as JobOptions.LockFile does
+	LockFile string `yaml:"lock_file"`
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool `yaml:"quiet"`
+
+	// Client is the endpoint and credentials of the job's profile, filled in by LoadJobs
+	Client ClientOptions `yaml:"-"`
+}
+
+// LoadJobs reads the jobs in a YAML jobs file, described by JobsFile, and checks that each is complete. Unknown
+// fields are errors, so a misspelt option isn't silently ignored.
+func LoadJobs(path string) ([]JobConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var file JobsFile
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("couldn't parse jobs file %v: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(file.Jobs))
+	for i := range file.Jobs {
+		job := &file.Jobs[i]
+
+		if job.Profile != "" {
+			profile, ok := file.Profiles[job.Profile]
+			if !ok {
+				return nil, fmt.Errorf("job %v: unknown profile %q", job.Name, job.Profile)
+			}
+			job.Client = profile.clientOptions()
+		}
+
+		if names[job.Name] {
+			return nil, fmt.Errorf("job %v is defined twice", job.Name)
+		}
+		names[job.Name] = true
+
+		if err := job.validate(); err != nil {
+			return nil, fmt.Errorf("job %v: %w", job.Name, err)
+		}
+	}
+
+	return file.Jobs, nil
+}
+
+// validate checks that the job has everything it needs to run.
+func (job JobConfig) validate() error {
+	sync := job.Direction == DirectionSyncUp || job.Direction == DirectionSyncDown
+
+	switch {
+	case job.Name == "":
+		return errors.New("every job needs a name")
+	case job.Bucket == "":
+		return errors.New("no bucket")
+	case job.Direction != DirectionUpload && job.Direction != DirectionDownload && !sync:
+		return fmt.Errorf("unknown direction %q", job.Direction)
+	case len(job.Patterns) == 0:
+		return errors.New("no patterns")
+	case sync && len(job.Patterns) != 1:
+		return errors.New("a sync takes a single pattern")
+	case sync && len(job.Excludes) > 0:
+		return errors.New("excludes can't be used with a sync")
+	case sync && (job.Workers != 0 || job.PartConcurrency != 0 || job.PartSize != 0):
+		return errors.New("workers, part_concurrency, and part_size can't be used with a sync")
+	case job.Mirror && !sync:
+		return errors.New("mirror can only be used with a sync")
+	case job.Mirror && job.Trash == "":
+		return errors.New("a mirror needs a trash")
+	}
+
+	if _, err := job.excluded(); err != nil {
+		return err
+	}
+
+	if job.Schedule != "" {
+		if _, err := parseJobSchedule(job.Schedule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseJobSchedule parses a cron expression or "@every" and a duration.
+func parseJobSchedule(expr string) (Schedule, error) {
+	if every, ok := strings.CutPrefix(strings.TrimSpace(expr), "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q", expr)
+		}
+		return Every(interval), nil
+	}
+
+	return ParseCron(expr)
+}
+
+// excluded returns a function reporting whether a path or key matches any of the job's excludes, or an error if
+// an exclude can't be compiled.
+func (job JobConfig) excluded() (func(name string) bool, error) {
+	patterns := make([]*regexp.Regexp, 0, len(job.Excludes))
+	for _, exclude := range job.Excludes {
+		re, err := regexp.Compile(strutil.WildCardToRegexp(exclude))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude %q: %w", exclude, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	return func(name string) bool {
+		return slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool { return re.MatchString(name) })
+	}, nil
+}
+
+// RunJob runs a job from a jobs file once, returning the combined report of its patterns. A pattern that fails to
+// start stops the job.
+func RunJob(ctx context.Context, job JobConfig) (*TransferReport, error) {
+	basics, err := NewBucketBasics(job.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded, err := job.excluded()
+	if err != nil {
+		return nil, err
+	}
+	concurrency := Concurrency{Workers: job.Workers, PartConcurrency: job.PartConcurrency, PartSize: job.PartSize}
+
+	// Syncs expand their local directory and prefix as uploads and downloads do
+	switch job.Direction {
+	case DirectionSyncUp:
+		localDir, err := expandLocalPath(job.Patterns[0])
+		if err != nil {
+			return nil, err
+		}
+		prefix, err := expandVars(job.Dest)
+		if err != nil {
+			return nil, err
+		}
+		trashPrefix, err := expandVars(job.Trash)
+		if err != nil {
+			return nil, err
+		}
+		return basics.SyncUpWithContext(ctx, localDir, prefix, job.Bucket, SyncOptions{Mirror: job.Mirror, TrashPrefix: trashPrefix, Quiet: job.Quiet})
+	case DirectionSyncDown:
+		prefix, err := expandVars(job.Patterns[0])
+		if err != nil {
+			return nil, err
+		}
+		localDir, err := expandLocalPath(job.Dest)
+		if err != nil {
+			return nil, err
+		}
+		trashDir, err := expandLocalPath(job.Trash)
+		if err != nil {
+			return nil, err
+		}
+		return basics.SyncDownWithContext(ctx, prefix, localDir, job.Bucket, SyncOptions{Mirror: job.Mirror, TrashDir: trashDir, Quiet: job.Quiet})
+	}
+
+	report := &TransferReport{}
+	for _, pattern := range job.Patterns {
+		var patternReport *TransferReport
+		if job.Direction == DirectionUpload {
+			// Key files as UploadObjects would, from the pattern it ends up with once expanded and rooted
+			pattern, err = expandLocalPath(pattern)
+			if err != nil {
+				return report, err
+			}
+
+			_, rel := splitAbsPattern(localPattern(pattern))
+			keys := RelativeKeys(rel)
+			patternReport, err = basics.UploadObjectsWithContext(ctx, pattern, job.Dest, job.Bucket, UploadObjectsOptions{
+				KeyMapper: func(localPath string) (string, bool) {
+					if excluded(localPath) {
+						return "", true
+					}
+					return keys(localPath)
+				},
+				Concurrency: concurrency,
+				Quiet:       job.Quiet,
+			})
+		} else {
+			patternReport, err = basics.DownloadObjectsWithContext(ctx, pattern, job.Dest, job.Bucket, DownloadObjectsOptions{
+				DestMapper: func(key string) (string, bool) {
+					return filepath.FromSlash(key), excluded(key)
+				},
+				Concurrency: concurrency,
+				Quiet:       job.Quiet,
+			})
+		}
+
+		if patternReport != nil {
+			report.merge(patternReport)
+		}
+
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// AddJobs adds the jobs with a schedule to the scheduler, each run with RunJob.
+func (scheduler *Scheduler) AddJobs(jobs []JobConfig) error {
+	for _, job := range jobs {
+		if job.Schedule == "" {
+			continue
+		}
+
+		schedule, err := parseJobSchedule(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("job %v: %w", job.Name, err)
+		}
+
+		scheduler.Add(job.Name, schedule, func(ctx context.Context) (*TransferReport, error) {
+			return RunJob(ctx, job)
+		}, JobOptions{LockFile: job.LockFile})
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package boto3manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadJobs(t *testing.T) {
+	t.Parallel()
+
+	const valid = `
+profiles:
+  west:
+    endpoint: https://s3-west.nrp-nautilus.io
+    aws_profile: lab
+jobs:
+  - name: results
+    profile: west
+    bucket: lab-data
+    direction: upload
+    patterns: ["results/**/*.csv"]
+    excludes: ["**/scratch/**/*"]
+    dest: results/
+    workers: 16
+    schedule: "@every 30m"
+  - name: mirror
+    bucket: lab-data
+    direction: sync-down
+    patterns: [results/]
+    dest: /srv/results
+    mirror: true
+    trash: /srv/trash
+`
+
+	tests := []struct {
+		name      string
+		file      string
+		wanted    []JobConfig
+		wantedErr string
+	}{
+		{
+			name: "valid",
+			file: valid,
+			wanted: []JobConfig{
+				{
+					Name: "results", Profile: "west", Bucket: "lab-data", Direction: DirectionUpload,
+					Patterns: []string{"results/**/*.csv"}, Excludes: []string{"**/scratch/**/*"}, Dest: "results/",
+					Workers: 16, Schedule: "@every 30m",
+					Client: ClientOptions{Endpoint: "https://s3-west.nrp-nautilus.io", Profile: "lab"},
+				},
+				{
+					Name: "mirror", Bucket: "lab-data", Direction: DirectionSyncDown,
+					Patterns: []string{"results/"}, Dest: "/srv/results", Mirror: true, Trash: "/srv/trash",
+				},
+			},
+		},
+		{name: "unknown field", file: "jobs:\n  - name: a\n    buckt: b\n", wantedErr: "buckt"},
+		{name: "unknown profile", file: "jobs:\n  - {name: a, profile: east, bucket: b, direction: upload, patterns: [x]}\n", wantedErr: "unknown profile"},
+		{name: "duplicate", file: "jobs:\n  - {name: a, bucket: b, direction: upload, patterns: [x]}\n  - {name: a, bucket: b, direction: upload, patterns: [y]}\n", wantedErr: "defined twice"},
+		{name: "direction", file: "jobs:\n  - {name: a, bucket: b, direction: sideways, patterns: [x]}\n", wantedErr: "unknown direction"},
+		{name: "sync excludes", file: "jobs:\n  - {name: a, bucket: b, direction: sync-up, patterns: [x], excludes: [y]}\n", wantedErr: "excludes"},
+		{name: "invalid exclude", file: "jobs:\n  - {name: a, bucket: b, direction: upload, patterns: [x], excludes: ['src/c++/**/*']}\n", wantedErr: "invalid exclude"},
+		{name: "sync workers", file: "jobs:\n  - {name: a, bucket: b, direction: sync-down, patterns: [x/], dest: y, workers: 4}\n", wantedErr: "workers"},
+		{name: "mirror without trash", file: "jobs:\n  - {name: a, bucket: b, direction: sync-down, patterns: [x/], dest: y, mirror: true}\n", wantedErr: "trash"},
+		{name: "mirror upload", file: "jobs:\n  - {name: a, bucket: b, direction: upload, patterns: [x], mirror: true, trash: t/}\n", wantedErr: "sync"},
+		{name: "schedule", file: "jobs:\n  - {name: a, bucket: b, direction: upload, patterns: [x], schedule: '@every soon'}\n", wantedErr: "schedule"},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "jobs.yaml")
+		if err := os.WriteFile(path, []byte(test.file), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := LoadJobs(path)
+		if test.wantedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantedErr) {
+				t.Errorf("LoadJobs(%v) = %v, want error containing %q", test.name, err, test.wantedErr)
+			}
+			continue
+		}
+
+		if err != nil || !reflect.DeepEqual(got, test.wanted) {
+			t.Errorf("LoadJobs(%v) = %+v, %v, want %+v", test.name, got, err, test.wanted)
+		}
+	}
+}
+
+func TestRunJobSyncExpandsPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("RUN", "2024")
+
+	fake := newFakeS3(t, "lab-data")
+	fake.put("lab-data", "results/2024/a.csv", "1,2", nil)
+
+	job := JobConfig{
+		Name:      "backup",
+		Bucket:    "lab-data",
+		Direction: DirectionSyncDown,
+		Patterns:  []string{"results/${RUN}/"},
+		Dest:      "~/backup",
+		Quiet:     true,
+		Client:    ClientOptions{Endpoint: fake.server.URL, Anonymous: true},
+	}
+
+	if _, err := RunJob(context.Background(), job); err != nil {
+		t.Fatalf("RunJob() = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(home, "backup", "a.csv"))
+	if err != nil || string(got) != "1,2" {
+		t.Errorf("RunJob() wrote ~/backup/a.csv = %q, %v, want %q", got, err, "1,2")
+	}
+}
@@ -0,0 +1,303 @@
+package boto3manager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// KeyListEntry is a key listed in a manifest for DownloadFromManifest, with the checksum it should have if the
+// manifest gives one.
+type KeyListEntry struct {
+	Key string
+
+	// Checksum is the hex encoded SHA-256 or MD5 checksum of the object, told apart by length. It may be empty
+	Checksum string
+}
+
+// isChecksum reports whether s is a hex encoded SHA-256 or MD5 checksum.
+func isChecksum(s string) bool {
+	if len(s) != 64 && len(s) != 32 {
+		return false
+	}
+
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// ParseKeyList reads the keys listed in a manifest. The format is picked by the name's extension:
+//
+//   - .json: an array of keys, an array of objects with a "key" and optionally a "sha256", "md5", or "checksum",
+//     or a delivery manifest written with DeliveryManifest
+//   - .csv: a key and optionally a checksum on each row, or columns named by a header row with a "key" column
+//   - anything else: a key on each line, or sha256sum or md5sum output. Blank lines and lines starting with "#"
+//     are ignored
+func ParseKeyList(r io.Reader, name string) ([]KeyListEntry, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return parseKeyListJSON(r)
+	case ".csv":
+		return parseKeyListCSV(r)
+	default:
+		return parseKeyListLines(r)
+	}
+}
+
+// parseKeyListLines reads a key, or a checksum and a key as written by sha256sum, from each line.
+func parseKeyListLines(r io.Reader) ([]KeyListEntry, error) {
+	entries := make([]KeyListEntry, 0)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// sha256sum separates the checksum with two spaces, or a space and "*" in binary mode
+		if sum, key, ok := strings.Cut(line, " "); ok && isChecksum(sum) && (strings.HasPrefix(key, " ") || strings.HasPrefix(key, "*")) {
+			entries = append(entries, KeyListEntry{Key: key[1:], Checksum: strings.ToLower(sum)})
+			continue
+		}
+
+		entries = append(entries, KeyListEntry{Key: line})
+	}
+
+	return entries, scanner.Err()
+}
+
+// parseKeyListCSV reads a key and an optional checksum from each row, using the header row's column names if the
+// first row has a "key" column.
+func parseKeyListCSV(r io.Reader) ([]KeyListEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	keyColumn, sumColumn := 0, 1
+	if len(rows) > 0 {
+		header := make([]string, len(rows[0]))
+		for i, name := range rows[0] {
+			header[i] = strings.ToLower(strings.TrimSpace(name))
+		}
+
+		if i := slices.Index(header, "key"); i >= 0 {
+			keyColumn, sumColumn = i, -1
+			for _, name := range []string{"sha256", "md5", "checksum"} {
+				if j := slices.Index(header, name); j >= 0 {
+					sumColumn = j
+					break
+				}
+			}
+			rows = rows[1:]
+		}
+	}
+
+	entries := make([]KeyListEntry, 0, len(rows))
+	for _, row := range rows {
+		if keyColumn >= len(row) || row[keyColumn] == "" {
+			continue
+		}
+
+		entry := KeyListEntry{Key: row[keyColumn]}
+		if sumColumn >= 0 && sumColumn < len(row) && isChecksum(strings.TrimSpace(row[sumColumn])) {
+			entry.Checksum = strings.ToLower(strings.TrimSpace(row[sumColumn]))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// keyListObject is an entry of a JSON key list.
+type keyListObject struct {
+	Key      string `json:"key"`
+	SHA256   string `json:"sha256"`
+	MD5      string `json:"md5"`
+	Checksum string `json:"checksum"`
+}
+
+// parseKeyListJSON reads an array of keys, an array of objects with keys, or a delivery manifest.
+func parseKeyListJSON(r io.Reader) ([]KeyListEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err == nil {
+		entries := make([]KeyListEntry, 0, len(keys))
+		for _, key := range keys {
+			entries = append(entries, KeyListEntry{Key: key})
+		}
+		return entries, nil
+	}
+
+	var objects []keyListObject
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var manifest struct {
+			Files []keyListObject `json:"files"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, err
+		}
+		objects = manifest.Files
+	} else if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, err
+	}
+
+	entries := make([]KeyListEntry, 0, len(objects))
+	for _, object := range objects {
+		if object.Key == "" {
+			return nil, errors.New("key list entry has no key")
+		}
+
+		entry := KeyListEntry{Key: object.Key}
+		for _, sum := range []string{object.SHA256, object.MD5, object.Checksum} {
+			if isChecksum(sum) {
+				entry.Checksum = strings.ToLower(sum)
+				break
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+type DownloadFromManifestOptions struct {
+	// Prefix is put in front of every key in the manifest, for manifests listing keys relative to a dataset's
+	// prefix, such as delivery manifests
+	Prefix string
+
+	// RetryPolicy controls retries of each object, including downloads that fail verification. If nil,
+	// DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Hooks, if set, are called as objects start and finish
+	Hooks *Hooks
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+}
+
+// DownloadFromManifest takes the path of a local manifest listing keys, a destination directory, and a bucket name
+// and downloads exactly the objects listed, each to its key under the destination. The manifest's format is
+// picked by its extension, as ParseKeyList describes. Objects the manifest gives a checksum for are hashed once
+// downloaded and fail with ErrChecksumMismatch, and are downloaded again, if they don't match. A key that would be
+// written outside the destination, such as one with a ".." in it, fails the download before it starts.
+func (basics BucketBasics) DownloadFromManifest(manifestPath string, dest string, bucketName string, options DownloadFromManifestOptions) (*TransferReport, error) {
+	return basics.DownloadFromManifestWithContext(context.Background(), manifestPath, dest, bucketName, options)
+}
+
+// DownloadFromManifestWithContext is DownloadFromManifest with a context.
+func (basics BucketBasics) DownloadFromManifestWithContext(ctx context.Context, manifestPath string, dest string, bucketName string, options DownloadFromManifestOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+	basics.detectRegions(ctx, bucketName)
+
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		log.Printf("Couldn't open manifest %v: %v", manifestPath, err)
+		return nil, err
+	}
+
+	entries, err := ParseKeyList(f, manifestPath)
+	f.Close()
+
+	if err != nil {
+		log.Printf("Couldn't parse manifest %v: %v", manifestPath, err)
+		return nil, err
+	}
+
+	checksums := make(map[string]string, len(entries))
+	downloads := make([]FileDownload, 0, len(entries))
+	for _, entry := range entries {
+		key := options.Prefix + entry.Key
+
+		// The manifest may come from anywhere, so a key can't be let write outside the destination
+		path := localKeyPath(key)
+		if !filepath.IsLocal(path) {
+			log.Printf("Couldn't download %v, which would be written outside %v", key, dest)
+			return nil, fmt.Errorf("key %q in manifest %v would be written outside %v", key, manifestPath, dest)
+		}
+
+		checksums[key] = entry.Checksum
+		downloads = append(downloads, FileDownload{Key: key, Destination: filepath.Join(dest, path)})
+	}
+
+	// The sizes aren't known without a request for each, so progress is counted in objects
+	bar := silentBar(int64(len(downloads)))
+	if !options.Quiet {
+		bar = newCountBar(int64(len(downloads)), "downloading")
+	}
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  defaultWorkers(50),
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		hooks:        options.Hooks,
+		backpressure: basics.Backpressure,
+	}
+
+	downloader := basics.newDownloader(bucketName)
+	err = runBatch(ctx, downloads, config, func(ctx context.Context, file FileDownload) error {
+		// Name the file after its destination, so the file checked is the one written
+		err := basics.DownloadObjectWithContext(ctx, file.Key, filepath.Dir(file.Destination), bucketName, DownloadObjectOptions{Quiet: options.Quiet, downloader: downloader, name: filepath.Base(file.Destination)})
+		if err != nil {
+			return err
+		}
+
+		if sum := checksums[file.Key]; sum != "" {
+			if err := verifyFileChecksum(file.Destination, sum); err != nil {
+				os.Remove(file.Destination)
+				return err
+			}
+		}
+
+		bar.Add(1)
+		return nil
+	})
+
+	bar.Finish()
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	return report, err
+}
+
+// verifyFileChecksum checks a local file against a hex encoded SHA-256 or MD5 checksum.
+func verifyFileChecksum(path string, want string) error {
+	hash := sha256File
+	if len(want) == 32 {
+		hash = md5File
+	}
+
+	sum, err := hash(osFS{}, path)
+	if err != nil {
+		return err
+	}
+
+	if sum != want {
+		return fmt.Errorf("%w: checksum of %v is %v, manifest says %v", ErrChecksumMismatch, path, sum, want)
+	}
+
+	return nil
+}
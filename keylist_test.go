@@ -0,0 +1,128 @@
+package boto3manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeyList(t *testing.T) {
+	t.Parallel()
+
+	const sha = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	const md5 = "5d41402abc4b2a76b9719d911017c592"
+
+	tests := []struct {
+		name     string
+		manifest string
+		wanted   []KeyListEntry
+	}{
+		{
+			name:     "keys.txt",
+			manifest: "# reviewer picks\ndata/a.csv\n\ndata/b c.csv\r\n",
+			wanted:   []KeyListEntry{{Key: "data/a.csv"}, {Key: "data/b c.csv"}},
+		},
+		{
+			name:     "SHA256SUMS",
+			manifest: sha + "  data/a.csv\n" + md5 + " *data/b.csv\n",
+			wanted:   []KeyListEntry{{Key: "data/a.csv", Checksum: sha}, {Key: "data/b.csv", Checksum: md5}},
+		},
+		{
+			name:     "keys.csv",
+			manifest: "data/a.csv," + sha + "\ndata/b.csv\n",
+			wanted:   []KeyListEntry{{Key: "data/a.csv", Checksum: sha}, {Key: "data/b.csv"}},
+		},
+		{
+			name:     "keys.CSV",
+			manifest: "size,Key,SHA256\n5,data/a.csv," + strings.ToUpper(sha) + "\n",
+			wanted:   []KeyListEntry{{Key: "data/a.csv", Checksum: sha}},
+		},
+		{
+			name:     "keys.json",
+			manifest: `["data/a.csv", "data/b.csv"]`,
+			wanted:   []KeyListEntry{{Key: "data/a.csv"}, {Key: "data/b.csv"}},
+		},
+		{
+			name:     "objects.json",
+			manifest: `[{"key": "data/a.csv", "md5": "` + md5 + `"}, {"key": "data/b.csv"}]`,
+			wanted:   []KeyListEntry{{Key: "data/a.csv", Checksum: md5}, {Key: "data/b.csv"}},
+		},
+		{
+			name:     DeliveryManifestName,
+			manifest: `{"tool": "boto3-manager", "files": [{"key": "a.csv", "size": 5, "sha256": "` + sha + `"}]}`,
+			wanted:   []KeyListEntry{{Key: "a.csv", Checksum: sha}},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseKeyList(strings.NewReader(test.manifest), test.name)
+		if err != nil || !reflect.DeepEqual(got, test.wanted) {
+			t.Errorf("ParseKeyList(%v) = %v, %v, want %v", test.name, got, err, test.wanted)
+		}
+	}
+}
+
+func TestDownloadFromManifest(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "data/a.txt", "hello", nil)
+	fake.put("bucket", "data/sub/b.txt", "changed", nil)
+
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "SHA256SUMS")
+	sums := sha256Hex("hello") + "  a.txt\n" + sha256Hex("original") + "  sub/b.txt\n"
+	if err := os.WriteFile(manifest, []byte(sums), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(dir, "out")
+	options := DownloadFromManifestOptions{Prefix: "data/", RetryPolicy: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}, Quiet: true}
+	report, err := fake.basics().DownloadFromManifest(manifest, dest, "bucket", options)
+	if err != nil {
+		t.Fatalf("DownloadFromManifest() = %v, want nil", err)
+	}
+
+	if got, _ := os.ReadFile(filepath.Join(dest, "data", "a.txt")); string(got) != "hello" {
+		t.Errorf("DownloadFromManifest() wrote a.txt as %q, want %q", got, "hello")
+	}
+
+	// The object that doesn't match is downloaded again, then fails and is removed
+	failed := failedKeys(report)
+	if len(failed) != 1 || !errors.Is(failed["data/sub/b.txt"], ErrChecksumMismatch) {
+		t.Errorf("DownloadFromManifest() failed %v, want data/sub/b.txt with a checksum mismatch", failed)
+	}
+	if got := fake.count("GET bucket/data/sub/b.txt"); got != 2 {
+		t.Errorf("DownloadFromManifest() got data/sub/b.txt %v times, want 2", got)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "data", "sub", "b.txt")); err == nil {
+		t.Errorf("DownloadFromManifest() kept data/sub/b.txt, which doesn't match the manifest")
+	}
+}
+
+func TestDownloadFromManifestOutsideDestination(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "keys.txt")
+	if err := os.WriteFile(manifest, []byte("../escaped.txt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(dir, "out", "nested")
+	if _, err := fake.basics().DownloadFromManifest(manifest, dest, "bucket", DownloadFromManifestOptions{Quiet: true}); err == nil {
+		t.Errorf("DownloadFromManifest() of ../escaped.txt = nil error, want an error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "escaped.txt")); err == nil {
+		t.Errorf("DownloadFromManifest() wrote escaped.txt outside %v", dest)
+	}
+	if got := fake.count("GET "); got != 0 {
+		t.Errorf("DownloadFromManifest() made %v GET requests, want none", got)
+	}
+}
@@ -0,0 +1,64 @@
+package boto3manager
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// KeyMapper maps the path of a local file, slash separated and relative to the file system uploaded from, to the
+// key it is uploaded to under the destination. Files it returns skip for aren't uploaded.
+type KeyMapper func(localPath string) (key string, skip bool)
+
+// patternDir returns the directory of a glob pattern's literal prefix, ending in "/", or "" if it has none.
+func patternDir(pattern string) string {
+	literal := pattern
+	if i := strings.IndexAny(pattern, "*?"); i != -1 {
+		literal = pattern[:i]
+	}
+
+	return literal[:strings.LastIndex(literal, "/")+1]
+}
+
+// RelativeKeys returns the default KeyMapper of an upload of pattern, keying each file by its path relative to
+// the directory of the pattern's literal prefix, so "data/**/*.csv" keys data/2024/a.csv as 2024/a.csv and
+// "data/a.csv" keys it as a.csv.
+func RelativeKeys(pattern string) KeyMapper {
+	dir := patternDir(pattern)
+	return func(localPath string) (string, bool) {
+		return strings.TrimPrefix(localPath, dir), false
+	}
+}
+
+// PreserveTreeKeys keys each file by its full path, keeping the whole directory tree.
+func PreserveTreeKeys(localPath string) (string, bool) {
+	return localPath, false
+}
+
+// FlattenKeys keys each file by its base name, dropping its directories. Files with the same name in different
+// directories are uploaded to the same key.
+func FlattenKeys(localPath string) (string, bool) {
+	return path.Base(localPath), false
+}
+
+// PrefixKeys returns a KeyMapper that puts prefix in front of the keys mapper returns.
+func PrefixKeys(prefix string, mapper KeyMapper) KeyMapper {
+	return func(localPath string) (string, bool) {
+		key, skip := mapper(localPath)
+		return prefix + key, skip
+	}
+}
+
+// LowercaseKeys returns a KeyMapper that lowercases the keys mapper returns.
+func LowercaseKeys(mapper KeyMapper) KeyMapper {
+	return func(localPath string) (string, bool) {
+		key, skip := mapper(localPath)
+		return strings.ToLower(key), skip
+	}
+}
+
+// DatePartitionedKeys returns a KeyMapper that puts the keys mapper returns under a Hive-style partition for the
+// date, e.g. "dt=2024-05-01/", so query engines can prune by date. The date is taken in UTC.
+func DatePartitionedKeys(date time.Time, mapper KeyMapper) KeyMapper {
+	return PrefixKeys("dt="+date.UTC().Format(time.DateOnly)+"/", mapper)
+}
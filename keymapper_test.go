@@ -0,0 +1,34 @@
+package boto3manager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyMappers(t *testing.T) {
+	t.Parallel()
+
+	date := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		mapper KeyMapper
+		path   string
+		wanted string
+	}{
+		{name: "relative", mapper: RelativeKeys("data/**/*.csv"), path: "data/2024/a.csv", wanted: "2024/a.csv"},
+		{name: "relative without wildcard", mapper: RelativeKeys("data/a.csv"), path: "data/a.csv", wanted: "a.csv"},
+		{name: "relative to current directory", mapper: RelativeKeys("*.csv"), path: "a.csv", wanted: "a.csv"},
+		{name: "preserve tree", mapper: PreserveTreeKeys, path: "data/2024/a.csv", wanted: "data/2024/a.csv"},
+		{name: "flatten", mapper: FlattenKeys, path: "data/2024/a.csv", wanted: "a.csv"},
+		{name: "prefix", mapper: PrefixKeys("raw/", FlattenKeys), path: "data/a.csv", wanted: "raw/a.csv"},
+		{name: "lowercase", mapper: LowercaseKeys(PreserveTreeKeys), path: "Data/A.CSV", wanted: "data/a.csv"},
+		{name: "date partitioned", mapper: DatePartitionedKeys(date.In(time.FixedZone("PDT", -7*3600)), FlattenKeys), path: "data/a.csv", wanted: "dt=2024-05-01/a.csv"},
+	}
+
+	for _, test := range tests {
+		if got, skip := test.mapper(test.path); got != test.wanted || skip {
+			t.Errorf("%v(%v) = %v, %v, want %v, false", test.name, test.path, got, skip, test.wanted)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package boto3manager
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// LifecycleRule is a simplified lifecycle rule applying to every object under a prefix. Zero values mean the action
+// is not part of the rule. Build one with NewLifecycleRule and its methods, e.g.
+//
+//	NewLifecycleRule("scratch/").Expire(30).AbortIncompleteUploads(7)
+type LifecycleRule struct {
+	ID     string
+	Prefix string
+
+	// ExpireDays deletes objects this many days after they are created
+	ExpireDays int32
+
+	// AbortIncompleteDays aborts multipart uploads this many days after they are started
+	AbortIncompleteDays int32
+
+	// TransitionDays moves objects to TransitionStorageClass this many days after they are created
+	TransitionDays         int32
+	TransitionStorageClass string
+
+	Disabled bool
+}
+
+// NewLifecycleRule returns an enabled rule for prefix with no actions, named after the prefix.
+func NewLifecycleRule(prefix string) LifecycleRule {
+	id := prefix
+	if id == "" {
+		id = "whole-bucket"
+	}
+
+	return LifecycleRule{ID: id, Prefix: prefix}
+}
+
+// Expire returns the rule with objects deleted days after they are created.
+func (rule LifecycleRule) Expire(days int32) LifecycleRule {
+	rule.ExpireDays = days
+	return rule
+}
+
+// AbortIncompleteUploads returns the rule with multipart uploads aborted days after they are started.
+func (rule LifecycleRule) AbortIncompleteUploads(days int32) LifecycleRule {
+	rule.AbortIncompleteDays = days
+	return rule
+}
+
+// Transition returns the rule with objects moved to storageClass days after they are created.
+func (rule LifecycleRule) Transition(days int32, storageClass string) LifecycleRule {
+	rule.TransitionDays = days
+	rule.TransitionStorageClass = storageClass
+	return rule
+}
+
+// toSDK converts the rule to the SDK's representation.
+func (rule LifecycleRule) toSDK() types.LifecycleRule {
+	sdkRule := types.LifecycleRule{
+		ID:     aws.String(rule.ID),
+		Filter: &types.LifecycleRuleFilterMemberPrefix{Value: rule.Prefix},
+		Status: types.ExpirationStatusEnabled,
+	}
+
+	if rule.Disabled {
+		sdkRule.Status = types.ExpirationStatusDisabled
+	}
+
+	if rule.ExpireDays > 0 {
+		sdkRule.Expiration = &types.LifecycleExpiration{Days: aws.Int32(rule.ExpireDays)}
+	}
+
+	if rule.AbortIncompleteDays > 0 {
+		sdkRule.AbortIncompleteMultipartUpload = &types.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int32(rule.AbortIncompleteDays)}
+	}
+
+	if rule.TransitionDays > 0 {
+		sdkRule.Transitions = []types.Transition{{
+			Days:         aws.Int32(rule.TransitionDays),
+			StorageClass: types.TransitionStorageClass(rule.TransitionStorageClass),
+		}}
+	}
+
+	return sdkRule
+}
+
+// lifecycleRuleFromSDK converts a rule from the SDK's representation. Only the parts a LifecycleRule can express
+// are kept.
+func lifecycleRuleFromSDK(sdkRule types.LifecycleRule) LifecycleRule {
+	rule := LifecycleRule{
+		ID:       aws.ToString(sdkRule.ID),
+		Prefix:   aws.ToString(sdkRule.Prefix),
+		Disabled: sdkRule.Status == types.ExpirationStatusDisabled,
+	}
+
+	if prefix, ok := sdkRule.Filter.(*types.LifecycleRuleFilterMemberPrefix); ok {
+		rule.Prefix = prefix.Value
+	}
+
+	if sdkRule.Expiration != nil {
+		rule.ExpireDays = aws.ToInt32(sdkRule.Expiration.Days)
+	}
+
+	if sdkRule.AbortIncompleteMultipartUpload != nil {
+		rule.AbortIncompleteDays = aws.ToInt32(sdkRule.AbortIncompleteMultipartUpload.DaysAfterInitiation)
+	}
+
+	if len(sdkRule.Transitions) > 0 {
+		rule.TransitionDays = aws.ToInt32(sdkRule.Transitions[0].Days)
+		rule.TransitionStorageClass = string(sdkRule.Transitions[0].StorageClass)
+	}
+
+	return rule
+}
+
+// GetLifecycle takes a bucket name and returns its lifecycle rules.
+func (basics BucketBasics) GetLifecycle(bucketName string) ([]LifecycleRule, error) {
+	output, err := basics.client(bucketName).GetBucketLifecycleConfiguration(context.TODO(), &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get lifecycle configuration of bucket %v: %v", bucketName, err)
+		return nil, err
+	}
+
+	rules := make([]LifecycleRule, 0, len(output.Rules))
+	for _, rule := range output.Rules {
+		rules = append(rules, lifecycleRuleFromSDK(rule))
+	}
+
+	return rules, nil
+}
+
+// PutLifecycle takes a bucket name and lifecycle rules and replaces the bucket's lifecycle configuration.
+func (basics BucketBasics) PutLifecycle(bucketName string, rules []LifecycleRule) error {
+	sdkRules := make([]types.LifecycleRule, 0, len(rules))
+	for _, rule := range rules {
+		sdkRules = append(sdkRules, rule.toSDK())
+	}
+
+	_, err := basics.client(bucketName).PutBucketLifecycleConfiguration(context.TODO(), &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucketName),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: sdkRules},
+	})
+
+	if err != nil {
+		log.Printf("Couldn't put lifecycle configuration on bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// DeleteLifecycle takes a bucket name and removes its lifecycle configuration.
+func (basics BucketBasics) DeleteLifecycle(bucketName string) error {
+	_, err := basics.client(bucketName).DeleteBucketLifecycle(context.TODO(), &s3.DeleteBucketLifecycleInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't delete lifecycle configuration of bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
@@ -0,0 +1,35 @@
+package boto3manager
+
+import (
+	"testing"
+)
+
+func TestLifecycleRuleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule LifecycleRule
+	}{
+		{
+			name: "expire and abort",
+			rule: NewLifecycleRule("scratch/").Expire(30).AbortIncompleteUploads(7),
+		},
+		{
+			name: "transition",
+			rule: NewLifecycleRule("archive/").Transition(90, "GLACIER"),
+		},
+		{
+			name: "disabled whole bucket",
+			rule: LifecycleRule{ID: "whole-bucket", ExpireDays: 1, Disabled: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lifecycleRuleFromSDK(tt.rule.toSDK()); got != tt.rule {
+				t.Errorf("lifecycleRuleFromSDK(toSDK(%+v)) = %+v", tt.rule, got)
+			}
+		})
+	}
+}
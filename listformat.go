@@ -0,0 +1,162 @@
+package boto3manager
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ListSortField is what ListObjectsSorted orders objects by.
+type ListSortField int
+
+const (
+	SortByKey ListSortField = iota
+	SortBySize
+	SortByLastModified
+)
+
+// ListSort orders a listing. Ties are broken by key, so the order is stable between runs.
+type ListSort struct {
+	By         ListSortField
+	Descending bool
+}
+
+// ListingFormat is the format WriteListing writes.
+type ListingFormat int
+
+const (
+	// ListingLong is a row per object like ls -l: size, last modified time, storage class, and key
+	ListingLong ListingFormat = iota
+	// ListingJSON is an indented JSON array of objects
+	ListingJSON
+	// ListingCSV is comma separated values with a header row
+	ListingCSV
+)
+
+// ListObjectsSorted takes a bucket name and a prefix and lists the objects under the prefix in the given order.
+func (basics BucketBasics) ListObjectsSorted(bucketName string, prefix string, order ListSort) ([]types.Object, error) {
+	objects := make([]types.Object, 0)
+	for object, err := range basics.listObjectsSeq(context.TODO(), bucketName, prefix) {
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, object)
+	}
+
+	sortObjects(objects, order)
+	return objects, nil
+}
+
+// sortObjects sorts objects in place in the given order.
+func sortObjects(objects []types.Object, order ListSort) {
+	slices.SortStableFunc(objects, func(a, b types.Object) int {
+		var c int
+		switch order.By {
+		case SortBySize:
+			c = cmp.Compare(aws.ToInt64(a.Size), aws.ToInt64(b.Size))
+		case SortByLastModified:
+			c = aws.ToTime(a.LastModified).Compare(aws.ToTime(b.LastModified))
+		}
+
+		c = cmp.Or(c, strings.Compare(aws.ToString(a.Key), aws.ToString(b.Key)))
+		if order.Descending {
+			return -c
+		}
+		return c
+	})
+}
+
+// jsonObject is an object as it appears in a JSON listing.
+type jsonObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	ETag         string    `json:"etag"`
+	StorageClass string    `json:"storage_class"`
+}
+
+// WriteListing writes objects to w in the given format.
+func WriteListing(w io.Writer, objects []types.Object, format ListingFormat) error {
+	switch format {
+	case ListingLong:
+		return writeLongListing(w, objects)
+	case ListingJSON:
+		return writeJSONListing(w, objects)
+	case ListingCSV:
+		return writeCSVListing(w, objects)
+	default:
+		return fmt.Errorf("unsupported listing format %v", format)
+	}
+}
+
+// writeLongListing writes a row per object with the sizes right aligned.
+func writeLongListing(w io.Writer, objects []types.Object) error {
+	var width int
+	for _, object := range objects {
+		width = max(width, len(strconv.FormatInt(aws.ToInt64(object.Size), 10)))
+	}
+
+	for _, object := range objects {
+		_, err := fmt.Fprintf(w, "%*d  %v  %-12v  %v\n",
+			width, aws.ToInt64(object.Size), aws.ToTime(object.LastModified).UTC().Format(time.DateTime), object.StorageClass, aws.ToString(object.Key))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeJSONListing(w io.Writer, objects []types.Object) error {
+	out := make([]jsonObject, 0, len(objects))
+	for _, object := range objects {
+		out = append(out, jsonObject{
+			Key:          aws.ToString(object.Key),
+			Size:         aws.ToInt64(object.Size),
+			LastModified: aws.ToTime(object.LastModified),
+			ETag:         strings.Trim(aws.ToString(object.ETag), `"`),
+			StorageClass: string(object.StorageClass),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+func writeCSVListing(w io.Writer, objects []types.Object) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"key", "size", "last_modified", "etag", "storage_class"}); err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		err := writer.Write([]string{
+			aws.ToString(object.Key),
+			strconv.FormatInt(aws.ToInt64(object.Size), 10),
+			aws.ToTime(object.LastModified).UTC().Format(time.RFC3339),
+			strings.Trim(aws.ToString(object.ETag), `"`),
+			string(object.StorageClass),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
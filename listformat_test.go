@@ -0,0 +1,86 @@
+package boto3manager
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestSortObjects(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	objects := []types.Object{
+		{Key: aws.String("b"), Size: aws.Int64(10), LastModified: aws.Time(day)},
+		{Key: aws.String("a"), Size: aws.Int64(30), LastModified: aws.Time(day.Add(time.Hour))},
+		{Key: aws.String("c"), Size: aws.Int64(10), LastModified: aws.Time(day.Add(-time.Hour))},
+	}
+
+	tests := []struct {
+		order  ListSort
+		wanted []string
+	}{
+		{order: ListSort{By: SortByKey}, wanted: []string{"a", "b", "c"}},
+		{order: ListSort{By: SortByKey, Descending: true}, wanted: []string{"c", "b", "a"}},
+		{order: ListSort{By: SortBySize}, wanted: []string{"b", "c", "a"}},
+		{order: ListSort{By: SortBySize, Descending: true}, wanted: []string{"a", "c", "b"}},
+		{order: ListSort{By: SortByLastModified}, wanted: []string{"c", "b", "a"}},
+	}
+
+	for _, test := range tests {
+		sorted := slices.Clone(objects)
+		sortObjects(sorted, test.order)
+
+		var got []string
+		for _, object := range sorted {
+			got = append(got, aws.ToString(object.Key))
+		}
+
+		if !slices.Equal(got, test.wanted) {
+			t.Errorf("sortObjects(%+v) = %v, want %v", test.order, got, test.wanted)
+		}
+	}
+}
+
+func TestWriteListing(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	objects := []types.Object{
+		{Key: aws.String("data/a.csv"), Size: aws.Int64(5), LastModified: aws.Time(modified), ETag: aws.String(`"abc"`), StorageClass: types.ObjectStorageClassStandard},
+		{Key: aws.String("data/b.csv"), Size: aws.Int64(1200), LastModified: aws.Time(modified), ETag: aws.String(`"def"`), StorageClass: types.ObjectStorageClassGlacier},
+	}
+
+	tests := []struct {
+		format ListingFormat
+		wanted string
+	}{
+		{
+			format: ListingLong,
+			wanted: "   5  2024-05-01 12:30:00  STANDARD      data/a.csv\n" +
+				"1200  2024-05-01 12:30:00  GLACIER       data/b.csv\n",
+		},
+		{
+			format: ListingCSV,
+			wanted: "key,size,last_modified,etag,storage_class\n" +
+				"data/a.csv,5,2024-05-01T12:30:00Z,abc,STANDARD\n" +
+				"data/b.csv,1200,2024-05-01T12:30:00Z,def,GLACIER\n",
+		},
+	}
+
+	for _, test := range tests {
+		var b strings.Builder
+		if err := WriteListing(&b, objects, test.format); err != nil {
+			t.Errorf("WriteListing(%v) returned %v", test.format, err)
+			continue
+		}
+
+		if got := b.String(); got != test.wanted {
+			t.Errorf("WriteListing(%v) = %q, want %q", test.format, got, test.wanted)
+		}
+	}
+}
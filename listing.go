@@ -0,0 +1,222 @@
+package boto3manager
+
+import (
+	"context"
+	"iter"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ListingOptions tunes how buckets are listed, for example to go easy on a shared gateway during a large scan.
+type ListingOptions struct {
+	// MaxKeys is the number of keys requested per page. If zero, the server default (usually 1000) is used
+	MaxKeys int32
+
+	// PageDelay is the minimum time between page requests. If zero, pages are requested as fast as possible
+	PageDelay time.Duration
+}
+
+// listPages lists the objects matching params a page at a time as the sequence is consumed, applying the
+// bucket's listing options.
+func (basics BucketBasics) listPages(ctx context.Context, params *s3.ListObjectsV2Input) iter.Seq2[*s3.ListObjectsV2Output, error] {
+	return func(yield func(*s3.ListObjectsV2Output, error) bool) {
+		if basics.Listing.MaxKeys > 0 {
+			params.MaxKeys = aws.Int32(basics.Listing.MaxKeys)
+		}
+
+		// Directory buckets only accept delimited prefixes ending in "/"
+		var keep string
+		if IsDirectoryBucket(aws.ToString(params.Bucket)) {
+			params, keep = directoryListParams(params)
+		}
+
+		// Create the Paginator for the ListObjectsV2 operation
+		p := s3.NewListObjectsV2Paginator(basics.client(aws.ToString(params.Bucket)), params)
+
+		// Iterate through S3 object pages
+		var i int
+		var last time.Time
+		for p.HasMorePages() {
+			i++
+
+			// Wait until the delay since the last page has passed
+			if wait := basics.Listing.PageDelay - time.Since(last); !last.IsZero() && wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					yield(nil, ctx.Err())
+					return
+				}
+			}
+			last = time.Now()
+
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				log.Printf("Failed to get page %v in bucket %v: %v", i, aws.ToString(params.Bucket), err)
+				yield(nil, classifyError(err))
+				return
+			}
+
+			if keep != "" {
+				filterPage(page, keep)
+			}
+
+			if !yield(page, nil) {
+				return
+			}
+		}
+	}
+}
+
+// listObjectsSeq lists the objects under a prefix in key order, fetching a page at a time as the sequence is consumed.
+// Directory buckets don't list in key order, so they are listed in full and sorted first.
+func (basics BucketBasics) listObjectsSeq(ctx context.Context, bucketName string, prefix string) iter.Seq2[types.Object, error] {
+	return func(yield func(types.Object, error) bool) {
+		if IsDirectoryBucket(bucketName) {
+			objects, err := basics.sortedObjects(ctx, bucketName, prefix)
+			if err != nil {
+				yield(types.Object{}, err)
+				return
+			}
+
+			for _, object := range objects {
+				if !yield(object, nil) {
+					return
+				}
+			}
+			return
+		}
+
+		params := &s3.ListObjectsV2Input{
+			Bucket: aws.String(bucketName),
+		}
+
+		if len(prefix) > 0 {
+			params.Prefix = aws.String(prefix)
+		}
+
+		for page, err := range basics.listPages(ctx, params) {
+			if err != nil {
+				yield(types.Object{}, err)
+				return
+			}
+
+			for _, object := range page.Contents {
+				if !yield(object, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ListDirectories takes a bucket name and a prefix and lists only the immediate children of the prefix, like a
+// directory listing: the "subdirectories" (common prefixes ending in "/") and the objects directly under it. This
+// lets file browsers expand one folder at a time instead of listing the whole bucket. prefix should be empty or end
+// in "/".
+func (basics BucketBasics) ListDirectories(bucketName string, prefix string) ([]string, []types.Object, error) {
+	params := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucketName),
+		Delimiter: aws.String("/"),
+	}
+
+	if len(prefix) > 0 {
+		params.Prefix = aws.String(prefix)
+	}
+
+	prefixes := make([]string, 0)
+	objects := make([]types.Object, 0)
+
+	for page, err := range basics.listPages(context.TODO(), params) {
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, commonPrefix := range page.CommonPrefixes {
+			prefixes = append(prefixes, aws.ToString(commonPrefix.Prefix))
+		}
+
+		objects = append(objects, page.Contents...)
+	}
+
+	return prefixes, objects, nil
+}
+
+// ListObjectsOptions limits how much of a bucket ListObjectsWithOptions lists.
+type ListObjectsOptions struct {
+	// Prefix, if set, lists only the objects whose keys start with it
+	Prefix string
+
+	// MaxObjects stops the listing after this many objects. If zero, every object is listed
+	MaxObjects int
+
+	// MaxPages stops the listing after this many pages. If zero, every page is listed
+	MaxPages int
+}
+
+// ListObjectsWithOptions takes a bucket name and lists the objects in the bucket, stopping early once either
+// limit in the options is reached so sampling a huge bucket doesn't list all of it.
+func (basics BucketBasics) ListObjectsWithOptions(bucketName string, options ListObjectsOptions) ([]types.Object, error) {
+	params := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+	}
+
+	if len(options.Prefix) > 0 {
+		params.Prefix = aws.String(options.Prefix)
+	}
+
+	// Don't ask for a full page when only a few objects are wanted
+	if options.MaxObjects > 0 && options.MaxObjects < 1000 {
+		params.MaxKeys = aws.Int32(int32(options.MaxObjects))
+	}
+
+	results := make([]types.Object, 0)
+
+	var pages int
+	for page, err := range basics.listPages(context.TODO(), params) {
+		if err != nil {
+			log.Printf("Couldn't list bucket %v: %v", bucketName, err)
+			return nil, err
+		}
+
+		results = append(results, page.Contents...)
+		pages++
+
+		if options.MaxObjects > 0 && len(results) >= options.MaxObjects {
+			return results[:options.MaxObjects], nil
+		}
+		if options.MaxPages > 0 && pages >= options.MaxPages {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// CountObjects takes a bucket name and a prefix and counts the objects under the prefix. Only each page's key count
+// is kept, so counting a bucket of millions of objects doesn't hold them all in memory.
+func (basics BucketBasics) CountObjects(bucketName string, prefix string) (int64, error) {
+	params := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+	}
+
+	if len(prefix) > 0 {
+		params.Prefix = aws.String(prefix)
+	}
+
+	var count int64
+	for page, err := range basics.listPages(context.TODO(), params) {
+		if err != nil {
+			log.Printf("Couldn't count objects in bucket %v: %v", bucketName, err)
+			return 0, err
+		}
+
+		count += int64(aws.ToInt32(page.KeyCount))
+	}
+
+	return count, nil
+}
@@ -0,0 +1,124 @@
+package boto3manager
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// listedKeys returns the keys of listed objects.
+func listedKeys(objects []types.Object) []string {
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		keys = append(keys, aws.ToString(object.Key))
+	}
+	return keys
+}
+
+func TestListDirectories(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	for _, key := range []string{"top.csv", "logs/a.csv", "logs/2024/b.csv", "logs/2024/c.csv", "logs/2025/d.csv", "raw/e.h5"} {
+		fake.put("data", key, "x", nil)
+	}
+
+	// Small pages split the common prefixes across them
+	fake.maxKeys = 2
+
+	tests := []struct {
+		prefix   string
+		prefixes []string
+		objects  []string
+	}{
+		{prefix: "", prefixes: []string{"logs/", "raw/"}, objects: []string{"top.csv"}},
+		{prefix: "logs/", prefixes: []string{"logs/2024/", "logs/2025/"}, objects: []string{"logs/a.csv"}},
+		{prefix: "logs/2024/", prefixes: []string{}, objects: []string{"logs/2024/b.csv", "logs/2024/c.csv"}},
+		{prefix: "missing/", prefixes: []string{}, objects: []string{}},
+	}
+
+	for _, test := range tests {
+		prefixes, objects, err := fake.basics().ListDirectories("data", test.prefix)
+		if err != nil {
+			t.Errorf("ListDirectories(%q) = %v, want nil", test.prefix, err)
+			continue
+		}
+
+		if !slices.Equal(prefixes, test.prefixes) || !slices.Equal(listedKeys(objects), test.objects) {
+			t.Errorf("ListDirectories(%q) = %v, %v, want %v, %v", test.prefix, prefixes, listedKeys(objects), test.prefixes, test.objects)
+		}
+	}
+}
+
+func TestListObjectsWithOptions(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	for _, key := range []string{"a/1", "a/2", "a/3", "a/4", "a/5", "b/1"} {
+		fake.put("data", key, "x", nil)
+	}
+	fake.maxKeys = 2
+
+	tests := []struct {
+		name    string
+		options ListObjectsOptions
+		wanted  []string
+		pages   int
+	}{
+		{name: "everything", options: ListObjectsOptions{}, wanted: []string{"a/1", "a/2", "a/3", "a/4", "a/5", "b/1"}, pages: 3},
+		{name: "prefix", options: ListObjectsOptions{Prefix: "a/"}, wanted: []string{"a/1", "a/2", "a/3", "a/4", "a/5"}, pages: 3},
+		{name: "objects within a page", options: ListObjectsOptions{MaxObjects: 1}, wanted: []string{"a/1"}, pages: 1},
+		{name: "objects across pages", options: ListObjectsOptions{MaxObjects: 3}, wanted: []string{"a/1", "a/2", "a/3"}, pages: 2},
+		{name: "pages", options: ListObjectsOptions{MaxPages: 2}, wanted: []string{"a/1", "a/2", "a/3", "a/4"}, pages: 2},
+	}
+
+	for _, tt := range tests {
+		before := fake.count("GET data/?")
+
+		objects, err := fake.basics().ListObjectsWithOptions("data", tt.options)
+		if err != nil {
+			t.Errorf("ListObjectsWithOptions(%v) = %v, want nil", tt.name, err)
+			continue
+		}
+
+		if got := listedKeys(objects); !slices.Equal(got, tt.wanted) {
+			t.Errorf("ListObjectsWithOptions(%v) = %v, want %v", tt.name, got, tt.wanted)
+		}
+
+		// Listing stops once a limit is reached rather than reading the rest of the bucket
+		if pages := fake.count("GET data/?") - before; pages != tt.pages {
+			t.Errorf("ListObjectsWithOptions(%v) listed %v pages, want %v", tt.name, pages, tt.pages)
+		}
+	}
+}
+
+func TestCountObjects(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	for _, key := range []string{"a/1", "a/2", "a/3", "b/1"} {
+		fake.put("data", key, "x", nil)
+	}
+	fake.maxKeys = 2
+
+	tests := []struct {
+		prefix string
+		wanted int64
+	}{
+		{prefix: "", wanted: 4},
+		{prefix: "a/", wanted: 3},
+		{prefix: "c/", wanted: 0},
+	}
+
+	for _, test := range tests {
+		if got, err := fake.basics().CountObjects("data", test.prefix); err != nil || got != test.wanted {
+			t.Errorf("CountObjects(%q) = %v, %v, want %v", test.prefix, got, err, test.wanted)
+		}
+	}
+
+	if _, err := fake.basics().CountObjects("missing", ""); err == nil {
+		t.Errorf("CountObjects() of a missing bucket returned no error")
+	}
+}
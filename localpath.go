@@ -0,0 +1,36 @@
+package boto3manager
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// windowsReserved are the device names Windows won't create files with, with or without an extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// localKeyPath returns the relative path an object's key is written to on this system, with each part of the key
+// made a valid file name.
+func localKeyPath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = localName(part)
+	}
+
+	return filepath.Join(parts...)
+}
+
+// splitAbsPattern splits an absolute upload pattern, such as "/data/**/*.csv" or "C:/data/*.csv", into the
+// directory of its literal prefix and the pattern relative to it. Relative patterns are returned as they are with
+// an empty root.
+func splitAbsPattern(pattern string) (string, string) {
+	if !filepath.IsAbs(filepath.FromSlash(pattern)) {
+		return "", pattern
+	}
+
+	root := patternDir(pattern)
+	return root, pattern[len(root):]
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package boto3manager
+
+import (
+	"io/fs"
+	"os"
+)
+
+// localName returns a name a file can be created with on this system.
+func localName(name string) string {
+	return name
+}
+
+// localPath returns a path that can be opened on this system however deep it is.
+func localPath(path string) string {
+	return path
+}
+
+// localPattern returns an upload pattern with "/" separators. A "\" is part of a file name here, so it is kept.
+func localPattern(pattern string) string {
+	return pattern
+}
+
+// localDirFS returns a file system rooted at dir.
+func localDirFS(dir string) fs.FS {
+	return os.DirFS(dir)
+}
@@ -0,0 +1,23 @@
+package boto3manager
+
+import "testing"
+
+func TestSplitAbsPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern    string
+		wantedRoot string
+		wantedRel  string
+	}{
+		{pattern: "data/*.csv", wantedRoot: "", wantedRel: "data/*.csv"},
+		{pattern: "/srv/data/**/*.csv", wantedRoot: "/srv/data/", wantedRel: "**/*.csv"},
+		{pattern: "/srv/data/a.csv", wantedRoot: "/srv/data/", wantedRel: "a.csv"},
+	}
+
+	for _, test := range tests {
+		if root, rel := splitAbsPattern(test.pattern); root != test.wantedRoot || rel != test.wantedRel {
+			t.Errorf("splitAbsPattern(%v) = %v, %v, want %v, %v", test.pattern, root, rel, test.wantedRoot, test.wantedRel)
+		}
+	}
+}
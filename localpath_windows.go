@@ -0,0 +1,53 @@
+//go:build windows
+
+package boto3manager
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxPath is the length past which Windows needs the \\?\ prefix to open a path, leaving room for a file name in
+// a directory of that length.
+const maxPath = 248
+
+// localName returns a name a file can be created with on this system.
+func localName(name string) string {
+	return escapeName(name, EscapeReplace, true)
+}
+
+// localPath returns a path that can be opened on this system however deep it is, by making long paths absolute
+// with the \\?\ prefix that lifts the MAX_PATH limit.
+func localPath(path string) string {
+	if len(path) < maxPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+
+	return `\\?\` + abs
+}
+
+// localPattern returns an upload pattern with "/" separators, so patterns written with "\" match.
+func localPattern(pattern string) string {
+	return filepath.ToSlash(pattern)
+}
+
+// localDirFS returns a file system rooted at dir. The root is made absolute, so the paths opened in it are too and
+// Windows doesn't limit how deep they can go.
+func localDirFS(dir string) fs.FS {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	return os.DirFS(dir)
+}
@@ -0,0 +1,261 @@
+package boto3manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type VerifyOptions struct {
+	// Rehash downloads and hashes every object, even those with a checksum recorded in their metadata
+	Rehash bool
+
+	// RetryPolicy controls retries of each object. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+}
+
+// sha256File returns the hex encoded SHA-256 checksum of a file in a file system.
+func sha256File(fsys fs.FS, path string) (string, error) {
+	f, err := fsys.Open(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// localChecksums returns the SHA-256 checksum of every local file matching the pattern, keyed by the file's path
+// relative to the pattern's parent directory. The pattern is expanded as in UploadObjects.
+func localChecksums(pattern string, options HashOptions) (map[string]string, error) {
+	fsys, root, pattern, _, err := uploadSource(nil, "", pattern, "")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := uploadsForPattern(fsys, pattern, "", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hashFiles(fsys, root, files, options)
+}
+
+// hashFiles returns the SHA-256 checksum of every file in a file system, keyed by the file's Key. The file system
+// is rooted at root on disk, or isn't on disk if root is empty, in which case files aren't cached.
+func hashFiles(fsys fs.FS, root string, files []FileUpload, options HashOptions) (map[string]string, error) {
+	var totalSize int64
+	for _, file := range files {
+		totalSize += file.Size
+	}
+
+	bar := silentBar(totalSize)
+	if !options.quiet {
+		bar = newBytesBar(totalSize, "hashing")
+	}
+
+	cache, err := openChecksumCache(options.CacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	workerCount := options.Workers
+	if workerCount <= 0 {
+		workerCount = 8
+	}
+
+	var mu sync.Mutex
+	sums := make(map[string]string, len(files))
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount: workerCount,
+		policy:      RetryPolicy{MaxAttempts: 1},
+		report:      report,
+	}
+
+	runBatch(context.Background(), files, config, func(ctx context.Context, file FileUpload) error {
+		info, err := fs.Stat(fsys, file.Path)
+		if err != nil {
+			log.Printf("Couldn't compute checksum of %v: %v", file.Path, err)
+			return err
+		}
+
+		path := cachePath(root, file.Path)
+		sum, ok := "", false
+		if path != "" {
+			sum, ok = cache.lookup(path, info)
+		}
+
+		if !ok {
+			sum, err = sha256File(fsys, file.Path)
+
+			if err != nil {
+				log.Printf("Couldn't compute checksum of %v: %v", file.Path, err)
+				return err
+			}
+
+			if path != "" {
+				cache.store(path, info, sum)
+			}
+		}
+
+		mu.Lock()
+		sums[file.Key] = sum
+		mu.Unlock()
+
+		bar.Add64(file.Size)
+		return nil
+	})
+
+	bar.Finish()
+
+	// Keep what was hashed even if some files failed
+	if err := cache.save(); err != nil {
+		return nil, err
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return nil, failed[0].Err
+	}
+
+	return sums, nil
+}
+
+// GenerateChecksumManifest takes a glob pattern for local files and writes a sha256sum-style manifest of them to w,
+// with paths relative to the pattern's parent directory so `sha256sum -c` works from there. options controls how
+// the files are hashed.
+func GenerateChecksumManifest(pattern string, w io.Writer, options HashOptions) error {
+	sums, err := localChecksums(pattern, options)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, formatSums(sums))
+	return err
+}
+
+// PutChecksumManifest takes a glob pattern for local files, a key, and a bucket name and uploads a sha256sum-style
+// manifest of the files to the key. options controls how the files are hashed.
+func (basics BucketBasics) PutChecksumManifest(pattern string, key string, bucketName string, options HashOptions) error {
+	sums, err := localChecksums(pattern, options)
+
+	if err != nil {
+		return err
+	}
+
+	return basics.writeSumsFile(context.TODO(), key, bucketName, sums)
+}
+
+// ReadChecksumManifest takes the key of a sha256sum-style manifest and a bucket name and returns its checksums keyed
+// by path.
+func (basics BucketBasics) ReadChecksumManifest(key string, bucketName string) (map[string]string, error) {
+	obj, err := basics.client(bucketName).GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get checksums file %v: %v", key, err)
+		return nil, err
+	}
+
+	defer obj.Body.Close()
+
+	return parseSums(obj.Body)
+}
+
+// VerifyAgainstManifest takes a sha256sum-style manifest, a prefix, and a bucket name and checks that the object at
+// prefix plus each path in the manifest exists and has the listed checksum. Objects with a checksum in their
+// metadata, such as those written by BackfillChecksums, are checked with a HEAD request; others are downloaded and
+// hashed. The report holds a failure for every missing or mismatched object.
+func (basics BucketBasics) VerifyAgainstManifest(manifest io.Reader, prefix string, bucketName string, options VerifyOptions) (*TransferReport, error) {
+	sums, err := parseSums(manifest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return basics.verifySums(context.Background(), sums, prefix, bucketName, options)
+}
+
+// verifySums checks that the object at prefix plus each path in sums exists and has its checksum.
+func (basics BucketBasics) verifySums(ctx context.Context, sums map[string]string, prefix string, bucketName string, options VerifyOptions) (*TransferReport, error) {
+	items := make([]FileDownload, 0, len(sums))
+	for path := range sums {
+		items = append(items, FileDownload{Key: prefix + path, Destination: path})
+	}
+
+	// Every object is checked with a HEAD, or downloaded if rehashing
+	estimate := requestEstimate{heads: int64(len(items))}
+	if options.Rehash {
+		estimate = requestEstimate{gets: int64(len(items))}
+	}
+	warnRequests("verification", estimate)
+
+	bar := newCountBar(int64(len(items)), "verifying")
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  25,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		backpressure: basics.Backpressure,
+	}
+
+	err := runBatch(ctx, items, config, func(ctx context.Context, item FileDownload) error {
+		sum, err := basics.objectChecksum(ctx, item.Key, bucketName, options.Rehash)
+
+		if err != nil {
+			return err
+		}
+
+		// A stored object that doesn't match will hash the same way however often it's checked
+		if wanted := sums[item.Destination]; sum != wanted {
+			return permanentError{fmt.Errorf("%w: checksum of %v is %v, manifest says %v", ErrChecksumMismatch, item.Key, sum, wanted)}
+		}
+
+		bar.Add(1)
+		return nil
+	})
+
+	return report, err
+}
+
+// objectChecksum returns an object's SHA-256 checksum, from its metadata if it has one and rehash is false, or by
+// downloading it otherwise.
+func (basics BucketBasics) objectChecksum(ctx context.Context, key string, bucketName string, rehash bool) (string, error) {
+	if !rehash {
+		head, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(key),
+		})
+
+		if err != nil {
+			log.Printf("Couldn't get object %v: %v", key, err)
+			return "", err
+		}
+
+		if sum := head.Metadata[ChecksumMetadataKey]; sum != "" {
+			return sum, nil
+		}
+	}
+
+	return basics.sha256Object(ctx, key, bucketName)
+}
@@ -0,0 +1,100 @@
+package boto3manager
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// failedKeys returns the key and error of every failure in a report.
+func failedKeys(report *TransferReport) map[string]error {
+	failed := make(map[string]error)
+	for _, result := range report.Failed() {
+		failed[result.Key] = result.Err
+	}
+	return failed
+}
+
+func TestVerifyAgainstManifest(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "out/good.txt", "good", nil)
+	fake.put("bucket", "out/changed.txt", "changed", nil)
+
+	manifest := sha256Hex("good") + "  good.txt\n" + sha256Hex("original") + "  changed.txt\n" + sha256Hex("gone") + "  missing.txt\n"
+	options := VerifyOptions{RetryPolicy: &RetryPolicy{MaxAttempts: 3}}
+	report, err := fake.basics().VerifyAgainstManifest(strings.NewReader(manifest), "out/", "bucket", options)
+	if err != nil {
+		t.Fatalf("VerifyAgainstManifest() = %v, want nil", err)
+	}
+
+	failed := failedKeys(report)
+	if len(failed) != 2 {
+		t.Errorf("VerifyAgainstManifest() failed %v, want out/changed.txt and out/missing.txt", failed)
+	}
+	if err := failed["out/changed.txt"]; !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("VerifyAgainstManifest() failed out/changed.txt with %v, want %v", err, ErrChecksumMismatch)
+	}
+	if _, ok := failed["out/missing.txt"]; !ok {
+		t.Errorf("VerifyAgainstManifest() didn't fail out/missing.txt")
+	}
+
+	// Neither a mismatch nor a missing object is tried again
+	tests := []struct {
+		request string
+		wanted  int
+	}{
+		{"GET bucket/out/changed.txt", 1},
+		{"HEAD bucket/out/missing.txt", 1},
+	}
+
+	for _, tt := range tests {
+		if got := fake.count(tt.request); got != tt.wanted {
+			t.Errorf("VerifyAgainstManifest() sent %v %v times, want %v", tt.request, got, tt.wanted)
+		}
+	}
+}
+
+func TestVerifyAgainstManifestMetadata(t *testing.T) {
+	t.Parallel()
+
+	// The metadata says what the object held when it was written, though the object has changed since
+	header := http.Header{"X-Amz-Meta-" + ChecksumMetadataKey: {sha256Hex("original")}}
+
+	tests := []struct {
+		name       string
+		rehash     bool
+		mismatched bool
+		gets       int
+	}{
+		{name: "metadata", rehash: false, mismatched: false, gets: 0},
+		{name: "rehash", rehash: true, mismatched: true, gets: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			fake.put("bucket", "out/a.txt", "changed", header)
+
+			manifest := sha256Hex("original") + "  a.txt\n"
+			report, err := fake.basics().VerifyAgainstManifest(strings.NewReader(manifest), "out/", "bucket", VerifyOptions{Rehash: tt.rehash})
+			if err != nil {
+				t.Fatalf("VerifyAgainstManifest() = %v, want nil", err)
+			}
+
+			if mismatched := len(report.Failed()) > 0; mismatched != tt.mismatched {
+				t.Errorf("VerifyAgainstManifest() failed %+v, want a mismatch %v", report.Failed(), tt.mismatched)
+			}
+			if got := fake.count("GET bucket/out/a.txt"); got != tt.gets {
+				t.Errorf("VerifyAgainstManifest() sent GET bucket/out/a.txt %v times, want %v", got, tt.gets)
+			}
+			if got, wanted := fake.count("HEAD bucket/out/a.txt"), 1-tt.gets; got != wanted {
+				t.Errorf("VerifyAgainstManifest() sent HEAD bucket/out/a.txt %v times, want %v", got, wanted)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// SuccessMarkerName is the name of the object written into a prefix once a batch has completed fully, following
+// the Hadoop and Spark convention.
+const SuccessMarkerName = "_SUCCESS"
+
+// writeSuccessMarker writes a _SUCCESS object with the given content into the prefix.
+func (basics BucketBasics) writeSuccessMarker(prefix string, bucketName string, content string) error {
+	key := prefix + SuccessMarkerName
+
+	_, err := basics.client(bucketName).PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		Body:        strings.NewReader(content),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't write success marker %v to bucket %v: %v", key, bucketName, err)
+	}
+
+	return err
+}
+
+// SuccessMarkerPollInterval is how often WaitForSuccessMarker and OnSuccessMarker check for a marker.
+var SuccessMarkerPollInterval = 10 * time.Second
+
+// readSuccessMarker returns the content and version of the marker in a prefix. found is false if there is no marker
+// yet. The version tells one write of the marker from the next: its version ID in a versioned bucket, and otherwise
+// its LastModified, since markers are usually empty and every write has the same ETag.
+func (basics BucketBasics) readSuccessMarker(ctx context.Context, prefix string, bucketName string) (content string, version string, found bool, err error) {
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(prefix + SuccessMarkerName),
+	})
+
+	if err != nil {
+		if isNotFound(err) {
+			return "", "", false, nil
+		}
+		log.Printf("Couldn't get success marker in %v: %v", prefix, err)
+		return "", "", false, err
+	}
+
+	defer obj.Body.Close()
+
+	body, err := io.ReadAll(obj.Body)
+
+	if err != nil {
+		return "", "", false, err
+	}
+
+	version = aws.ToString(obj.VersionId)
+	if version == "" {
+		version = aws.ToTime(obj.LastModified).Format(time.RFC3339) + " " + aws.ToString(obj.ETag)
+	}
+
+	return string(body), version, true, nil
+}
+
+// WaitForSuccessMarker takes a prefix, a bucket name, and a timeout and waits until a producer writes a _SUCCESS
+// marker into the prefix, returning the marker's content. It returns an error if no marker appears before the timeout
+// or the marker can't be read for a reason that won't pass.
+func (basics BucketBasics) WaitForSuccessMarker(prefix string, bucketName string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for {
+		content, _, found, err := basics.readSuccessMarker(ctx, prefix, bucketName)
+
+		// Transient errors are tried again at the next poll
+		if err != nil && ctx.Err() == nil && !RetryableError(err) {
+			return "", classifyError(err)
+		}
+
+		if found {
+			return content, nil
+		}
+
+		select {
+		case <-time.After(SuccessMarkerPollInterval):
+		case <-ctx.Done():
+			return "", fmt.Errorf("no success marker in %v after %v", prefix, timeout)
+		}
+	}
+}
+
+// OnSuccessMarker takes a context, a prefix, a bucket name, and a callback and calls fn with the marker's content each
+// time a new _SUCCESS marker is written into the prefix, including one that already exists when it starts. It watches
+// until the context is cancelled, fn returns an error, or the marker can't be read for a reason that won't pass,
+// such as ErrAccessDenied or ErrBucketNotFound.
+func (basics BucketBasics) OnSuccessMarker(ctx context.Context, prefix string, bucketName string, fn func(content string) error) error {
+	var lastVersion string
+
+	for {
+		content, version, found, err := basics.readSuccessMarker(ctx, prefix, bucketName)
+
+		// A watcher runs for a long time, so errors that may pass, such as a 503 or a dropped connection, are tried
+		// again at the next poll and only errors that won't, such as denied access, stop it
+		if err != nil && ctx.Err() == nil && !RetryableError(err) {
+			return classifyError(err)
+		}
+
+		// A new version means the producer has written the marker again
+		if found && version != lastVersion {
+			lastVersion = version
+
+			if err := fn(content); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-time.After(SuccessMarkerPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// isNotFound reports whether an error means the key or object doesn't exist.
+func isNotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NoSuchKey" || apiErr.ErrorCode() == "NotFound")
+}
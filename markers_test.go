@@ -0,0 +1,90 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnSuccessMarkerRewrites(t *testing.T) {
+	interval := SuccessMarkerPollInterval
+	SuccessMarkerPollInterval = 10 * time.Millisecond
+	defer func() { SuccessMarkerPollInterval = interval }()
+
+	fake := newFakeS3(t, "bucket")
+	basics := fake.basics()
+
+	// Spark and the package itself write empty markers, so every write has the same ETag
+	if err := basics.writeSuccessMarker("out/", "bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	calls := 0
+	err := basics.OnSuccessMarker(ctx, "out/", "bucket", func(content string) error {
+		calls++
+		if calls == 3 {
+			cancel()
+			return nil
+		}
+		return basics.writeSuccessMarker("out/", "bucket", "")
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("OnSuccessMarker() = %v, want %v", err, context.Canceled)
+	}
+	if calls != 3 {
+		t.Errorf("OnSuccessMarker() called fn %v times for 3 writes, want 3", calls)
+	}
+}
+
+func TestOnSuccessMarkerErrors(t *testing.T) {
+	interval := SuccessMarkerPollInterval
+	SuccessMarkerPollInterval = 10 * time.Millisecond
+	defer func() { SuccessMarkerPollInterval = interval }()
+
+	tests := []struct {
+		status   int
+		failures int
+		wanted   error
+	}{
+		// A watcher outlives a few unavailable responses
+		{http.StatusServiceUnavailable, 3, context.Canceled},
+		{http.StatusInternalServerError, 3, context.Canceled},
+		// but stops at the first denied one
+		{http.StatusForbidden, 1, ErrAccessDenied},
+	}
+
+	for _, test := range tests {
+		fake := newFakeS3(t, "bucket")
+		basics := fake.basics()
+		if err := basics.writeSuccessMarker("out/", "bucket", "done"); err != nil {
+			t.Fatal(err)
+		}
+
+		failed := 0
+		fake.fail = func(r *http.Request) int {
+			if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, SuccessMarkerName) && failed < test.failures {
+				failed++
+				return test.status
+			}
+			return 0
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := basics.OnSuccessMarker(ctx, "out/", "bucket", func(content string) error {
+			cancel()
+			return nil
+		})
+		cancel()
+
+		if !errors.Is(err, test.wanted) {
+			t.Errorf("OnSuccessMarker() after %v responses of %v = %v, want %v", test.failures, test.status, err, test.wanted)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package boto3manager
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gitlab.nrp-nautilus.io/humboldt/boto3-manager/strutil"
+)
+
+const (
+	// maxPatternShards caps how many prefixes a pattern is expanded into before listing
+	maxPatternShards = 1000
+
+	// shardListWorkers is how many prefixes are listed at once
+	shardListWorkers = 8
+)
+
+// literalPrefix returns the part of a pattern before its first wildcard.
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?"); i > -1 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// patternShards expands the leading directories of a pattern into the literal prefixes that can hold matches, so
+// that data/*/2024/*.csv is listed as data/a/2024/, data/b/2024/, and so on rather than as everything under data/.
+// Directory segments with wildcards are expanded by listing them with a delimiter. Expansion stops at a "**"
+// segment, at the file name segment, or once there would be more than maxPatternShards prefixes.
+func (basics BucketBasics) patternShards(ctx context.Context, pattern string, bucketName string) ([]string, error) {
+	segments := strings.Split(pattern, "/")
+	prefixes := []string{""}
+
+	for i, segment := range segments[:len(segments)-1] {
+		if segment == "**" {
+			break
+		}
+
+		if !strings.ContainsAny(segment, "*?") {
+			for j := range prefixes {
+				prefixes[j] += segment + "/"
+			}
+			continue
+		}
+
+		// List the directories at this level and keep those matching the segment
+		re := regexp.MustCompile(strutil.WildCardToRegexp(segment + "/"))
+		expanded := make([]string, 0)
+
+		for _, prefix := range prefixes {
+			params := &s3.ListObjectsV2Input{
+				Bucket:    aws.String(bucketName),
+				Prefix:    aws.String(prefix + literalPrefix(segment)),
+				Delimiter: aws.String("/"),
+			}
+
+			for page, err := range basics.listPages(ctx, params) {
+				if err != nil {
+					return nil, err
+				}
+
+				for _, commonPrefix := range page.CommonPrefixes {
+					dir := aws.ToString(commonPrefix.Prefix)
+					if re.MatchString(strings.TrimPrefix(dir, prefix)) {
+						expanded = append(expanded, dir)
+					}
+				}
+			}
+		}
+
+		// Too many directories to be worth listing one by one, so list the level above instead
+		if len(expanded) > maxPatternShards {
+			for j := range prefixes {
+				prefixes[j] += literalPrefix(strings.Join(segments[i:], "/"))
+			}
+			return prefixes, nil
+		}
+
+		prefixes = expanded
+	}
+
+	if len(prefixes) == 0 {
+		return prefixes, nil
+	}
+
+	// Narrow each prefix to the literal start of the rest of the pattern
+	depth := strings.Count(prefixes[0], "/")
+	rest := literalPrefix(strings.Join(segments[depth:], "/"))
+	for j := range prefixes {
+		prefixes[j] += rest
+	}
+
+	return prefixes, nil
+}
+
+// matchObjects lists the objects in a bucket whose keys match a pattern. The pattern is split into the literal
+// prefixes that can hold matches, which are listed in parallel and filtered page by page. The matches are returned
+// in key order.
+func (basics BucketBasics) matchObjects(ctx context.Context, pattern string, bucketName string) ([]types.Object, error) {
+	shards, err := basics.patternShards(ctx, pattern, bucketName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a regular expression from the given pattern
+	re := regexp.MustCompile(strutil.WildCardToRegexp(pattern))
+
+	var mu sync.Mutex
+	var firstErr error
+	matches := make([]types.Object, 0)
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+
+	for range min(shardListWorkers, len(shards)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for prefix := range queue {
+				for object, err := range basics.listObjectsSeq(ctx, bucketName, prefix) {
+					if err != nil {
+						mu.Lock()
+						if firstErr == nil {
+							firstErr = err
+						}
+						mu.Unlock()
+						break
+					}
+
+					if re.MatchString(aws.ToString(object.Key)) {
+						mu.Lock()
+						matches = append(matches, object)
+						mu.Unlock()
+					}
+				}
+			}
+		}()
+	}
+
+	for _, prefix := range shards {
+		queue <- prefix
+	}
+	close(queue)
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	slices.SortFunc(matches, func(a types.Object, b types.Object) int {
+		return strings.Compare(aws.ToString(a.Key), aws.ToString(b.Key))
+	})
+
+	return matches, nil
+}
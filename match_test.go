@@ -0,0 +1,147 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestLiteralPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		wanted  string
+	}{
+		{pattern: "data/*/2024/*.csv", wanted: "data/"},
+		{pattern: "data/run-?/x", wanted: "data/run-"},
+		{pattern: "data/x.csv", wanted: "data/x.csv"},
+	}
+
+	for _, tt := range tests {
+		if got := literalPrefix(tt.pattern); got != tt.wanted {
+			t.Errorf("literalPrefix(%q) = %q, want %q", tt.pattern, got, tt.wanted)
+		}
+	}
+}
+
+func newMatchFake(t *testing.T) *fakeS3 {
+	fake := newFakeS3(t, "bucket")
+	for _, key := range []string{
+		"data/a/2024/x.csv",
+		"data/a/2024/x.txt",
+		"data/b/2024/y.csv",
+		"data/b/2024/deep/z.csv",
+		"data/b/2023/old.csv",
+		"data/c/2024/w.txt",
+		"database/2024/n.csv",
+	} {
+		fake.put("bucket", key, key, nil)
+	}
+	return fake
+}
+
+func objectKeys(basics BucketBasics, pattern string) ([]string, error) {
+	objects, err := basics.matchObjects(context.Background(), pattern, "bucket")
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		keys = append(keys, aws.ToString(object.Key))
+	}
+	return keys, err
+}
+
+func TestMatchObjects(t *testing.T) {
+	t.Parallel()
+
+	fake := newMatchFake(t)
+	basics := fake.basics()
+
+	tests := []struct {
+		pattern      string
+		wantedShards []string
+		wanted       []string
+	}{
+		{
+			pattern:      "data/*/2024/*.csv",
+			wantedShards: []string{"data/a/2024/", "data/b/2024/", "data/c/2024/"},
+			wanted:       []string{"data/a/2024/x.csv", "data/b/2024/y.csv"},
+		},
+		{
+			pattern:      "data/b/*/*.csv",
+			wantedShards: []string{"data/b/2023/", "data/b/2024/"},
+			wanted:       []string{"data/b/2023/old.csv", "data/b/2024/y.csv"},
+		},
+		{
+			pattern:      "data/**/*.csv",
+			wantedShards: []string{"data/"},
+			wanted:       []string{"data/a/2024/x.csv", "data/b/2023/old.csv", "data/b/2024/deep/z.csv", "data/b/2024/y.csv"},
+		},
+		{
+			pattern:      "data/a/2024/x.*",
+			wantedShards: []string{"data/a/2024/x."},
+			wanted:       []string{"data/a/2024/x.csv", "data/a/2024/x.txt"},
+		},
+		{
+			pattern:      "data/z*/*.csv",
+			wantedShards: []string{},
+			wanted:       []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		shards, err := basics.patternShards(context.Background(), tt.pattern, "bucket")
+		if err != nil || !reflect.DeepEqual(shards, tt.wantedShards) {
+			t.Errorf("patternShards(%q) = %q, %v, want %q", tt.pattern, shards, err, tt.wantedShards)
+		}
+
+		got, err := objectKeys(basics, tt.pattern)
+		if err != nil || !reflect.DeepEqual(got, tt.wanted) {
+			t.Errorf("matchObjects(%q) = %q, %v, want %q", tt.pattern, got, err, tt.wanted)
+		}
+	}
+}
+
+func TestMatchObjectsShardFallback(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	for i := range maxPatternShards + 1 {
+		fake.put("bucket", fmt.Sprintf("logs/d%04d/x.csv", i), "", nil)
+	}
+	basics := fake.basics()
+
+	shards, err := basics.patternShards(context.Background(), "logs/*/x.csv", "bucket")
+	if wanted := []string{"logs/"}; err != nil || !reflect.DeepEqual(shards, wanted) {
+		t.Errorf("patternShards() over %v directories = %q, %v, want %q", maxPatternShards+1, shards, err, wanted)
+	}
+
+	got, err := objectKeys(basics, "logs/*/x.csv")
+	if err != nil || len(got) != maxPatternShards+1 {
+		t.Errorf("matchObjects() over %v directories = %v matches, %v, want %v", maxPatternShards+1, len(got), err, maxPatternShards+1)
+	}
+}
+
+func TestMatchObjectsListingError(t *testing.T) {
+	t.Parallel()
+
+	fake := newMatchFake(t)
+	fake.maxKeys = 1
+
+	// The second page of one shard's listing fails
+	fake.fail = func(r *http.Request) int {
+		query := r.URL.Query()
+		if query.Get("prefix") == "data/b/2024/" && query.Has("continuation-token") {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+
+	got, err := objectKeys(fake.basics(), "data/*/2024/*.csv")
+	if err == nil {
+		t.Errorf("matchObjects() with a failed page = %q, nil error, want an error", got)
+	}
+}
@@ -0,0 +1,206 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// trashStamp names the folder a mirror sync's deletions are moved into, so each run's deletions are kept apart.
+const trashStamp = "20060102T150405Z"
+
+// mirrorDeletions returns the sorted names at the destination that are missing from the source.
+func mirrorDeletions[S any, D any](source map[string]S, dest map[string]D) []string {
+	deletions := make([]string, 0)
+	for name := range dest {
+		if _, ok := source[name]; !ok {
+			deletions = append(deletions, name)
+		}
+	}
+
+	slices.Sort(deletions)
+	return deletions
+}
+
+// checkMirror returns an error if a mirror from a source with count entries shouldn't start. A bad pattern or path
+// leaves things out of the source that a mirror would then delete for good, so it needs trash to move deletions into
+// or a ConfirmDelete to approve them. An empty source, such as a mistyped or unmounted directory, would delete
+// everything at the destination, so it needs ConfirmDelete even with a trash.
+func (options SyncOptions) checkMirror(source string, count int, trash string) error {
+	if !options.Mirror || options.ConfirmDelete != nil {
+		return nil
+	}
+
+	if trash == "" {
+		log.Printf("Refusing to mirror from %v without a trash or ConfirmDelete", source)
+		return fmt.Errorf("refusing to mirror from %v without a trash or ConfirmDelete", source)
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	log.Printf("Refusing to mirror from %v, which is empty, without ConfirmDelete", source)
+	return fmt.Errorf("refusing to mirror from empty source %v without ConfirmDelete", source)
+}
+
+// checkTrashPrefix returns an error if a mirror up would move deleted objects under the synced prefix itself, where
+// the next mirror would delete them for good.
+func (options SyncOptions) checkTrashPrefix(prefix string) error {
+	if !options.Mirror || options.TrashPrefix == "" || !strings.HasPrefix(options.TrashPrefix, prefix) {
+		return nil
+	}
+
+	log.Printf("TrashPrefix %v lies under the synced prefix %v", options.TrashPrefix, prefix)
+	return fmt.Errorf("trash prefix %q must be outside the synced prefix %q", options.TrashPrefix, prefix)
+}
+
+// checkTrashDir returns an error if a mirror down would move deleted files into the synced directory itself, where a
+// sync up would send them back and the next mirror would delete them again.
+func (options SyncOptions) checkTrashDir(localDir string) error {
+	if !options.Mirror || options.TrashDir == "" {
+		return nil
+	}
+
+	dir, err := filepath.Abs(localDir)
+	if err != nil {
+		return err
+	}
+	trash, err := filepath.Abs(options.TrashDir)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(dir, trash)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	log.Printf("TrashDir %v lies in the synced directory %v", options.TrashDir, localDir)
+	return fmt.Errorf("trash directory %q must be outside the synced directory %q", options.TrashDir, localDir)
+}
+
+// confirmDeletions asks the sync's ConfirmDelete whether deletions may go ahead. Without one they may, since
+// checkMirror has made sure they go to the trash.
+func (options SyncOptions) confirmDeletions(deletions []string) bool {
+	if len(deletions) == 0 {
+		return false
+	}
+
+	if options.ConfirmDelete != nil && !options.ConfirmDelete(deletions) {
+		log.Printf("Left %v deletions unconfirmed", len(deletions))
+		return false
+	}
+
+	return true
+}
+
+// deleteRemote deletes the named objects, first copying them under trashPrefix if it is set, and records them in the
+// report.
+func (basics BucketBasics) deleteRemote(ctx context.Context, bucketName string, objects map[string]ObjectInfo, names []string, trashPrefix string, report *TransferReport, quiet bool) error {
+	trash := ""
+	if trashPrefix != "" {
+		trash = trashPrefix + time.Now().UTC().Format(trashStamp) + "/"
+	}
+
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		object := objects[name]
+
+		if trash != "" {
+			if err := basics.copyObject(ctx, bucketName, object.Key, trash+name, object.Size); err != nil {
+				return err
+			}
+		}
+
+		keys = append(keys, object.Key)
+	}
+
+	if err := basics.deleteKeys(ctx, bucketName, keys); err != nil {
+		return err
+	}
+
+	report.Deleted = append(report.Deleted, keys...)
+	if !quiet {
+		fmt.Printf("Deleted %v objects\n", len(keys))
+	}
+
+	return nil
+}
+
+// copyObject copies an object within a bucket, in parts if it is too large for a single CopyObject.
+func (basics BucketBasics) copyObject(ctx context.Context, bucketName string, srcKey string, dstKey string, size int64) error {
+	if size <= maxCopyPartSize {
+		_, err := basics.client(bucketName).CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     aws.String(bucketName),
+			Key:        aws.String(dstKey),
+			CopySource: aws.String(copySource(bucketName, srcKey)),
+		})
+
+		if err != nil {
+			log.Printf("Couldn't copy %v to %v: %v", srcKey, dstKey, err)
+		}
+
+		return classifyError(err)
+	}
+
+	splice, err := basics.newMultipartSplice(ctx, dstKey, bucketName, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := splice.copyRange(bucketName, srcKey, 0, size); err != nil {
+		splice.abort()
+		return err
+	}
+
+	if err := splice.complete(); err != nil {
+		splice.abort()
+		return err
+	}
+
+	return nil
+}
+
+// deleteLocal removes the named files, moving them into trashDir instead if it is set, and records them in the report.
+func deleteLocal(files map[string]FileInfo, names []string, trashDir string, report *TransferReport, quiet bool) error {
+	trash := ""
+	if trashDir != "" {
+		trash = filepath.Join(trashDir, time.Now().UTC().Format(trashStamp))
+	}
+
+	for _, name := range names {
+		path := files[name].Path
+
+		var err error
+		if trash != "" {
+			dest := filepath.Join(trash, filepath.FromSlash(name))
+			if err = os.MkdirAll(filepath.Dir(dest), os.ModePerm); err == nil {
+				err = os.Rename(path, dest)
+			}
+		} else {
+			err = os.Remove(path)
+		}
+
+		if err != nil {
+			log.Printf("Couldn't delete %v: %v", path, err)
+			return err
+		}
+
+		report.Deleted = append(report.Deleted, path)
+	}
+
+	if !quiet {
+		fmt.Printf("Deleted %v files\n", len(names))
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package boto3manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMirrorDeletions(t *testing.T) {
+	t.Parallel()
+
+	source := map[string]FileInfo{"a": {}, "b/c": {}}
+	dest := map[string]ObjectInfo{"a": {}, "b/c": {}, "b/d": {}, "z": {}}
+
+	if got, wanted := mirrorDeletions(source, dest), []string{"b/d", "z"}; !slices.Equal(got, wanted) {
+		t.Errorf("mirrorDeletions() = %v, want %v", got, wanted)
+	}
+}
+
+func TestDeleteLocalTrash(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	trash := filepath.Join(dir, "trash")
+
+	if err := os.MkdirAll(filepath.Join(local, "sub"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(local, "sub", "old.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := localFiles(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := &TransferReport{}
+	if err := deleteLocal(files, []string{"sub/old.txt"}, trash, report, true); err != nil {
+		t.Fatalf("deleteLocal() = %v, want nil", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(local, "sub", "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("deleteLocal() left the file in place: %v", err)
+	}
+
+	moved, _ := filepath.Glob(filepath.Join(trash, "*", "sub", "old.txt"))
+	if len(moved) != 1 {
+		t.Errorf("deleteLocal() moved the file to %v, want one copy in the trash", moved)
+	}
+
+	if len(report.Deleted) != 1 {
+		t.Errorf("deleteLocal() recorded %v, want the deleted file", report.Deleted)
+	}
+}
+
+func TestSyncMirrorGuards(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	confirm := func(names []string) bool { return true }
+
+	tests := []struct {
+		name    string
+		files   bool
+		options SyncOptions
+		wanted  bool
+	}{
+		{name: "no trash", files: true, options: SyncOptions{Mirror: true}, wanted: false},
+		{name: "no trash confirmed", files: true, options: SyncOptions{Mirror: true, ConfirmDelete: confirm}, wanted: true},
+		{name: "empty source", options: SyncOptions{Mirror: true, TrashPrefix: "trash/"}, wanted: false},
+		{name: "empty source confirmed", options: SyncOptions{Mirror: true, ConfirmDelete: confirm}, wanted: true},
+		{name: "empty source without mirror", options: SyncOptions{}, wanted: true},
+		{name: "trash under prefix", files: true, options: SyncOptions{Mirror: true, TrashPrefix: "data/trash/"}, wanted: false},
+		{name: "trash outside prefix", files: true, options: SyncOptions{Mirror: true, TrashPrefix: "trash/"}, wanted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			store.objects["data/keep.txt"] = []byte("keep")
+
+			dir := t.TempDir()
+			if tt.files {
+				if err := os.WriteFile(filepath.Join(dir, "keep.txt"), []byte("keep"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tt.options.Quiet = true
+			_, err := SyncUpStore(ctx, store, dir, "data/", tt.options)
+			if got := err == nil; got != tt.wanted {
+				t.Errorf("SyncUpStore() = %v, want success %v", err, tt.wanted)
+			}
+			if !tt.wanted && len(store.objects) != 1 {
+				t.Errorf("SyncUpStore() refused but left %v objects, want 1", len(store.objects))
+			}
+		})
+	}
+}
+
+func TestSyncDownMirrorEmptySource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "keep.txt"), []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	options := SyncOptions{Mirror: true, TrashDir: filepath.Join(t.TempDir(), "trash"), Quiet: true}
+	if _, err := SyncDownStore(context.Background(), newMemStore(), "data/", dir, options); err == nil {
+		t.Errorf("SyncDownStore() from an empty prefix = nil error, want an error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "keep.txt")); err != nil {
+		t.Errorf("SyncDownStore() from an empty prefix deleted keep.txt: %v", err)
+	}
+}
+
+func TestCheckTrashDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+
+	tests := []struct {
+		trashDir string
+		wanted   bool
+	}{
+		{trashDir: "", wanted: true},
+		{trashDir: filepath.Join(dir, "trash"), wanted: true},
+		{trashDir: filepath.Join(dir, "local-trash"), wanted: true},
+		{trashDir: filepath.Join(local, "trash"), wanted: false},
+		{trashDir: local, wanted: false},
+	}
+
+	for _, tt := range tests {
+		options := SyncOptions{Mirror: true, TrashDir: tt.trashDir}
+		if err := options.checkTrashDir(local); (err == nil) != tt.wanted {
+			t.Errorf("checkTrashDir(%v) with TrashDir %v = %v, want success %v", local, tt.trashDir, err, tt.wanted)
+		}
+	}
+
+	// A trash inside the directory is refused before anything is deleted into it
+	store := newMemStore()
+	store.objects["data/keep.txt"] = []byte("keep")
+	options := SyncOptions{Mirror: true, TrashDir: filepath.Join(local, ".trash"), Quiet: true}
+	if _, err := SyncDownStore(context.Background(), store, "data/", local, options); err == nil {
+		t.Errorf("SyncDownStore() with a trash inside the directory = nil error, want an error")
+	}
+}
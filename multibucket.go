@@ -0,0 +1,184 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/schollz/progressbar/v3"
+)
+
+type UploadObjectsMultiOptions struct {
+	// RetryPolicy controls retries of each file. A retry only uploads to the buckets that failed. If nil,
+	// DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Hooks, if set, are called as files start and finish
+	Hooks *Hooks
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+}
+
+// UploadObjectsMulti takes a glob pattern for files, a destination path, and several bucket names and uploads every
+// file matching the pattern to the destination in each bucket. Each file is read once and its bytes sent to every
+// bucket at the same time, so replicating a dataset to several regions costs one pass over the disk. Buckets can be
+// on different endpoints by giving them their own clients in BucketClients. The pattern and dest are expanded as in
+// UploadObjects, so the pattern may be absolute or start with ~. dest must be empty or end with a "/".
+// A file only succeeds once it is in every bucket.
+func (basics BucketBasics) UploadObjectsMulti(pattern string, dest string, buckets []string, options UploadObjectsMultiOptions) (*TransferReport, error) {
+	return basics.UploadObjectsMultiWithContext(context.Background(), pattern, dest, buckets, options)
+}
+
+// UploadObjectsMultiWithContext is UploadObjectsMulti with a context.
+func (basics BucketBasics) UploadObjectsMultiWithContext(ctx context.Context, pattern string, dest string, buckets []string, options UploadObjectsMultiOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+
+	if len(buckets) == 0 {
+		return nil, errors.New("no buckets to upload to")
+	}
+	basics.detectRegions(ctx, buckets...)
+
+	fsys, _, pattern, dest, err := uploadSource(nil, "", pattern, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads, err := uploadsForPattern(fsys, pattern, dest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalSize int64
+	for _, upload := range uploads {
+		totalSize += upload.Size
+	}
+
+	for _, bucketName := range buckets {
+		warnRequests("upload to "+bucketName, uploadRequests(uploads, UploadObjectsOptions{}))
+	}
+
+	progress := newTransferProgress("uploading", totalSize, false, options.Quiet)
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  defaultWorkers(25),
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		hooks:        options.Hooks,
+		backpressure: basics.Backpressure,
+	}
+
+	// Each bucket gets an upload manager shared by the workers
+	uploaders := make(map[string]*manager.Uploader, len(buckets))
+	for _, bucketName := range buckets {
+		uploaders[bucketName] = basics.newUploader(bucketName)
+	}
+
+	// Remember which buckets each file has reached, so retries skip them
+	var mu sync.Mutex
+	uploaded := make(map[string][]string)
+
+	err = runBatch(ctx, uploads, config, func(ctx context.Context, file FileUpload) error {
+		bar := progress.file(file.Key, file.Size)
+		defer progress.done(file.Key)
+
+		mu.Lock()
+		remaining := slices.DeleteFunc(slices.Clone(buckets), func(bucketName string) bool {
+			return slices.Contains(uploaded[file.Key], bucketName)
+		})
+		mu.Unlock()
+
+		succeeded, err := teeUpload(ctx, fsys, file, remaining, uploaders, bar)
+
+		mu.Lock()
+		uploaded[file.Key] = append(uploaded[file.Key], succeeded...)
+		mu.Unlock()
+
+		return err
+	})
+
+	progress.close()
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	return report, err
+}
+
+// teeUpload reads a file from fsys once and uploads it to every bucket at the same time, returning the buckets it
+// reached. A bucket that fails has the rest of the file discarded so the others aren't held up.
+func teeUpload(ctx context.Context, fsys fs.FS, file FileUpload, buckets []string, uploaders map[string]*manager.Uploader, bar *progressbar.ProgressBar) ([]string, error) {
+	f, err := fsys.Open(file.Path)
+	if err != nil {
+		log.Printf("Couldn't open file %v to upload: %v", file.Path, err)
+		return nil, err
+	}
+
+	defer f.Close()
+
+	errs := make([]error, len(buckets))
+	writers := make([]io.Writer, len(buckets))
+	pipes := make([]*io.PipeWriter, len(buckets))
+
+	var wg sync.WaitGroup
+	for i, bucketName := range buckets {
+		r, w := io.Pipe()
+		writers[i], pipes[i] = w, w
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := uploaders[bucketName].Upload(ctx, &s3.PutObjectInput{
+				Bucket: aws.String(bucketName),
+				Key:    aws.String(file.Key),
+				Body:   r,
+			})
+
+			if err != nil {
+				log.Printf("Couldn't upload object %v to bucket %v: %v", file.Path, bucketName, err)
+				errs[i] = fmt.Errorf("bucket %v: %w", bucketName, classifyError(err))
+			}
+
+			// Keep reading so writes to the other buckets aren't blocked
+			io.Copy(io.Discard, r)
+		}()
+	}
+
+	body, progress := newProgressReader(f, bar)
+	_, err = io.Copy(io.MultiWriter(writers...), body)
+	for _, w := range pipes {
+		w.CloseWithError(err)
+	}
+	wg.Wait()
+
+	if err != nil {
+		log.Printf("Couldn't read file %v: %v", file.Path, err)
+		progress.rollback()
+		return nil, err
+	}
+
+	succeeded := make([]string, 0, len(buckets))
+	for i, bucketName := range buckets {
+		if errs[i] == nil {
+			succeeded = append(succeeded, bucketName)
+		}
+	}
+
+	// A retry sends the file again, so don't count its bytes twice
+	if err := errors.Join(errs...); err != nil {
+		progress.rollback()
+		return succeeded, err
+	}
+
+	return succeeded, nil
+}
@@ -0,0 +1,83 @@
+package boto3manager
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUploadObjectsMultiRetriesFailedBucket(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("replicated"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeS3(t, "east", "west")
+
+	// The first upload to west fails, and its retry succeeds
+	failed := false
+	fake.fail = func(r *http.Request) int {
+		if r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/west/") && !failed {
+			failed = true
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+
+	// An absolute pattern is read from its own directory
+	options := UploadObjectsMultiOptions{RetryPolicy: &RetryPolicy{MaxAttempts: 2}, Quiet: true}
+	report, err := fake.basics().UploadObjectsMulti(filepath.Join(dir, "*.txt"), "copies/", []string{"east", "west"}, options)
+	if err != nil {
+		t.Fatalf("UploadObjectsMulti() = %v, want nil", err)
+	}
+
+	if got := len(report.Succeeded()); got != 1 {
+		t.Errorf("UploadObjectsMulti() succeeded %v files, want 1", got)
+	}
+
+	for _, bucket := range []string{"east", "west"} {
+		if object, ok := fake.object(bucket, "copies/a.txt"); !ok || string(object.body) != "replicated" {
+			t.Errorf("UploadObjectsMulti() left %v/copies/a.txt = %q, %v, want %q", bucket, object.body, ok, "replicated")
+		}
+	}
+
+	// The retry only goes to the bucket that failed
+	tests := []struct {
+		request string
+		wanted  int
+	}{
+		{"PUT east/copies/a.txt", 1},
+		{"PUT west/copies/a.txt", 2},
+	}
+
+	for _, tt := range tests {
+		if got := fake.count(tt.request); got != tt.wanted {
+			t.Errorf("UploadObjectsMulti() sent %v %v times, want %v", tt.request, got, tt.wanted)
+		}
+	}
+}
+
+func TestUploadObjectsMultiExpandsPattern(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("HOME", dir)
+	t.Setenv("DEST", "copies")
+
+	fake := newFakeS3(t, "east", "west")
+	if _, err := fake.basics().UploadObjectsMulti("~/*.txt", "${DEST}/", []string{"east", "west"}, UploadObjectsMultiOptions{Quiet: true}); err != nil {
+		t.Fatalf("UploadObjectsMulti() = %v, want nil", err)
+	}
+
+	for _, bucket := range []string{"east", "west"} {
+		if _, ok := fake.object(bucket, "copies/a.txt"); !ok {
+			t.Errorf("UploadObjectsMulti(\"~/*.txt\") didn't upload %v/copies/a.txt: %q", bucket, fake.keys(bucket))
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package boto3manager
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+const (
+	// fileProgressFiles is how many in-flight files the per-file display shows
+	fileProgressFiles = 8
+
+	// fileProgressInterval is how often the per-file display is redrawn
+	fileProgressInterval = 200 * time.Millisecond
+
+	// fileProgressWidth is the width of each bar of the per-file display
+	fileProgressWidth = 30
+
+	// fileProgressNameWidth is the widest a file name is shown; longer names keep their end
+	fileProgressNameWidth = 40
+
+	// plainProgressInterval is how often progress is logged as plain lines when stderr isn't a terminal
+	plainProgressInterval = 10 * time.Second
+)
+
+// transferProgress displays the progress of a batch. file returns the bar a file's bytes are counted on, and done
+// is called once the file has finished, whether or not it succeeded.
+type transferProgress interface {
+	file(key string, size int64) *progressbar.ProgressBar
+	done(key string)
+	close()
+}
+
+// newTransferProgress returns the display for a batch of totalBytes: nothing if quiet is set, plain progress lines
+// if stderr isn't a terminal, a bar for each of the largest files in flight above an overall bar if perFile is set,
+// or a single overall bar.
+func newTransferProgress(description string, totalBytes int64, perFile bool, quiet bool) transferProgress {
+	switch {
+	case quiet:
+		return singleProgress{silentBar(totalBytes)}
+	case !progressTerminal():
+		return newPlainProgress(os.Stderr, description, totalBytes)
+	case perFile:
+		return newFileProgress(os.Stderr, description, totalBytes)
+	}
+
+	return singleProgress{progressbar.DefaultBytes(totalBytes, description)}
+}
+
+// singleProgress counts every file on one bar.
+type singleProgress struct {
+	bar *progressbar.ProgressBar
+}
+
+func (progress singleProgress) file(key string, size int64) *progressbar.ProgressBar {
+	return progress.bar
+}
+
+func (progress singleProgress) done(key string) {}
+
+func (progress singleProgress) close() {}
+
+// plainProgress counts every file on one bar that isn't drawn, and instead writes a line of progress every
+// plainProgressInterval, which reads well in CI logs.
+type plainProgress struct {
+	w           io.Writer
+	description string
+	bar         *progressbar.ProgressBar
+	started     time.Time
+
+	mu        sync.Mutex
+	doneFiles int
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newPlainProgress starts writing progress lines to w.
+func newPlainProgress(w io.Writer, description string, totalBytes int64) *plainProgress {
+	progress := &plainProgress{
+		w:           w,
+		description: description,
+		bar:         silentBar(totalBytes),
+		started:     time.Now(),
+		stop:        make(chan struct{}),
+	}
+
+	progress.wg.Add(1)
+	go func() {
+		defer progress.wg.Done()
+
+		ticker := time.NewTicker(plainProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				progress.render()
+			case <-progress.stop:
+				return
+			}
+		}
+	}()
+
+	return progress
+}
+
+func (progress *plainProgress) file(key string, size int64) *progressbar.ProgressBar {
+	return progress.bar
+}
+
+func (progress *plainProgress) done(key string) {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	progress.doneFiles++
+}
+
+// close stops writing lines after writing the final state.
+func (progress *plainProgress) close() {
+	close(progress.stop)
+	progress.wg.Wait()
+	progress.render()
+}
+
+// render writes a line of progress.
+func (progress *plainProgress) render() {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	state := progress.bar.State()
+	fmt.Fprintln(progress.w, plainLine(progress.description, state.CurrentNum, state.Max, progress.doneFiles, time.Since(progress.started)))
+}
+
+// plainLine returns a line of progress for current of total bytes and files done in elapsed.
+func plainLine(description string, current int64, total int64, files int, elapsed time.Duration) string {
+	percent := 100.0
+	if total > 0 {
+		percent = min(max(float64(current)/float64(total), 0), 1) * 100
+	}
+
+	var speed float64
+	if elapsed > 0 {
+		speed = float64(current) / elapsed.Seconds()
+	}
+
+	return fmt.Sprintf("%v: %.0f%% %.1f/%.1f MB, %v files, %.1f MB/s, %v elapsed", description, percent, float64(current)/1e6, float64(total)/1e6, files, speed/1e6, elapsed.Round(time.Second))
+}
+
+// inFlightFile is a file the per-file display is showing.
+type inFlightFile struct {
+	name    string
+	size    int64
+	bar     *progressbar.ProgressBar
+	started time.Time
+}
+
+// fileProgress redraws a bar for each of the largest files in flight, with how long it has been running, above a
+// bar for the whole batch.
+type fileProgress struct {
+	w           io.Writer
+	description string
+	totalBytes  int64
+	started     time.Time
+
+	mu        sync.Mutex
+	files     map[string]*inFlightFile
+	doneBytes int64
+	doneFiles int
+	lines     int
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newFileProgress starts redrawing a per-file display to w.
+func newFileProgress(w io.Writer, description string, totalBytes int64) *fileProgress {
+	progress := &fileProgress{
+		w:           w,
+		description: description,
+		totalBytes:  totalBytes,
+		started:     time.Now(),
+		files:       make(map[string]*inFlightFile),
+		stop:        make(chan struct{}),
+	}
+
+	progress.wg.Add(1)
+	go func() {
+		defer progress.wg.Done()
+
+		ticker := time.NewTicker(fileProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				progress.render()
+			case <-progress.stop:
+				return
+			}
+		}
+	}()
+
+	return progress
+}
+
+func (progress *fileProgress) file(key string, size int64) *progressbar.ProgressBar {
+	// The bar only counts bytes; the display draws it
+	bar := progressbar.NewOptions64(size, progressbar.OptionSetWriter(io.Discard))
+
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	progress.files[key] = &inFlightFile{name: key, size: size, bar: bar, started: time.Now()}
+	return bar
+}
+
+func (progress *fileProgress) done(key string) {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	if file, ok := progress.files[key]; ok {
+		progress.doneBytes += file.bar.State().CurrentNum
+		progress.doneFiles++
+		delete(progress.files, key)
+	}
+}
+
+// close stops redrawing after drawing the final state.
+func (progress *fileProgress) close() {
+	close(progress.stop)
+	progress.wg.Wait()
+	progress.render()
+}
+
+// render redraws the display over the previous one.
+func (progress *fileProgress) render() {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	fmt.Fprint(progress.w, progress.frame(time.Now()))
+}
+
+// frame returns the text that replaces the previous frame. progress.mu must be held.
+func (progress *fileProgress) frame(now time.Time) string {
+	var b strings.Builder
+
+	// Move back to the top of the previous frame
+	if progress.lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", progress.lines)
+	}
+
+	files := make([]*inFlightFile, 0, len(progress.files))
+	for _, file := range progress.files {
+		files = append(files, file)
+	}
+	slices.SortFunc(files, func(a, b *inFlightFile) int {
+		return cmp.Or(cmp.Compare(b.size, a.size), strings.Compare(a.name, b.name))
+	})
+
+	lines := 0
+	transferring := progress.doneBytes
+	for i, file := range files {
+		current := file.bar.State().CurrentNum
+		transferring += current
+
+		if i < fileProgressFiles {
+			fmt.Fprintf(&b, "\x1b[2K  %-*v %v %v\n", fileProgressNameWidth, shortName(file.name), progressLine(current, file.size), now.Sub(file.started).Round(time.Second))
+			lines++
+		}
+	}
+
+	if len(files) > fileProgressFiles {
+		fmt.Fprintf(&b, "\x1b[2K  and %v more\n", len(files)-fileProgressFiles)
+		lines++
+	}
+
+	// Clear lines left over from a taller previous frame
+	for i := lines; i < progress.lines; i++ {
+		b.WriteString("\x1b[2K\n")
+	}
+	lines = max(lines, progress.lines)
+
+	elapsed := now.Sub(progress.started)
+	var speed float64
+	if elapsed > 0 {
+		speed = float64(transferring) / elapsed.Seconds()
+	}
+
+	fmt.Fprintf(&b, "\x1b[2K%v %v %v files, %.1f MB/s\n", progress.description, progressLine(transferring, progress.totalBytes), progress.doneFiles, speed/1e6)
+	progress.lines = lines + 1
+
+	return b.String()
+}
+
+// shortName returns a name cut to fileProgressNameWidth, keeping its end.
+func shortName(name string) string {
+	if len(name) <= fileProgressNameWidth {
+		return name
+	}
+	return "..." + name[len(name)-fileProgressNameWidth+3:]
+}
+
+// progressLine returns a bar, percentage, and byte counts for current of total bytes.
+func progressLine(current int64, total int64) string {
+	fraction := 1.0
+	if total > 0 {
+		fraction = min(max(float64(current)/float64(total), 0), 1)
+	}
+
+	filled := int(fraction * fileProgressWidth)
+	return fmt.Sprintf("[%v%v] %3.0f%% %.1f/%.1f MB", strings.Repeat("=", filled), strings.Repeat(" ", fileProgressWidth-filled), fraction*100, float64(current)/1e6, float64(total)/1e6)
+}
@@ -0,0 +1,74 @@
+package boto3manager
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileProgressFrame(t *testing.T) {
+	t.Parallel()
+
+	progress := &fileProgress{description: "uploading", totalBytes: 1000, started: time.Now(), files: make(map[string]*inFlightFile)}
+
+	for i := range fileProgressFiles + 2 {
+		bar := progress.file(fmt.Sprintf("file%02d", i), int64(10+i))
+		bar.Add(5)
+	}
+
+	first := progress.frame(time.Now())
+	if !strings.HasPrefix(first, "\x1b[2K") {
+		t.Errorf("first frame moves the cursor up: %q", first)
+	}
+
+	// The largest files are shown, the rest counted
+	if !strings.Contains(first, "file09") || strings.Contains(first, "file00") || !strings.Contains(first, "and 2 more") {
+		t.Errorf("first frame = %q, want the largest files and 2 more", first)
+	}
+
+	progress.done("file09")
+	if progress.doneFiles != 1 || progress.doneBytes != 5 {
+		t.Errorf("done() counted %v files, %v bytes, want 1, 5", progress.doneFiles, progress.doneBytes)
+	}
+
+	// The next frame draws over the last, which had a line per file shown, a line for the rest, and the overall bar
+	second := progress.frame(time.Now())
+	if wanted := fmt.Sprintf("\x1b[%dA", fileProgressFiles+2); !strings.HasPrefix(second, wanted) {
+		t.Errorf("second frame = %q, want it to start with %q", second, wanted)
+	}
+}
+
+func TestShortName(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("a", 50) + "/end.csv"
+	if got := shortName(long); len(got) != fileProgressNameWidth || !strings.HasSuffix(got, "/end.csv") || !strings.HasPrefix(got, "...") {
+		t.Errorf("shortName(%v) = %v, want %v characters ending in /end.csv", long, got, fileProgressNameWidth)
+	}
+
+	if got := shortName("short.csv"); got != "short.csv" {
+		t.Errorf("shortName(short.csv) = %v, want short.csv", got)
+	}
+}
+
+func TestPlainLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		current int64
+		total   int64
+		files   int
+		elapsed time.Duration
+		wanted  string
+	}{
+		{current: 5e6, total: 20e6, files: 3, elapsed: 10 * time.Second, wanted: "uploading: 25% 5.0/20.0 MB, 3 files, 0.5 MB/s, 10s elapsed"},
+		{current: 0, total: 0, files: 0, elapsed: 0, wanted: "uploading: 100% 0.0/0.0 MB, 0 files, 0.0 MB/s, 0s elapsed"},
+	}
+
+	for _, test := range tests {
+		if got := plainLine("uploading", test.current, test.total, test.files, test.elapsed); got != test.wanted {
+			t.Errorf("plainLine(%v, %v, %v, %v) = %v, want %v", test.current, test.total, test.files, test.elapsed, got, test.wanted)
+		}
+	}
+}
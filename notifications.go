@@ -0,0 +1,209 @@
+package boto3manager
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Event types to be notified of. See the S3 documentation for the full list.
+const (
+	ObjectCreatedEvents = "s3:ObjectCreated:*"
+	ObjectRemovedEvents = "s3:ObjectRemoved:*"
+)
+
+// NotificationTarget is the kind of destination a bucket notification is sent to.
+type NotificationTarget int
+
+const (
+	// NotifyQueue sends events to an SQS queue
+	NotifyQueue NotificationTarget = iota
+	// NotifyTopic publishes events to an SNS topic. On Ceph RGW, topics can push to HTTP webhooks, AMQP, or Kafka
+	NotifyTopic
+	// NotifyFunction invokes a Lambda function
+	NotifyFunction
+)
+
+// Notification is a simplified bucket notification sending events for keys with a prefix and suffix to a target.
+// Build one with NewQueueNotification or NewTopicNotification and its methods, e.g.
+//
+//	NewTopicNotification("new-images", RGWTopicARN("default", "", "thumbnailer"), ObjectCreatedEvents).Filter("raw/", ".jpg")
+type Notification struct {
+	ID     string
+	Target NotificationTarget
+
+	// ARN is the ARN of the queue, topic, or function events are sent to
+	ARN string
+
+	// Events are the event types sent, such as ObjectCreatedEvents
+	Events []string
+
+	// Prefix and Suffix, if set, limit the events to keys starting and ending with them
+	Prefix string
+	Suffix string
+}
+
+// NewQueueNotification returns a notification sending events to the SQS queue with the given ARN.
+func NewQueueNotification(id string, queueARN string, events ...string) Notification {
+	return Notification{ID: id, Target: NotifyQueue, ARN: queueARN, Events: events}
+}
+
+// NewTopicNotification returns a notification publishing events to the SNS topic with the given ARN.
+func NewTopicNotification(id string, topicARN string, events ...string) Notification {
+	return Notification{ID: id, Target: NotifyTopic, ARN: topicARN, Events: events}
+}
+
+// Filter returns the notification limited to keys starting with prefix and ending with suffix. Either can be empty.
+func (notification Notification) Filter(prefix string, suffix string) Notification {
+	notification.Prefix = prefix
+	notification.Suffix = suffix
+	return notification
+}
+
+// RGWTopicARN returns the ARN of a Ceph RGW topic in a zonegroup, such as "default", and tenant, which is empty
+// for users without one. The topic must already exist; an HTTP webhook topic is created through RGW's SNS API with
+// a push-endpoint attribute, for example with the AWS CLI's sns create-topic.
+func RGWTopicARN(zonegroup string, tenant string, topic string) string {
+	return "arn:aws:sns:" + zonegroup + ":" + tenant + ":" + topic
+}
+
+// filter returns the SDK's representation of the notification's key filter, or nil if it has none.
+func (notification Notification) filter() *types.NotificationConfigurationFilter {
+	rules := make([]types.FilterRule, 0, 2)
+	if notification.Prefix != "" {
+		rules = append(rules, types.FilterRule{Name: types.FilterRuleNamePrefix, Value: aws.String(notification.Prefix)})
+	}
+	if notification.Suffix != "" {
+		rules = append(rules, types.FilterRule{Name: types.FilterRuleNameSuffix, Value: aws.String(notification.Suffix)})
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	return &types.NotificationConfigurationFilter{Key: &types.S3KeyFilter{FilterRules: rules}}
+}
+
+// events returns the notification's events as the SDK's type.
+func (notification Notification) events() []types.Event {
+	events := make([]types.Event, 0, len(notification.Events))
+	for _, event := range notification.Events {
+		events = append(events, types.Event(event))
+	}
+
+	return events
+}
+
+// notificationsToSDK converts notifications to the SDK's representation of a bucket's notification configuration.
+func notificationsToSDK(notifications []Notification) *types.NotificationConfiguration {
+	config := &types.NotificationConfiguration{}
+
+	for _, notification := range notifications {
+		switch notification.Target {
+		case NotifyQueue:
+			config.QueueConfigurations = append(config.QueueConfigurations, types.QueueConfiguration{
+				Id:       aws.String(notification.ID),
+				QueueArn: aws.String(notification.ARN),
+				Events:   notification.events(),
+				Filter:   notification.filter(),
+			})
+		case NotifyTopic:
+			config.TopicConfigurations = append(config.TopicConfigurations, types.TopicConfiguration{
+				Id:       aws.String(notification.ID),
+				TopicArn: aws.String(notification.ARN),
+				Events:   notification.events(),
+				Filter:   notification.filter(),
+			})
+		case NotifyFunction:
+			config.LambdaFunctionConfigurations = append(config.LambdaFunctionConfigurations, types.LambdaFunctionConfiguration{
+				Id:                aws.String(notification.ID),
+				LambdaFunctionArn: aws.String(notification.ARN),
+				Events:            notification.events(),
+				Filter:            notification.filter(),
+			})
+		}
+	}
+
+	return config
+}
+
+// notificationFromSDK builds a notification from the parts every kind of SDK configuration has.
+func notificationFromSDK(target NotificationTarget, id *string, arn *string, events []types.Event, filter *types.NotificationConfigurationFilter) Notification {
+	notification := Notification{ID: aws.ToString(id), Target: target, ARN: aws.ToString(arn)}
+
+	for _, event := range events {
+		notification.Events = append(notification.Events, string(event))
+	}
+
+	if filter != nil && filter.Key != nil {
+		for _, rule := range filter.Key.FilterRules {
+			switch types.FilterRuleName(strings.ToLower(string(rule.Name))) {
+			case types.FilterRuleNamePrefix:
+				notification.Prefix = aws.ToString(rule.Value)
+			case types.FilterRuleNameSuffix:
+				notification.Suffix = aws.ToString(rule.Value)
+			}
+		}
+	}
+
+	return notification
+}
+
+// notificationsFromSDK converts a bucket's notification configuration from the SDK's representation.
+func notificationsFromSDK(config *types.NotificationConfiguration) []Notification {
+	notifications := make([]Notification, 0)
+
+	for _, queue := range config.QueueConfigurations {
+		notifications = append(notifications, notificationFromSDK(NotifyQueue, queue.Id, queue.QueueArn, queue.Events, queue.Filter))
+	}
+	for _, topic := range config.TopicConfigurations {
+		notifications = append(notifications, notificationFromSDK(NotifyTopic, topic.Id, topic.TopicArn, topic.Events, topic.Filter))
+	}
+	for _, function := range config.LambdaFunctionConfigurations {
+		notifications = append(notifications, notificationFromSDK(NotifyFunction, function.Id, function.LambdaFunctionArn, function.Events, function.Filter))
+	}
+
+	return notifications
+}
+
+// GetNotifications takes a bucket name and returns its event notifications.
+func (basics BucketBasics) GetNotifications(bucketName string) ([]Notification, error) {
+	output, err := basics.client(bucketName).GetBucketNotificationConfiguration(context.TODO(), &s3.GetBucketNotificationConfigurationInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get notification configuration of bucket %v: %v", bucketName, err)
+		return nil, err
+	}
+
+	return notificationsFromSDK(&types.NotificationConfiguration{
+		QueueConfigurations:          output.QueueConfigurations,
+		TopicConfigurations:          output.TopicConfigurations,
+		LambdaFunctionConfigurations: output.LambdaFunctionConfigurations,
+	}), nil
+}
+
+// PutNotifications takes a bucket name and notifications and replaces the bucket's event notifications. The
+// targets must allow the bucket to send to them.
+func (basics BucketBasics) PutNotifications(bucketName string, notifications []Notification) error {
+	_, err := basics.client(bucketName).PutBucketNotificationConfiguration(context.TODO(), &s3.PutBucketNotificationConfigurationInput{
+		Bucket:                    aws.String(bucketName),
+		NotificationConfiguration: notificationsToSDK(notifications),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't put notification configuration on bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// DeleteNotifications takes a bucket name and removes all of its event notifications.
+func (basics BucketBasics) DeleteNotifications(bucketName string) error {
+	return basics.PutNotifications(bucketName, nil)
+}
@@ -0,0 +1,45 @@
+package boto3manager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNotificationsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		notification Notification
+	}{
+		{
+			name:         "queue",
+			notification: NewQueueNotification("ingest", "arn:aws:sqs:us-east-1:123456789012:ingest", ObjectCreatedEvents),
+		},
+		{
+			name:         "filtered topic",
+			notification: NewTopicNotification("images", RGWTopicARN("default", "", "thumbnailer"), ObjectCreatedEvents, ObjectRemovedEvents).Filter("raw/", ".jpg"),
+		},
+		{
+			name:         "function with suffix",
+			notification: Notification{ID: "index", Target: NotifyFunction, ARN: "arn:aws:lambda:us-east-1:123456789012:function:index", Events: []string{ObjectCreatedEvents}, Suffix: ".csv"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := notificationsFromSDK(notificationsToSDK([]Notification{tt.notification}))
+			if len(got) != 1 || !reflect.DeepEqual(got[0], tt.notification) {
+				t.Errorf("notificationsFromSDK(notificationsToSDK(%+v)) = %+v", tt.notification, got)
+			}
+		})
+	}
+}
+
+func TestRGWTopicARN(t *testing.T) {
+	t.Parallel()
+
+	if got, wanted := RGWTopicARN("default", "", "webhook"), "arn:aws:sns:default::webhook"; got != wanted {
+		t.Errorf("RGWTopicARN(default, , webhook) = %v, want %v", got, wanted)
+	}
+}
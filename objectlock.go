@@ -0,0 +1,140 @@
+package boto3manager
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectRetention is an Object Lock retention period: the object version can't be deleted or overwritten until
+// RetainUntil. In governance mode users allowed to bypass governance retention can still remove it; in compliance
+// mode nobody can, including the root user.
+type ObjectRetention struct {
+	Mode        types.ObjectLockRetentionMode
+	RetainUntil time.Time
+}
+
+// RetainFor returns a retention in mode lasting for period from now.
+func RetainFor(mode types.ObjectLockRetentionMode, period time.Duration) ObjectRetention {
+	return ObjectRetention{Mode: mode, RetainUntil: time.Now().UTC().Add(period)}
+}
+
+// objectLock is the Object Lock settings an object is created with: a retention period, a legal hold, or both.
+type objectLock struct {
+	retention *ObjectRetention
+	legalHold bool
+}
+
+// locked reports whether the lock sets anything.
+func (lock objectLock) locked() bool {
+	return lock.retention != nil || lock.legalHold
+}
+
+// putObject sets the lock on a PutObject request.
+func (lock objectLock) putObject(input *s3.PutObjectInput) {
+	if lock.retention != nil {
+		input.ObjectLockMode = types.ObjectLockMode(lock.retention.Mode)
+		input.ObjectLockRetainUntilDate = aws.Time(lock.retention.RetainUntil.UTC())
+	}
+	if lock.legalHold {
+		input.ObjectLockLegalHoldStatus = types.ObjectLockLegalHoldStatusOn
+	}
+}
+
+// createMultipartUpload sets the lock on a CreateMultipartUpload request, so the completed object has it.
+func (lock objectLock) createMultipartUpload(input *s3.CreateMultipartUploadInput) {
+	if lock.retention != nil {
+		input.ObjectLockMode = types.ObjectLockMode(lock.retention.Mode)
+		input.ObjectLockRetainUntilDate = aws.Time(lock.retention.RetainUntil.UTC())
+	}
+	if lock.legalHold {
+		input.ObjectLockLegalHoldStatus = types.ObjectLockLegalHoldStatusOn
+	}
+}
+
+// GetObjectRetention takes a key and a bucket name and returns the retention period of the object's current
+// version. An object without one returns an error.
+func (basics BucketBasics) GetObjectRetention(key string, bucketName string) (ObjectRetention, error) {
+	output, err := basics.client(bucketName).GetObjectRetention(context.TODO(), &s3.GetObjectRetentionInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get retention of object %v in bucket %v: %v", key, bucketName, err)
+		return ObjectRetention{}, classifyError(err)
+	}
+
+	if output.Retention == nil {
+		return ObjectRetention{}, nil
+	}
+
+	return ObjectRetention{Mode: output.Retention.Mode, RetainUntil: aws.ToTime(output.Retention.RetainUntilDate)}, nil
+}
+
+// PutObjectRetention takes a key, a bucket name, and a retention and sets the retention period of the object's
+// current version. A period can always be extended, but shortening a governance mode period needs
+// bypassGovernance and the permission to use it. Compliance mode periods can't be shortened.
+func (basics BucketBasics) PutObjectRetention(key string, bucketName string, retention ObjectRetention, bypassGovernance bool) error {
+	input := &s3.PutObjectRetentionInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Retention: &types.ObjectLockRetention{
+			Mode:            retention.Mode,
+			RetainUntilDate: aws.Time(retention.RetainUntil),
+		},
+	}
+
+	if bypassGovernance {
+		input.BypassGovernanceRetention = aws.Bool(true)
+	}
+
+	_, err := basics.client(bucketName).PutObjectRetention(context.TODO(), input)
+	if err != nil {
+		log.Printf("Couldn't put retention on object %v in bucket %v: %v", key, bucketName, err)
+	}
+
+	return classifyError(err)
+}
+
+// GetObjectLegalHold takes a key and a bucket name and returns whether the object's current version is under a
+// legal hold.
+func (basics BucketBasics) GetObjectLegalHold(key string, bucketName string) (bool, error) {
+	output, err := basics.client(bucketName).GetObjectLegalHold(context.TODO(), &s3.GetObjectLegalHoldInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get legal hold of object %v in bucket %v: %v", key, bucketName, err)
+		return false, classifyError(err)
+	}
+
+	return output.LegalHold != nil && output.LegalHold.Status == types.ObjectLockLegalHoldStatusOn, nil
+}
+
+// PutObjectLegalHold takes a key, a bucket name, and whether to hold the object and places or removes a legal
+// hold on its current version. A held object can't be deleted or overwritten, whatever its retention period,
+// until the hold is removed.
+func (basics BucketBasics) PutObjectLegalHold(key string, bucketName string, hold bool) error {
+	status := types.ObjectLockLegalHoldStatusOff
+	if hold {
+		status = types.ObjectLockLegalHoldStatusOn
+	}
+
+	_, err := basics.client(bucketName).PutObjectLegalHold(context.TODO(), &s3.PutObjectLegalHoldInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		LegalHold: &types.ObjectLockLegalHold{Status: status},
+	})
+
+	if err != nil {
+		log.Printf("Couldn't put legal hold on object %v in bucket %v: %v", key, bucketName, err)
+	}
+
+	return classifyError(err)
+}
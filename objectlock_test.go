@@ -0,0 +1,96 @@
+package boto3manager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestUploadObjectLock(t *testing.T) {
+	t.Parallel()
+
+	until := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	retention := &ObjectRetention{Mode: types.ObjectLockRetentionModeCompliance, RetainUntil: until}
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.csv")
+	large := filepath.Join(dir, "large.csv")
+	if err := os.WriteFile(small, []byte("locked"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Large enough to go through a multipart upload
+	if err := os.WriteFile(large, bytes.Repeat([]byte("x"), verifiedPartSize+1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		options       UploadObjectOptions
+		wantedMode    string
+		wantedUntil   string
+		wantedHold    string
+		wantedRequest string
+	}{
+		{
+			name:          "retention",
+			path:          small,
+			options:       UploadObjectOptions{Retention: retention},
+			wantedMode:    "COMPLIANCE",
+			wantedUntil:   "2030-01-02T03:04:05Z",
+			wantedRequest: "PUT locks/retention",
+		},
+		{
+			name:          "legal hold",
+			path:          small,
+			options:       UploadObjectOptions{LegalHold: true},
+			wantedHold:    "ON",
+			wantedRequest: "PUT locks/legal hold",
+		},
+		{
+			name:          "multipart",
+			path:          large,
+			options:       UploadObjectOptions{Retention: retention, LegalHold: true},
+			wantedMode:    "COMPLIANCE",
+			wantedUntil:   "2030-01-02T03:04:05Z",
+			wantedHold:    "ON",
+			wantedRequest: "POST locks/multipart?uploads",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "locks")
+			if err := fake.basics().UploadObject(tt.path, tt.name, "locks", tt.options); err != nil {
+				t.Fatalf("UploadObject() = %v, want nil", err)
+			}
+
+			object, ok := fake.object("locks", tt.name)
+			if !ok {
+				t.Fatalf("UploadObject() didn't write %v", tt.name)
+			}
+
+			header := object.header
+			if got := header.Get("X-Amz-Object-Lock-Mode"); got != tt.wantedMode {
+				t.Errorf("UploadObject() lock mode = %q, want %q", got, tt.wantedMode)
+			}
+			if got := header.Get("X-Amz-Object-Lock-Retain-Until-Date"); got != tt.wantedUntil {
+				t.Errorf("UploadObject() retain until = %q, want %q", got, tt.wantedUntil)
+			}
+			if got := header.Get("X-Amz-Object-Lock-Legal-Hold"); got != tt.wantedHold {
+				t.Errorf("UploadObject() legal hold = %q, want %q", got, tt.wantedHold)
+			}
+
+			// The lock is set on the request that creates the object
+			if got := fake.count(tt.wantedRequest); got != 1 {
+				t.Errorf("UploadObject() sent %v %v times, want 1: %v", tt.wantedRequest, got, fake.served())
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"mime"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// MetadataUpdate is a change to an object's headers and user metadata, made in place by copying the object onto
+// itself so nothing is downloaded or uploaded again. Headers left empty keep their current values.
+type MetadataUpdate struct {
+	// ContentType, if set, replaces the object's content type
+	ContentType string
+
+	// GuessContentType sets the content type from the key's extension, for keys with an extension that is known.
+	// ContentType takes precedence
+	GuessContentType bool
+
+	// CacheControl, if set, replaces the object's Cache-Control header
+	CacheControl string
+
+	// Metadata is merged into the object's user metadata. A key with an empty value is removed
+	Metadata map[string]string
+
+	// ReplaceMetadata replaces the object's user metadata with Metadata instead of merging them
+	ReplaceMetadata bool
+}
+
+// apply returns the object's headers and user metadata with the update made.
+func (update MetadataUpdate) apply(key string, head *s3.HeadObjectOutput) (*s3.HeadObjectOutput, map[string]string) {
+	updated := *head
+
+	if update.GuessContentType {
+		if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+			updated.ContentType = aws.String(contentType)
+		}
+	}
+	if update.ContentType != "" {
+		updated.ContentType = aws.String(update.ContentType)
+	}
+	if update.CacheControl != "" {
+		updated.CacheControl = aws.String(update.CacheControl)
+	}
+
+	metadata := make(map[string]string)
+	if !update.ReplaceMetadata {
+		maps.Copy(metadata, head.Metadata)
+	}
+	for name, value := range update.Metadata {
+		if value == "" {
+			delete(metadata, name)
+			continue
+		}
+		metadata[name] = value
+	}
+
+	return &updated, metadata
+}
+
+// SetMetadata takes a key, a bucket name, and user metadata and replaces the object's user metadata in place,
+// keeping its content type and other headers.
+func (basics BucketBasics) SetMetadata(key string, bucketName string, metadata map[string]string) error {
+	return basics.UpdateMetadata(key, bucketName, MetadataUpdate{Metadata: metadata, ReplaceMetadata: true})
+}
+
+// SetContentType takes a key, a bucket name, and a content type and changes the object's content type in place,
+// keeping its user metadata and other headers.
+func (basics BucketBasics) SetContentType(key string, bucketName string, contentType string) error {
+	return basics.UpdateMetadata(key, bucketName, MetadataUpdate{ContentType: contentType})
+}
+
+// UpdateMetadata takes a key, a bucket name, and an update and makes the update to the object in place.
+func (basics BucketBasics) UpdateMetadata(key string, bucketName string, update MetadataUpdate) error {
+	return basics.updateMetadata(context.TODO(), key, bucketName, update)
+}
+
+func (basics BucketBasics) updateMetadata(ctx context.Context, key string, bucketName string, update MetadataUpdate) error {
+	head, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get object %v: %v", key, err)
+		return classifyError(err)
+	}
+
+	updated, metadata := update.apply(key, head)
+	return classifyError(basics.replaceMetadata(ctx, key, bucketName, updated, metadata))
+}
+
+type UpdateMetadataOptions struct {
+	// RetryPolicy controls retries of each object. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Hooks, if set, are called as objects start and finish
+	Hooks *Hooks
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+}
+
+// UpdateMetadataObjects takes a pattern, a bucket name, and an update and makes the update in place to every object
+// matching the pattern, such as fixing the content types of thousands of objects uploaded without one. The
+// returned report holds the result of every object.
+func (basics BucketBasics) UpdateMetadataObjects(pattern string, bucketName string, update MetadataUpdate, options UpdateMetadataOptions) (*TransferReport, error) {
+	ctx := context.TODO()
+	options.Quiet = defaultQuiet(options.Quiet)
+
+	matches, err := basics.matchObjects(ctx, pattern, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]FileDownload, 0, len(matches))
+	for _, object := range matches {
+		objects = append(objects, FileDownload{Key: aws.ToString(object.Key), Size: aws.ToInt64(object.Size)})
+	}
+
+	bar := newCountBar(int64(len(objects)), "updating")
+	if options.Quiet {
+		bar = silentBar(int64(len(objects)))
+	}
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  25,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		hooks:        options.Hooks,
+		backpressure: basics.Backpressure,
+	}
+
+	err = runBatch(ctx, objects, config, func(ctx context.Context, object FileDownload) error {
+		if err := basics.updateMetadata(ctx, object.Key, bucketName, update); err != nil {
+			return err
+		}
+
+		bar.Add(1)
+		return nil
+	})
+
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	return report, err
+}
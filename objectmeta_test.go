@@ -0,0 +1,189 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestMetadataUpdateApply(t *testing.T) {
+	t.Parallel()
+
+	head := &s3.HeadObjectOutput{
+		ContentType:  aws.String("binary/octet-stream"),
+		CacheControl: aws.String("no-cache"),
+		Metadata:     map[string]string{"owner": "lab", "sha256": "abc"},
+	}
+
+	tests := []struct {
+		name               string
+		update             MetadataUpdate
+		wantedContentType  string
+		wantedCacheControl string
+		wantedMetadata     map[string]string
+	}{
+		{
+			name:               "content type",
+			update:             MetadataUpdate{ContentType: "text/csv"},
+			wantedContentType:  "text/csv",
+			wantedCacheControl: "no-cache",
+			wantedMetadata:     map[string]string{"owner": "lab", "sha256": "abc"},
+		},
+		{
+			name:               "guessed content type",
+			update:             MetadataUpdate{GuessContentType: true, CacheControl: "max-age=60"},
+			wantedContentType:  "text/html; charset=utf-8",
+			wantedCacheControl: "max-age=60",
+			wantedMetadata:     map[string]string{"owner": "lab", "sha256": "abc"},
+		},
+		{
+			name:               "merged metadata",
+			update:             MetadataUpdate{Metadata: map[string]string{"owner": "", "project": "kelp"}},
+			wantedContentType:  "binary/octet-stream",
+			wantedCacheControl: "no-cache",
+			wantedMetadata:     map[string]string{"sha256": "abc", "project": "kelp"},
+		},
+		{
+			name:               "replaced metadata",
+			update:             MetadataUpdate{Metadata: map[string]string{"project": "kelp"}, ReplaceMetadata: true},
+			wantedContentType:  "binary/octet-stream",
+			wantedCacheControl: "no-cache",
+			wantedMetadata:     map[string]string{"project": "kelp"},
+		},
+	}
+
+	for _, test := range tests {
+		updated, metadata := test.update.apply("site/index.html", head)
+
+		if got := aws.ToString(updated.ContentType); got != test.wantedContentType {
+			t.Errorf("%v: ContentType = %v, want %v", test.name, got, test.wantedContentType)
+		}
+		if got := aws.ToString(updated.CacheControl); got != test.wantedCacheControl {
+			t.Errorf("%v: CacheControl = %v, want %v", test.name, got, test.wantedCacheControl)
+		}
+		if !maps.Equal(metadata, test.wantedMetadata) {
+			t.Errorf("%v: Metadata = %v, want %v", test.name, metadata, test.wantedMetadata)
+		}
+	}
+
+	// The object's own headers are left alone
+	if aws.ToString(head.ContentType) != "binary/octet-stream" || len(head.Metadata) != 2 {
+		t.Errorf("apply() changed the object's headers: %+v", head)
+	}
+}
+
+// archivedHeaders are the headers of an object in STANDARD_IA with every content header set.
+func archivedHeaders() http.Header {
+	return http.Header{
+		"Content-Type":        {"text/csv"},
+		"Content-Encoding":    {"gzip"},
+		"Content-Disposition": {"attachment"},
+		"Content-Language":    {"en"},
+		"Cache-Control":       {"no-cache"},
+		"X-Amz-Storage-Class": {"STANDARD_IA"},
+		"X-Amz-Meta-Owner":    {"lab"},
+	}
+}
+
+func TestUpdateMetadataKeepsHeaders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		multipart bool
+	}{
+		{name: "copy"},
+		{name: "multipart copy", multipart: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			fake.put("bucket", "data.csv.gz", "a,b,c", archivedHeaders())
+			basics := fake.basics()
+			ctx := context.Background()
+
+			update := MetadataUpdate{CacheControl: "max-age=60", Metadata: map[string]string{"checked": "yes"}}
+			if tt.multipart {
+				// Objects over 5 GiB take this path; call it directly on a small one
+				head, err := basics.client("bucket").HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("bucket"), Key: aws.String("data.csv.gz")})
+				if err != nil {
+					t.Fatal(err)
+				}
+				updated, metadata := update.apply("data.csv.gz", head)
+				if err := basics.replaceMetadataMultipart(ctx, "data.csv.gz", "bucket", updated, metadata); err != nil {
+					t.Fatalf("replaceMetadataMultipart() = %v", err)
+				}
+			} else if err := basics.UpdateMetadata("data.csv.gz", "bucket", update); err != nil {
+				t.Fatalf("UpdateMetadata() = %v", err)
+			}
+
+			object, _ := fake.object("bucket", "data.csv.gz")
+			wanted := archivedHeaders()
+			wanted.Set("Cache-Control", "max-age=60")
+			wanted.Set("X-Amz-Meta-Checked", "yes")
+
+			for name := range wanted {
+				if got := object.header.Get(name); got != wanted.Get(name) {
+					t.Errorf("%v after update = %q, want %q", name, got, wanted.Get(name))
+				}
+			}
+			if string(object.body) != "a,b,c" {
+				t.Errorf("body after update = %q, want %q", object.body, "a,b,c")
+			}
+		})
+	}
+}
+
+func TestUpdateMetadataPinnedToETag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		multipart bool
+	}{
+		{name: "copy"},
+		{name: "multipart copy", multipart: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			fake.put("bucket", "data.csv", "old", http.Header{"Content-Type": {"text/csv"}})
+			basics := fake.basics()
+			ctx := context.Background()
+
+			head, err := basics.client("bucket").HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("bucket"), Key: aws.String("data.csv")})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Another writer replaces the object between the HEAD and the copy
+			fake.put("bucket", "data.csv", "new", http.Header{"Content-Type": {"application/json"}})
+
+			updated, metadata := MetadataUpdate{ContentType: "text/plain"}.apply("data.csv", head)
+			if tt.multipart {
+				err = basics.replaceMetadataMultipart(ctx, "data.csv", "bucket", updated, metadata)
+			} else {
+				err = basics.replaceMetadata(ctx, "data.csv", "bucket", updated, metadata)
+			}
+			if !errors.Is(classifyError(err), ErrPreconditionFailed) {
+				t.Errorf("replaceMetadata() of an overwritten object = %v, want %v", err, ErrPreconditionFailed)
+			}
+
+			object, _ := fake.object("bucket", "data.csv")
+			if got := object.header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type of the new object = %q, want %q", got, "application/json")
+			}
+		})
+	}
+}
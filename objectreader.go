@@ -0,0 +1,258 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// OpenOptions configures how OpenObject reads an object: how big each ranged GET is, how many blocks are cached,
+// and how far ahead of a sequential reader it fetches.
+type OpenOptions struct {
+	// BlockSize is the size of each ranged GET. Defaults to 1 MiB
+	BlockSize int64
+
+	// CacheBlocks is how many blocks are kept in memory, least recently used dropped first. Defaults to 16
+	CacheBlocks int
+
+	// ReadAhead is how many blocks after the current one are fetched in the background while reading
+	// sequentially. Defaults to 2; a negative value turns read-ahead off
+	ReadAhead int
+}
+
+// objectBlock is one block of an ObjectReader's cache. ready is closed once data or err is set.
+type objectBlock struct {
+	ready chan struct{}
+	data  []byte
+	err   error
+}
+
+// ObjectReader reads an object with ranged GETs, a block at a time, so file formats that seek, such as Parquet,
+// zip, and HDF5, can be read without downloading the whole object. It implements io.ReadSeeker, io.ReaderAt, and
+// io.Closer. ReadAt may be called concurrently; Read and Seek may not. Every request is pinned to the ETag the
+// object had when it was opened, so reads fail with ErrPreconditionFailed if it is overwritten.
+type ObjectReader struct {
+	size        int64
+	blockSize   int64
+	cacheBlocks int
+	readAhead   int
+
+	// fetch returns bytes [start, end) of the object
+	fetch func(ctx context.Context, start int64, end int64) ([]byte, error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	blocks map[int64]*objectBlock
+	recent []int64
+	last   int64
+
+	offset int64
+}
+
+// OpenObject takes a key, a bucket name, and options and returns a reader for the object that fetches only the
+// parts that are read. Close it to stop any read-ahead in progress.
+func (basics BucketBasics) OpenObject(key string, bucketName string, options OpenOptions) (*ObjectReader, error) {
+	head, err := basics.client(bucketName).HeadObject(context.TODO(), &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't open object %v: %v", key, err)
+		return nil, classifyError(err)
+	}
+
+	fetch := func(ctx context.Context, start int64, end int64) ([]byte, error) {
+		output, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+			Bucket:  aws.String(bucketName),
+			Key:     aws.String(key),
+			Range:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end-1)),
+			IfMatch: head.ETag,
+		})
+
+		if err != nil {
+			log.Printf("Couldn't read bytes %v-%v of object %v: %v", start, end-1, key, err)
+			return nil, classifyError(err)
+		}
+
+		defer output.Body.Close()
+		return io.ReadAll(output.Body)
+	}
+
+	return newObjectReader(aws.ToInt64(head.ContentLength), fetch, options), nil
+}
+
+// newObjectReader returns a reader for an object of the given size read with fetch.
+func newObjectReader(size int64, fetch func(ctx context.Context, start int64, end int64) ([]byte, error), options OpenOptions) *ObjectReader {
+	if options.BlockSize <= 0 {
+		options.BlockSize = 1024 * 1024
+	}
+	if options.CacheBlocks <= 0 {
+		options.CacheBlocks = 16
+	}
+	if options.ReadAhead == 0 {
+		options.ReadAhead = 2
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ObjectReader{
+		size:        size,
+		blockSize:   options.BlockSize,
+		cacheBlocks: options.CacheBlocks,
+		readAhead:   max(options.ReadAhead, 0),
+		fetch:       fetch,
+		ctx:         ctx,
+		cancel:      cancel,
+		blocks:      make(map[int64]*objectBlock),
+		last:        -1,
+	}
+}
+
+// Size returns the size of the object.
+func (r *ObjectReader) Size() int64 {
+	return r.size
+}
+
+// block returns a block of the object, starting to fetch it if it isn't cached. The caller waits on its ready
+// channel. r.mu must be held.
+func (r *ObjectReader) block(index int64) *objectBlock {
+	// Mark the block most recently used
+	if i := slices.Index(r.recent, index); i > -1 {
+		r.recent = slices.Delete(r.recent, i, i+1)
+	}
+	r.recent = append(r.recent, index)
+
+	if b, ok := r.blocks[index]; ok {
+		return b
+	}
+
+	b := &objectBlock{ready: make(chan struct{})}
+	r.blocks[index] = b
+
+	for len(r.recent) > r.cacheBlocks {
+		delete(r.blocks, r.recent[0])
+		r.recent = r.recent[1:]
+	}
+
+	start := index * r.blockSize
+	end := min(start+r.blockSize, r.size)
+
+	go func() {
+		b.data, b.err = r.fetch(r.ctx, start, end)
+
+		// A short block would leave ReadAt copying past its end, or spinning on one that is empty
+		if b.err == nil && int64(len(b.data)) != end-start {
+			log.Printf("Couldn't read bytes %v-%v: got %v bytes", start, end-1, len(b.data))
+			b.data, b.err = nil, io.ErrUnexpectedEOF
+		}
+
+		// Drop failed blocks so they are fetched again on the next read
+		if b.err != nil {
+			r.mu.Lock()
+			if r.blocks[index] == b {
+				delete(r.blocks, index)
+			}
+			r.mu.Unlock()
+		}
+
+		close(b.ready)
+	}()
+
+	return b
+}
+
+// ReadAt reads len(p) bytes starting at off, fetching the blocks they fall in.
+func (r *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	n := 0
+	for n < len(p) && off < r.size {
+		index := off / r.blockSize
+
+		r.mu.Lock()
+		b := r.block(index)
+
+		// Fetch the next blocks ahead of a sequential reader
+		if index == r.last+1 {
+			for ahead := int64(1); ahead <= int64(r.readAhead) && (index+ahead)*r.blockSize < r.size; ahead++ {
+				r.block(index + ahead)
+			}
+		}
+		r.last = index
+		r.mu.Unlock()
+
+		select {
+		case <-b.ready:
+		case <-r.ctx.Done():
+			return n, r.ctx.Err()
+		}
+
+		if b.err != nil {
+			return n, b.err
+		}
+
+		copied := copy(p[n:], b.data[off-index*r.blockSize:])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Read reads from the current offset.
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	n, err := r.ReadAt(p, r.offset)
+	r.offset += int64(n)
+
+	if errors.Is(err, io.EOF) && n > 0 {
+		err = nil
+	}
+
+	return n, err
+}
+
+// Seek sets the offset of the next Read.
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	r.offset = offset
+	return offset, nil
+}
+
+// Close stops any fetches in progress. Reads after Close fail.
+func (r *ObjectReader) Close() error {
+	r.cancel()
+	return nil
+}
@@ -0,0 +1,110 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestObjectReader(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	fetch := func(ctx context.Context, start int64, end int64) ([]byte, error) {
+		return data[start:end], nil
+	}
+
+	tests := []struct {
+		name   string
+		offset int64
+		length int
+		wanted []byte
+		eof    bool
+	}{
+		{name: "within a block", offset: 10, length: 20, wanted: data[10:30]},
+		{name: "across blocks", offset: 90, length: 250, wanted: data[90:340]},
+		{name: "tail", offset: 990, length: 20, wanted: data[990:], eof: true},
+		{name: "past the end", offset: 1000, length: 5, wanted: []byte{}, eof: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newObjectReader(int64(len(data)), fetch, OpenOptions{BlockSize: 100, CacheBlocks: 4})
+			defer r.Close()
+
+			p := make([]byte, tt.length)
+			n, err := r.ReadAt(p, tt.offset)
+			if (err == io.EOF) != tt.eof || (err != nil && err != io.EOF) {
+				t.Errorf("ReadAt(%v, %v) error = %v, want EOF %v", tt.length, tt.offset, err, tt.eof)
+			}
+			if !bytes.Equal(p[:n], tt.wanted) {
+				t.Errorf("ReadAt(%v, %v) = %v, want %v", tt.length, tt.offset, p[:n], tt.wanted)
+			}
+		})
+	}
+}
+
+func TestObjectReaderSequential(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	fetch := func(ctx context.Context, start int64, end int64) ([]byte, error) {
+		return data[start:end], nil
+	}
+
+	r := newObjectReader(int64(len(data)), fetch, OpenOptions{BlockSize: 64, CacheBlocks: 2, ReadAhead: 1})
+	defer r.Close()
+
+	if _, err := r.Seek(100, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() = %v", err)
+	}
+	if !bytes.Equal(got, data[100:]) {
+		t.Errorf("ReadAll() after Seek(100) = %d bytes, want %d", len(got), len(data)-100)
+	}
+}
+
+func TestObjectReaderShortBlock(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 1000)
+
+	tests := []struct {
+		name  string
+		short int64
+	}{
+		{name: "short", short: 10},
+		{name: "empty", short: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The server returns fewer bytes than each range asked for
+			fetch := func(ctx context.Context, start int64, end int64) ([]byte, error) {
+				return data[start : end-tt.short], nil
+			}
+
+			r := newObjectReader(int64(len(data)), fetch, OpenOptions{BlockSize: 100})
+			defer r.Close()
+
+			_, err := r.ReadAt(make([]byte, 50), 60)
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("ReadAt() of a %v block = %v, want %v", tt.name, err, io.ErrUnexpectedEOF)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package boto3manager
+
+import (
+	"context"
+	"io"
+	"iter"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// StoreObject describes an object in an ObjectStore.
+type StoreObject struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+	Metadata     map[string]string
+}
+
+// ObjectStore is the set of operations the store-based transfers, such as SyncUpStore and SyncDownStore, need from
+// a storage backend. BucketBasics.Store returns the S3 implementation; implement it over GCS, Azure Blob Storage, or
+// anything else to reuse the batching, retries, reports, hooks, and progress bars with that backend. Keys are
+// slash separated and relative to whatever container the store is bound to.
+type ObjectStore interface {
+	// List returns the objects whose keys start with prefix, in key order
+	List(ctx context.Context, prefix string) iter.Seq2[StoreObject, error]
+
+	// Get opens an object for reading. The caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, StoreObject, error)
+
+	// Put writes size bytes from r to key with the given metadata, replacing any object already there
+	Put(ctx context.Context, key string, r io.Reader, size int64, metadata map[string]string) error
+
+	// Delete removes the objects at keys. Keys that don't exist are not an error
+	Delete(ctx context.Context, keys ...string) error
+
+	// Head returns an object's description without its contents. A missing object is an error wrapping
+	// ErrObjectNotFound
+	Head(ctx context.Context, key string) (StoreObject, error)
+
+	// Copy copies the object at srcKey to dstKey within the store
+	Copy(ctx context.Context, srcKey string, dstKey string) error
+}
+
+// s3Store is the ObjectStore for a bucket.
+type s3Store struct {
+	basics     BucketBasics
+	bucketName string
+	uploader   *manager.Uploader
+}
+
+// Store returns an ObjectStore for a bucket, for use with the store-based transfers.
+func (basics BucketBasics) Store(bucketName string) ObjectStore {
+	return s3Store{basics: basics, bucketName: bucketName, uploader: basics.newUploader(bucketName)}
+}
+
+func (store s3Store) List(ctx context.Context, prefix string) iter.Seq2[StoreObject, error] {
+	return func(yield func(StoreObject, error) bool) {
+		for object, err := range store.basics.listObjectsSeq(ctx, store.bucketName, prefix) {
+			if err != nil {
+				yield(StoreObject{}, err)
+				return
+			}
+
+			storeObject := StoreObject{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				ETag:         strings.Trim(aws.ToString(object.ETag), `"`),
+				LastModified: aws.ToTime(object.LastModified),
+			}
+
+			if !yield(storeObject, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (store s3Store) Get(ctx context.Context, key string) (io.ReadCloser, StoreObject, error) {
+	output, err := store.basics.client(store.bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(store.bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get object %v: %v", key, err)
+		return nil, StoreObject{}, classifyError(err)
+	}
+
+	return output.Body, StoreObject{
+		Key:          key,
+		Size:         aws.ToInt64(output.ContentLength),
+		ETag:         strings.Trim(aws.ToString(output.ETag), `"`),
+		LastModified: aws.ToTime(output.LastModified),
+		Metadata:     output.Metadata,
+	}, nil
+}
+
+func (store s3Store) Put(ctx context.Context, key string, r io.Reader, size int64, metadata map[string]string) error {
+	_, err := store.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:   aws.String(store.bucketName),
+		Key:      aws.String(key),
+		Body:     r,
+		Metadata: metadata,
+	})
+
+	if err != nil {
+		log.Printf("Couldn't upload object %v to bucket %v: %v", key, store.bucketName, err)
+	}
+
+	return classifyError(err)
+}
+
+func (store s3Store) Delete(ctx context.Context, keys ...string) error {
+	return classifyError(store.basics.deleteKeys(ctx, store.bucketName, keys))
+}
+
+func (store s3Store) Head(ctx context.Context, key string) (StoreObject, error) {
+	output, err := store.basics.client(store.bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(store.bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		return StoreObject{}, classifyError(err)
+	}
+
+	return StoreObject{
+		Key:          key,
+		Size:         aws.ToInt64(output.ContentLength),
+		ETag:         strings.Trim(aws.ToString(output.ETag), `"`),
+		LastModified: aws.ToTime(output.LastModified),
+		Metadata:     output.Metadata,
+	}, nil
+}
+
+func (store s3Store) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	head, err := store.Head(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+
+	return classifyError(store.basics.copyObject(ctx, store.bucketName, srcKey, dstKey, head.Size))
+}
@@ -0,0 +1,215 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// memStore is an ObjectStore held in memory.
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (store *memStore) object(key string) StoreObject {
+	return StoreObject{Key: key, Size: int64(len(store.objects[key])), LastModified: time.Now()}
+}
+
+func (store *memStore) List(ctx context.Context, prefix string) iter.Seq2[StoreObject, error] {
+	return func(yield func(StoreObject, error) bool) {
+		store.mu.Lock()
+		keys := slices.Sorted(maps.Keys(store.objects))
+		store.mu.Unlock()
+
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) && !yield(store.object(key), nil) {
+				return
+			}
+		}
+	}
+}
+
+func (store *memStore) Get(ctx context.Context, key string) (io.ReadCloser, StoreObject, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	data, ok := store.objects[key]
+	if !ok {
+		return nil, StoreObject{}, fmt.Errorf("%w: %v", ErrObjectNotFound, key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), store.object(key), nil
+}
+
+func (store *memStore) Put(ctx context.Context, key string, r io.Reader, size int64, metadata map[string]string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.objects[key] = data
+	return nil
+}
+
+func (store *memStore) Delete(ctx context.Context, keys ...string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, key := range keys {
+		delete(store.objects, key)
+	}
+	return nil
+}
+
+func (store *memStore) Head(ctx context.Context, key string) (StoreObject, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.objects[key]; !ok {
+		return StoreObject{}, fmt.Errorf("%w: %v", ErrObjectNotFound, key)
+	}
+	return store.object(key), nil
+}
+
+func (store *memStore) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.objects[dstKey] = store.objects[srcKey]
+	return nil
+}
+
+func TestSyncStoreRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newMemStore()
+	store.objects["data/stale.txt"] = []byte("stale")
+
+	src := t.TempDir()
+	files := map[string]string{"a.txt": "alpha", "sub/b.txt": "bravo"}
+	for name, contents := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := SyncUpStore(ctx, store, src, "data/", SyncOptions{Mirror: true, TrashPrefix: "trash/"})
+	if err != nil {
+		t.Fatalf("SyncUpStore() = %v", err)
+	}
+	if got := len(report.Succeeded()); got != 2 {
+		t.Errorf("SyncUpStore() uploaded %v files, want 2", got)
+	}
+	if _, ok := store.objects["data/stale.txt"]; ok {
+		t.Errorf("SyncUpStore() with Mirror left data/stale.txt")
+	}
+
+	dest := t.TempDir()
+	if _, err := SyncDownStore(ctx, store, "data/", dest, SyncOptions{}); err != nil {
+		t.Fatalf("SyncDownStore() = %v", err)
+	}
+
+	for name, wanted := range files {
+		got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != wanted {
+			t.Errorf("SyncDownStore() wrote %v = %q, want %q", name, got, wanted)
+		}
+	}
+}
+
+// truncatedStore is a memStore whose objects fail partway through being read.
+type truncatedStore struct {
+	*memStore
+}
+
+func (store truncatedStore) Get(ctx context.Context, key string) (io.ReadCloser, StoreObject, error) {
+	body, object, err := store.memStore.Get(ctx, key)
+	if err != nil {
+		return nil, object, err
+	}
+	return io.NopCloser(io.MultiReader(io.LimitReader(body, 2), iotest.ErrReader(io.ErrUnexpectedEOF))), object, nil
+}
+
+func TestGetFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	store := truncatedStore{newMemStore()}
+	store.objects["data/a.txt"] = []byte("replacement")
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := getFile(context.Background(), store, "data/a.txt", path, silentBar(-1)); err == nil {
+		t.Fatalf("getFile() of a truncated object = nil, want an error")
+	}
+
+	if got, _ := os.ReadFile(path); string(got) != "original" {
+		t.Errorf("getFile() of a truncated object left %q, want the original file", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("getFile() of a truncated object left %v files, want only the original", len(entries))
+	}
+}
+
+func TestSyncStoreQuiet(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	store.objects["data/stale.txt"] = []byte("stale")
+
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("alpha"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dest, "old.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	confirm := func(names []string) bool { return true }
+	out := captureStdout(t, func() {
+		if _, err := SyncUpStore(ctx, store, src, "data/", SyncOptions{Mirror: true, ConfirmDelete: confirm, Quiet: true}); err != nil {
+			t.Errorf("SyncUpStore() = %v", err)
+		}
+		if _, err := SyncDownStore(ctx, store, "data/", dest, SyncOptions{Mirror: true, ConfirmDelete: confirm, Quiet: true}); err != nil {
+			t.Errorf("SyncDownStore() = %v", err)
+		}
+	})
+
+	if out != "" {
+		t.Errorf("SyncUpStore() and SyncDownStore() while quiet printed %q, want nothing", out)
+	}
+	if _, ok := store.objects["data/stale.txt"]; ok {
+		t.Errorf("SyncUpStore() with Mirror left data/stale.txt")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("SyncDownStore() with Mirror left old.txt: %v", err)
+	}
+}
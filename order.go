@@ -0,0 +1,38 @@
+package boto3manager
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// TransferOrder is the order files in a batch operation are started in.
+type TransferOrder int
+
+const (
+	// OrderListed starts files in the order they were found
+	OrderListed TransferOrder = iota
+	// OrderSmallestFirst starts the smallest files first to finish as many as possible early
+	OrderSmallestFirst
+	// OrderLargestFirst starts the largest files first so the longest transfers aren't left until the end
+	OrderLargestFirst
+	// OrderShuffled starts files in a random order to spread requests across prefixes
+	OrderShuffled
+)
+
+// orderBySize reorders items in place according to order, using size to get the size of each item.
+func orderBySize[T any](items []T, size func(T) int64, order TransferOrder) {
+	switch order {
+	case OrderSmallestFirst:
+		sort.SliceStable(items, func(i, j int) bool {
+			return size(items[i]) < size(items[j])
+		})
+	case OrderLargestFirst:
+		sort.SliceStable(items, func(i, j int) bool {
+			return size(items[i]) > size(items[j])
+		})
+	case OrderShuffled:
+		rand.Shuffle(len(items), func(i, j int) {
+			items[i], items[j] = items[j], items[i]
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package boto3manager
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOrderBySize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		order  TransferOrder
+		wanted []int64
+	}{
+		{
+			name:   "listed",
+			order:  OrderListed,
+			wanted: []int64{3, 1, 2},
+		},
+		{
+			name:   "smallest first",
+			order:  OrderSmallestFirst,
+			wanted: []int64{1, 2, 3},
+		},
+		{
+			name:   "largest first",
+			order:  OrderLargestFirst,
+			wanted: []int64{3, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int64{3, 1, 2}
+			orderBySize(got, func(size int64) int64 { return size }, tt.order)
+			if !slices.Equal(got, tt.wanted) {
+				t.Errorf("orderBySize(%v) = %v, want %v", tt.order, got, tt.wanted)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package boto3manager
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// HexShards splits a key space by its first hex digit, for keys that start with hashes or UUIDs.
+var HexShards = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "a", "b", "c", "d", "e", "f"}
+
+// ParallelListOptions controls how ParallelList splits up a listing.
+type ParallelListOptions struct {
+	// Shards are strings, relative to the prefix, that split the key space into ranges listed separately, such as
+	// HexShards. Keys between shards or outside them are still listed
+	Shards []string
+
+	// Delimiter, if Shards is empty, finds the shards by listing the prefix's common prefixes with this delimiter,
+	// e.g. "/" to list each top level directory separately
+	Delimiter string
+
+	// Workers is how many ranges are listed at once. Defaults to 8
+	Workers int
+}
+
+// keyRange is a range of keys (after, upTo] to list. Empty bounds are unbounded.
+type keyRange struct {
+	after string
+	upTo  string
+}
+
+// shardRanges splits the keys under a prefix into ranges at each shard, covering every key exactly once.
+func shardRanges(prefix string, shards []string) []keyRange {
+	boundaries := make([]string, 0, len(shards))
+	for _, shard := range shards {
+		boundaries = append(boundaries, prefix+shard)
+	}
+	slices.Sort(boundaries)
+	boundaries = slices.Compact(boundaries)
+
+	ranges := make([]keyRange, 0, len(boundaries)+1)
+	after := ""
+	for _, boundary := range boundaries {
+		ranges = append(ranges, keyRange{after: after, upTo: boundary})
+		after = boundary
+	}
+
+	return append(ranges, keyRange{after: after})
+}
+
+// ParallelList takes a context, a bucket name, a prefix, and options and lists the objects under the prefix by
+// splitting the key space into ranges that are paginated concurrently, for buckets too large to list one page at a
+// time. Objects are yielded as they arrive, so they are only in key order within each range. Stopping the iteration
+// stops the listing. Directory buckets can't be listed by range, so they are listed in one pass.
+func (basics BucketBasics) ParallelList(ctx context.Context, bucketName string, prefix string, options ParallelListOptions) iter.Seq2[types.Object, error] {
+	if IsDirectoryBucket(bucketName) {
+		return basics.listObjectsSeq(ctx, bucketName, prefix)
+	}
+
+	return func(yield func(types.Object, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		shards := options.Shards
+		if len(shards) == 0 && options.Delimiter != "" {
+			var err error
+			shards, err = basics.delimiterShards(ctx, bucketName, prefix, options.Delimiter)
+
+			if err != nil {
+				yield(types.Object{}, err)
+				return
+			}
+		}
+
+		workers := options.Workers
+		if workers <= 0 {
+			workers = 8
+		}
+
+		type listed struct {
+			object types.Object
+			err    error
+		}
+
+		queue := make(chan keyRange)
+		results := make(chan listed, 1000)
+
+		// send passes a result on unless the listing has been stopped
+		send := func(result listed) bool {
+			select {
+			case results <- result:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for r := range queue {
+					for object, err := range basics.listRange(ctx, bucketName, prefix, r) {
+						if !send(listed{object: object, err: err}) || err != nil {
+							break
+						}
+					}
+				}
+			}()
+		}
+
+		go func() {
+			defer close(queue)
+
+			for _, r := range shardRanges(prefix, shards) {
+				select {
+				case queue <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		for result := range results {
+			if result.err != nil {
+				yield(types.Object{}, result.err)
+				return
+			}
+
+			if !yield(result.object, nil) {
+				return
+			}
+		}
+	}
+}
+
+// listRange lists the objects under a prefix in a range of keys.
+func (basics BucketBasics) listRange(ctx context.Context, bucketName string, prefix string, r keyRange) iter.Seq2[types.Object, error] {
+	return func(yield func(types.Object, error) bool) {
+		params := &s3.ListObjectsV2Input{
+			Bucket: aws.String(bucketName),
+			Prefix: aws.String(prefix),
+		}
+
+		if r.after != "" {
+			params.StartAfter = aws.String(r.after)
+		}
+
+		for page, err := range basics.listPages(ctx, params) {
+			if err != nil {
+				yield(types.Object{}, err)
+				return
+			}
+
+			for _, object := range page.Contents {
+				// Stop at the end of the range
+				if r.upTo != "" && aws.ToString(object.Key) > r.upTo {
+					return
+				}
+
+				if !yield(object, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// delimiterShards returns the common prefixes under a prefix, relative to it, for use as shards.
+func (basics BucketBasics) delimiterShards(ctx context.Context, bucketName string, prefix string, delimiter string) ([]string, error) {
+	params := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucketName),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String(delimiter),
+	}
+
+	shards := make([]string, 0)
+	for page, err := range basics.listPages(ctx, params) {
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commonPrefix := range page.CommonPrefixes {
+			shards = append(shards, aws.ToString(commonPrefix.Prefix)[len(prefix):])
+		}
+	}
+
+	return shards, nil
+}
@@ -0,0 +1,127 @@
+package boto3manager
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestShardRanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		prefix string
+		shards []string
+		wanted []keyRange
+	}{
+		{
+			name:   "no shards",
+			prefix: "data/",
+			wanted: []keyRange{{}},
+		},
+		{
+			name:   "unsorted with duplicates",
+			prefix: "data/",
+			shards: []string{"b", "a", "b"},
+			wanted: []keyRange{
+				{upTo: "data/a"},
+				{after: "data/a", upTo: "data/b"},
+				{after: "data/b"},
+			},
+		},
+		{
+			name:   "directories",
+			shards: []string{"x/", "y/"},
+			wanted: []keyRange{
+				{upTo: "x/"},
+				{after: "x/", upTo: "y/"},
+				{after: "y/"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := shardRanges(tt.prefix, tt.shards); !slices.Equal(got, tt.wanted) {
+				t.Errorf("shardRanges(%q, %v) = %v, want %v", tt.prefix, tt.shards, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestParallelList(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.maxKeys = 3
+
+	// Keys on the shard boundaries, between them, and outside them, in several pages each
+	wanted := make([]string, 0)
+	for _, name := range []string{"0", "0a", "0b/x", "1", "3f", "3g", "9zz", "a", "a0", "b/c/d", "f", "ff", "g", "zz", "~"} {
+		for _, n := range []string{"", "-1", "-2"} {
+			key := "data/" + name + n
+			fake.put("bucket", key, "x", nil)
+			wanted = append(wanted, key)
+		}
+	}
+	fake.put("bucket", "datb", "x", nil)
+	slices.Sort(wanted)
+
+	tests := []struct {
+		name    string
+		options ParallelListOptions
+	}{
+		{name: "no shards", options: ParallelListOptions{}},
+		{name: "hex shards", options: ParallelListOptions{Shards: HexShards, Workers: 4}},
+		{name: "delimiter", options: ParallelListOptions{Delimiter: "/", Workers: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			keys := make([]string, 0)
+			for object, err := range fake.basics().ParallelList(context.Background(), "bucket", "data/", tt.options) {
+				if err != nil {
+					t.Fatalf("ParallelList() = %v, want nil", err)
+				}
+				keys = append(keys, aws.ToString(object.Key))
+			}
+
+			slices.Sort(keys)
+			if !slices.Equal(keys, wanted) {
+				t.Errorf("ParallelList() = %v, want %v", keys, wanted)
+			}
+		})
+	}
+}
+
+func TestParallelListStop(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.maxKeys = 2
+	for _, shard := range HexShards {
+		fake.put("bucket", "data/"+shard+"1", "x", nil)
+		fake.put("bucket", "data/"+shard+"2", "x", nil)
+	}
+
+	n := 0
+	for _, err := range fake.basics().ParallelList(context.Background(), "bucket", "data/", ParallelListOptions{Shards: HexShards}) {
+		if err != nil {
+			t.Fatalf("ParallelList() = %v, want nil", err)
+		}
+		if n++; n == 3 {
+			break
+		}
+	}
+
+	if n != 3 {
+		t.Errorf("ParallelList() yielded %v objects after being stopped, want 3", n)
+	}
+}
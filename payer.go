@@ -0,0 +1,31 @@
+package boto3manager
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// RequestPayerOption returns an s3.Options function that sends the x-amz-request-payer header with every request,
+// accepting the charges for requests to requester-pays buckets. Pass it to s3.NewFromConfig, or use
+// BucketBasics.WithRequestPayer on an existing client.
+func RequestPayerOption(payer types.RequestPayer) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue("x-amz-request-payer", string(payer)))
+	}
+}
+
+// WithRequestPayer returns a copy of basics whose client sends the x-amz-request-payer header with every GET, LIST,
+// PUT, and other request, so requester-pays buckets such as public genomics datasets can be read and written instead
+// of returning 403.
+func (basics BucketBasics) WithRequestPayer(payer types.RequestPayer) BucketBasics {
+	basics.S3Client = s3.New(basics.S3Client.Options(), RequestPayerOption(payer))
+
+	clients := make(map[string]*s3.Client, len(basics.BucketClients))
+	for bucketName, client := range basics.BucketClients {
+		clients[bucketName] = s3.New(client.Options(), RequestPayerOption(payer))
+	}
+	basics.BucketClients = clients
+
+	return basics
+}
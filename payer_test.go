@@ -0,0 +1,56 @@
+package boto3manager
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestWithRequestPayer(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "genomes", "other")
+	fake.put("genomes", "ref/chr1.fa", "ACGT", nil)
+	fake.put("other", "ref/chr2.fa", "TTGA", nil)
+
+	// Record the payer header of every request, by method and bucket
+	payers := make(map[string]string)
+	fake.fail = func(r *http.Request) int {
+		bucket, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		payers[r.Method+" "+bucket] = r.Header.Get("X-Amz-Request-Payer")
+		return 0
+	}
+
+	basics := BucketBasics{S3Client: fake.client(), BucketClients: map[string]*s3.Client{"other": fake.client()}}
+	payer := basics.WithRequestPayer(types.RequestPayerRequester)
+
+	if _, err := payer.ListObjects("genomes"); err != nil {
+		t.Fatalf("ListObjects() = %v, want nil", err)
+	}
+	if _, err := payer.DownloadObjectBytes("ref/chr1.fa", "genomes"); err != nil {
+		t.Fatalf("DownloadObjectBytes() = %v, want nil", err)
+	}
+	if _, err := payer.UploadStream(strings.NewReader("GGCC"), "ref/chr3.fa", "genomes", UploadStreamOptions{}); err != nil {
+		t.Fatalf("UploadStream() = %v, want nil", err)
+	}
+	if _, err := payer.DownloadObjectBytes("ref/chr2.fa", "other"); err != nil {
+		t.Fatalf("DownloadObjectBytes() = %v, want nil", err)
+	}
+
+	for _, request := range []string{"GET genomes", "PUT genomes", "GET other"} {
+		if got := payers[request]; got != "requester" {
+			t.Errorf("WithRequestPayer() sent %v with x-amz-request-payer %q, want requester", request, got)
+		}
+	}
+
+	// The original client is left alone
+	if _, err := basics.DownloadObjectBytes("ref/chr1.fa", "genomes"); err != nil {
+		t.Fatalf("DownloadObjectBytes() = %v, want nil", err)
+	}
+	if got := payers["GET genomes"]; got != "" {
+		t.Errorf("BucketBasics without a payer sent x-amz-request-payer %q, want none", got)
+	}
+}
@@ -0,0 +1,268 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// FailurePolicy decides what happens when a pipeline stage fails for an item.
+type FailurePolicy int
+
+const (
+	// FailItem stops the item at the failed stage and records the error. Other items carry on
+	FailItem FailurePolicy = iota
+	// SkipStage ignores the error and passes the item on to the next stage
+	SkipStage
+	// AbortPipeline stops the whole pipeline: no new items are started
+	AbortPipeline
+)
+
+// PipelineItem is a file moving through a pipeline. Bucket and Key are the object the item currently refers to,
+// and Path is its local copy. Stages may change any of them.
+type PipelineItem struct {
+	Bucket string
+	Key    string
+	Path   string
+	Size   int64
+
+	// Name is the item's path relative to the source, used to name it in later stages
+	Name string
+}
+
+func (item PipelineItem) result() TransferResult {
+	return TransferResult{Key: item.Key, Path: item.Path, Size: item.Size}
+}
+
+// pipelineStage is a named step run for every item.
+type pipelineStage struct {
+	name      string
+	run       func(ctx context.Context, item *PipelineItem) error
+	onFailure FailurePolicy
+}
+
+// Pipeline chains operations, such as download, transform, upload, and tag, that run for every item of a source
+// with one progress bar and one report. Build one with NewPipeline and run it with Run.
+type Pipeline struct {
+	basics      BucketBasics
+	source      func(ctx context.Context) ([]PipelineItem, error)
+	stages      []pipelineStage
+	notify      []func(report *TransferReport)
+	workerCount int
+	policy      RetryPolicy
+}
+
+// NewPipeline returns an empty pipeline that uses DefaultRetryPolicy for each stage.
+func (basics BucketBasics) NewPipeline() *Pipeline {
+	return &Pipeline{basics: basics, workerCount: 25, policy: DefaultRetryPolicy}
+}
+
+// Workers sets the number of items processed at once.
+func (p *Pipeline) Workers(n int) *Pipeline {
+	p.workerCount = n
+	return p
+}
+
+// Retry sets the retry policy applied to each stage of each item.
+func (p *Pipeline) Retry(policy RetryPolicy) *Pipeline {
+	p.policy = policy
+	return p
+}
+
+// Download makes the objects in the bucket matching the pattern the source of the pipeline and adds a stage that
+// downloads each one under dest. The pattern and destination are expanded as DownloadObjects expands them, and
+// directory markers are left out.
+func (p *Pipeline) Download(pattern string, dest string, bucketName string) *Pipeline {
+	p.source = func(ctx context.Context) ([]PipelineItem, error) {
+		matches, _, pattern, dest, err := p.basics.downloadSource(ctx, pattern, dest, bucketName, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := pattern
+		if i := strings.Index(pattern, "*"); i > -1 {
+			prefix = pattern[:i]
+		}
+		prefix = prefix[:strings.LastIndex(prefix, "/")+1]
+
+		downloads := downloadsForObjects(matches, dest)
+		items := make([]PipelineItem, 0, len(downloads))
+		for _, download := range downloads {
+			items = append(items, PipelineItem{
+				Bucket: bucketName,
+				Key:    download.Key,
+				Path:   download.Destination,
+				Size:   download.Size,
+				Name:   strings.TrimPrefix(download.Key, prefix),
+			})
+		}
+
+		return items, nil
+	}
+
+	return p.Stage("download", func(ctx context.Context, item *PipelineItem) error {
+		return p.basics.DownloadObjectWithContext(ctx, item.Key, filepath.Dir(item.Path), item.Bucket, DownloadObjectOptions{name: filepath.Base(item.Path)})
+	})
+}
+
+// Files makes the local files matching the pattern the source of the pipeline. The pattern is expanded as
+// UploadObjects expands it.
+func (p *Pipeline) Files(pattern string) *Pipeline {
+	p.source = func(ctx context.Context) ([]PipelineItem, error) {
+		fsys, root, pattern, _, err := uploadSource(nil, "", pattern, "")
+		if err != nil {
+			return nil, err
+		}
+
+		uploads, err := uploadsForPattern(fsys, pattern, "", nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]PipelineItem, 0, len(uploads))
+		for _, upload := range uploads {
+			path := filepath.Join(root, filepath.FromSlash(upload.Path))
+			items = append(items, PipelineItem{Path: path, Size: upload.Size, Name: upload.Key})
+		}
+
+		return items, nil
+	}
+
+	return p
+}
+
+// Transform adds a stage that runs fn on each item's local copy. fn may replace the file or point the item at a new
+// one.
+func (p *Pipeline) Transform(name string, fn func(ctx context.Context, item *PipelineItem) error) *Pipeline {
+	return p.Stage(name, fn)
+}
+
+// Upload adds a stage that uploads each item's local copy to prefix plus the item's name in the bucket.
+func (p *Pipeline) Upload(bucketName string, prefix string) *Pipeline {
+	return p.Stage("upload", func(ctx context.Context, item *PipelineItem) error {
+		key := prefix + item.Name
+
+		err := p.basics.UploadObjectWithContext(ctx, item.Path, key, bucketName, UploadObjectOptions{})
+		if err != nil {
+			return err
+		}
+
+		item.Bucket, item.Key = bucketName, key
+
+		if fileInfo, err := os.Stat(item.Path); err == nil {
+			item.Size = fileInfo.Size()
+		}
+
+		return nil
+	})
+}
+
+// Tag adds a stage that sets tags on the object each item currently refers to.
+func (p *Pipeline) Tag(tags map[string]string) *Pipeline {
+	tagSet := make([]types.Tag, 0, len(tags))
+	for key, value := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+
+	return p.Stage("tag", func(ctx context.Context, item *PipelineItem) error {
+		_, err := p.basics.client(item.Bucket).PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+			Bucket:  aws.String(item.Bucket),
+			Key:     aws.String(item.Key),
+			Tagging: &types.Tagging{TagSet: tagSet},
+		})
+
+		if err != nil {
+			log.Printf("Couldn't tag object %v: %v", item.Key, err)
+		}
+
+		return err
+	})
+}
+
+// Notify adds a callback that is called with the report once every item has been through the pipeline.
+func (p *Pipeline) Notify(fn func(report *TransferReport)) *Pipeline {
+	p.notify = append(p.notify, fn)
+	return p
+}
+
+// Stage adds a custom stage.
+func (p *Pipeline) Stage(name string, fn func(ctx context.Context, item *PipelineItem) error) *Pipeline {
+	p.stages = append(p.stages, pipelineStage{name: name, run: fn})
+	return p
+}
+
+// OnFailure sets the failure policy of the most recently added stage. Stages use FailItem by default.
+func (p *Pipeline) OnFailure(policy FailurePolicy) *Pipeline {
+	if len(p.stages) > 0 {
+		p.stages[len(p.stages)-1].onFailure = policy
+	}
+	return p
+}
+
+// Run runs every item of the source through the stages concurrently and returns a single report. An item's error
+// names the stage that failed.
+func (p *Pipeline) Run(ctx context.Context) (*TransferReport, error) {
+	if p.source == nil {
+		return nil, fmt.Errorf("pipeline has no source; start it with Download or Files")
+	}
+
+	items, err := p.source(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Make a progress bar counting finished items
+	bar := newCountBar(int64(len(items)), "pipeline")
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  p.workerCount,
+		policy:       RetryPolicy{MaxAttempts: 1},
+		report:       report,
+		backpressure: p.basics.Backpressure,
+	}
+
+	err = runBatch(ctx, items, config, func(ctx context.Context, item PipelineItem) error {
+		defer bar.Add(1)
+
+		for _, stage := range p.stages {
+			_, err := p.policy.do(ctx, func() error {
+				return stage.run(ctx, &item)
+			})
+
+			if err == nil {
+				continue
+			}
+
+			switch stage.onFailure {
+			case SkipStage:
+				continue
+			case AbortPipeline:
+				cancel()
+			}
+
+			return fmt.Errorf("%v: %w", stage.name, err)
+		}
+
+		return nil
+	})
+
+	for _, fn := range p.notify {
+		fn(report)
+	}
+
+	return report, err
+}
@@ -0,0 +1,228 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// upperCase is a pipeline transform that upper-cases an item's local copy in place.
+func upperCase(ctx context.Context, item *PipelineItem) error {
+	data, err := os.ReadFile(item.Path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(item.Path, []byte(strings.ToUpper(string(data))), 0o644)
+}
+
+func TestPipelineRun(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "raw", "clean")
+	fake.put("raw", "in/a.txt", "alpha", nil)
+	fake.put("raw", "in/b.txt", "beta", nil)
+
+	var notified *TransferReport
+	report, err := fake.basics().NewPipeline().
+		Workers(2).
+		Download("in/*.txt", t.TempDir(), "raw").
+		Transform("upper", upperCase).
+		Upload("clean", "out/").
+		Tag(map[string]string{"stage": "clean"}).
+		Notify(func(report *TransferReport) { notified = report }).
+		Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	if notified != report {
+		t.Errorf("Run() notified %p, want the report %p", notified, report)
+	}
+	if got := len(report.Succeeded()); got != 2 {
+		t.Errorf("Run() succeeded %v items, want 2", got)
+	}
+
+	tests := []struct {
+		key    string
+		wanted string
+	}{
+		{key: "out/a.txt", wanted: "ALPHA"},
+		{key: "out/b.txt", wanted: "BETA"},
+	}
+
+	for _, tt := range tests {
+		object, ok := fake.object("clean", tt.key)
+		if !ok || string(object.body) != tt.wanted {
+			t.Errorf("Run() left clean/%v = %q, %v, want %q", tt.key, object.body, ok, tt.wanted)
+			continue
+		}
+		if wanted := map[string]string{"stage": "clean"}; !maps.Equal(object.tags, wanted) {
+			t.Errorf("Run() tagged clean/%v with %v, want %v", tt.key, object.tags, wanted)
+		}
+	}
+}
+
+func TestPipelineFailurePolicies(t *testing.T) {
+	t.Parallel()
+
+	failing := errors.New("transform failed")
+
+	tests := []struct {
+		name            string
+		policy          FailurePolicy
+		wantedFailed    int
+		wantedCancelled int
+		wantedUploads   int
+	}{
+		// The failed item stops, the other carries on
+		{name: "fail item", policy: FailItem, wantedFailed: 1, wantedUploads: 1},
+		// The failed item is uploaded untransformed
+		{name: "skip stage", policy: SkipStage, wantedFailed: 0, wantedUploads: 2},
+		// With one worker, the item after the failed one is cancelled
+		{name: "abort", policy: AbortPipeline, wantedFailed: 1, wantedCancelled: 1, wantedUploads: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "raw", "clean")
+			fake.put("raw", "in/a.txt", "alpha", nil)
+			fake.put("raw", "in/b.txt", "beta", nil)
+
+			report, _ := fake.basics().NewPipeline().
+				Workers(1).
+				Retry(RetryPolicy{MaxAttempts: 1}).
+				Download("in/*.txt", t.TempDir(), "raw").
+				Transform("upper", func(ctx context.Context, item *PipelineItem) error {
+					if item.Name == "a.txt" {
+						return failing
+					}
+					return upperCase(ctx, item)
+				}).
+				OnFailure(tt.policy).
+				Upload("clean", "out/").
+				Run(context.Background())
+
+			failed, cancelled := 0, 0
+			for _, result := range report.Failed() {
+				switch {
+				case errors.Is(result.Err, failing) && strings.HasPrefix(result.Err.Error(), "upper: "):
+					failed++
+				case errors.Is(result.Err, context.Canceled):
+					cancelled++
+				default:
+					t.Errorf("Run() failed %v with %v, want the upper stage's error", result.Key, result.Err)
+				}
+			}
+			if failed != tt.wantedFailed || cancelled != tt.wantedCancelled {
+				t.Errorf("Run() failed %v items and cancelled %v, want %v and %v", failed, cancelled, tt.wantedFailed, tt.wantedCancelled)
+			}
+
+			if got := len(fake.keys("clean")); got != tt.wantedUploads {
+				t.Errorf("Run() uploaded %v objects, want %v", got, tt.wantedUploads)
+			}
+		})
+	}
+}
+
+func TestPipelineRetriesStage(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "raw")
+	fake.put("raw", "in/a.txt", "alpha", nil)
+
+	// The stage fails once and succeeds when retried
+	var attempts atomic.Int32
+	report, err := fake.basics().NewPipeline().
+		Retry(RetryPolicy{MaxAttempts: 2}).
+		Download("in/*.txt", t.TempDir(), "raw").
+		Stage("flaky", func(ctx context.Context, item *PipelineItem) error {
+			if attempts.Add(1) == 1 {
+				return errors.New("try again")
+			}
+			return nil
+		}).
+		Run(context.Background())
+
+	if err != nil || len(report.Failed()) != 0 {
+		t.Fatalf("Run() = %v with %v failed, want nil and none", err, report.Failed())
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("Run() ran the flaky stage %v times, want 2", got)
+	}
+}
+
+func TestPipelineNoSource(t *testing.T) {
+	t.Parallel()
+
+	if _, err := (BucketBasics{}).NewPipeline().Stage("noop", nil).Run(context.Background()); err == nil {
+		t.Error("Run() without a source = nil, want an error")
+	}
+}
+
+func TestPipelineFilesExpandsPattern(t *testing.T) {
+	fake := newFakeS3(t, "clean")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("alpha"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PIPELINE_DATA", dir)
+
+	report, err := fake.basics().NewPipeline().
+		Files("${PIPELINE_DATA}/*.txt").
+		Upload("clean", "out/").
+		Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	if got := len(report.Succeeded()); got != 1 {
+		t.Errorf("Run() succeeded %v items, want 1", got)
+	}
+	if object, ok := fake.object("clean", "out/a.txt"); !ok || string(object.body) != "alpha" {
+		t.Errorf("Run() left clean/out/a.txt = %q, %v, want %q", object.body, ok, "alpha")
+	}
+}
+
+func TestPipelineDownloadSkipsMarkers(t *testing.T) {
+	dest := t.TempDir()
+	t.Setenv("PIPELINE_PREFIX", "in")
+
+	fake := newFakeS3(t, "raw")
+	for _, key := range []string{"in/", "in/a.txt", "in/sub/", "in/sub/b.txt"} {
+		fake.put("raw", key, strings.TrimPrefix(key, "in/"), nil)
+	}
+
+	report, err := fake.basics().NewPipeline().
+		Download("${PIPELINE_PREFIX}/**/*", dest, "raw").
+		Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+
+	names := make(map[string]string)
+	for _, result := range report.Succeeded() {
+		names[result.Key] = result.Path
+	}
+	wanted := map[string]string{
+		"in/a.txt":     filepath.Join(dest, "in", "a.txt"),
+		"in/sub/b.txt": filepath.Join(dest, "in", "sub", "b.txt"),
+	}
+	if !maps.Equal(names, wanted) || len(report.Failed()) != 0 {
+		t.Errorf("Run() downloaded %v and failed %+v, want %v", names, report.Failed(), wanted)
+	}
+
+	for key, path := range wanted {
+		if data, err := os.ReadFile(path); err != nil || string(data) != strings.TrimPrefix(key, "in/") {
+			t.Errorf("Run() wrote %v = %q, %v, want %q", path, data, err, strings.TrimPrefix(key, "in/"))
+		}
+	}
+}
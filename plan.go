@@ -0,0 +1,147 @@
+package boto3manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// PlanOp is the kind of change a plan action makes.
+type PlanOp string
+
+const (
+	PlanUpload   PlanOp = "upload"
+	PlanDownload PlanOp = "download"
+)
+
+// PlanAction is a single transfer in a plan. Path is the local file: the one uploaded, or the one a download is
+// written to.
+type PlanAction struct {
+	Op   PlanOp `json:"op"`
+	Key  string `json:"key"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+
+	// Error is why the transfer failed, for plans written as retry files
+	Error string `json:"error,omitempty"`
+}
+
+// Plan is a reviewable list of transfers against a bucket that can be saved and executed later with Apply.
+type Plan struct {
+	Bucket  string       `json:"bucket"`
+	Created time.Time    `json:"created"`
+	Actions []PlanAction `json:"actions"`
+}
+
+type ApplyOptions struct {
+	// RetryPolicy controls retries of each action. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+}
+
+// PlanUploads takes a glob pattern for files, a destination path, and a bucket name and returns a plan to upload
+// the files UploadObjects would upload, without uploading anything. The pattern and destination are expanded as
+// UploadObjects expands them.
+func (basics BucketBasics) PlanUploads(pattern string, dest string, bucketName string) (*Plan, error) {
+	fsys, root, pattern, dest, err := uploadSource(nil, "", pattern, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads, err := uploadsForPattern(fsys, pattern, dest, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Bucket: bucketName, Created: time.Now().UTC(), Actions: make([]PlanAction, 0, len(uploads))}
+	for _, upload := range uploads {
+		path := filepath.Join(root, filepath.FromSlash(upload.Path))
+		plan.Actions = append(plan.Actions, PlanAction{Op: PlanUpload, Key: upload.Key, Path: path, Size: upload.Size})
+	}
+
+	return plan, nil
+}
+
+// PlanDownloads takes a pattern, a destination, and a bucket name and returns a plan to download the objects
+// DownloadObjects would download, without downloading anything. The pattern and destination are expanded as
+// DownloadObjects expands them, and directory markers are left out since they aren't downloaded as files.
+func (basics BucketBasics) PlanDownloads(pattern string, dest string, bucketName string) (*Plan, error) {
+	matches, _, _, dest, err := basics.downloadSource(context.TODO(), pattern, dest, bucketName, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	downloads := downloadsForObjects(matches, dest)
+
+	plan := &Plan{Bucket: bucketName, Created: time.Now().UTC(), Actions: make([]PlanAction, 0, len(downloads))}
+	for _, download := range downloads {
+		plan.Actions = append(plan.Actions, PlanAction{Op: PlanDownload, Key: download.Key, Path: download.Destination, Size: download.Size})
+	}
+
+	return plan, nil
+}
+
+// WritePlan encodes a plan as indented JSON so it can be reviewed and archived.
+func WritePlan(plan *Plan, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(plan)
+}
+
+// ReadPlan decodes a plan written by WritePlan.
+func ReadPlan(r io.Reader) (*Plan, error) {
+	var plan Plan
+	if err := json.NewDecoder(r).Decode(&plan); err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// Apply executes every action in a plan concurrently and returns a report of the results.
+func (basics BucketBasics) Apply(plan *Plan, options ApplyOptions) (*TransferReport, error) {
+	return basics.ApplyWithContext(context.Background(), plan, options)
+}
+
+// ApplyWithContext is Apply with a context. If the context is cancelled, no new actions are started and the
+// report of what completed is returned along with the context's error.
+func (basics BucketBasics) ApplyWithContext(ctx context.Context, plan *Plan, options ApplyOptions) (*TransferReport, error) {
+	basics.detectRegions(ctx, plan.Bucket)
+
+	// Check every action before changing anything
+	var totalSize int64
+	for _, action := range plan.Actions {
+		if action.Op != PlanUpload && action.Op != PlanDownload {
+			return nil, fmt.Errorf("unknown plan action %q for key %v", action.Op, action.Key)
+		}
+		totalSize += action.Size
+	}
+
+	// Make a progress bar
+	bar := newBytesBar(totalSize, "applying")
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  defaultWorkers(25),
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		backpressure: basics.Backpressure,
+	}
+
+	err := runBatch(ctx, plan.Actions, config, func(ctx context.Context, action PlanAction) error {
+		if action.Op == PlanUpload {
+			return basics.UploadObjectWithContext(ctx, action.Path, action.Key, plan.Bucket, UploadObjectOptions{bar: bar})
+		}
+		return basics.DownloadObjectWithContext(ctx, action.Key, filepath.Dir(action.Path), plan.Bucket, DownloadObjectOptions{bar: bar, name: filepath.Base(action.Path)})
+	})
+
+	return report, err
+}
+
+func (action PlanAction) result() TransferResult {
+	return TransferResult{Key: action.Key, Path: action.Path, Size: action.Size}
+}
@@ -0,0 +1,191 @@
+package boto3manager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestApplyPlannedDownloads(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "logs/a.csv", "a", nil)
+	fake.put("data", "logs/nested/b.csv", "bb", nil)
+
+	dest := t.TempDir()
+	plan, err := fake.basics().PlanDownloads("logs/**/*.csv", dest, "data")
+	if err != nil {
+		t.Fatalf("PlanDownloads() = %v", err)
+	}
+
+	// Planning changes nothing, and the plan survives being written out and read back
+	if _, err := os.Stat(filepath.Join(dest, "logs")); !os.IsNotExist(err) {
+		t.Errorf("PlanDownloads() wrote to the destination: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePlan(plan, &buf); err != nil {
+		t.Fatalf("WritePlan() = %v", err)
+	}
+	plan, err = ReadPlan(&buf)
+	if err != nil {
+		t.Fatalf("ReadPlan() = %v", err)
+	}
+
+	report, err := fake.basics().Apply(plan, ApplyOptions{})
+	if err != nil || len(report.Succeeded()) != 2 {
+		t.Fatalf("Apply() = %v with %v succeeded, want nil and 2", err, len(report.Succeeded()))
+	}
+
+	tests := []struct {
+		path   string
+		wanted string
+	}{
+		{path: filepath.Join(dest, "logs", "a.csv"), wanted: "a"},
+		{path: filepath.Join(dest, "logs", "nested", "b.csv"), wanted: "bb"},
+	}
+
+	for _, tt := range tests {
+		if data, err := os.ReadFile(tt.path); err != nil || string(data) != tt.wanted {
+			t.Errorf("Apply() wrote %v = %q, %v, want %q", tt.path, data, err, tt.wanted)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local.csv")
+	if err := os.WriteFile(local, []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		actions       []PlanAction
+		wantedErr     bool
+		wantedFailed  int
+		wantedObjects []string
+
+		// wantedFiles are downloaded files by their paths under dir, each named by its action's path
+		wantedFiles map[string]string
+	}{
+		{
+			name: "upload and download",
+			actions: []PlanAction{
+				{Op: PlanUpload, Key: "in/local.csv", Path: local, Size: 5},
+				{Op: PlanDownload, Key: "remote.csv", Path: filepath.Join(dir, "upload", "out", "copy.csv"), Size: 6},
+			},
+			wantedObjects: []string{"in/local.csv", "remote.csv"},
+			wantedFiles:   map[string]string{"upload/out/copy.csv": "remote"},
+		},
+		{
+			// A missing object fails on its own
+			name: "missing object",
+			actions: []PlanAction{
+				{Op: PlanDownload, Key: "gone.csv", Path: filepath.Join(dir, "missing", "gone.csv")},
+				{Op: PlanUpload, Key: "in/other.csv", Path: local, Size: 5},
+			},
+			wantedFailed:  1,
+			wantedObjects: []string{"in/other.csv", "remote.csv"},
+		},
+		{
+			// An unknown action stops the plan before anything is changed
+			name: "unknown action",
+			actions: []PlanAction{
+				{Op: PlanUpload, Key: "in/local.csv", Path: local, Size: 5},
+				{Op: "delete", Key: "remote.csv"},
+			},
+			wantedErr:     true,
+			wantedObjects: []string{"remote.csv"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "data")
+			fake.put("data", "remote.csv", "remote", nil)
+
+			plan := &Plan{Bucket: "data", Actions: tt.actions}
+			report, err := fake.basics().Apply(plan, ApplyOptions{RetryPolicy: &RetryPolicy{MaxAttempts: 1}})
+
+			if (err != nil) != tt.wantedErr {
+				t.Fatalf("Apply() = %v, want error %v", err, tt.wantedErr)
+			}
+			if report != nil && len(report.Failed()) != tt.wantedFailed {
+				t.Errorf("Apply() failed %v actions, want %v", len(report.Failed()), tt.wantedFailed)
+			}
+
+			if got := fake.keys("data"); !slices.Equal(got, tt.wantedObjects) {
+				t.Errorf("Apply() left objects %q, want %q", got, tt.wantedObjects)
+			}
+
+			for path, wanted := range tt.wantedFiles {
+				if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path))); err != nil || string(data) != wanted {
+					t.Errorf("Apply() downloaded %v = %q, %v, want %q", path, data, err, wanted)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanUploadsExpandsPattern(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.csv", "sub/b.csv"} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PLAN_DATA", dir)
+	t.Setenv("PLAN_DEST", "results")
+
+	// The plan holds what UploadObjects would upload from the same pattern, at paths that can be opened from anywhere
+	plan, err := BucketBasics{}.PlanUploads("${PLAN_DATA}/**/*.csv", "${PLAN_DEST}/", "data")
+	if err != nil {
+		t.Fatalf("PlanUploads() = %v, want nil", err)
+	}
+
+	wanted := []PlanAction{
+		{Op: PlanUpload, Key: "results/a.csv", Path: filepath.Join(dir, "a.csv"), Size: 5},
+		{Op: PlanUpload, Key: "results/sub/b.csv", Path: filepath.Join(dir, "sub", "b.csv"), Size: 9},
+	}
+	if !slices.Equal(plan.Actions, wanted) {
+		t.Errorf("PlanUploads() = %+v, want %+v", plan.Actions, wanted)
+	}
+}
+
+func TestPlanDownloadsSkipsMarkers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PLAN_PREFIX", "logs")
+
+	fake := newFakeS3(t, "data")
+	for _, key := range []string{"logs/", "logs/a.csv", "logs/dir/", "logs/dir/b.csv"} {
+		fake.put("data", key, strings.TrimPrefix(key, "logs/"), nil)
+	}
+
+	// The plan holds what DownloadObjects would write from the same pattern, without the directory markers
+	plan, err := fake.basics().PlanDownloads("${PLAN_PREFIX}/**/*", "~/out", "data")
+	if err != nil {
+		t.Fatalf("PlanDownloads() = %v, want nil", err)
+	}
+
+	slices.SortFunc(plan.Actions, func(a, b PlanAction) int { return strings.Compare(a.Key, b.Key) })
+	wanted := []PlanAction{
+		{Op: PlanDownload, Key: "logs/a.csv", Path: filepath.Join(home, "out", "logs", "a.csv"), Size: 5},
+		{Op: PlanDownload, Key: "logs/dir/b.csv", Path: filepath.Join(home, "out", "logs", "dir", "b.csv"), Size: 9},
+	}
+	if !slices.Equal(plan.Actions, wanted) {
+		t.Errorf("PlanDownloads() = %+v, want %+v", plan.Actions, wanted)
+	}
+}
@@ -0,0 +1,36 @@
+package boto3manager
+
+import (
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+)
+
+// transferBufferSize is the size of the pooled buffers file data is copied through on its way to and from S3.
+const transferBufferSize = 256 * 1024
+
+// Buffers shared by every upload and download manager, so batches of many small files reuse the same memory instead
+// of allocating buffers for each file.
+var (
+	uploadBuffers   = manager.NewBufferedReadSeekerWriteToPool(transferBufferSize)
+	downloadBuffers = manager.NewPooledBufferedWriterReadFromProvider(transferBufferSize)
+)
+
+// newUploader returns an upload manager for a bucket using the pooled buffers, with optFns applied. An upload manager
+// also pools the part buffers of uploads from streams, so batch operations create one and share it between their
+// workers.
+func (basics BucketBasics) newUploader(bucketName string, optFns ...func(*manager.Uploader)) *manager.Uploader {
+	optFns = append([]func(*manager.Uploader){func(u *manager.Uploader) {
+		u.BufferProvider = uploadBuffers
+	}}, optFns...)
+
+	return manager.NewUploader(basics.client(bucketName), optFns...)
+}
+
+// newDownloader returns a download manager for a bucket using the pooled buffers, with optFns applied. Batch
+// operations create one and share it between their workers.
+func (basics BucketBasics) newDownloader(bucketName string, optFns ...func(*manager.Downloader)) *manager.Downloader {
+	optFns = append([]func(*manager.Downloader){func(d *manager.Downloader) {
+		d.BufferProvider = downloadBuffers
+	}}, optFns...)
+
+	return manager.NewDownloader(basics.client(bucketName), optFns...)
+}
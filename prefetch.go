@@ -0,0 +1,158 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// PrefetchedObject is an object that has been downloaded into the cache of a Prefetcher.
+type PrefetchedObject struct {
+	Key  string
+	Path string
+	Err  error
+}
+
+// Prefetcher downloads objects for an ordered list of keys ahead of a consumer, keeping at most
+// depth downloaded objects in a local cache directory at any time.
+type Prefetcher struct {
+	basics     BucketBasics
+	bucketName string
+	cacheDir   string
+
+	// slots bounds the number of objects that are downloading or waiting in the cache
+	slots chan struct{}
+
+	// pending holds the result channel of each started download in key order
+	pending chan chan PrefetchedObject
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewPrefetcher takes an ordered list of keys, a bucket name, a cache directory, and a depth and starts downloading
+// the first depth objects into the cache directory. Objects are handed to the consumer in order by Next.
+func (basics BucketBasics) NewPrefetcher(keys []string, bucketName string, cacheDir string, depth int) (*Prefetcher, error) {
+	if depth < 1 {
+		depth = 1
+	}
+
+	// Create the cache directory if it doesn't exist already
+	err := os.MkdirAll(cacheDir, os.ModePerm)
+
+	if err != nil {
+		log.Printf("Couldn't create directory %v: %v", cacheDir, err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Prefetcher{
+		basics:     basics,
+		bucketName: bucketName,
+		cacheDir:   cacheDir,
+		slots:      make(chan struct{}, depth),
+		pending:    make(chan chan PrefetchedObject, depth),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	go p.dispatch(keys)
+
+	return p, nil
+}
+
+// dispatch starts a download for each key as soon as a cache slot is free.
+func (p *Prefetcher) dispatch(keys []string) {
+	defer close(p.pending)
+
+	for i, key := range keys {
+		// Wait for a free slot in the cache
+		select {
+		case p.slots <- struct{}{}:
+		case <-p.ctx.Done():
+			return
+		}
+
+		result := make(chan PrefetchedObject, 1)
+		p.pending <- result
+
+		// Name the cached file by its position so keys with the same base name don't collide
+		path := filepath.Join(p.cacheDir, fmt.Sprintf("%06d-%s", i, filepath.Base(key)))
+
+		go func(key string) {
+			result <- p.fetch(key, path)
+		}(key)
+	}
+}
+
+// fetch downloads a single object into the cache.
+func (p *Prefetcher) fetch(key string, path string) PrefetchedObject {
+	obj := PrefetchedObject{Key: key, Path: path}
+
+	// Create the file
+	f, err := os.Create(path)
+
+	if err != nil {
+		log.Printf("Couldn't open file %v: %v", path, err)
+		obj.Err = err
+		return obj
+	}
+
+	defer f.Close()
+
+	// Download the object
+	downloader := p.basics.newDownloader(p.bucketName)
+	_, err = downloader.Download(p.ctx, f, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't download file %v: %v", key, err)
+		obj.Err = err
+	}
+
+	return obj
+}
+
+// Next blocks until the next object in key order has been downloaded and returns it. The second return value is
+// false once every key has been consumed or the Prefetcher has been closed. Each object returned must be handed
+// back with Release so its cache slot can be reused.
+func (p *Prefetcher) Next() (PrefetchedObject, bool) {
+	result, ok := <-p.pending
+	if !ok {
+		return PrefetchedObject{}, false
+	}
+
+	return <-result, true
+}
+
+// Release removes a consumed object from the cache and frees its slot for the next download.
+func (p *Prefetcher) Release(obj PrefetchedObject) error {
+	err := os.Remove(obj.Path)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+
+	<-p.slots
+
+	return err
+}
+
+// Close stops any further downloads, waits for those in flight to stop, and removes the objects cached but not yet
+// returned by Next. Objects Next has returned are left in the cache for the caller to Release.
+func (p *Prefetcher) Close() {
+	p.cancel()
+
+	// Drain downloads that were already started so their goroutines can exit
+	for result := range p.pending {
+		obj := <-result
+		os.Remove(obj.Path)
+	}
+}
@@ -0,0 +1,109 @@
+package boto3manager
+
+import (
+	"os"
+	"testing"
+)
+
+// cachedFiles returns the number of files in a cache directory.
+func cachedFiles(t *testing.T, dir string) int {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestPrefetcher(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	keys := []string{"a/x.csv", "b/x.csv", "c.csv", "missing.csv", "d.csv"}
+	for _, key := range keys {
+		if key != "missing.csv" {
+			fake.put("data", key, "body of "+key, nil)
+		}
+	}
+
+	const depth = 2
+	cacheDir := t.TempDir()
+	p, err := fake.basics().NewPrefetcher(keys, "data", cacheDir, depth)
+	if err != nil {
+		t.Fatalf("NewPrefetcher() = %v", err)
+	}
+	defer p.Close()
+
+	// Objects come back in key order, and the cache never holds more than depth of them
+	for _, key := range keys {
+		obj, ok := p.Next()
+		if !ok {
+			t.Fatalf("Next() = false, want %v", key)
+		}
+		if obj.Key != key {
+			t.Errorf("Next() = %v, want %v", obj.Key, key)
+		}
+
+		if n := cachedFiles(t, cacheDir); n > depth {
+			t.Errorf("cache holds %v files with depth %v", n, depth)
+		}
+
+		if key == "missing.csv" {
+			if obj.Err == nil {
+				t.Errorf("Next() for a missing object = nil error, want an error")
+			}
+		} else if data, err := os.ReadFile(obj.Path); err != nil || string(data) != "body of "+key {
+			t.Errorf("Next() for %v cached %q, %v, want %q", key, data, err, "body of "+key)
+		}
+
+		if err := p.Release(obj); err != nil {
+			t.Errorf("Release(%v) = %v, want nil", key, err)
+		}
+		if _, err := os.Stat(obj.Path); !os.IsNotExist(err) {
+			t.Errorf("Release(%v) left %v in the cache", key, obj.Path)
+		}
+	}
+
+	if _, ok := p.Next(); ok {
+		t.Error("Next() after the last key = true, want false")
+	}
+}
+
+func TestPrefetcherClose(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	keys := []string{"a.csv", "b.csv", "c.csv", "d.csv"}
+	for _, key := range keys {
+		fake.put("data", key, "body of "+key, nil)
+	}
+
+	cacheDir := t.TempDir()
+	p, err := fake.basics().NewPrefetcher(keys, "data", cacheDir, 2)
+	if err != nil {
+		t.Fatalf("NewPrefetcher() = %v", err)
+	}
+
+	consumed, ok := p.Next()
+	if !ok || consumed.Err != nil {
+		t.Fatalf("Next() = %+v, %v, want the first object", consumed, ok)
+	}
+
+	p.Close()
+
+	// The object Next returned stays for the caller, and the one prefetched behind it is removed
+	if _, err := os.Stat(consumed.Path); err != nil {
+		t.Errorf("Close() removed %v, which Next had returned: %v", consumed.Path, err)
+	}
+	if n := cachedFiles(t, cacheDir); n != 1 {
+		t.Errorf("Close() left %v files in the cache, want 1", n)
+	}
+
+	if _, ok := p.Next(); ok {
+		t.Error("Next() after Close = true, want false")
+	}
+	if err := p.Release(consumed); err != nil {
+		t.Errorf("Release() after Close = %v, want nil", err)
+	}
+}
@@ -0,0 +1,206 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// probePrefix is where probe objects are written. They are deleted when the probe finishes
+	probePrefix = ".s3m-probe/"
+
+	// probeObjectSize is the size of each object the probe uploads and downloads
+	probeObjectSize = 8 * 1024 * 1024
+
+	// probeStreams is the number of objects transferred at once to measure aggregate throughput
+	probeStreams = 4
+)
+
+// ProbeResult is what a probe measured about an endpoint.
+type ProbeResult struct {
+	// Latency is the time taken by a request with no body
+	Latency time.Duration
+
+	// UploadThroughput and DownloadThroughput are bytes per second with several transfers at once
+	UploadThroughput   float64
+	DownloadThroughput float64
+
+	// SingleStreamThroughput is bytes per second uploading one object on its own
+	SingleStreamThroughput float64
+}
+
+// Workers suggests how many transfers to run at once, starting from a default worker count. If running several
+// transfers at once barely beats a single one, the endpoint is the bottleneck and fewer workers are suggested.
+func (result ProbeResult) Workers(defaultWorkers int) int {
+	if result.SingleStreamThroughput == 0 {
+		return defaultWorkers
+	}
+
+	scaling := result.UploadThroughput / result.SingleStreamThroughput
+	if scaling >= 0.75*probeStreams {
+		return defaultWorkers
+	}
+
+	return max(probeStreams, int(float64(defaultWorkers)*scaling/probeStreams))
+}
+
+// UploadETA estimates how long uploading totalBytes in the given number of files would take, using the upload
+// throughput and one request's latency per file.
+func (result ProbeResult) UploadETA(totalBytes int64, files int, workers int) time.Duration {
+	return result.eta(result.UploadThroughput, totalBytes, files, workers)
+}
+
+// DownloadETA is UploadETA for downloads.
+func (result ProbeResult) DownloadETA(totalBytes int64, files int, workers int) time.Duration {
+	return result.eta(result.DownloadThroughput, totalBytes, files, workers)
+}
+
+func (result ProbeResult) eta(throughput float64, totalBytes int64, files int, workers int) time.Duration {
+	if throughput == 0 {
+		return 0
+	}
+
+	transfer := time.Duration(float64(totalBytes) / throughput * float64(time.Second))
+	overhead := result.Latency * time.Duration(files) / time.Duration(max(workers, 1))
+
+	return transfer + overhead
+}
+
+func (result ProbeResult) String() string {
+	return fmt.Sprintf("latency %v, upload %.1f MB/s (%.1f MB/s single stream), download %.1f MB/s",
+		result.Latency.Round(time.Millisecond), result.UploadThroughput/1e6, result.SingleStreamThroughput/1e6, result.DownloadThroughput/1e6)
+}
+
+// Probe takes a bucket name and measures the latency and throughput of the endpoint by uploading and downloading a
+// few test objects under .s3m-probe/, which are deleted afterwards. Run it before a long job to size the job's
+// workers and estimate how long it will take.
+func (basics BucketBasics) Probe(bucketName string) (ProbeResult, error) {
+	ctx := context.TODO()
+	var result ProbeResult
+
+	data := make([]byte, probeObjectSize)
+	rand.Read(data)
+
+	keys := make([]string, probeStreams)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%v%v-%d", probePrefix, time.Now().UnixNano(), i)
+	}
+
+	defer basics.deleteKeys(context.Background(), bucketName, append(keys, keys[0]+"-empty"))
+
+	put := func(key string, body []byte) error {
+		_, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(key),
+			Body:   bytes.NewReader(body),
+		})
+
+		if err != nil {
+			log.Printf("Couldn't upload probe object %v to bucket %v: %v", key, bucketName, err)
+		}
+
+		return err
+	}
+
+	get := func(key string) error {
+		obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(key),
+		})
+
+		if err != nil {
+			log.Printf("Couldn't get probe object %v: %v", key, err)
+			return err
+		}
+
+		defer obj.Body.Close()
+
+		_, err = io.Copy(io.Discard, obj.Body)
+		return err
+	}
+
+	// Measure latency with an empty object
+	start := time.Now()
+	if err := put(keys[0]+"-empty", nil); err != nil {
+		return result, err
+	}
+	result.Latency = time.Since(start)
+
+	// Measure a single stream
+	start = time.Now()
+	if err := put(keys[0], data); err != nil {
+		return result, err
+	}
+	result.SingleStreamThroughput = probeObjectSize / time.Since(start).Seconds()
+
+	// Measure several streams at once in each direction
+	elapsed, err := probeConcurrently(keys, func(key string) error { return put(key, data) })
+	if err != nil {
+		return result, err
+	}
+	result.UploadThroughput = probeObjectSize * probeStreams / elapsed.Seconds()
+
+	elapsed, err = probeConcurrently(keys, get)
+	if err != nil {
+		return result, err
+	}
+	result.DownloadThroughput = probeObjectSize * probeStreams / elapsed.Seconds()
+
+	return result, nil
+}
+
+// probeConcurrently runs fn for every key at once and returns how long they took together.
+func probeConcurrently(keys []string, fn func(key string) error) (time.Duration, error) {
+	var wg sync.WaitGroup
+	errs := make([]error, len(keys))
+
+	start := time.Now()
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(key)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	for _, err := range errs {
+		if err != nil {
+			return elapsed, err
+		}
+	}
+
+	return elapsed, nil
+}
+
+// probeBatch runs a probe before a batch of files and prints the result and an ETA. It returns the number of
+// workers to use, or defaultWorkers if the probe fails.
+func (basics BucketBasics) probeBatch(bucketName string, download bool, totalBytes int64, files int, defaultWorkers int) int {
+	result, err := basics.Probe(bucketName)
+
+	if err != nil {
+		log.Printf("Couldn't probe bucket %v, using %v workers: %v", bucketName, defaultWorkers, err)
+		return defaultWorkers
+	}
+
+	workers := result.Workers(defaultWorkers)
+
+	eta := result.UploadETA(totalBytes, files, workers)
+	if download {
+		eta = result.DownloadETA(totalBytes, files, workers)
+	}
+
+	fmt.Printf("%v; using %v workers, estimated time %v\n", result, workers, eta.Round(time.Second))
+
+	return workers
+}
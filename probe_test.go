@@ -0,0 +1,55 @@
+package boto3manager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProbeResultWorkers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		result ProbeResult
+		wanted int
+	}{
+		{
+			name:   "scales well",
+			result: ProbeResult{SingleStreamThroughput: 10, UploadThroughput: 40},
+			wanted: 25,
+		},
+		{
+			name:   "endpoint bound",
+			result: ProbeResult{SingleStreamThroughput: 10, UploadThroughput: 20},
+			wanted: 12,
+		},
+		{
+			name:   "no gain at all",
+			result: ProbeResult{SingleStreamThroughput: 10, UploadThroughput: 10},
+			wanted: 6,
+		},
+		{
+			name:   "not measured",
+			result: ProbeResult{},
+			wanted: 25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.Workers(25); got != tt.wanted {
+				t.Errorf("Workers(25) = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestProbeResultUploadETA(t *testing.T) {
+	t.Parallel()
+
+	result := ProbeResult{Latency: 100 * time.Millisecond, UploadThroughput: 1e6}
+
+	if got, wanted := result.UploadETA(10e6, 100, 10), 11*time.Second; got != wanted {
+		t.Errorf("UploadETA(10e6, 100, 10) = %v, want %v", got, wanted)
+	}
+}
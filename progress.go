@@ -0,0 +1,118 @@
+package boto3manager
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+// progressTerminal reports whether progress bars are drawn on a terminal. Bars are drawn on stderr; when it is
+// redirected, as in CI logs, their control characters would only clutter the output.
+var progressTerminal = sync.OnceValue(func() bool {
+	return term.IsTerminal(int(os.Stderr.Fd()))
+})
+
+// newBytesBar returns a progress bar counting bytes, drawn only if stderr is a terminal.
+func newBytesBar(total int64, description string) *progressbar.ProgressBar {
+	if !progressTerminal() {
+		return silentBar(total)
+	}
+	return progressbar.DefaultBytes(total, description)
+}
+
+// newCountBar returns a progress bar counting items, drawn only if stderr is a terminal.
+func newCountBar(total int64, description string) *progressbar.ProgressBar {
+	if !progressTerminal() {
+		return silentBar(total)
+	}
+	return progressbar.Default(total, description)
+}
+
+// silentBar returns a progress bar that counts without drawing anything.
+func silentBar(total int64) *progressbar.ProgressBar {
+	return progressbar.NewOptions64(total, progressbar.OptionSetWriter(io.Discard))
+}
+
+// readSeekerAt is a file that can be read at any offset, which lets the upload manager read parts concurrently
+// without buffering them.
+type readSeekerAt interface {
+	io.ReadSeeker
+	io.ReaderAt
+}
+
+// progressReader wraps a file being uploaded and adds every byte read to a progress bar.
+type progressReader struct {
+	r     io.Reader
+	bar   *progressbar.ProgressBar
+	count atomic.Int64
+}
+
+// progressReadSeeker is a progressReader that keeps the file's ReadAt and Seek methods.
+type progressReadSeeker struct {
+	*progressReader
+	rs readSeekerAt
+}
+
+// newProgressReader wraps r so reads are added to bar. The returned reader keeps ReadAt and Seek if r has them.
+func newProgressReader(r io.Reader, bar *progressbar.ProgressBar) (io.Reader, *progressReader) {
+	progress := &progressReader{r: r, bar: bar}
+
+	if rs, ok := r.(readSeekerAt); ok {
+		return progressReadSeeker{progressReader: progress, rs: rs}, progress
+	}
+
+	return progress, progress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.add(n)
+	return n, err
+}
+
+func (r progressReadSeeker) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.rs.ReadAt(p, off)
+	r.add(n)
+	return n, err
+}
+
+func (r progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return r.rs.Seek(offset, whence)
+}
+
+func (r *progressReader) add(n int) {
+	if n > 0 {
+		r.count.Add(int64(n))
+		r.bar.Add(n)
+	}
+}
+
+// rollback removes the bytes counted so far from the progress bar, for when a transfer fails and will be retried.
+func (r *progressReader) rollback() {
+	r.bar.Add64(-r.count.Swap(0))
+}
+
+// progressWriterAt wraps the destination of a download and adds every byte written to a progress bar.
+type progressWriterAt struct {
+	w     io.WriterAt
+	bar   *progressbar.ProgressBar
+	count atomic.Int64
+}
+
+func (w *progressWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := w.w.WriteAt(p, off)
+	if n > 0 {
+		w.count.Add(int64(n))
+		w.bar.Add(n)
+	}
+	return n, err
+}
+
+// rollback removes the bytes counted so far from the progress bar, for when a transfer fails and will be retried.
+func (w *progressWriterAt) rollback() {
+	w.bar.Add64(-w.count.Swap(0))
+}
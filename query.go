@@ -0,0 +1,134 @@
+package boto3manager
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// SelectFormat is the format of the data read or written by QueryObject.
+type SelectFormat string
+
+const (
+	// SelectCSV is comma separated values. As input, the first line is used as a header so columns can be named
+	SelectCSV SelectFormat = "CSV"
+	// SelectJSON is newline delimited JSON
+	SelectJSON SelectFormat = "JSON"
+	// SelectParquet is Apache Parquet. It can only be used as input
+	SelectParquet SelectFormat = "Parquet"
+)
+
+// inputSerialization returns how S3 should parse an object of the given format.
+func (format SelectFormat) inputSerialization() (*types.InputSerialization, error) {
+	switch format {
+	case SelectCSV:
+		return &types.InputSerialization{CSV: &types.CSVInput{FileHeaderInfo: types.FileHeaderInfoUse}}, nil
+	case SelectJSON:
+		return &types.InputSerialization{JSON: &types.JSONInput{Type: types.JSONTypeLines}}, nil
+	case SelectParquet:
+		return &types.InputSerialization{Parquet: &types.ParquetInput{}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported input format %q", format)
+	}
+}
+
+// outputSerialization returns how S3 should format query results.
+func (format SelectFormat) outputSerialization() (*types.OutputSerialization, error) {
+	switch format {
+	case SelectCSV:
+		return &types.OutputSerialization{CSV: &types.CSVOutput{}}, nil
+	case SelectJSON:
+		return &types.OutputSerialization{JSON: &types.JSONOutput{RecordDelimiter: aws.String("\n")}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// QueryObject takes a bucket name, a key, an SQL expression, and input and output formats and runs the query against
+// the object with S3 Select, streaming the results to w. Only the matching rows are transferred, so slices of large
+// datasets can be pulled without downloading the whole object.
+func (basics BucketBasics) QueryObject(bucketName string, key string, sql string, inputFormat SelectFormat, outputFormat SelectFormat, w io.Writer) error {
+	input, err := inputFormat.inputSerialization()
+
+	if err != nil {
+		return err
+	}
+
+	output, err := outputFormat.outputSerialization()
+
+	if err != nil {
+		return err
+	}
+
+	// Start the query
+	resp, err := basics.client(bucketName).SelectObjectContent(context.TODO(), &s3.SelectObjectContentInput{
+		Bucket:              aws.String(bucketName),
+		Key:                 aws.String(key),
+		Expression:          aws.String(sql),
+		ExpressionType:      types.ExpressionTypeSql,
+		InputSerialization:  input,
+		OutputSerialization: output,
+	})
+
+	if err != nil {
+		log.Printf("Couldn't query object %v: %v", key, err)
+		return err
+	}
+
+	stream := resp.GetStream()
+	defer stream.Close()
+
+	// Copy each chunk of records to the writer as it arrives
+	for event := range stream.Events() {
+		records, ok := event.(*types.SelectObjectContentEventStreamMemberRecords)
+		if !ok {
+			continue
+		}
+
+		if _, err := w.Write(records.Value.Payload); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		log.Printf("Couldn't read query results for %v: %v", key, err)
+		return err
+	}
+
+	return nil
+}
+
+// QueryObjectRows is QueryObject that sends each result row to rows instead of writing to a writer. Records may be
+// split across chunks by S3, so rows are reassembled before being sent. rows is closed when the query finishes.
+func (basics BucketBasics) QueryObjectRows(bucketName string, key string, sql string, inputFormat SelectFormat, outputFormat SelectFormat, rows chan<- string) error {
+	defer close(rows)
+
+	r, w := io.Pipe()
+
+	// Split the stream into rows while the query writes to it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			rows <- scanner.Text()
+		}
+
+		r.CloseWithError(scanner.Err())
+	}()
+
+	err := basics.QueryObject(bucketName, key, sql, inputFormat, outputFormat, w)
+	w.CloseWithError(err)
+
+	<-done
+
+	return err
+}
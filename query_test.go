@@ -0,0 +1,82 @@
+package boto3manager
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestQueryObject(t *testing.T) {
+	t.Parallel()
+
+	// The fake sends the object back as the results, split into chunks that cut across rows
+	results := "id,name\n1,alpha\n2,beta\n"
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "table.csv", results, nil)
+
+	var out bytes.Buffer
+	if err := fake.basics().QueryObject("data", "table.csv", "SELECT * FROM S3Object", SelectCSV, SelectCSV, &out); err != nil {
+		t.Fatalf("QueryObject() = %v, want nil", err)
+	}
+	if out.String() != results {
+		t.Errorf("QueryObject() wrote %q, want %q", out.String(), results)
+	}
+
+	rows := make(chan string)
+	var got []string
+	done := make(chan error)
+	go func() {
+		done <- fake.basics().QueryObjectRows("data", "table.csv", "SELECT * FROM S3Object", SelectCSV, SelectCSV, rows)
+	}()
+	for row := range rows {
+		got = append(got, row)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("QueryObjectRows() = %v, want nil", err)
+	}
+	if wanted := []string{"id,name", "1,alpha", "2,beta"}; !slices.Equal(got, wanted) {
+		t.Errorf("QueryObjectRows() = %q, want %q", got, wanted)
+	}
+}
+
+func TestQueryObjectErrors(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "table.csv", "id\n1\n", nil)
+
+	tests := []struct {
+		key    string
+		input  SelectFormat
+		output SelectFormat
+	}{
+		{key: "missing.csv", input: SelectCSV, output: SelectCSV},
+		// Parquet can be read but not written
+		{key: "table.csv", input: SelectCSV, output: SelectParquet},
+		{key: "table.csv", input: "XML", output: SelectCSV},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := fake.basics().QueryObject("data", test.key, "SELECT * FROM S3Object", test.input, test.output, &out); err == nil {
+			t.Errorf("QueryObject(%v, %v, %v) returned no error", test.key, test.input, test.output)
+		}
+	}
+
+	// Bad formats are refused before any request is sent
+	if got := fake.count("POST"); got != 1 {
+		t.Errorf("QueryObject() sent %v queries, want 1", got)
+	}
+
+	var apiErr smithy.APIError
+	var out bytes.Buffer
+	err := fake.basics().QueryObject("data", "missing.csv", "SELECT * FROM S3Object", SelectCSV, SelectCSV, &out)
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "NoSuchKey" {
+		t.Errorf("QueryObject(missing.csv) = %v, want NoSuchKey", err)
+	}
+}
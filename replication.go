@@ -0,0 +1,168 @@
+package boto3manager
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ReplicationRule is a simplified replication rule copying every new object under a prefix to another bucket.
+// Build one with NewReplicationRule and its methods, e.g.
+//
+//	NewReplicationRule("results/", "project-backup").StoreAs("GLACIER").ReplicateDeletes()
+type ReplicationRule struct {
+	ID     string
+	Prefix string
+
+	// DestinationBucket is the name of the bucket objects are copied to. It must have versioning enabled
+	DestinationBucket string
+
+	// StorageClass is the storage class of the copies. If empty, the source object's class is kept
+	StorageClass string
+
+	// DeleteMarkers copies delete markers too, so deleting an object hides it in the destination as well
+	DeleteMarkers bool
+
+	Disabled bool
+}
+
+// NewReplicationRule returns an enabled rule copying objects under prefix to destinationBucket, named after the
+// prefix.
+func NewReplicationRule(prefix string, destinationBucket string) ReplicationRule {
+	id := prefix
+	if id == "" {
+		id = "whole-bucket"
+	}
+
+	return ReplicationRule{ID: id, Prefix: prefix, DestinationBucket: destinationBucket}
+}
+
+// StoreAs returns the rule with copies stored in storageClass.
+func (rule ReplicationRule) StoreAs(storageClass string) ReplicationRule {
+	rule.StorageClass = storageClass
+	return rule
+}
+
+// ReplicateDeletes returns the rule with delete markers copied to the destination.
+func (rule ReplicationRule) ReplicateDeletes() ReplicationRule {
+	rule.DeleteMarkers = true
+	return rule
+}
+
+// toSDK converts the rule to the SDK's representation with the given priority.
+func (rule ReplicationRule) toSDK(priority int32) types.ReplicationRule {
+	sdkRule := types.ReplicationRule{
+		ID:       aws.String(rule.ID),
+		Priority: aws.Int32(priority),
+		Filter:   &types.ReplicationRuleFilterMemberPrefix{Value: rule.Prefix},
+		Status:   types.ReplicationRuleStatusEnabled,
+		Destination: &types.Destination{
+			Bucket: aws.String(bucketARN(rule.DestinationBucket)),
+		},
+		DeleteMarkerReplication: &types.DeleteMarkerReplication{Status: types.DeleteMarkerReplicationStatusDisabled},
+	}
+
+	if rule.Disabled {
+		sdkRule.Status = types.ReplicationRuleStatusDisabled
+	}
+
+	if rule.StorageClass != "" {
+		sdkRule.Destination.StorageClass = types.StorageClass(rule.StorageClass)
+	}
+
+	if rule.DeleteMarkers {
+		sdkRule.DeleteMarkerReplication.Status = types.DeleteMarkerReplicationStatusEnabled
+	}
+
+	return sdkRule
+}
+
+// replicationRuleFromSDK converts a rule from the SDK's representation. Only the parts a ReplicationRule can
+// express are kept.
+func replicationRuleFromSDK(sdkRule types.ReplicationRule) ReplicationRule {
+	rule := ReplicationRule{
+		ID:       aws.ToString(sdkRule.ID),
+		Prefix:   aws.ToString(sdkRule.Prefix),
+		Disabled: sdkRule.Status == types.ReplicationRuleStatusDisabled,
+	}
+
+	if prefix, ok := sdkRule.Filter.(*types.ReplicationRuleFilterMemberPrefix); ok {
+		rule.Prefix = prefix.Value
+	}
+
+	if sdkRule.Destination != nil {
+		rule.DestinationBucket = strings.TrimPrefix(aws.ToString(sdkRule.Destination.Bucket), "arn:aws:s3:::")
+		rule.StorageClass = string(sdkRule.Destination.StorageClass)
+	}
+
+	if sdkRule.DeleteMarkerReplication != nil {
+		rule.DeleteMarkers = sdkRule.DeleteMarkerReplication.Status == types.DeleteMarkerReplicationStatusEnabled
+	}
+
+	return rule
+}
+
+// GetReplication takes a bucket name and returns the ARN of the IAM role its replication runs as and its
+// replication rules.
+func (basics BucketBasics) GetReplication(bucketName string) (string, []ReplicationRule, error) {
+	output, err := basics.client(bucketName).GetBucketReplication(context.TODO(), &s3.GetBucketReplicationInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get replication configuration of bucket %v: %v", bucketName, err)
+		return "", nil, err
+	}
+
+	if output.ReplicationConfiguration == nil {
+		return "", nil, nil
+	}
+
+	rules := make([]ReplicationRule, 0, len(output.ReplicationConfiguration.Rules))
+	for _, rule := range output.ReplicationConfiguration.Rules {
+		rules = append(rules, replicationRuleFromSDK(rule))
+	}
+
+	return aws.ToString(output.ReplicationConfiguration.Role), rules, nil
+}
+
+// PutReplication takes a bucket name, the ARN of an IAM role S3 can assume to copy objects, and replication rules
+// and replaces the bucket's replication configuration. Where rules' prefixes overlap, the earlier rule wins. Both
+// the bucket and the destinations must have versioning enabled, see EnableVersioning.
+func (basics BucketBasics) PutReplication(bucketName string, roleARN string, rules []ReplicationRule) error {
+	sdkRules := make([]types.ReplicationRule, 0, len(rules))
+	for i, rule := range rules {
+		sdkRules = append(sdkRules, rule.toSDK(int32(len(rules)-i)))
+	}
+
+	_, err := basics.client(bucketName).PutBucketReplication(context.TODO(), &s3.PutBucketReplicationInput{
+		Bucket: aws.String(bucketName),
+		ReplicationConfiguration: &types.ReplicationConfiguration{
+			Role:  aws.String(roleARN),
+			Rules: sdkRules,
+		},
+	})
+
+	if err != nil {
+		log.Printf("Couldn't put replication configuration on bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
+
+// DeleteReplication takes a bucket name and removes its replication configuration.
+func (basics BucketBasics) DeleteReplication(bucketName string) error {
+	_, err := basics.client(bucketName).DeleteBucketReplication(context.TODO(), &s3.DeleteBucketReplicationInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't delete replication configuration of bucket %v: %v", bucketName, err)
+	}
+
+	return err
+}
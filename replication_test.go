@@ -0,0 +1,35 @@
+package boto3manager
+
+import (
+	"testing"
+)
+
+func TestReplicationRuleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule ReplicationRule
+	}{
+		{
+			name: "prefix",
+			rule: NewReplicationRule("results/", "project-backup"),
+		},
+		{
+			name: "storage class and deletes",
+			rule: NewReplicationRule("raw/", "archive").StoreAs("GLACIER").ReplicateDeletes(),
+		},
+		{
+			name: "disabled whole bucket",
+			rule: ReplicationRule{ID: "whole-bucket", DestinationBucket: "copy", Disabled: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replicationRuleFromSDK(tt.rule.toSDK(1)); got != tt.rule {
+				t.Errorf("replicationRuleFromSDK(toSDK(%+v)) = %+v", tt.rule, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package boto3manager
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TransferResult is the outcome of transferring a single file in a batch operation.
+type TransferResult struct {
+	Key      string
+	Path     string
+	Size     int64
+	Attempts int
+	Err      error
+
+	// Duration is how long the file took, including every attempt and the waits between them
+	Duration time.Duration
+
+	// SkipReason is set when the file was skipped rather than transferred, saying why
+	SkipReason string
+}
+
+// Skipped reports whether the file was skipped rather than transferred.
+func (result TransferResult) Skipped() bool {
+	return result.SkipReason != ""
+}
+
+// TransferReport collects the result of every file in a batch operation.
+type TransferReport struct {
+	mu      sync.Mutex
+	Results []TransferResult
+
+	// Compression estimates the savings gzip compression would give, if the operation was asked to
+	Compression *CompressionEstimate
+
+	// Concurrency is how many files and parts the operation transferred at once and why, if it chose them
+	Concurrency *Concurrency
+
+	// Started and Finished are when the operation's transfers started and finished
+	Started  time.Time
+	Finished time.Time
+
+	// Deleted lists the keys or paths a mirror sync deleted from the destination
+	Deleted []string
+
+	// Sanitized maps the keys a download with SanitizeOptions wrote somewhere other than their own paths, because
+	// they held characters the file system can't or collided with other keys, to the files written
+	Sanitized map[string]string
+}
+
+// start marks the start of the operation, unless an earlier step already has.
+func (report *TransferReport) start() {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	if report.Started.IsZero() {
+		report.Started = time.Now()
+	}
+}
+
+// finish marks the end of the operation.
+func (report *TransferReport) finish() {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	report.Finished = time.Now()
+}
+
+// record adds a result to the report. It is safe to call from multiple workers.
+func (report *TransferReport) record(result TransferResult) {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	report.Results = append(report.Results, result)
+}
+
+// merge adds the results of another report of the same operation, widening Started and Finished to cover both.
+func (report *TransferReport) merge(other *TransferReport) {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	report.Results = append(report.Results, other.Results...)
+	report.Deleted = append(report.Deleted, other.Deleted...)
+
+	if report.Started.IsZero() || other.Started.Before(report.Started) {
+		report.Started = other.Started
+	}
+
+	if other.Finished.After(report.Finished) {
+		report.Finished = other.Finished
+	}
+}
+
+// progress returns the number of files done and failed so far and the bytes transferred. It is safe to call while
+// workers are still recording results.
+func (report *TransferReport) progress() (done int, failed int, bytes int64) {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	for _, result := range report.Results {
+		if result.Err != nil {
+			failed++
+			continue
+		}
+		done++
+		if !result.Skipped() {
+			bytes += result.Size
+		}
+	}
+
+	return done, failed, bytes
+}
+
+// Succeeded returns the results of the files that were transferred.
+func (report *TransferReport) Succeeded() []TransferResult {
+	succeeded := make([]TransferResult, 0, len(report.Results))
+	for _, result := range report.Results {
+		if result.Err == nil && !result.Skipped() {
+			succeeded = append(succeeded, result)
+		}
+	}
+
+	return succeeded
+}
+
+// Skipped returns the results of the files that were skipped because there was nothing to transfer.
+func (report *TransferReport) Skipped() []TransferResult {
+	skipped := make([]TransferResult, 0)
+	for _, result := range report.Results {
+		if result.Skipped() {
+			skipped = append(skipped, result)
+		}
+	}
+
+	return skipped
+}
+
+// Failed returns the results of the files that couldn't be transferred.
+func (report *TransferReport) Failed() []TransferResult {
+	failed := make([]TransferResult, 0)
+	for _, result := range report.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Retries returns the total number of extra attempts made across all files.
+func (report *TransferReport) Retries() int {
+	var retries int
+	for _, result := range report.Results {
+		if result.Attempts > 1 {
+			retries += result.Attempts - 1
+		}
+	}
+
+	return retries
+}
+
+// ExtensionSummary totals the results of files sharing an extension.
+type ExtensionSummary struct {
+	Count    int
+	Bytes    int64
+	Failures int
+}
+
+// ByExtension groups the results by lowercase file extension, such as ".h5", so patterns in failures stand out.
+// Files without an extension are grouped under "".
+func (report *TransferReport) ByExtension() map[string]ExtensionSummary {
+	summaries := make(map[string]ExtensionSummary)
+
+	for _, result := range report.Results {
+		ext := strings.ToLower(path.Ext(result.name()))
+
+		summary := summaries[ext]
+		summary.Count++
+		summary.Bytes += result.Size
+		if result.Err != nil {
+			summary.Failures++
+		}
+		summaries[ext] = summary
+	}
+
+	return summaries
+}
+
+// ReportSummary totals a report for printing at the end of a run.
+type ReportSummary struct {
+	Transferred int           `json:"transferred"`
+	Skipped     int           `json:"skipped"`
+	Failed      int           `json:"failed"`
+	Bytes       int64         `json:"bytes"`
+	Elapsed     time.Duration `json:"-"`
+
+	// FailedByExtension counts the failures for each lowercase file extension, as ByExtension groups them, so a
+	// kind of file that keeps failing stands out
+	FailedByExtension map[string]int `json:"failed_by_extension,omitempty"`
+}
+
+// Throughput returns the bytes transferred per second over the whole operation.
+func (summary ReportSummary) Throughput() float64 {
+	if summary.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(summary.Bytes) / summary.Elapsed.Seconds()
+}
+
+func (summary ReportSummary) String() string {
+	s := fmt.Sprintf("%v transferred, %v skipped, %v failed, %.1f MB in %v (%.1f MB/s)",
+		summary.Transferred, summary.Skipped, summary.Failed, float64(summary.Bytes)/1e6, summary.Elapsed.Round(time.Millisecond), summary.Throughput()/1e6)
+
+	if len(summary.FailedByExtension) == 0 {
+		return s
+	}
+
+	// List the extensions with the most failures first
+	exts := slices.Collect(maps.Keys(summary.FailedByExtension))
+	slices.SortFunc(exts, func(a, b string) int {
+		if n := summary.FailedByExtension[b] - summary.FailedByExtension[a]; n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+
+	failures := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		name := ext
+		if name == "" {
+			name = "no extension"
+		}
+		failures = append(failures, fmt.Sprintf("%v %v", summary.FailedByExtension[ext], name))
+	}
+
+	return s + "; failed: " + strings.Join(failures, ", ")
+}
+
+// Summary counts the files transferred, skipped, and failed and the bytes transferred in the time the operation took.
+func (report *TransferReport) Summary() ReportSummary {
+	var summary ReportSummary
+	for _, result := range report.Results {
+		switch {
+		case result.Err != nil:
+			summary.Failed++
+
+			if summary.FailedByExtension == nil {
+				summary.FailedByExtension = make(map[string]int)
+			}
+			summary.FailedByExtension[strings.ToLower(path.Ext(result.name()))]++
+		case result.Skipped():
+			summary.Skipped++
+		default:
+			summary.Transferred++
+			summary.Bytes += result.Size
+		}
+	}
+
+	if !report.Started.IsZero() && !report.Finished.IsZero() {
+		summary.Elapsed = report.Finished.Sub(report.Started)
+	}
+
+	return summary
+}
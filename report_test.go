@@ -0,0 +1,97 @@
+package boto3manager
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTransferReportByExtension(t *testing.T) {
+	t.Parallel()
+
+	report := &TransferReport{Results: []TransferResult{
+		{Key: "a/data.h5", Size: 10, Err: errors.New("failed")},
+		{Key: "b/DATA.H5", Size: 20},
+		{Key: "c/readme.txt", Size: 1},
+		{Key: "d/Makefile", Size: 2},
+	}}
+
+	tests := []struct {
+		ext    string
+		wanted ExtensionSummary
+	}{
+		{
+			ext:    ".h5",
+			wanted: ExtensionSummary{Count: 2, Bytes: 30, Failures: 1},
+		},
+		{
+			ext:    ".txt",
+			wanted: ExtensionSummary{Count: 1, Bytes: 1},
+		},
+		{
+			ext:    "",
+			wanted: ExtensionSummary{Count: 1, Bytes: 2},
+		},
+	}
+
+	summaries := report.ByExtension()
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			if got := summaries[tt.ext]; got != tt.wanted {
+				t.Errorf("ByExtension()[%q] = %+v, want %+v", tt.ext, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestTransferReportSummaryNoFailures(t *testing.T) {
+	t.Parallel()
+
+	report := &TransferReport{Results: []TransferResult{{Key: "a.csv", Size: 1_000_000}}}
+	if got, want := report.Summary().String(), "1 transferred, 0 skipped, 0 failed, 1.0 MB in 0s (0.0 MB/s)"; got != want {
+		t.Errorf("Summary().String() = %q, want %q", got, want)
+	}
+}
+
+func TestTransferReportProgress(t *testing.T) {
+	t.Parallel()
+
+	report := &TransferReport{Results: []TransferResult{
+		{Key: "a", Size: 10},
+		{Key: "b", Size: 20, Err: errors.New("failed")},
+		{Key: "c", Size: 5},
+	}}
+
+	done, failed, bytes := report.progress()
+	if done != 2 || failed != 1 || bytes != 15 {
+		t.Errorf("progress() = %v, %v, %v, want 2, 1, 15", done, failed, bytes)
+	}
+}
+
+func TestTransferReportSummary(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := &TransferReport{
+		Started:  started,
+		Finished: started.Add(10 * time.Second),
+		Results: []TransferResult{
+			{Key: "a", Size: 30_000_000},
+			{Key: "b", Size: 20_000_000},
+			{Key: "c", Size: 5, SkipReason: "unchanged"},
+			{Key: "d.h5", Size: 7, Err: errors.New("failed")},
+			{Key: "e.H5", Size: 7, Err: errors.New("failed")},
+			{Key: "f", Size: 7, Err: errors.New("failed")},
+		},
+	}
+
+	wanted := ReportSummary{Transferred: 2, Skipped: 1, Failed: 3, Bytes: 50_000_000, Elapsed: 10 * time.Second, FailedByExtension: map[string]int{".h5": 2, "": 1}}
+	if got := report.Summary(); !reflect.DeepEqual(got, wanted) {
+		t.Errorf("Summary() = %+v, want %+v", got, wanted)
+	}
+
+	if got, want := report.Summary().String(), "2 transferred, 1 skipped, 3 failed, 50.0 MB in 10s (5.0 MB/s); failed: 2 .h5, 1 no extension"; got != want {
+		t.Errorf("Summary().String() = %q, want %q", got, want)
+	}
+}
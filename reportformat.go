@@ -0,0 +1,219 @@
+package boto3manager
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// name returns the name a result is reported under: its key, or its path if it has no key.
+func (result TransferResult) name() string {
+	if result.Key != "" {
+		return result.Key
+	}
+	return result.Path
+}
+
+// status returns "ok", "skipped", or "failed".
+func (result TransferResult) status() string {
+	switch {
+	case result.Err != nil:
+		return "failed"
+	case result.Skipped():
+		return "skipped"
+	default:
+		return "ok"
+	}
+}
+
+// speed returns the bytes per second the file was transferred at, or zero if it wasn't transferred.
+func (result TransferResult) speed() float64 {
+	if result.Err != nil || result.Skipped() || result.Duration <= 0 {
+		return 0
+	}
+
+	return float64(result.Size) / result.Duration.Seconds()
+}
+
+// jsonResult is a TransferResult as it appears in a JSON report.
+type jsonResult struct {
+	Key            string  `json:"key,omitempty"`
+	Path           string  `json:"path,omitempty"`
+	Status         string  `json:"status"`
+	Bytes          int64   `json:"bytes"`
+	Attempts       int     `json:"attempts"`
+	Seconds        float64 `json:"seconds"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	Error          string  `json:"error,omitempty"`
+	SkipReason     string  `json:"skip_reason,omitempty"`
+}
+
+// jsonReport is a TransferReport as it is written by WriteJSON.
+type jsonReport struct {
+	Started        time.Time         `json:"started"`
+	Finished       time.Time         `json:"finished"`
+	Seconds        float64           `json:"seconds"`
+	BytesPerSecond float64           `json:"bytes_per_second"`
+	Summary        ReportSummary     `json:"summary"`
+	Concurrency    *Concurrency      `json:"concurrency,omitempty"`
+	Sanitized      map[string]string `json:"sanitized,omitempty"`
+	Results        []jsonResult      `json:"results"`
+}
+
+// WriteJSON writes the report's summary and the outcome of every file as indented JSON, as evidence of what an
+// operation did.
+func (report *TransferReport) WriteJSON(w io.Writer) error {
+	summary := report.Summary()
+	out := jsonReport{
+		Started:        report.Started,
+		Finished:       report.Finished,
+		Seconds:        summary.Elapsed.Seconds(),
+		BytesPerSecond: summary.Throughput(),
+		Summary:        summary,
+		Concurrency:    report.Concurrency,
+		Sanitized:      report.Sanitized,
+		Results:        make([]jsonResult, 0, len(report.Results)),
+	}
+
+	for _, result := range report.Results {
+		r := jsonResult{
+			Key:            result.Key,
+			Path:           result.Path,
+			Status:         result.status(),
+			Bytes:          result.Size,
+			Attempts:       result.Attempts,
+			Seconds:        result.Duration.Seconds(),
+			BytesPerSecond: result.speed(),
+			SkipReason:     result.SkipReason,
+		}
+
+		if result.Err != nil {
+			r.Error = result.Err.Error()
+		}
+
+		out.Results = append(out.Results, r)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// WriteCSV writes the outcome of every file as CSV with a header row.
+func (report *TransferReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"key", "path", "status", "bytes", "attempts", "seconds", "bytes_per_second", "error"}); err != nil {
+		return err
+	}
+
+	for _, result := range report.Results {
+		var message string
+		if result.Err != nil {
+			message = result.Err.Error()
+		} else if result.Skipped() {
+			message = result.SkipReason
+		}
+
+		err := writer.Write([]string{
+			result.Key,
+			result.Path,
+			result.status(),
+			strconv.FormatInt(result.Size, 10),
+			strconv.Itoa(result.Attempts),
+			strconv.FormatFloat(result.Duration.Seconds(), 'f', 3, 64),
+			strconv.FormatFloat(result.speed(), 'f', 0, 64),
+			message,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// junitTestSuite is the JUnit XML structure CI dashboards read.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as a JUnit XML test suite with a test case for every file, so CI dashboards show
+// which objects failed.
+func (report *TransferReport) WriteJUnit(w io.Writer, suiteName string) error {
+	suite := junitTestSuite{Name: suiteName, Tests: len(report.Results)}
+
+	for _, result := range report.Results {
+		testCase := junitTestCase{Name: result.name(), ClassName: suiteName}
+
+		if result.Err != nil {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: result.Err.Error(),
+				Text:    fmt.Sprintf("%v after %v attempts: %v", result.name(), result.Attempts, result.Err),
+			}
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteTAP writes the report in the Test Anything Protocol with a test point for every file.
+func (report *TransferReport) WriteTAP(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "TAP version 13\n1..%d\n", len(report.Results)); err != nil {
+		return err
+	}
+
+	for i, result := range report.Results {
+		// A "#" would start a TAP directive, so escape it in names
+		name := strings.ReplaceAll(result.name(), "#", `\#`)
+
+		var err error
+		if result.Err == nil {
+			_, err = fmt.Fprintf(w, "ok %d - %v\n", i+1, name)
+		} else {
+			_, err = fmt.Fprintf(w, "not ok %d - %v\n  ---\n  message: %q\n  attempts: %d\n  ...\n", i+1, name, result.Err.Error(), result.Attempts)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
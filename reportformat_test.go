@@ -0,0 +1,61 @@
+package boto3manager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransferReportWriters(t *testing.T) {
+	t.Parallel()
+
+	report := &TransferReport{Results: []TransferResult{
+		{Key: "data/a.csv", Size: 2000, Attempts: 1, Duration: 2 * time.Second},
+		{Key: "data/#b.csv", Attempts: 3, Err: errors.New("NoSuchKey")},
+	}}
+
+	tests := []struct {
+		name   string
+		write  func(b *strings.Builder) error
+		wanted string
+	}{
+		{
+			name:  "tap",
+			write: func(b *strings.Builder) error { return report.WriteTAP(b) },
+			wanted: "TAP version 13\n1..2\nok 1 - data/a.csv\nnot ok 2 - data/\\#b.csv\n" +
+				"  ---\n  message: \"NoSuchKey\"\n  attempts: 3\n  ...\n",
+		},
+		{
+			name:  "junit",
+			write: func(b *strings.Builder) error { return report.WriteJUnit(b, "upload") },
+			wanted: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<testsuite name="upload" tests="2" failures="1">` + "\n" +
+				`  <testcase name="data/a.csv" classname="upload"></testcase>` + "\n" +
+				`  <testcase name="data/#b.csv" classname="upload">` + "\n" +
+				`    <failure message="NoSuchKey">data/#b.csv after 3 attempts: NoSuchKey</failure>` + "\n" +
+				`  </testcase>` + "\n" +
+				`</testsuite>` + "\n",
+		},
+		{
+			name:  "csv",
+			write: func(b *strings.Builder) error { return report.WriteCSV(b) },
+			wanted: "key,path,status,bytes,attempts,seconds,bytes_per_second,error\n" +
+				"data/a.csv,,ok,2000,1,2.000,1000,\n" +
+				"data/#b.csv,,failed,0,3,0.000,0,NoSuchKey\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := tt.write(&b); err != nil {
+				t.Fatalf("write = %v", err)
+			}
+
+			if got := b.String(); got != tt.wanted {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.wanted)
+			}
+		})
+	}
+}
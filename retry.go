@@ -0,0 +1,139 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"math/rand"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// RetryPolicy controls how many times a single file in a batch operation is attempted and how long to wait
+// between attempts. These retries happen on top of the retries the SDK makes for each request.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts for each file, including the first
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the second attempt; it doubles for each attempt after that
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between attempts
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction of each wait, between 0 and 1, that is randomized
+	Jitter float64
+
+	// RetryOn decides whether an error is worth another attempt. If nil, RetryableError is used
+	RetryOn func(err error) bool
+}
+
+// DefaultRetryPolicy is used by batch operations when no RetryPolicy is given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+	Jitter:         0.2,
+}
+
+// nonRetryableCodes are S3 error codes that won't succeed by trying again.
+var nonRetryableCodes = map[string]bool{
+	"AccessDenied":          true,
+	"InvalidAccessKeyId":    true,
+	"InvalidBucketName":     true,
+	"NoSuchBucket":          true,
+	"NoSuchKey":             true,
+	"NotFound":              true,
+	"PreconditionFailed":    true,
+	"SignatureDoesNotMatch": true,
+}
+
+// permanentError marks an error that trying again won't fix, whatever it wraps.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// RetryableError classifies an error from a transfer. Local file errors, cancellation, objects that don't match
+// a checksum manifest, and errors such as missing keys or denied access are not retryable; anything else is,
+// including data corrupted on the way or by the server, which may arrive intact the next time.
+func RetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return !nonRetryableCodes[apiErr.ErrorCode()]
+	}
+
+	return true
+}
+
+// backoff returns how long to wait after the given failed attempt, starting at 1.
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	wait := policy.InitialBackoff
+	for i := 1; i < attempt && wait < policy.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+		wait = policy.MaxBackoff
+	}
+
+	// Randomize part of the wait so workers don't retry in lockstep
+	if policy.Jitter > 0 && wait > 0 {
+		spread := float64(wait) * policy.Jitter
+		wait = time.Duration(float64(wait) - spread + rand.Float64()*2*spread)
+	}
+
+	return wait
+}
+
+// do calls fn until it succeeds, returns an error that shouldn't be retried, or runs out of attempts.
+// It returns the number of attempts made and the last error.
+func (policy RetryPolicy) do(ctx context.Context, fn func() error) (int, error) {
+	retryOn := policy.RetryOn
+	if retryOn == nil {
+		retryOn = RetryableError
+	}
+
+	var attempt int
+	for {
+		attempt++
+
+		err := fn()
+		if err == nil || attempt >= policy.MaxAttempts || !retryOn(err) {
+			return attempt, err
+		}
+
+		// Wait before the next attempt unless the context is finished first
+		select {
+		case <-time.After(policy.backoff(attempt)):
+		case <-ctx.Done():
+			return attempt, err
+		}
+	}
+}
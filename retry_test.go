@@ -0,0 +1,154 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		name    string
+		attempt int
+		wanted  time.Duration
+	}{
+		{
+			name:    "first attempt",
+			attempt: 1,
+			wanted:  time.Second,
+		},
+		{
+			name:    "third attempt",
+			attempt: 3,
+			wanted:  4 * time.Second,
+		},
+		{
+			name:    "capped",
+			attempt: 10,
+			wanted:  5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.backoff(tt.attempt); got != tt.wanted {
+				t.Errorf("backoff(%v) = %v, want %v", tt.attempt, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestRetryableError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		err    error
+		wanted bool
+	}{
+		{
+			name:   "nil",
+			err:    nil,
+			wanted: false,
+		},
+		{
+			name:   "canceled",
+			err:    context.Canceled,
+			wanted: false,
+		},
+		{
+			name:   "missing local file",
+			err:    &fs.PathError{Op: "open", Path: "missing", Err: fs.ErrNotExist},
+			wanted: false,
+		},
+		{
+			name:   "access denied",
+			err:    &smithy.GenericAPIError{Code: "AccessDenied"},
+			wanted: false,
+		},
+		{
+			name:   "internal error",
+			err:    &smithy.GenericAPIError{Code: "InternalError"},
+			wanted: true,
+		},
+		{
+			name:   "ETag mismatch",
+			err:    fmt.Errorf("%w: ETag of data.csv is 1a2b, but the data sent has MD5 3c4d", ErrChecksumMismatch),
+			wanted: true,
+		},
+		{
+			name:   "manifest mismatch",
+			err:    permanentError{fmt.Errorf("%w: checksum of data.csv is 1a2b, manifest says 3c4d", ErrChecksumMismatch)},
+			wanted: false,
+		},
+		{
+			name:   "bad digest",
+			err:    classifyError(&smithy.GenericAPIError{Code: "BadDigest"}),
+			wanted: true,
+		},
+		{
+			name:   "network error",
+			err:    errors.New("connection reset by peer"),
+			wanted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RetryableError(tt.err); got != tt.wanted {
+				t.Errorf("RetryableError(%v) = %v, want %v", tt.err, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{MaxAttempts: 3}
+
+	var calls int
+	attempts, err := policy.do(context.Background(), func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+
+	if err != nil || attempts != 2 {
+		t.Errorf("do() = %v, %v, want 2, nil", attempts, err)
+	}
+}
+
+func TestManifestMismatchNotRetried(t *testing.T) {
+	t.Parallel()
+
+	items := []FileDownload{{Key: "corrupt.csv", Size: 1}}
+	config := batchConfig{workerCount: 1, policy: RetryPolicy{MaxAttempts: 3}, report: &TransferReport{}}
+
+	attempts := 0
+	err := runBatch(context.Background(), items, config, func(ctx context.Context, item FileDownload) error {
+		attempts++
+		return permanentError{fmt.Errorf("%w: checksum of %v differs", ErrChecksumMismatch, item.Key)}
+	})
+
+	if err != nil {
+		t.Fatalf("runBatch() = %v, want nil", err)
+	}
+	if attempts != 1 {
+		t.Errorf("runBatch() tried a checksum mismatch %v times, want 1", attempts)
+	}
+	if failed := config.report.Failed(); len(failed) != 1 || !errors.Is(failed[0].Err, ErrChecksumMismatch) {
+		t.Errorf("runBatch() failed %+v, want corrupt.csv with %v", failed, ErrChecksumMismatch)
+	}
+}
@@ -0,0 +1,96 @@
+package boto3manager
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// writeRetryFile writes the transfers that failed in a batch to a retry file: a plan, as written by WritePlan, of
+// the failed transfers with the error each failed with. Nothing is written if none failed. For uploads, root is the
+// directory the files' paths are relative to.
+func writeRetryFile(path string, bucketName string, op PlanOp, root string, report *TransferReport) error {
+	failed := report.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	plan := &Plan{Bucket: bucketName, Created: time.Now().UTC(), Actions: make([]PlanAction, 0, len(failed))}
+	for _, result := range failed {
+		action := PlanAction{Op: op, Key: result.Key, Path: result.Path, Size: result.Size, Error: result.Err.Error()}
+		if op == PlanUpload {
+			action.Path = filepath.Join(root, filepath.FromSlash(result.Path))
+		}
+
+		plan.Actions = append(plan.Actions, action)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePlan(plan, &buf); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves half a retry file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		log.Printf("Couldn't write retry file %v: %v", path, err)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		log.Printf("Couldn't write retry file %v: %v", path, err)
+		return err
+	}
+
+	log.Printf("Wrote %v failed transfers to retry file %v", len(failed), path)
+	return nil
+}
+
+// ResumeFromRetryFile takes the path of a retry file written by a batch operation with RetryFile set and tries
+// the transfers in it again, with default transfer options, so a scheduled job can finish off what failed without
+// rescanning the source. Afterwards the file holds only the transfers that failed again, or is removed if none
+// did.
+func (basics BucketBasics) ResumeFromRetryFile(path string, options ApplyOptions) (*TransferReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("No retry file at %v; nothing to resume", path)
+			return &TransferReport{}, nil
+		}
+
+		log.Printf("Couldn't open retry file %v: %v", path, err)
+		return nil, err
+	}
+
+	plan, err := ReadPlan(f)
+	f.Close()
+
+	if err != nil {
+		log.Printf("Couldn't parse retry file %v: %v", path, err)
+		return nil, err
+	}
+
+	report, err := basics.Apply(plan, options)
+	if report == nil {
+		return nil, err
+	}
+
+	if len(report.Failed()) == 0 {
+		if removeErr := os.Remove(path); removeErr != nil {
+			log.Printf("Couldn't remove retry file %v: %v", path, removeErr)
+			return report, removeErr
+		}
+		return report, err
+	}
+
+	// The paths in the plan are already rooted, so keep them as they are
+	op := plan.Actions[0].Op
+	if writeErr := writeRetryFile(path, plan.Bucket, op, "", report); writeErr != nil {
+		return report, writeErr
+	}
+
+	return report, err
+}
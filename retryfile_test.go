@@ -0,0 +1,69 @@
+package boto3manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRetryFile(t *testing.T) {
+	t.Parallel()
+
+	report := &TransferReport{Results: []TransferResult{
+		{Key: "data/a.csv", Path: "run/a.csv", Size: 5},
+		{Key: "data/b.csv", Path: "run/b.csv", Size: 3, Err: errors.New("connection reset")},
+		{Key: "data/c.csv", Path: "run/c.csv", Size: 1, SkipReason: "unchanged"},
+	}}
+
+	tests := []struct {
+		op     PlanOp
+		root   string
+		report *TransferReport
+		wanted []PlanAction
+	}{
+		{
+			op:     PlanUpload,
+			root:   "/srv",
+			report: report,
+			wanted: []PlanAction{{Op: PlanUpload, Key: "data/b.csv", Path: filepath.Join("/srv", "run", "b.csv"), Size: 3, Error: "connection reset"}},
+		},
+		{
+			op:     PlanDownload,
+			report: report,
+			wanted: []PlanAction{{Op: PlanDownload, Key: "data/b.csv", Path: "run/b.csv", Size: 3, Error: "connection reset"}},
+		},
+		{
+			op:     PlanDownload,
+			report: &TransferReport{Results: report.Results[:1]},
+		},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "retry.json")
+		if err := writeRetryFile(path, "lab", test.op, test.root, test.report); err != nil {
+			t.Fatalf("writeRetryFile(%v) = %v", test.op, err)
+		}
+
+		f, err := os.Open(path)
+		if test.wanted == nil {
+			if err == nil {
+				f.Close()
+				t.Errorf("writeRetryFile(%v) wrote a file with no failures", test.op)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("writeRetryFile(%v) wrote no file: %v", test.op, err)
+		}
+
+		plan, err := ReadPlan(f)
+		f.Close()
+
+		if err != nil || plan.Bucket != "lab" || !reflect.DeepEqual(plan.Actions, test.wanted) {
+			t.Errorf("writeRetryFile(%v) = %+v, %v, want %+v", test.op, plan, err, test.wanted)
+		}
+	}
+}
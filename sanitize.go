@@ -0,0 +1,234 @@
+package boto3manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ErrKeyCollision means keys in a download would be written to the same file, such as keys differing only by case
+// on a case-insensitive file system.
+var ErrKeyCollision = errors.New("keys collide")
+
+// NameEscaping is how characters a file system can't hold in a file name are written.
+type NameEscaping int
+
+const (
+	// EscapeReplace replaces each character with "_"
+	EscapeReplace NameEscaping = iota
+	// EscapePercent writes each character, and "%" itself, as "%" and its hex encoded bytes, like a URL, so the key
+	// can be recovered from the name
+	EscapePercent
+)
+
+// CollisionPolicy is what a download does when keys would be written to the same file.
+type CollisionPolicy int
+
+const (
+	// CollisionError fails the download with ErrKeyCollision before it starts
+	CollisionError CollisionPolicy = iota
+	// CollisionRename writes each key after the first to the file's name with "~1", "~2", and so on before its
+	// extension
+	CollisionRename
+	// CollisionSkip downloads only the first key, skipping the rest
+	CollisionSkip
+)
+
+// SanitizeOptions controls how a download writes keys the local file system can't hold as they are.
+type SanitizeOptions struct {
+	// Escaping is how characters that can't be in a file name are written
+	Escaping NameEscaping
+
+	// Collisions is what happens when keys would be written to the same file, after escaping and with case
+	// ignored if the destination's file system ignores it
+	Collisions CollisionPolicy
+
+	// Windows escapes the names Windows can't hold, such as those containing ":" or named "CON", on any system, for
+	// downloads to shares that will be read from Windows. It is always on on Windows
+	Windows bool
+}
+
+// escapeName returns name with the characters that can't be in a file name escaped. Names Windows reserves for
+// devices, and the trailing dots and spaces it drops, are escaped too if windows is set.
+func escapeName(name string, escaping NameEscaping, windows bool) string {
+	var b strings.Builder
+
+	escape := func(s string) {
+		if escaping == EscapePercent {
+			for i := range len(s) {
+				fmt.Fprintf(&b, "%%%02X", s[i])
+			}
+			return
+		}
+		b.WriteString("_")
+	}
+
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		s := name[i : i+size]
+
+		switch {
+		case r == 0 || r == '/':
+			escape(s)
+		case windows && (r < 0x20 || strings.ContainsRune(`<>:"\|?*`, r)):
+			escape(s)
+		case windows && strings.Trim(name[i:], ". ") == "":
+			escape(s)
+		case (name == "." || name == "..") && r == '.':
+			escape(s)
+		case escaping == EscapePercent && r == '%':
+			escape(s)
+		default:
+			b.WriteString(s)
+		}
+
+		i += size
+	}
+
+	escaped := b.String()
+
+	stem, _, _ := strings.Cut(escaped, ".")
+	if windows && windowsReserved[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		if escaping == EscapePercent {
+			var first strings.Builder
+			fmt.Fprintf(&first, "%%%02X", escaped[0])
+			return first.String() + escaped[1:]
+		}
+		return "_" + escaped
+	}
+
+	return escaped
+}
+
+// mapper returns a DestMapper that escapes each part of the relative paths mapper returns, or of the keys if it is
+// nil, and the names of the absolute paths it returns. The unescaped paths are recorded in original.
+func (options SanitizeOptions) mapper(mapper DestMapper, original map[string]string) DestMapper {
+	windows := options.Windows || runtime.GOOS == "windows"
+
+	return func(key string) (string, bool) {
+		localPath, skip := filepath.FromSlash(key), false
+		if mapper != nil {
+			localPath, skip = mapper(key)
+		}
+
+		if skip {
+			return "", true
+		}
+
+		original[key] = localPath
+
+		dir, parts := "", strings.Split(localPath, string(filepath.Separator))
+		if filepath.IsAbs(localPath) {
+			dir, parts = filepath.Dir(localPath), []string{filepath.Base(localPath)}
+		}
+
+		for i, part := range parts {
+			parts[i] = escapeName(part, options.Escaping, windows)
+		}
+
+		return filepath.Join(dir, filepath.Join(parts...)), false
+	}
+}
+
+// resolveCollisions applies the collision policy to downloads whose destinations are the files written, comparing
+// paths without case if fold is set. It returns the downloads to make and the ones skipped.
+func (options SanitizeOptions) resolveCollisions(downloads []FileDownload, fold bool) ([]FileDownload, []FileDownload, error) {
+	normalize := func(path string) string {
+		if fold {
+			return strings.ToLower(path)
+		}
+		return path
+	}
+
+	used := make(map[string]string, len(downloads))
+	for _, download := range downloads {
+		if _, ok := used[normalize(download.Destination)]; !ok {
+			used[normalize(download.Destination)] = download.Key
+		}
+	}
+
+	kept := make([]FileDownload, 0, len(downloads))
+	skipped := make([]FileDownload, 0)
+
+	for _, download := range downloads {
+		first := used[normalize(download.Destination)]
+		if first == download.Key {
+			kept = append(kept, download)
+			continue
+		}
+
+		switch options.Collisions {
+		case CollisionRename:
+			ext := filepath.Ext(download.Destination)
+			base := strings.TrimSuffix(download.Destination, ext)
+			for n := 1; ; n++ {
+				candidate := fmt.Sprintf("%v~%v%v", base, n, ext)
+				if _, ok := used[normalize(candidate)]; !ok {
+					used[normalize(candidate)] = download.Key
+					download.Destination = candidate
+					break
+				}
+			}
+
+			kept = append(kept, download)
+		case CollisionSkip:
+			skipped = append(skipped, download)
+		default:
+			return nil, nil, fmt.Errorf("%w: %v and %v would both be written to %v", ErrKeyCollision, first, download.Key, download.Destination)
+		}
+	}
+
+	return kept, skipped, nil
+}
+
+// caseInsensitive reports whether the file system holding dir ignores the case of names, by creating a file and
+// looking for it under another case.
+func caseInsensitive(dir string) bool {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return false
+	}
+
+	f, err := os.CreateTemp(dir, ".case-probe-*")
+	if err != nil {
+		return false
+	}
+
+	f.Close()
+	defer os.Remove(f.Name())
+
+	_, err = os.Stat(filepath.Join(dir, strings.ToUpper(filepath.Base(f.Name()))))
+	return err == nil
+}
+
+// sanitizedDownloads returns a download for each object the mapper doesn't skip, to its escaped path under the
+// destination with collisions settled, recording the keys skipped and written elsewhere in the report.
+func sanitizedDownloads(objects []types.Object, dest string, mapper DestMapper, options SanitizeOptions, report *TransferReport) ([]FileDownload, error) {
+	original := make(map[string]string, len(objects))
+	downloads := mappedDownloads(objects, dest, options.mapper(mapper, original))
+
+	downloads, skipped, err := options.resolveCollisions(downloads, caseInsensitive(dest))
+	if err != nil {
+		return nil, err
+	}
+
+	report.Sanitized = make(map[string]string)
+	for _, download := range downloads {
+		if localPath := original[download.Key]; download.Destination != localPath && download.Destination != filepath.Join(dest, localPath) {
+			report.Sanitized[download.Key] = download.Destination
+		}
+	}
+
+	for _, download := range skipped {
+		log.Printf("Skipping %v, which would be written to %v like another key", download.Key, download.Destination)
+		report.record(TransferResult{Key: download.Key, Path: download.Destination, Size: download.Size, SkipReason: "collision"})
+	}
+
+	return downloads, nil
+}
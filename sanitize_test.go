@@ -0,0 +1,176 @@
+package boto3manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEscapeName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		escaping NameEscaping
+		windows  bool
+		wanted   string
+	}{
+		{name: "report.csv", windows: true, wanted: "report.csv"},
+		{name: "12:00:00.log", windows: true, wanted: "12_00_00.log"},
+		{name: "12:00:00.log", wanted: "12:00:00.log"},
+		{name: "12:00:00.log", escaping: EscapePercent, windows: true, wanted: "12%3A00%3A00.log"},
+		{name: `a<b>c"d|e?f*g\h`, windows: true, wanted: "a_b_c_d_e_f_g_h"},
+		{name: "tab\there", windows: true, wanted: "tab_here"},
+		{name: "trailing. ", windows: true, wanted: "trailing__"},
+		{name: "trailing.", escaping: EscapePercent, windows: true, wanted: "trailing%2E"},
+		{name: "CON", windows: true, wanted: "_CON"},
+		{name: "nul.txt", windows: true, wanted: "_nul.txt"},
+		{name: "Com1.tar.gz", escaping: EscapePercent, windows: true, wanted: "%43om1.tar.gz"},
+		{name: "console.txt", windows: true, wanted: "console.txt"},
+		{name: "CON", wanted: "CON"},
+		{name: "..", wanted: "__"},
+		{name: "..", escaping: EscapePercent, wanted: "%2E%2E"},
+		{name: "100%.txt", escaping: EscapePercent, wanted: "100%25.txt"},
+		{name: "100%.txt", wanted: "100%.txt"},
+		{name: "naïve:é", escaping: EscapePercent, windows: true, wanted: "naïve%3Aé"},
+	}
+
+	for _, test := range tests {
+		if got := escapeName(test.name, test.escaping, test.windows); got != test.wanted {
+			t.Errorf("escapeName(%q, %v, %v) = %q, want %q", test.name, test.escaping, test.windows, got, test.wanted)
+		}
+	}
+}
+
+func TestResolveCollisions(t *testing.T) {
+	t.Parallel()
+
+	downloads := []FileDownload{
+		{Key: "data/A.csv", Destination: "out/data/A.csv"},
+		{Key: "data/a.csv", Destination: "out/data/a.csv"},
+		{Key: "data/a~1.csv", Destination: "out/data/a~1.csv"},
+		{Key: "data/b.csv", Destination: "out/data/b.csv"},
+	}
+
+	tests := []struct {
+		policy        CollisionPolicy
+		fold          bool
+		wanted        []FileDownload
+		wantedSkipped []FileDownload
+		wantedErr     error
+	}{
+		{
+			policy:        CollisionError,
+			wanted:        downloads,
+			wantedSkipped: []FileDownload{},
+		},
+		{
+			policy:    CollisionError,
+			fold:      true,
+			wantedErr: ErrKeyCollision,
+		},
+		{
+			policy: CollisionRename,
+			fold:   true,
+			wanted: []FileDownload{
+				{Key: "data/A.csv", Destination: "out/data/A.csv"},
+				{Key: "data/a.csv", Destination: "out/data/a~2.csv"},
+				{Key: "data/a~1.csv", Destination: "out/data/a~1.csv"},
+				{Key: "data/b.csv", Destination: "out/data/b.csv"},
+			},
+			wantedSkipped: []FileDownload{},
+		},
+		{
+			policy:        CollisionSkip,
+			fold:          true,
+			wanted:        []FileDownload{downloads[0], downloads[2], downloads[3]},
+			wantedSkipped: []FileDownload{downloads[1]},
+		},
+	}
+
+	for _, test := range tests {
+		input := append([]FileDownload(nil), downloads...)
+		got, skipped, err := SanitizeOptions{Collisions: test.policy}.resolveCollisions(input, test.fold)
+		if !errors.Is(err, test.wantedErr) || !reflect.DeepEqual(got, test.wanted) || !reflect.DeepEqual(skipped, test.wantedSkipped) {
+			t.Errorf("resolveCollisions(%v, %v) = %v, %v, %v, want %v, %v, %v", test.policy, test.fold, got, skipped, err, test.wanted, test.wantedSkipped, test.wantedErr)
+		}
+	}
+}
+
+func TestDownloadObjectsSanitize(t *testing.T) {
+	t.Parallel()
+
+	// With Windows names, a:b.txt is written as a_b.txt, where a_b.txt itself would go
+	tests := []struct {
+		policy    CollisionPolicy
+		files     map[string]string
+		sanitized map[string]string
+		skipped   []string
+	}{
+		{
+			policy:    CollisionRename,
+			files:     map[string]string{"a_b.txt": "colon", "a_b~1.txt": "underscore", "q_.txt": "question"},
+			sanitized: map[string]string{"data/a:b.txt": "a_b.txt", "data/a_b.txt": "a_b~1.txt", "data/q?.txt": "q_.txt"},
+			skipped:   []string{},
+		},
+		{
+			policy:    CollisionSkip,
+			files:     map[string]string{"a_b.txt": "colon", "q_.txt": "question"},
+			sanitized: map[string]string{"data/a:b.txt": "a_b.txt", "data/q?.txt": "q_.txt"},
+			skipped:   []string{"data/a_b.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.policy), func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeS3(t, "bucket")
+			fake.put("bucket", "data/a:b.txt", "colon", nil)
+			fake.put("bucket", "data/a_b.txt", "underscore", nil)
+			fake.put("bucket", "data/q?.txt", "question", nil)
+
+			dest := t.TempDir()
+			options := DownloadObjectsOptions{Sanitize: &SanitizeOptions{Windows: true, Collisions: tt.policy}, Quiet: true}
+			report, err := fake.basics().DownloadObjects("data/*", dest, "bucket", options)
+			if err != nil {
+				t.Fatalf("DownloadObjects() = %v, want nil", err)
+			}
+
+			dir := filepath.Join(dest, "data")
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := make(map[string]string)
+			for _, entry := range entries {
+				body, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+				files[entry.Name()] = string(body)
+			}
+			if !reflect.DeepEqual(files, tt.files) {
+				t.Errorf("DownloadObjects() wrote %v, want %v", files, tt.files)
+			}
+
+			sanitized := make(map[string]string)
+			for key, path := range report.Sanitized {
+				sanitized[key] = filepath.Base(path)
+			}
+			if !reflect.DeepEqual(sanitized, tt.sanitized) {
+				t.Errorf("DownloadObjects() reported %v sanitized, want %v", sanitized, tt.sanitized)
+			}
+
+			skipped := make([]string, 0)
+			for _, result := range report.Results {
+				if result.SkipReason == "collision" {
+					skipped = append(skipped, result.Key)
+				}
+			}
+			if !reflect.DeepEqual(skipped, tt.skipped) {
+				t.Errorf("DownloadObjects() skipped %v as collisions, want %v", skipped, tt.skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package boto3manager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a scheduled job runs next.
+type Schedule interface {
+	// Next returns the first time after the given time that the job should run
+	Next(after time.Time) time.Time
+}
+
+// intervalSchedule runs a job at a fixed interval.
+type intervalSchedule time.Duration
+
+func (interval intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(interval))
+}
+
+// Every returns a schedule that runs a job every interval, measured from when the previous run was scheduled.
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule(interval)
+}
+
+// cronSchedule is a parsed five field cron expression. Each field holds the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+
+	// domAny and dowAny record whether the day fields were "*". If both are restricted, a day matching either runs
+	domAny, dowAny bool
+}
+
+// cronFields are the bounds of each field of a cron expression.
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// cronShorthands are the named schedules ParseCron accepts in place of five fields.
+var cronShorthands = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+// ParseCron parses a standard five field cron expression, "minute hour day-of-month month day-of-week", in local
+// time. Fields may be "*", numbers, ranges like 1-5, lists like 1,15, and steps like */15 or 0-30/10. The shorthands
+// @hourly, @daily, @weekly, @monthly, and @yearly are accepted too.
+func ParseCron(expr string) (Schedule, error) {
+	if shorthand, ok := cronShorthands[strings.TrimSpace(expr)]; ok {
+		expr = shorthand
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have %v fields", expr, len(cronFields))
+	}
+
+	values := make([]map[int]bool, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("%v field of %q: %w", cronFields[i].name, expr, err)
+		}
+		values[i] = set
+	}
+
+	// Sunday may be written as 7
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	return &cronSchedule{
+		minute: values[0],
+		hour:   values[1],
+		dom:    values[2],
+		month:  values[3],
+		dow:    values[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField returns the set of values a field matches.
+func parseCronField(field string, min int, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		start, end := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			start, err = strconv.Atoi(from)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", from)
+			}
+
+			end = start
+			if isRange {
+				end, err = strconv.Atoi(to)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				end = max
+			}
+		}
+
+		// Allow 7 as Sunday in the day of week field
+		limit := max
+		if min == 0 && max == 6 {
+			limit = 7
+		}
+
+		if start < min || end > limit || start > end {
+			return nil, fmt.Errorf("%q is outside %v-%v", rangePart, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			set[value] = true
+		}
+	}
+
+	return set, nil
+}
+
+// dayMatches reports whether the schedule runs on t's day.
+func (schedule *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := schedule.dom[t.Day()]
+	dowMatch := schedule.dow[int(t.Weekday())]
+
+	if !schedule.domAny && !schedule.dowAny {
+		return domMatch || dowMatch
+	}
+
+	return domMatch && dowMatch
+}
+
+func (schedule *cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	loc := t.Location()
+
+	// Skip whole months, days, and hours that don't match. Give up after five years, e.g. for February 30th
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !schedule.month[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !schedule.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !schedule.hour[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if !schedule.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,100 @@
+package boto3manager
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCronNext(t *testing.T) {
+	t.Parallel()
+
+	// A Wednesday
+	after := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr   string
+		wanted time.Time
+	}{
+		{
+			expr:   "* * * * *",
+			wanted: time.Date(2024, 5, 15, 10, 8, 0, 0, time.UTC),
+		},
+		{
+			expr:   "*/15 * * * *",
+			wanted: time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			expr:   "30 2 * * *",
+			wanted: time.Date(2024, 5, 16, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			expr:   "0 0 * * 0",
+			wanted: time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:   "0 0 * * 7",
+			wanted: time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:   "0 9 1-5 6 *",
+			wanted: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:   "0 0 1 * 5",
+			wanted: time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:   "@monthly",
+			wanted: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:   "0 0 30 2 *",
+			wanted: time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) = %v", tt.expr, err)
+			}
+
+			if got := schedule.Next(after); !got.Equal(tt.wanted) {
+				t.Errorf("ParseCron(%q).Next(%v) = %v, want %v", tt.expr, after, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) = nil error, want an error", expr)
+		}
+	}
+}
+
+func TestAcquireLockFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "job.lock")
+
+	unlock, err := acquireLockFile(path)
+	if err != nil {
+		t.Fatalf("acquireLockFile() = %v", err)
+	}
+
+	if _, err := acquireLockFile(path); err == nil {
+		t.Errorf("acquireLockFile() while locked = nil error, want an error")
+	}
+
+	unlock()
+
+	if _, err := acquireLockFile(path); err != nil {
+		t.Errorf("acquireLockFile() after unlock = %v, want nil", err)
+	}
+}
@@ -0,0 +1,211 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Job is a transfer run by a Scheduler, such as a closure calling UploadObjectsWithContext.
+type Job func(ctx context.Context) (*TransferReport, error)
+
+// JobOptions tune how a scheduled job runs.
+type JobOptions struct {
+	// LockFile, if set, is created while the job runs and a run is skipped if it already exists, so runs are not
+	// overlapped by other processes either. A lock left behind by a crash must be removed by hand
+	LockFile string
+}
+
+// JobStatus is the state of a scheduled job.
+type JobStatus struct {
+	Name    string
+	Running bool
+
+	// Runs counts the runs started, and Skipped the runs skipped because the previous one was still going
+	Runs    int
+	Skipped int
+
+	LastStart  time.Time
+	LastEnd    time.Time
+	LastErr    error
+	LastReport *TransferReport
+
+	Next time.Time
+}
+
+// scheduledJob is a job added to a Scheduler.
+type scheduledJob struct {
+	name     string
+	schedule Schedule
+	job      Job
+	options  JobOptions
+
+	mu     sync.Mutex
+	status JobStatus
+}
+
+// Scheduler runs jobs on schedules, such as nightly archiving in a small daemon. A run is skipped if the previous
+// run of the same job is still in progress.
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs []*scheduledJob
+}
+
+// NewScheduler returns a Scheduler with no jobs.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add adds a job that runs on a schedule, such as Every(time.Hour) or one from ParseCron. Jobs must be added before
+// Run is called.
+func (scheduler *Scheduler) Add(name string, schedule Schedule, job Job, options JobOptions) {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	scheduler.jobs = append(scheduler.jobs, &scheduledJob{
+		name:     name,
+		schedule: schedule,
+		job:      job,
+		options:  options,
+		status:   JobStatus{Name: name},
+	})
+}
+
+// Status returns the state of every job, in the order they were added.
+func (scheduler *Scheduler) Status() []JobStatus {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(scheduler.jobs))
+	for _, job := range scheduler.jobs {
+		job.mu.Lock()
+		statuses = append(statuses, job.status)
+		job.mu.Unlock()
+	}
+
+	return statuses
+}
+
+// Run runs every job on its schedule until the context is cancelled, then waits for runs in progress to finish and
+// returns the context's error.
+func (scheduler *Scheduler) Run(ctx context.Context) error {
+	scheduler.mu.Lock()
+	jobs := scheduler.jobs
+	scheduler.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job.loop(ctx, &wg)
+		}()
+	}
+
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// loop waits for each scheduled time and starts a run, unless the previous one is still going.
+func (job *scheduledJob) loop(ctx context.Context, wg *sync.WaitGroup) {
+	next := job.schedule.Next(time.Now())
+
+	for !next.IsZero() {
+		job.mu.Lock()
+		job.status.Next = next
+		job.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		job.mu.Lock()
+		running := job.status.Running
+		if running {
+			job.status.Skipped++
+		} else {
+			job.status.Running = true
+		}
+		job.mu.Unlock()
+
+		if running {
+			log.Printf("Skipping run of job %v: the previous run is still in progress", job.name)
+		} else {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				job.run(ctx)
+			}()
+		}
+
+		next = job.schedule.Next(next)
+	}
+}
+
+// run runs the job once, holding its lock file if it has one.
+func (job *scheduledJob) run(ctx context.Context) {
+	defer func() {
+		job.mu.Lock()
+		job.status.Running = false
+		job.mu.Unlock()
+	}()
+
+	if job.options.LockFile != "" {
+		unlock, err := acquireLockFile(job.options.LockFile)
+
+		if err != nil {
+			log.Printf("Skipping run of job %v: %v", job.name, err)
+			job.mu.Lock()
+			job.status.Skipped++
+			job.mu.Unlock()
+			return
+		}
+
+		defer unlock()
+	}
+
+	job.mu.Lock()
+	job.status.Runs++
+	job.status.LastStart = time.Now()
+	job.mu.Unlock()
+
+	report, err := job.job(ctx)
+
+	if err != nil {
+		log.Printf("Job %v failed: %v", job.name, err)
+	}
+
+	job.mu.Lock()
+	job.status.LastEnd = time.Now()
+	job.status.LastErr = err
+	job.status.LastReport = report
+	job.mu.Unlock()
+}
+
+// acquireLockFile creates a lock file holding the process ID, failing if it already exists. The returned function
+// removes it.
+func acquireLockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("lock file %v exists; another run is in progress", path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(f, "%d\n", os.Getpid())
+	f.Close()
+
+	return func() { os.Remove(path) }, nil
+}
@@ -0,0 +1,160 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fixedSchedule fires at each of its times, in order, and then stops.
+type fixedSchedule []time.Time
+
+func (schedule fixedSchedule) Next(after time.Time) time.Time {
+	for _, next := range schedule {
+		if next.After(after) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// ticks returns a schedule firing n times, interval apart, starting interval from now.
+func ticks(n int, interval time.Duration) fixedSchedule {
+	start := time.Now()
+	schedule := make(fixedSchedule, 0, n)
+	for i := 1; i <= n; i++ {
+		schedule = append(schedule, start.Add(time.Duration(i)*interval))
+	}
+	return schedule
+}
+
+// waitFor polls until cond holds, failing the test if it doesn't within a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerSkipsOverlappingRuns(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	report := &TransferReport{}
+
+	scheduler := NewScheduler()
+	scheduler.Add("slow", ticks(3, 10*time.Millisecond), func(ctx context.Context) (*TransferReport, error) {
+		started <- struct{}{}
+		<-release
+		return report, nil
+	}, JobOptions{})
+
+	done := make(chan error)
+	go func() { done <- scheduler.Run(context.Background()) }()
+
+	// The first run holds on while the other two times pass
+	<-started
+	waitFor(t, "the overlapping runs to be skipped", func() bool {
+		return scheduler.Status()[0].Skipped == 2
+	})
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run() = %v, want nil once the schedule ends", err)
+	}
+
+	status := scheduler.Status()[0]
+	if status.Runs != 1 || status.Skipped != 2 || status.Running || status.LastReport != report || status.LastErr != nil {
+		t.Errorf("Status() = %+v, want 1 run, 2 skipped, and the run's report", status)
+	}
+	if len(started) != 0 {
+		t.Errorf("Run() started %v more runs while the first was going, want 0", len(started))
+	}
+}
+
+func TestSchedulerLockFile(t *testing.T) {
+	t.Parallel()
+
+	lockFile := filepath.Join(t.TempDir(), "job.lock")
+	failed := errors.New("job failed")
+
+	// The lock is held while the job runs and removed after
+	held := false
+	scheduler := NewScheduler()
+	scheduler.Add("locked", ticks(1, time.Millisecond), func(ctx context.Context) (*TransferReport, error) {
+		_, err := os.Stat(lockFile)
+		held = err == nil
+		return nil, failed
+	}, JobOptions{LockFile: lockFile})
+
+	if err := scheduler.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+
+	status := scheduler.Status()[0]
+	if !held || status.Runs != 1 || !errors.Is(status.LastErr, failed) {
+		t.Errorf("Run() held the lock %v, status %+v, want the lock held and 1 failed run", held, status)
+	}
+	if _, err := os.Stat(lockFile); !os.IsNotExist(err) {
+		t.Errorf("Run() left the lock file behind: %v", err)
+	}
+
+	// A lock held by another process skips the run
+	if err := os.WriteFile(lockFile, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ran := false
+	scheduler = NewScheduler()
+	scheduler.Add("locked", ticks(1, time.Millisecond), func(ctx context.Context) (*TransferReport, error) {
+		ran = true
+		return nil, nil
+	}, JobOptions{LockFile: lockFile})
+
+	if err := scheduler.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+
+	if status := scheduler.Status()[0]; ran || status.Runs != 0 || status.Skipped != 1 {
+		t.Errorf("Run() while locked ran the job %v, status %+v, want it skipped", ran, status)
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	scheduler := NewScheduler()
+	scheduler.Add("long", ticks(1, time.Millisecond), func(ctx context.Context) (*TransferReport, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, JobOptions{})
+	scheduler.Add("later", Every(time.Hour), func(ctx context.Context) (*TransferReport, error) {
+		return nil, nil
+	}, JobOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	// Run waits for the run in progress to see the cancellation and finish
+	<-started
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want %v", err, context.Canceled)
+	}
+	if status := scheduler.Status()[0]; status.Running || !errors.Is(status.LastErr, context.Canceled) {
+		t.Errorf("Status() after Run = %+v, want the run finished with %v", status, context.Canceled)
+	}
+}
@@ -0,0 +1,108 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// defaultSecretRefresh is how often credentials from a secret directory are reread when no interval is given.
+// Kubernetes takes up to a minute or so to update a mounted secret after it changes.
+const defaultSecretRefresh = time.Minute
+
+// secretDir holds the values read from the files of a mounted secret.
+type secretDir struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Endpoint        string
+	Region          string
+}
+
+// secretFileName normalizes a file name so the spellings secrets commonly use, such as AWS_ACCESS_KEY_ID,
+// access-key-id, and accessKeyId, compare equal.
+func secretFileName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("_", "", "-", "", ".", "").Replace(name)
+	return strings.TrimPrefix(name, "aws")
+}
+
+// readSecretDir reads the values in a secret directory, one per file named for the value. Files it doesn't
+// recognize are ignored, as are the hidden ..data entries Kubernetes uses to swap in a new version atomically.
+func readSecretDir(dir string) (secretDir, error) {
+	var secret secretDir
+	fields := map[string]*string{
+		"accesskeyid":     &secret.AccessKeyID,
+		"accesskey":       &secret.AccessKeyID,
+		"secretaccesskey": &secret.SecretAccessKey,
+		"secretkey":       &secret.SecretAccessKey,
+		"sessiontoken":    &secret.SessionToken,
+		"endpoint":        &secret.Endpoint,
+		"endpointurl":     &secret.Endpoint,
+		"s3endpoint":      &secret.Endpoint,
+		"region":          &secret.Region,
+		"defaultregion":   &secret.Region,
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return secret, fmt.Errorf("couldn't read secret directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		field, ok := fields[secretFileName(entry.Name())]
+		if !ok || strings.HasPrefix(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return secret, fmt.Errorf("couldn't read secret %v: %w", entry.Name(), err)
+		}
+		*field = strings.TrimSpace(string(data))
+	}
+
+	return secret, nil
+}
+
+// SecretDirProvider provides credentials from the files of a mounted secret, such as a Kubernetes secret volume,
+// with the access key ID, secret access key, and optionally a session token each in a file named for it, such as
+// AWS_ACCESS_KEY_ID or access_key_id. Wrapped in an aws.CredentialsCache, the files are reread every
+// RefreshInterval, so rotated keys are picked up without restarting.
+type SecretDirProvider struct {
+	// Dir is the directory the secret is mounted at
+	Dir string
+
+	// RefreshInterval is how long credentials are used before the files are reread. If zero, a minute
+	RefreshInterval time.Duration
+}
+
+// Retrieve reads the credentials in the secret directory.
+func (provider SecretDirProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	secret, err := readSecretDir(provider.Dir)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	if secret.AccessKeyID == "" || secret.SecretAccessKey == "" {
+		return aws.Credentials{}, fmt.Errorf("secret directory %v has no access key ID and secret access key", provider.Dir)
+	}
+
+	refresh := provider.RefreshInterval
+	if refresh <= 0 {
+		refresh = defaultSecretRefresh
+	}
+
+	return aws.Credentials{
+		AccessKeyID:     secret.AccessKeyID,
+		SecretAccessKey: secret.SecretAccessKey,
+		SessionToken:    secret.SessionToken,
+		Source:          "SecretDirProvider",
+		CanExpire:       true,
+		Expires:         time.Now().Add(refresh),
+	}, nil
+}
@@ -0,0 +1,155 @@
+package boto3manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretFileName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		wanted string
+	}{
+		{name: "AWS_ACCESS_KEY_ID", wanted: "accesskeyid"},
+		{name: "access-key-id", wanted: "accesskeyid"},
+		{name: "accessKeyId", wanted: "accesskeyid"},
+		{name: "AWS_SECRET_ACCESS_KEY", wanted: "secretaccesskey"},
+		{name: "endpoint_url", wanted: "endpointurl"},
+	}
+
+	for _, tt := range tests {
+		if got := secretFileName(tt.name); got != tt.wanted {
+			t.Errorf("secretFileName(%q) = %q, want %q", tt.name, got, tt.wanted)
+		}
+	}
+}
+
+func writeSecret(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, value := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadSecretDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeSecret(t, dir, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE\n",
+		"secret-key":            "wJalrXUtnFEMI\n",
+		"endpoint":              "https://s3-west.nrp-nautilus.io\n",
+		"..data":                "ignored",
+		"unrelated":             "ignored",
+		"AWS_DEFAULT_REGION":    "us-west-2",
+		"AWS_SESSION_TOKEN.bak": "ignored",
+	})
+
+	got, err := readSecretDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := secretDir{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI",
+		Endpoint:        "https://s3-west.nrp-nautilus.io",
+		Region:          "us-west-2",
+	}
+	if got != wanted {
+		t.Errorf("readSecretDir() = %+v, want %+v", got, wanted)
+	}
+}
+
+func TestSecretDirProviderRotation(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	provider := SecretDirProvider{Dir: dir}
+
+	if _, err := provider.Retrieve(context.TODO()); err == nil {
+		t.Errorf("Retrieve() of an empty secret = nil error, want an error")
+	}
+
+	writeSecret(t, dir, map[string]string{"access_key_id": "OLD", "secret_access_key": "old-secret"})
+	creds, err := provider.Retrieve(context.TODO())
+	if err != nil || creds.AccessKeyID != "OLD" || !creds.CanExpire {
+		t.Fatalf("Retrieve() = %+v, %v, want OLD expiring credentials", creds, err)
+	}
+
+	writeSecret(t, dir, map[string]string{"access_key_id": "NEW", "secret_access_key": "new-secret"})
+	creds, err = provider.Retrieve(context.TODO())
+	if err != nil || creds.AccessKeyID != "NEW" || creds.SecretAccessKey != "new-secret" {
+		t.Errorf("Retrieve() after rotation = %+v, %v, want NEW", creds, err)
+	}
+}
+
+func TestNewS3ClientSecretDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeSecret(t, dir, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY": "wJalrXUtnFEMI",
+		"endpoint":              "https://s3-west.nrp-nautilus.io",
+	})
+
+	client, err := newS3Client(context.TODO(), ClientOptions{SecretDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := client.Options().BaseEndpoint; got == nil || *got != "https://s3-west.nrp-nautilus.io" {
+		t.Errorf("BaseEndpoint = %v, want https://s3-west.nrp-nautilus.io", got)
+	}
+
+	creds, err := client.Options().Credentials.Retrieve(context.TODO())
+	if err != nil || creds.AccessKeyID != "AKIDEXAMPLE" {
+		t.Errorf("Retrieve() = %+v, %v, want AKIDEXAMPLE", creds, err)
+	}
+}
+
+func TestNewS3ClientDefaultEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY": "wJalrXUtnFEMI",
+		"endpoint":              "https://s3-west.nrp-nautilus.io",
+	})
+
+	tests := []struct {
+		name      string
+		secretDir string
+		env       string
+		options   ClientOptions
+		wanted    string
+	}{
+		{name: "default", options: ClientOptions{DefaultEndpoint: "https://s3-tide.nrp-nautilus.io"}, wanted: "https://s3-tide.nrp-nautilus.io"},
+		{name: "environment", env: "https://s3-east.nrp-nautilus.io", options: ClientOptions{DefaultEndpoint: "https://s3-tide.nrp-nautilus.io"}, wanted: "https://s3-east.nrp-nautilus.io"},
+		{name: "secret", secretDir: dir, options: ClientOptions{DefaultEndpoint: "https://s3-tide.nrp-nautilus.io"}, wanted: "https://s3-west.nrp-nautilus.io"},
+		{name: "explicit", env: "https://s3-east.nrp-nautilus.io", options: ClientOptions{Endpoint: "https://s3.example.com", DefaultEndpoint: "https://s3-tide.nrp-nautilus.io"}, wanted: "https://s3.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvSecretDir, tt.secretDir)
+			t.Setenv(EnvEndpoint, tt.env)
+
+			client, err := newS3Client(context.TODO(), tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := client.Options().BaseEndpoint; got == nil || *got != tt.wanted {
+				t.Errorf("BaseEndpoint = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
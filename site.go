@@ -0,0 +1,256 @@
+package boto3manager
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"log"
+	"mime"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// hashedAsset matches file names that may contain a content hash, such as app.3f9a2c1b.js or index-BkQ8f0aZ.css.
+var hashedAsset = regexp.MustCompile(`[.-]([0-9a-zA-Z_]{8,})\.[a-zA-Z0-9]+$`)
+
+// compressibleTypes are content type prefixes worth compressing with gzip.
+var compressibleTypes = []string{"text/", "application/javascript", "application/json", "application/xml", "image/svg+xml"}
+
+type DeploySiteOptions struct {
+	// Prefix is the key prefix the site is deployed under. It must be empty or end in "/"
+	Prefix string
+
+	// HashedCacheControl is the Cache-Control for files with a content hash in their name.
+	// Defaults to "public, max-age=31536000, immutable"
+	HashedCacheControl string
+
+	// HTMLCacheControl is the Cache-Control for HTML files. Defaults to "public, max-age=60, must-revalidate"
+	HTMLCacheControl string
+
+	// DefaultCacheControl is the Cache-Control for everything else. Defaults to "public, max-age=3600"
+	DefaultCacheControl string
+
+	// Gzip compresses text files with gzip before uploading them and sets Content-Encoding
+	Gzip bool
+
+	// Precompressed uploads the contents of a file's .gz or .br sibling in its place with Content-Encoding set.
+	// If both exist, gzip is used since every client supports it
+	Precompressed bool
+
+	// Delete removes objects under the prefix that no longer exist in the site
+	Delete bool
+
+	// RetryPolicy controls retries of each file. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+}
+
+// siteFile is a file of a site to upload.
+type siteFile struct {
+	Path     string
+	Key      string
+	Size     int64
+	Encoding string
+}
+
+func (file siteFile) result() TransferResult {
+	return TransferResult{Key: file.Key, Path: file.Path, Size: file.Size}
+}
+
+// DeploySite takes a directory containing a built site, a bucket name, and options and uploads the site with content
+// types and Cache-Control headers suited to a website bucket: long-lived caching for hashed assets and short caching
+// for HTML. With Delete set, objects for files that were removed from the site are deleted. The directory is
+// expanded as an upload pattern is in UploadObjects.
+func (basics BucketBasics) DeploySite(localDir string, bucketName string, options DeploySiteOptions) (*TransferReport, error) {
+	ctx := context.TODO()
+
+	dir, err := expandLocalPath(localDir)
+	if err != nil {
+		log.Printf("Couldn't expand site directory %v: %v", localDir, err)
+		return nil, err
+	}
+	fsys := localDirFS(dir)
+
+	// Without a trailing "/", Delete would take in the objects under sibling prefixes
+	if err := checkPrefix(options.Prefix); err != nil {
+		return nil, err
+	}
+
+	// Find every file in the site
+	files := make([]siteFile, 0)
+	paths := make(map[string]bool)
+
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		paths[p] = true
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Couldn't read site directory %v: %v", localDir, err)
+		return nil, err
+	}
+
+	var totalSize int64
+	for p := range paths {
+		// Precompressed variants are uploaded in place of the file they belong to
+		if options.Precompressed && (strings.HasSuffix(p, ".gz") || strings.HasSuffix(p, ".br")) && paths[p[:len(p)-3]] {
+			continue
+		}
+
+		file := siteFile{Path: p, Key: options.Prefix + p}
+		if options.Precompressed {
+			if paths[p+".gz"] {
+				file.Path, file.Encoding = p+".gz", "gzip"
+			} else if paths[p+".br"] {
+				file.Path, file.Encoding = p+".br", "br"
+			}
+		}
+
+		if fileInfo, err := fs.Stat(fsys, file.Path); err == nil {
+			file.Size = fileInfo.Size()
+			totalSize += file.Size
+		}
+
+		files = append(files, file)
+	}
+
+	// Make a progress bar
+	bar := newBytesBar(totalSize, "deploying")
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  25,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		backpressure: basics.Backpressure,
+	}
+
+	err = runBatch(ctx, files, config, func(ctx context.Context, file siteFile) error {
+		err := basics.uploadSiteFile(ctx, fsys, file, bucketName, options)
+		if err == nil {
+			bar.Add64(file.Size)
+		}
+		return err
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	// Delete objects for files that are no longer part of the site
+	if options.Delete && len(report.Failed()) == 0 {
+		deployed := make(map[string]bool, len(files))
+		for _, file := range files {
+			deployed[file.Key] = true
+		}
+
+		stale := make([]string, 0)
+		for object, err := range basics.listObjectsSeq(ctx, bucketName, options.Prefix) {
+			if err != nil {
+				return report, err
+			}
+
+			if !deployed[aws.ToString(object.Key)] {
+				stale = append(stale, aws.ToString(object.Key))
+			}
+		}
+
+		if err := basics.deleteKeys(ctx, bucketName, stale); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// uploadSiteFile uploads a single file of a site with the headers for its type.
+func (basics BucketBasics) uploadSiteFile(ctx context.Context, fsys fs.FS, file siteFile, bucketName string, options DeploySiteOptions) error {
+	body, err := fs.ReadFile(fsys, file.Path)
+
+	if err != nil {
+		log.Printf("Couldn't read file %v: %v", file.Path, err)
+		return err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(file.Key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Compress text files if asked to and they aren't compressed already
+	encoding := file.Encoding
+	if options.Gzip && encoding == "" && compressible(contentType) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write(body)
+		w.Close()
+
+		body, encoding = buf.Bytes(), "gzip"
+	}
+
+	params := &s3.PutObjectInput{
+		Bucket:       aws.String(bucketName),
+		Key:          aws.String(file.Key),
+		Body:         bytes.NewReader(body),
+		ContentType:  aws.String(contentType),
+		CacheControl: aws.String(siteCacheControl(file.Key, contentType, options)),
+	}
+
+	if encoding != "" {
+		params.ContentEncoding = aws.String(encoding)
+	}
+
+	_, err = basics.client(bucketName).PutObject(ctx, params)
+
+	if err != nil {
+		log.Printf("Couldn't upload object %v to bucket %v: %v", file.Key, bucketName, err)
+	}
+
+	return err
+}
+
+// siteCacheControl returns the Cache-Control header for a file of a site.
+func siteCacheControl(key string, contentType string, options DeploySiteOptions) string {
+	switch {
+	case strings.HasPrefix(contentType, "text/html"):
+		if options.HTMLCacheControl != "" {
+			return options.HTMLCacheControl
+		}
+		return "public, max-age=60, must-revalidate"
+	case isHashedAsset(path.Base(key)):
+		if options.HashedCacheControl != "" {
+			return options.HashedCacheControl
+		}
+		return "public, max-age=31536000, immutable"
+	default:
+		if options.DefaultCacheControl != "" {
+			return options.DefaultCacheControl
+		}
+		return "public, max-age=3600"
+	}
+}
+
+// isHashedAsset reports whether a file name contains a content hash. Hashes contain digits, which rules out names
+// like my-component.css.
+func isHashedAsset(name string) bool {
+	match := hashedAsset.FindStringSubmatch(name)
+	return match != nil && strings.ContainsAny(match[1], "0123456789")
+}
+
+// compressible reports whether a content type benefits from gzip.
+func compressible(contentType string) bool {
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package boto3manager
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSiteCacheControl(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		key         string
+		contentType string
+		wanted      string
+	}{
+		{
+			name:        "html",
+			key:         "docs/index.html",
+			contentType: "text/html; charset=utf-8",
+			wanted:      "public, max-age=60, must-revalidate",
+		},
+		{
+			name:        "hashed asset",
+			key:         "assets/app.3f9a2c1b.js",
+			contentType: "text/javascript; charset=utf-8",
+			wanted:      "public, max-age=31536000, immutable",
+		},
+		{
+			name:        "dashed name without hash",
+			key:         "assets/my-component.css",
+			contentType: "text/css; charset=utf-8",
+			wanted:      "public, max-age=3600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := siteCacheControl(tt.key, tt.contentType, DeploySiteOptions{}); got != tt.wanted {
+				t.Errorf("siteCacheControl(%q) = %v, want %v", tt.key, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestDeploySiteRejectsPrefix(t *testing.T) {
+	t.Parallel()
+
+	var basics BucketBasics
+	if _, err := basics.DeploySite(t.TempDir(), "bucket", DeploySiteOptions{Prefix: "docs", Delete: true}); err == nil {
+		t.Errorf("DeploySite() with prefix %q = nil error, want an error", "docs")
+	}
+}
+
+func TestDeploySiteExpandsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"public/index.html": "<html></html>", "public/assets/app.3f9a2c1b.js": "app()"})
+	t.Setenv("SITE_DIR", filepath.ToSlash(dir))
+
+	fake := newFakeS3(t, "bucket")
+	report, err := fake.basics().DeploySite("${SITE_DIR}/public", "bucket", DeploySiteOptions{Prefix: "docs/"})
+	if err != nil {
+		t.Fatalf("DeploySite() = %v, want nil", err)
+	}
+	if len(report.Failed()) > 0 {
+		t.Fatalf("DeploySite() failed %v", report.Failed())
+	}
+
+	if keys, wanted := fake.keys("bucket"), []string{"docs/assets/app.3f9a2c1b.js", "docs/index.html"}; !slices.Equal(keys, wanted) {
+		t.Errorf("DeploySite() wrote %v, want %v", keys, wanted)
+	}
+	if object, _ := fake.object("bucket", "docs/assets/app.3f9a2c1b.js"); object.header.Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Errorf("DeploySite() cached the hashed asset with %q, want it immutable", object.header.Get("Cache-Control"))
+	}
+}
@@ -0,0 +1,72 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/schollz/progressbar/v3"
+)
+
+// smallObjectSize is the size below which files skip the transfer managers and are sent or fetched with a single
+// PutObject or GetObject. For batches of tiny files the managers' setup costs more than the transfer itself.
+const smallObjectSize = 4 * 1024 * 1024
+
+// smallObjectBuffers holds buffers of smallObjectSize bytes for reading small files into before they are uploaded.
+var smallObjectBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, smallObjectSize)
+		return &buf
+	},
+}
+
+// putSmallObject reads size bytes from r into a pooled buffer and uploads them with a single PutObject with the
+// given metadata. size must be less than smallObjectSize. optFns are passed to the request.
+func (basics BucketBasics) putSmallObject(ctx context.Context, r io.Reader, size int64, key string, bucketName string, metadata map[string]string, bar *progressbar.ProgressBar, optFns ...func(*s3.Options)) error {
+	buf := smallObjectBuffers.Get().(*[]byte)
+	defer smallObjectBuffers.Put(buf)
+
+	data := (*buf)[:size]
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	_, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(bucketName),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(size),
+		Metadata:      metadata,
+	}, optFns...)
+
+	if err != nil {
+		return err
+	}
+
+	if bar != nil {
+		bar.Add64(size)
+	}
+
+	return nil
+}
+
+// getSmallObject downloads an object with a single GetObject, copying it into w, and returns the number of bytes
+// written and the object's metadata. It is meant for objects already known to be smaller than smallObjectSize.
+func (basics BucketBasics) getSmallObject(ctx context.Context, key string, bucketName string, w io.Writer) (int64, map[string]string, error) {
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	defer obj.Body.Close()
+
+	n, err := io.Copy(w, obj.Body)
+	return n, obj.Metadata, err
+}
@@ -0,0 +1,93 @@
+package boto3manager
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadObjectSmall(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.csv")
+	large := filepath.Join(dir, "large.bin")
+	largeData := bytes.Repeat([]byte("x"), smallObjectSize+1)
+	if err := os.WriteFile(small, []byte("a,b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, largeData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeS3(t, "data")
+	basics := fake.basics()
+
+	if err := basics.UploadObject(small, "small.csv", "data", UploadObjectOptions{}); err != nil {
+		t.Fatalf("UploadObject(small.csv) = %v, want nil", err)
+	}
+	if err := basics.UploadObject(large, "large.bin", "data", UploadObjectOptions{}); err != nil {
+		t.Fatalf("UploadObject(large.bin) = %v, want nil", err)
+	}
+
+	// Small files skip the upload manager, so they are never sent as multipart uploads
+	if got := fake.count("POST data/small.csv"); got != 0 {
+		t.Errorf("UploadObject(small.csv) sent %v multipart requests, want 0", got)
+	}
+	if got := fake.count("PUT data/small.csv"); got != 1 {
+		t.Errorf("UploadObject(small.csv) sent %v PUTs, want 1", got)
+	}
+	if object, _ := fake.object("data", "small.csv"); string(object.body) != "a,b\n" {
+		t.Errorf("UploadObject(small.csv) wrote %q, want %q", object.body, "a,b\n")
+	}
+	if object, _ := fake.object("data", "large.bin"); !bytes.Equal(object.body, largeData) {
+		t.Errorf("UploadObject(large.bin) wrote %v bytes, want %v", len(object.body), len(largeData))
+	}
+
+	// Conditions still apply to single requests
+	err := basics.UploadObject(small, "small.csv", "data", UploadObjectOptions{IfNoneMatch: "*"})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("UploadObject(small.csv) over an existing key with IfNoneMatch = %v, want %v", err, ErrPreconditionFailed)
+	}
+}
+
+func TestDownloadObjectsSmall(t *testing.T) {
+	t.Parallel()
+
+	largeData := bytes.Repeat([]byte("x"), 6*1024*1024)
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "set/small.csv", "a,b\n", nil)
+	fake.put("data", "set/empty.csv", "", nil)
+	fake.put("data", "set/large.bin", string(largeData), nil)
+
+	dest := t.TempDir()
+	if _, err := fake.basics().DownloadObjects("set/*", dest, "data", DownloadObjectsOptions{Quiet: true}); err != nil {
+		t.Fatalf("DownloadObjects() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		wanted []byte
+		gets   int
+	}{
+		// Small objects are fetched with a single GET, larger ones in parts by the download manager
+		{name: "small.csv", wanted: []byte("a,b\n"), gets: 1},
+		{name: "empty.csv", wanted: []byte{}, gets: 1},
+		{name: "large.bin", wanted: largeData, gets: 2},
+	}
+
+	for _, test := range tests {
+		// Each object is written into a directory named after its key
+		got, err := os.ReadFile(filepath.Join(dest, "set", test.name, test.name))
+		if err != nil || !bytes.Equal(got, test.wanted) {
+			t.Errorf("DownloadObjects() wrote %v bytes to %v, %v, want %v bytes", len(got), test.name, err, len(test.wanted))
+		}
+
+		if gets := fake.count("GET data/set/" + test.name); gets != test.gets {
+			t.Errorf("DownloadObjects() sent %v GETs for %v, want %v", gets, test.name, test.gets)
+		}
+	}
+}
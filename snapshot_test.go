@@ -0,0 +1,37 @@
+package boto3manager
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffListings(t *testing.T) {
+	t.Parallel()
+
+	a := `{"bucket":"data","prefix":"2024-05-01/","created":"2024-05-01T00:00:00Z"}
+{"k":"2024-05-01/a.csv","s":10,"e":"\"aaa\"","m":"2024-04-30T00:00:00Z"}
+{"k":"2024-05-01/b.csv","s":20,"e":"\"bbb\"","m":"2024-04-30T00:00:00Z"}
+{"k":"2024-05-01/c.csv","s":30,"e":"\"ccc\"","m":"2024-04-30T00:00:00Z"}
+`
+	b := `{"bucket":"data","prefix":"2024-05-02/","created":"2024-05-02T00:00:00Z"}
+{"k":"2024-05-02/a.csv","s":10,"e":"\"aaa\"","m":"2024-05-01T00:00:00Z"}
+{"k":"2024-05-02/c.csv","s":31,"e":"\"ddd\"","m":"2024-05-01T00:00:00Z"}
+{"k":"2024-05-02/d.csv","s":40,"e":"\"eee\"","m":"2024-05-01T00:00:00Z"}
+`
+
+	got := make([]string, 0)
+	err := DiffListings(strings.NewReader(a), strings.NewReader(b), func(entry DiffEntry) error {
+		got = append(got, entry.Kind.String()+" "+entry.Key)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("DiffListings() = %v", err)
+	}
+
+	wanted := []string{"only-in-source b.csv", "different c.csv", "only-in-destination d.csv"}
+	if !reflect.DeepEqual(got, wanted) {
+		t.Errorf("DiffListings() = %v, want %v", got, wanted)
+	}
+}
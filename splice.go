@@ -0,0 +1,382 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// minPartSize is the smallest size S3 allows for any part of a multipart upload except the last
+	minPartSize = 5 * 1024 * 1024
+
+	// maxCopyPartSize is the largest range S3 allows in a single UploadPartCopy
+	maxCopyPartSize = 5 * 1024 * 1024 * 1024
+
+	// spliceChunkSize is the size of the parts uploaded from new data
+	spliceChunkSize = 64 * 1024 * 1024
+)
+
+// escapeKey URL encodes each segment of a key, keeping the slashes between them.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// copySource returns the URL encoded CopySource value for an object.
+func copySource(bucketName string, key string) string {
+	// Objects are copied from access points by their object ARN
+	if isBucketARN(bucketName) {
+		return bucketName + "/object/" + escapeKey(key)
+	}
+
+	return bucketName + "/" + escapeKey(key)
+}
+
+// multipartSplice builds a multipart upload from copied ranges of existing objects and new data.
+type multipartSplice struct {
+	basics     BucketBasics
+	ctx        context.Context
+	bucketName string
+	key        string
+	uploadId   *string
+	parts      []types.CompletedPart
+
+	// verifyMD5 sends Content-MD5 with every uploaded part and checks the returned ETag against it
+	verifyMD5 bool
+
+	// completeOptions are passed to CompleteMultipartUpload, such as write conditions
+	completeOptions []func(*s3.Options)
+
+	// copyIfMatch, if set, is the ETag every copied source must still have, so a source overwritten mid-copy fails
+	// the copy instead of being mixed in
+	copyIfMatch *string
+}
+
+// newMultipartSplice starts a multipart upload for key with the given content type and metadata. optFns are passed
+// to the request that starts it.
+func (basics BucketBasics) newMultipartSplice(ctx context.Context, key string, bucketName string, contentType *string, metadata map[string]string, optFns ...func(*s3.Options)) (*multipartSplice, error) {
+	return basics.startMultipartSplice(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		ContentType: contentType,
+		Metadata:    metadata,
+	}, optFns...)
+}
+
+// startMultipartSplice starts a multipart upload with every header in input. optFns are passed to the request that
+// starts it.
+func (basics BucketBasics) startMultipartSplice(ctx context.Context, input *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*multipartSplice, error) {
+	bucketName, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+	upload, err := basics.client(bucketName).CreateMultipartUpload(ctx, input, optFns...)
+
+	if err != nil {
+		log.Printf("Couldn't start multipart upload for %v: %v", key, err)
+		return nil, err
+	}
+
+	return &multipartSplice{basics: basics, ctx: ctx, bucketName: bucketName, key: key, uploadId: upload.UploadId}, nil
+}
+
+// startRewrite starts a multipart upload that rewrites an object in place, keeping every header, storage class,
+// encryption setting, and tag the object has and giving it metadata. Every range read from or copied out of the
+// object, and the object when the upload completes, must still have the ETag in head, so an object overwritten
+// mid-rewrite fails instead of being mixed in or replaced.
+func (basics BucketBasics) startRewrite(ctx context.Context, key string, bucketName string, head *s3.HeadObjectOutput, metadata map[string]string) (*multipartSplice, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:                  aws.String(bucketName),
+		Key:                     aws.String(key),
+		Metadata:                metadata,
+		ContentType:             head.ContentType,
+		ContentEncoding:         head.ContentEncoding,
+		ContentDisposition:      head.ContentDisposition,
+		ContentLanguage:         head.ContentLanguage,
+		CacheControl:            head.CacheControl,
+		Expires:                 head.Expires,
+		WebsiteRedirectLocation: head.WebsiteRedirectLocation,
+		StorageClass:            head.StorageClass,
+		ServerSideEncryption:    head.ServerSideEncryption,
+		SSEKMSKeyId:             head.SSEKMSKeyId,
+	}
+
+	// A multipart upload starts without tags, unlike a copy, so they're read and given to it
+	tagging, err := basics.client(bucketName).GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get tags of %v: %v", key, err)
+		return nil, err
+	}
+
+	if len(tagging.TagSet) > 0 {
+		tags := make(url.Values, len(tagging.TagSet))
+		for _, tag := range tagging.TagSet {
+			tags.Set(aws.ToString(tag.Key), aws.ToString(tag.Value))
+		}
+		input.Tagging = aws.String(tags.Encode())
+	}
+
+	splice, err := basics.startMultipartSplice(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	splice.copyIfMatch = head.ETag
+
+	// A rewrite that reads nothing from the object, such as a range covering all of it, is only checked here
+	if etag := aws.ToString(head.ETag); etag != "" {
+		splice.completeOptions = append(splice.completeOptions, writeCondition("If-Match", etag))
+	}
+
+	return splice, nil
+}
+
+// copyRange adds parts copying bytes [start, end) of a source object. Large ranges are split evenly so that every
+// part stays within the size limits.
+func (splice *multipartSplice) copyRange(srcBucket string, srcKey string, start int64, end int64) error {
+	size := end - start
+	if size <= 0 {
+		return nil
+	}
+
+	count := (size + maxCopyPartSize - 1) / maxCopyPartSize
+	partSize := (size + count - 1) / count
+
+	for partStart := start; partStart < end; partStart += partSize {
+		partEnd := min(partStart+partSize, end)
+
+		partNumber := int32(len(splice.parts) + 1)
+		part, err := splice.basics.client(splice.bucketName).UploadPartCopy(splice.ctx, &s3.UploadPartCopyInput{
+			Bucket:            aws.String(splice.bucketName),
+			Key:               aws.String(splice.key),
+			UploadId:          splice.uploadId,
+			PartNumber:        aws.Int32(partNumber),
+			CopySource:        aws.String(copySource(srcBucket, srcKey)),
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", partStart, partEnd-1)),
+			CopySourceIfMatch: splice.copyIfMatch,
+		})
+
+		if err != nil {
+			log.Printf("Couldn't copy part %v of %v from %v: %v", partNumber, splice.key, srcKey, err)
+			return err
+		}
+
+		splice.parts = append(splice.parts, types.CompletedPart{ETag: part.CopyPartResult.ETag, PartNumber: aws.Int32(partNumber)})
+	}
+
+	return nil
+}
+
+// uploadBytes adds a part containing data.
+func (splice *multipartSplice) uploadBytes(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	partNumber := int32(len(splice.parts) + 1)
+	input := &s3.UploadPartInput{
+		Bucket:     aws.String(splice.bucketName),
+		Key:        aws.String(splice.key),
+		UploadId:   splice.uploadId,
+		PartNumber: aws.Int32(partNumber),
+		Body:       bytes.NewReader(data),
+	}
+
+	var etag string
+	if splice.verifyMD5 {
+		var header string
+		header, etag = contentMD5(data)
+		input.ContentMD5 = aws.String(header)
+	}
+
+	part, err := splice.basics.client(splice.bucketName).UploadPart(splice.ctx, input)
+
+	if err != nil {
+		log.Printf("Couldn't upload part %v of %v: %v", partNumber, splice.key, err)
+		return err
+	}
+
+	if splice.verifyMD5 {
+		if err := checkETag(fmt.Sprintf("part %v of %v", partNumber, splice.key), part.ETag, etag); err != nil {
+			return err
+		}
+	}
+
+	splice.parts = append(splice.parts, types.CompletedPart{ETag: part.ETag, PartNumber: aws.Int32(partNumber)})
+
+	return nil
+}
+
+// complete finishes the multipart upload, replacing the object.
+func (splice *multipartSplice) complete() error {
+	_, err := splice.basics.client(splice.bucketName).CompleteMultipartUpload(splice.ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(splice.bucketName),
+		Key:             aws.String(splice.key),
+		UploadId:        splice.uploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: splice.parts},
+	}, splice.completeOptions...)
+
+	if err != nil {
+		log.Printf("Couldn't complete multipart upload for %v: %v", splice.key, err)
+	}
+
+	return err
+}
+
+// abort cancels the multipart upload so no parts are left behind.
+func (splice *multipartSplice) abort() {
+	_, err := splice.basics.client(splice.bucketName).AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(splice.bucketName),
+		Key:      aws.String(splice.key),
+		UploadId: splice.uploadId,
+	})
+
+	if err != nil {
+		log.Printf("Couldn't abort multipart upload for %v: %v", splice.key, err)
+	}
+}
+
+// getRange downloads bytes [start, end) of an object into memory. If ifMatch is set, the object must still have
+// that ETag.
+func (basics BucketBasics) getRange(ctx context.Context, key string, bucketName string, start int64, end int64, ifMatch *string) ([]byte, error) {
+	if end <= start {
+		return nil, nil
+	}
+
+	obj, err := basics.client(bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(bucketName),
+		Key:     aws.String(key),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end-1)),
+		IfMatch: ifMatch,
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get bytes %v-%v of %v: %v", start, end-1, key, err)
+		return nil, err
+	}
+
+	defer obj.Body.Close()
+
+	return io.ReadAll(obj.Body)
+}
+
+// ReplaceRange takes a key, a bucket name, an offset, a reader, and a length and replaces length bytes of the object
+// starting at offset with length bytes read from r. The untouched regions are copied server-side with UploadPartCopy,
+// so only small regions next to the replaced range are ever downloaded. If the range runs past the end of the object,
+// the object grows. The object keeps its headers, metadata, and tags, and if it is overwritten while its range is
+// being replaced, ReplaceRange fails and leaves the new object alone.
+func (basics BucketBasics) ReplaceRange(key string, bucketName string, offset int64, r io.Reader, length int64) error {
+	ctx := context.TODO()
+
+	// Get the size and metadata of the object so they can be kept
+	head, err := basics.client(bucketName).HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get object %v: %v", key, err)
+		return err
+	}
+
+	size := aws.ToInt64(head.ContentLength)
+	if offset < 0 || offset > size || length < 0 {
+		return fmt.Errorf("range at offset %v of length %v is outside object %v of size %v", offset, length, key, size)
+	}
+
+	// Nothing to replace
+	if length == 0 {
+		return nil
+	}
+
+	splice, err := basics.startRewrite(ctx, key, bucketName, head, head.Metadata)
+
+	if err != nil {
+		return err
+	}
+
+	if err := splice.replace(r, offset, length, size); err != nil {
+		splice.abort()
+		return err
+	}
+
+	if err := splice.complete(); err != nil {
+		splice.abort()
+		return err
+	}
+
+	return nil
+}
+
+// replace adds the parts for ReplaceRange.
+func (splice *multipartSplice) replace(r io.Reader, offset int64, length int64, size int64) error {
+	var pending []byte
+
+	// Copy the head server-side if it is large enough to be a part, otherwise send it with the new data
+	if offset >= minPartSize {
+		if err := splice.copyRange(splice.bucketName, splice.key, 0, offset); err != nil {
+			return err
+		}
+	} else {
+		head, err := splice.basics.getRange(splice.ctx, splice.key, splice.bucketName, 0, offset, splice.copyIfMatch)
+		if err != nil {
+			return err
+		}
+		pending = head
+	}
+
+	// Upload the new data in chunks
+	remaining := length
+	for remaining > 0 {
+		n := min(int64(spliceChunkSize-len(pending)), remaining)
+		chunk := make([]byte, n)
+
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return err
+		}
+
+		pending = append(pending, chunk...)
+		remaining -= n
+
+		if len(pending) >= spliceChunkSize {
+			if err := splice.uploadBytes(pending); err != nil {
+				return err
+			}
+			pending = nil
+		}
+	}
+
+	// If the tail follows a part that's too small, send the start of the tail with it
+	tailStart := min(offset+length, size)
+	if tailStart < size && len(pending) > 0 && len(pending) < minPartSize {
+		tailEnd := min(size, tailStart+int64(minPartSize-len(pending)))
+
+		tail, err := splice.basics.getRange(splice.ctx, splice.key, splice.bucketName, tailStart, tailEnd, splice.copyIfMatch)
+		if err != nil {
+			return err
+		}
+
+		pending = append(pending, tail...)
+		tailStart = tailEnd
+	}
+
+	if err := splice.uploadBytes(pending); err != nil {
+		return err
+	}
+
+	// Copy the rest of the tail server-side
+	return splice.copyRange(splice.bucketName, splice.key, tailStart, size)
+}
@@ -0,0 +1,129 @@
+package boto3manager
+
+import (
+	"bytes"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReplaceRange(t *testing.T) {
+	t.Parallel()
+
+	key := "data/a.txt"
+	header := http.Header{
+		"Content-Type":                 {"text/plain"},
+		"Content-Encoding":             {"identity"},
+		"Cache-Control":                {"max-age=60"},
+		"Content-Disposition":          {"attachment"},
+		"X-Amz-Storage-Class":          {"STANDARD_IA"},
+		"X-Amz-Server-Side-Encryption": {"AES256"},
+		"X-Amz-Meta-Owner":             {"alice"},
+	}
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", key, "hello world", header)
+	fake.mu.Lock()
+	fake.buckets["bucket"][key].tags = map[string]string{"project": "tide", "stage": "raw"}
+	fake.mu.Unlock()
+
+	if err := fake.basics().ReplaceRange(key, "bucket", 6, strings.NewReader("there!"), 6); err != nil {
+		t.Fatalf("ReplaceRange() = %v, want nil", err)
+	}
+
+	object, _ := fake.object("bucket", key)
+	if string(object.body) != "hello there!" {
+		t.Errorf("ReplaceRange() left %q, want %q", object.body, "hello there!")
+	}
+
+	for name := range header {
+		if got, wanted := object.header.Get(name), header.Get(name); got != wanted {
+			t.Errorf("ReplaceRange() left %v = %q, want %q", name, got, wanted)
+		}
+	}
+
+	if wanted := map[string]string{"project": "tide", "stage": "raw"}; !maps.Equal(object.tags, wanted) {
+		t.Errorf("ReplaceRange() left tags %v, want %v", object.tags, wanted)
+	}
+}
+
+func TestReplaceRangeCopiesHead(t *testing.T) {
+	t.Parallel()
+
+	// The head is large enough to be copied server-side, from a key that needs escaping in the copy source
+	key := "my data/a+b?.bin"
+	original := bytes.Repeat([]byte("a"), minPartSize+10)
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", key, string(original), nil)
+
+	if err := fake.basics().ReplaceRange(key, "bucket", minPartSize, strings.NewReader("bbbbb"), 5); err != nil {
+		t.Fatalf("ReplaceRange() = %v, want nil", err)
+	}
+
+	wanted := slices.Concat(original[:minPartSize], []byte("bbbbb"), original[minPartSize+5:])
+	if object, _ := fake.object("bucket", key); !bytes.Equal(object.body, wanted) {
+		t.Errorf("ReplaceRange() left %v bytes, want the head, bbbbb, and the tail", len(object.body))
+	}
+}
+
+func TestReplaceRangeOverwritten(t *testing.T) {
+	t.Parallel()
+
+	// The head is large enough to be copied server-side
+	original := bytes.Repeat([]byte("a"), minPartSize+10)
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "big.bin", string(original), nil)
+
+	// Another writer replaces the object before its head is copied
+	overwritten := false
+	fake.fail = func(r *http.Request) int {
+		if r.Header.Get("X-Amz-Copy-Source") != "" && !overwritten {
+			overwritten = true
+			fake.buckets["bucket"]["big.bin"] = &fakeObject{body: []byte("replaced"), etag: fakeETag([]byte("replaced")), header: make(http.Header), lastModified: fake.tick()}
+		}
+		return 0
+	}
+
+	if err := fake.basics().ReplaceRange("big.bin", "bucket", minPartSize, strings.NewReader("bbbbb"), 5); err == nil {
+		t.Fatal("ReplaceRange() of an overwritten object = nil, want an error")
+	}
+
+	if object, _ := fake.object("bucket", "big.bin"); string(object.body) != "replaced" {
+		t.Errorf("ReplaceRange() of an overwritten object left %q, want %q", object.body, "replaced")
+	}
+	if got := fake.count("DELETE bucket/big.bin?uploadId="); got != 1 {
+		t.Errorf("ReplaceRange() of an overwritten object aborted its upload %v times, want 1", got)
+	}
+}
+
+func TestReplaceRangeWholeObjectOverwritten(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "small.txt", "hello", nil)
+
+	// Replacing every byte reads nothing from the object, so only the complete can notice another writer
+	overwritten := false
+	fake.fail = func(r *http.Request) int {
+		if r.Method == http.MethodPost && r.URL.Query().Has("uploadId") && !overwritten {
+			overwritten = true
+			fake.buckets["bucket"]["small.txt"] = &fakeObject{body: []byte("theirs"), etag: fakeETag([]byte("theirs")), header: make(http.Header), lastModified: fake.tick()}
+		}
+		return 0
+	}
+
+	if err := fake.basics().ReplaceRange("small.txt", "bucket", 0, strings.NewReader("world"), 5); err == nil {
+		t.Fatal("ReplaceRange() of an overwritten object = nil, want an error")
+	}
+
+	if object, _ := fake.object("bucket", "small.txt"); string(object.body) != "theirs" {
+		t.Errorf("ReplaceRange() of an overwritten object left %q, want %q", object.body, "theirs")
+	}
+	if got := fake.count("DELETE bucket/small.txt?uploadId="); got != 1 {
+		t.Errorf("ReplaceRange() of an overwritten object aborted its upload %v times, want 1", got)
+	}
+}
@@ -0,0 +1,199 @@
+package boto3manager
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StatsOptions turns on live statistics for a batch operation, for dashboards and progress displays that need more
+// than a byte counter.
+type StatsOptions struct {
+	// Interval is how often OnStats is called. Defaults to 1 second
+	Interval time.Duration
+
+	// OnStats is called every interval while the batch runs and once more when it finishes
+	OnStats func(TransferStats)
+}
+
+// TransferStats is a snapshot of a running batch. Counts cover the files of the batch, not files a sync skipped
+// before starting it. Bytes are counted as files complete.
+type TransferStats struct {
+	Elapsed time.Duration
+
+	Objects     int
+	ObjectsDone int
+	Failed      int
+	InFlight    int
+
+	Bytes     int64
+	BytesDone int64
+
+	// Throughput is bytes per second since the previous snapshot, and AverageThroughput since the start
+	Throughput        float64
+	AverageThroughput float64
+
+	// ObjectsPerSecond is files finished per second since the start
+	ObjectsPerSecond float64
+
+	// ETA is the time left at the average throughput, or zero before anything has finished
+	ETA time.Duration
+
+	// WorkerUtilization is the fraction of the elapsed time each worker has spent transferring
+	WorkerUtilization []float64
+
+	// Finished is set on the final snapshot
+	Finished bool
+}
+
+// batchStats collects the statistics of a batch and passes them to the callback every interval.
+type batchStats struct {
+	options    StatsOptions
+	report     *TransferReport
+	objects    int
+	bytes      int64
+	started    time.Time
+	inFlight   atomic.Int64
+	busy       []atomic.Int64
+	workStart  []atomic.Int64
+	baseDone   int
+	baseFailed int
+	baseBytes  int64
+	lastBytes  int64
+	lastTime   time.Time
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// newBatchStats returns the statistics of a batch of objects and bytes run by workers, or nil if options is nil
+// or has no callback.
+func newBatchStats(options *StatsOptions, report *TransferReport, objects int, bytes int64, workers int) *batchStats {
+	if options == nil || options.OnStats == nil {
+		return nil
+	}
+
+	stats := &batchStats{
+		options:   *options,
+		report:    report,
+		objects:   objects,
+		bytes:     bytes,
+		busy:      make([]atomic.Int64, workers),
+		workStart: make([]atomic.Int64, workers),
+	}
+
+	if stats.options.Interval <= 0 {
+		stats.options.Interval = time.Second
+	}
+
+	return stats
+}
+
+// start records where the report stands and calls the callback every interval until finish.
+func (stats *batchStats) start() {
+	if stats == nil {
+		return
+	}
+
+	stats.baseDone, stats.baseFailed, stats.baseBytes = stats.report.progress()
+	stats.started = time.Now()
+	stats.lastTime = stats.started
+
+	stats.stop = make(chan struct{})
+	stats.done.Add(1)
+
+	go func() {
+		defer stats.done.Done()
+
+		ticker := time.NewTicker(stats.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				stats.options.OnStats(stats.snapshot(false))
+			case <-stats.stop:
+				return
+			}
+		}
+	}()
+}
+
+// finish stops the updates and calls the callback with the final statistics.
+func (stats *batchStats) finish() {
+	if stats == nil {
+		return
+	}
+
+	close(stats.stop)
+	stats.done.Wait()
+
+	stats.options.OnStats(stats.snapshot(true))
+}
+
+// begin marks a worker as transferring.
+func (stats *batchStats) begin(worker int) {
+	if stats == nil {
+		return
+	}
+
+	stats.inFlight.Add(1)
+	stats.workStart[worker].Store(time.Now().UnixNano())
+}
+
+// end marks a worker as idle.
+func (stats *batchStats) end(worker int) {
+	if stats == nil {
+		return
+	}
+
+	started := stats.workStart[worker].Swap(0)
+	stats.busy[worker].Add(time.Now().UnixNano() - started)
+	stats.inFlight.Add(-1)
+}
+
+// snapshot returns the current statistics.
+func (stats *batchStats) snapshot(finished bool) TransferStats {
+	now := time.Now()
+	done, failed, bytes := stats.report.progress()
+
+	snapshot := TransferStats{
+		Elapsed:     now.Sub(stats.started),
+		Objects:     stats.objects,
+		ObjectsDone: done - stats.baseDone,
+		Failed:      failed - stats.baseFailed,
+		InFlight:    int(stats.inFlight.Load()),
+		Bytes:       stats.bytes,
+		BytesDone:   bytes - stats.baseBytes,
+		Finished:    finished,
+	}
+
+	if interval := now.Sub(stats.lastTime).Seconds(); interval > 0 {
+		snapshot.Throughput = float64(snapshot.BytesDone-stats.lastBytes) / interval
+	}
+	stats.lastBytes = snapshot.BytesDone
+	stats.lastTime = now
+
+	if elapsed := snapshot.Elapsed.Seconds(); elapsed > 0 {
+		snapshot.AverageThroughput = float64(snapshot.BytesDone) / elapsed
+		snapshot.ObjectsPerSecond = float64(snapshot.ObjectsDone+snapshot.Failed) / elapsed
+	}
+
+	if snapshot.AverageThroughput > 0 {
+		remaining := float64(snapshot.Bytes - snapshot.BytesDone)
+		snapshot.ETA = time.Duration(remaining / snapshot.AverageThroughput * float64(time.Second))
+	}
+
+	snapshot.WorkerUtilization = make([]float64, len(stats.busy))
+	for i := range stats.busy {
+		busy := stats.busy[i].Load()
+		if started := stats.workStart[i].Load(); started != 0 {
+			busy += now.UnixNano() - started
+		}
+		if snapshot.Elapsed > 0 {
+			snapshot.WorkerUtilization[i] = min(float64(busy)/float64(snapshot.Elapsed), 1)
+		}
+	}
+
+	return snapshot
+}
@@ -0,0 +1,57 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunBatchStats(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var snapshots []TransferStats
+
+	stats := &StatsOptions{
+		Interval: 5 * time.Millisecond,
+		OnStats: func(stats TransferStats) {
+			mu.Lock()
+			defer mu.Unlock()
+			snapshots = append(snapshots, stats)
+		},
+	}
+
+	// A skipped file recorded before the batch isn't counted in its statistics
+	report := &TransferReport{}
+	report.record(TransferResult{Key: "skipped", Size: 100, SkipReason: "unchanged"})
+
+	items := []FileUpload{{Key: "a", Size: 10}, {Key: "b", Size: 20}, {Key: "c", Size: 30}}
+	config := batchConfig{workerCount: 2, policy: RetryPolicy{MaxAttempts: 1}, report: report, stats: stats}
+
+	err := runBatch(context.Background(), items, config, func(ctx context.Context, item FileUpload) error {
+		time.Sleep(10 * time.Millisecond)
+		if item.Key == "c" {
+			return errors.New("broken")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("runBatch() = %v, want nil", err)
+	}
+
+	if len(snapshots) == 0 {
+		t.Fatal("OnStats was never called")
+	}
+
+	final := snapshots[len(snapshots)-1]
+	if !final.Finished || final.Objects != 3 || final.ObjectsDone != 2 || final.Failed != 1 || final.Bytes != 60 || final.BytesDone != 30 || final.InFlight != 0 {
+		t.Errorf("final stats = %+v, want 3 objects, 2 done, 1 failed, 30 of 60 bytes, none in flight", final)
+	}
+
+	if len(final.WorkerUtilization) != 2 || final.WorkerUtilization[0] <= 0 {
+		t.Errorf("final WorkerUtilization = %v, want 2 busy workers", final.WorkerUtilization)
+	}
+}
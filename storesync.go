@@ -0,0 +1,352 @@
+package boto3manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// storeObjects returns the objects in a store under prefix keyed by their key relative to prefix. Directory markers
+// are left out.
+func storeObjects(ctx context.Context, store ObjectStore, prefix string) (map[string]ObjectInfo, error) {
+	objects := make(map[string]ObjectInfo)
+
+	for object, err := range store.List(ctx, prefix) {
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+
+		name := strings.TrimPrefix(object.Key, prefix)
+		objects[name] = ObjectInfo{
+			Key:          object.Key,
+			Name:         name,
+			Size:         object.Size,
+			ETag:         object.ETag,
+			LastModified: object.LastModified,
+		}
+	}
+
+	return objects, nil
+}
+
+// putFile uploads a local file to a store, counting its bytes on the progress bar.
+func putFile(ctx context.Context, store ObjectStore, path string, key string, bar *progressbar.ProgressBar) error {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("Couldn't read file %v: %v", path, err)
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	body, progress := newProgressReader(f, bar)
+	if err := store.Put(ctx, key, body, info.Size(), nil); err != nil {
+		progress.rollback()
+		return err
+	}
+
+	return nil
+}
+
+// getFile downloads an object from a store to a local file, counting its bytes on the progress bar. The object is
+// written to a temporary file next to the destination and moved into place once complete, so the destination never
+// holds a partial file.
+func getFile(ctx context.Context, store ObjectStore, key string, path string, bar *progressbar.ProgressBar) error {
+	body, _, err := store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		log.Printf("Couldn't create directory %v: %v", filepath.Dir(path), err)
+		return err
+	}
+
+	partial := path + ".partial"
+	f, err := os.Create(partial)
+	if err != nil {
+		log.Printf("Couldn't create file %v: %v", partial, err)
+		return err
+	}
+
+	r, progress := newProgressReader(body, bar)
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(partial, path)
+	}
+
+	if err != nil {
+		log.Printf("Couldn't download object %v: %v", key, err)
+		progress.rollback()
+		os.Remove(partial)
+		return err
+	}
+
+	return nil
+}
+
+// deleteFromStore deletes the named objects from a store, first copying them under trashPrefix if it is set, and
+// records them in the report.
+func deleteFromStore(ctx context.Context, store ObjectStore, objects map[string]ObjectInfo, names []string, trashPrefix string, report *TransferReport, quiet bool) error {
+	trash := ""
+	if trashPrefix != "" {
+		trash = trashPrefix + time.Now().UTC().Format(trashStamp) + "/"
+	}
+
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		key := objects[name].Key
+
+		if trash != "" {
+			if err := store.Copy(ctx, key, trash+name); err != nil {
+				log.Printf("Couldn't move %v to the trash: %v", key, err)
+				return err
+			}
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := store.Delete(ctx, keys...); err != nil {
+		log.Printf("Couldn't delete %v objects: %v", len(keys), err)
+		return err
+	}
+
+	report.Deleted = append(report.Deleted, keys...)
+	if !quiet {
+		fmt.Printf("Deleted %v objects\n", len(keys))
+	}
+
+	return nil
+}
+
+// uploadFunc sends a file of a sync up to its key, counting its bytes on bar.
+type uploadFunc func(ctx context.Context, file FileUpload, bar *progressbar.ProgressBar) error
+
+// downloadFunc writes the object of a sync down to its destination, counting its bytes on bar.
+type downloadFunc func(ctx context.Context, file FileDownload, bar *progressbar.ProgressBar) error
+
+// syncUp is the sync up behind SyncUp and SyncUpStore: it plans the sync from the local files and the store's
+// listing, sends the files that need it with upload, and mirrors deletions to the store. backpressure is that of the
+// client behind the store.
+func syncUp(ctx context.Context, store ObjectStore, localDir string, prefix string, options SyncOptions, backpressure BackpressureOptions, upload uploadFunc) (*TransferReport, error) {
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	skip := options.Skip
+	if skip == nil {
+		skip = skipUnchangedUpload
+	}
+
+	if err := options.checkTrashPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	files, err := localFiles(localDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.checkMirror(localDir, len(files), options.TrashPrefix); err != nil {
+		return nil, err
+	}
+
+	objects, err := storeObjects(ctx, store, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TransferReport{}
+
+	// Decide which files need uploading
+	uploads := make([]FileUpload, 0)
+	var totalSize int64
+	for name, file := range files {
+		if object, ok := objects[name]; ok {
+			if skipped, reason := skip(file, object); skipped {
+				report.record(TransferResult{Key: object.Key, Path: file.Path, Size: file.Size, SkipReason: reason})
+				continue
+			}
+		}
+
+		uploads = append(uploads, FileUpload{Path: file.Path, Key: prefix + name, Size: file.Size})
+		totalSize += file.Size
+	}
+
+	warnRequests("sync", uploadRequests(uploads, UploadObjectsOptions{}))
+
+	progress := newTransferProgress("syncing", totalSize, options.FileProgress, options.Quiet)
+
+	config := batchConfig{
+		workerCount:  defaultWorkers(25),
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		hooks:        options.Hooks,
+		stats:        options.Stats,
+		backpressure: backpressure,
+	}
+
+	err = runBatch(ctx, uploads, config, func(ctx context.Context, file FileUpload) error {
+		bar := progress.file(file.Key, file.Size)
+		defer progress.done(file.Key)
+
+		return upload(ctx, file, bar)
+	})
+
+	progress.close()
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	if err != nil || len(report.Failed()) > 0 || !options.Mirror {
+		return report, err
+	}
+
+	// Delete the objects that no longer exist locally, apart from the pages and manifest the sync writes itself
+	deletions := mirrorDeletions(files, objects)
+	if options.IndexPages {
+		deletions = slices.DeleteFunc(deletions, isIndexPage)
+	}
+	if options.DeliveryManifest != "" {
+		deletions = slices.DeleteFunc(deletions, func(name string) bool { return name == options.DeliveryManifest })
+	}
+
+	if options.confirmDeletions(deletions) {
+		err = deleteFromStore(ctx, store, objects, deletions, options.TrashPrefix, report, options.Quiet)
+	}
+
+	return report, err
+}
+
+// syncDown is the sync down behind SyncDown and SyncDownStore: it plans the sync from the store's listing and the
+// local files, writes the objects that need it with download, and mirrors deletions to localDir. source names the
+// store's prefix in messages, and backpressure is that of the client behind the store.
+func syncDown(ctx context.Context, store ObjectStore, prefix string, localDir string, source string, options SyncOptions, backpressure BackpressureOptions, download downloadFunc) (*TransferReport, error) {
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	skip := options.Skip
+	if skip == nil {
+		skip = skipUnchangedDownload
+	}
+
+	if err := options.checkTrashDir(localDir); err != nil {
+		return nil, err
+	}
+
+	objects, err := storeObjects(ctx, store, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.checkMirror(source, len(objects), options.TrashDir); err != nil {
+		return nil, err
+	}
+
+	report := &TransferReport{}
+
+	// Decide which objects need downloading
+	downloads := make([]FileDownload, 0)
+	var totalSize int64
+	for name, object := range objects {
+		path := filepath.Join(localDir, filepath.FromSlash(name))
+
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			file := FileInfo{Path: path, Name: name, Size: info.Size(), ModTime: info.ModTime(), Mode: info.Mode()}
+
+			if skipped, reason := skip(file, object); skipped {
+				report.record(TransferResult{Key: object.Key, Path: path, Size: object.Size, SkipReason: reason})
+				continue
+			}
+		}
+
+		downloads = append(downloads, FileDownload{Key: object.Key, Destination: path, Size: object.Size})
+		totalSize += object.Size
+	}
+
+	warnRequests("sync", downloadRequests(downloads))
+
+	progress := newTransferProgress("syncing", totalSize, options.FileProgress, options.Quiet)
+
+	config := batchConfig{
+		workerCount:  defaultWorkers(50),
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		hooks:        options.Hooks,
+		stats:        options.Stats,
+		backpressure: backpressure,
+	}
+
+	err = runBatch(ctx, downloads, config, func(ctx context.Context, file FileDownload) error {
+		bar := progress.file(file.Key, file.Size)
+		defer progress.done(file.Key)
+
+		return download(ctx, file, bar)
+	})
+
+	progress.close()
+	if !options.Quiet {
+		fmt.Println(report.Summary())
+	}
+
+	if err != nil || len(report.Failed()) > 0 || !options.Mirror {
+		return report, err
+	}
+
+	// Delete the files that no longer exist in the store. A directory that was never created has nothing to delete
+	files, err := localFiles(localDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+
+	if deletions := mirrorDeletions(objects, files); options.confirmDeletions(deletions) {
+		err = deleteLocal(files, deletions, options.TrashDir, report, options.Quiet)
+	}
+
+	return report, err
+}
+
+// SyncUpStore is SyncUp for any ObjectStore: it uploads the files in localDir that are missing from the prefix in
+// the store or that the skip decider says have changed. IndexPages and DeliveryManifest are only written by SyncUp.
+func SyncUpStore(ctx context.Context, store ObjectStore, localDir string, prefix string, options SyncOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+
+	return syncUp(ctx, store, localDir, prefix, options, BackpressureOptions{}, func(ctx context.Context, file FileUpload, bar *progressbar.ProgressBar) error {
+		return putFile(ctx, store, file.Path, file.Key, bar)
+	})
+}
+
+// SyncDownStore is SyncDown for any ObjectStore: it downloads the objects under the prefix in the store that are
+// missing from localDir or that the skip decider says have changed.
+func SyncDownStore(ctx context.Context, store ObjectStore, prefix string, localDir string, options SyncOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+
+	return syncDown(ctx, store, prefix, localDir, prefix, options, BackpressureOptions{}, func(ctx context.Context, file FileDownload, bar *progressbar.ProgressBar) error {
+		return getFile(ctx, store, file.Key, file.Destination, bar)
+	})
+}
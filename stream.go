@@ -0,0 +1,117 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxUploadParts is the most parts S3 allows in one multipart upload.
+const maxUploadParts = 10000
+
+type UploadStreamOptions struct {
+	// PartSize is the size of each part, and the only data held in memory. Streams can be at most 10,000 parts long,
+	// so the default of 64 MiB allows streams of up to 625 GiB
+	PartSize int64
+
+	ContentType string
+
+	// Progress shows a progress bar counting uploaded bytes
+	Progress bool
+}
+
+// UploadStream takes a reader of unknown length, a key, and a bucket name and uploads everything read to the key,
+// e.g. a database dump piped into stdin. Only one part is buffered at a time. Streams shorter than a part are uploaded
+// with a single PutObject. It returns the number of bytes uploaded.
+func (basics BucketBasics) UploadStream(r io.Reader, key string, bucketName string, options UploadStreamOptions) (int64, error) {
+	return basics.UploadStreamWithContext(context.Background(), r, key, bucketName, options)
+}
+
+// UploadStreamWithContext is UploadStream with a context. If the context is cancelled or reading fails, the multipart
+// upload is aborted and nothing is written to the key.
+func (basics BucketBasics) UploadStreamWithContext(ctx context.Context, r io.Reader, key string, bucketName string, options UploadStreamOptions) (int64, error) {
+	partSize := options.PartSize
+	if partSize == 0 {
+		partSize = spliceChunkSize
+	}
+
+	if partSize < minPartSize {
+		return 0, fmt.Errorf("part size %v is smaller than the minimum of %v", partSize, minPartSize)
+	}
+
+	var contentType *string
+	if options.ContentType != "" {
+		contentType = aws.String(options.ContentType)
+	}
+
+	if options.Progress {
+		r = io.TeeReader(r, newBytesBar(-1, "uploading"))
+	}
+
+	// Read the first part to find out whether a multipart upload is needed at all
+	buf := make([]byte, partSize)
+	n, err := io.ReadFull(r, buf)
+
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		log.Printf("Couldn't read stream for %v: %v", key, err)
+		return 0, err
+	}
+
+	if int64(n) < partSize {
+		_, err := basics.client(bucketName).PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(bucketName),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(buf[:n]),
+			ContentType: contentType,
+		})
+
+		if err != nil {
+			log.Printf("Couldn't upload object %v to bucket %v: %v", key, bucketName, err)
+			return 0, err
+		}
+
+		return int64(n), nil
+	}
+
+	splice, err := basics.newMultipartSplice(ctx, key, bucketName, contentType, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Upload each full buffer as a part, reusing the buffer for the next one
+	total := int64(0)
+	for n > 0 {
+		if len(splice.parts) == maxUploadParts {
+			splice.abort()
+			return total, fmt.Errorf("stream for %v is longer than %v parts of %v bytes; use a larger part size", key, maxUploadParts, partSize)
+		}
+
+		if err := splice.uploadBytes(buf[:n]); err != nil {
+			splice.abort()
+			return total, err
+		}
+		total += int64(n)
+
+		n, err = io.ReadFull(r, buf)
+
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			log.Printf("Couldn't read stream for %v: %v", key, err)
+			splice.abort()
+			return total, err
+		}
+	}
+
+	if err := splice.complete(); err != nil {
+		splice.abort()
+		return total, err
+	}
+
+	return total, nil
+}
@@ -0,0 +1,53 @@
+package boto3manager
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUploadStream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		size  int
+		puts  int
+		parts int
+	}{
+		{name: "shorter than a part", size: 100, puts: 1},
+		{name: "exactly a part", size: minPartSize, parts: 1},
+		{name: "across a part boundary", size: 2*minPartSize + 100, parts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := make([]byte, tt.size)
+			for i := range data {
+				data[i] = byte(i % 251)
+			}
+
+			fake := newFakeS3(t, "bucket")
+			n, err := fake.basics().UploadStream(bytes.NewReader(data), "dump.sql", "bucket", UploadStreamOptions{PartSize: minPartSize, ContentType: "application/sql"})
+			if err != nil || n != int64(tt.size) {
+				t.Fatalf("UploadStream() = %v, %v, want %v, nil", n, err, tt.size)
+			}
+
+			object, ok := fake.object("bucket", "dump.sql")
+			if !ok || !bytes.Equal(object.body, data) {
+				t.Errorf("UploadStream() stored %v bytes, want the %v bytes read", len(object.body), tt.size)
+			}
+			if got := object.header.Get("Content-Type"); got != "application/sql" {
+				t.Errorf("UploadStream() stored Content-Type %q, want application/sql", got)
+			}
+
+			if got := fake.count("PUT bucket/dump.sql?partNumber="); got != tt.parts {
+				t.Errorf("UploadStream() uploaded %v parts, want %v", got, tt.parts)
+			}
+			if got := fake.count("PUT bucket/dump.sql") - tt.parts; got != tt.puts {
+				t.Errorf("UploadStream() sent %v whole objects, want %v", got, tt.puts)
+			}
+		})
+	}
+}
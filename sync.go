@@ -0,0 +1,249 @@
+package boto3manager
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/schollz/progressbar/v3"
+)
+
+// FileInfo describes a local file considered by a sync. Name is its slash separated path relative to the directory
+// being synced.
+type FileInfo struct {
+	Path    string
+	Name    string
+	Size    int64
+	ModTime time.Time
+	Mode    fs.FileMode
+}
+
+// ObjectInfo describes an object considered by a sync. Name is its key relative to the prefix being synced.
+type ObjectInfo struct {
+	Key          string
+	Name         string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// SkipDecider decides whether a file that exists both locally and in the bucket can be skipped by a sync, and why.
+// Implement one to compare files in a custom way, such as by a dataset version embedded in them.
+type SkipDecider func(local FileInfo, remote ObjectInfo) (skip bool, reason string)
+
+// SkipSameSize skips files whose size matches the object's.
+func SkipSameSize(local FileInfo, remote ObjectInfo) (bool, string) {
+	if local.Size == remote.Size {
+		return true, "same size"
+	}
+	return false, ""
+}
+
+// SkipExisting never overwrites anything that already exists at the destination.
+func SkipExisting(local FileInfo, remote ObjectInfo) (bool, string) {
+	return true, "exists"
+}
+
+// SkipNothing transfers every file, overwriting whatever is at the destination.
+func SkipNothing(local FileInfo, remote ObjectInfo) (bool, string) {
+	return false, ""
+}
+
+// skipUnchangedUpload skips files with the same size as the object that haven't been modified since it was written.
+func skipUnchangedUpload(local FileInfo, remote ObjectInfo) (bool, string) {
+	if local.Size == remote.Size && !local.ModTime.Truncate(time.Second).After(remote.LastModified) {
+		return true, "unchanged"
+	}
+	return false, ""
+}
+
+// skipUnchangedDownload skips objects with the same size as the file that haven't been modified since it was written.
+func skipUnchangedDownload(local FileInfo, remote ObjectInfo) (bool, string) {
+	if local.Size == remote.Size && !remote.LastModified.After(local.ModTime) {
+		return true, "unchanged"
+	}
+	return false, ""
+}
+
+type SyncOptions struct {
+	// Skip decides which files that exist on both sides are left alone. If nil, files are skipped when their sizes
+	// match and the destination is at least as new as the source
+	Skip SkipDecider
+
+	// RetryPolicy controls retries of each file. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+
+	// Hooks, if set, are called as files start and finish. Skipped files are not passed to the object hooks
+	Hooks *Hooks
+
+	// Stats, if set, has live statistics of the transfer passed to its OnStats as it runs
+	Stats *StatsOptions
+
+	// FileProgress shows a bar for each of the largest files in flight, with how long it has been running, above
+	// the overall bar, so a stuck file stands out. It redraws in place, so it is ignored when stderr isn't a
+	// terminal; progress is then logged as a plain line every few seconds instead
+	FileProgress bool
+
+	// Quiet turns off progress output and the summary printed at the end
+	Quiet bool
+
+	// Mirror deletes whatever is at the destination but missing from the source once every transfer has
+	// succeeded, so the destination ends up an exact copy. Deleted keys or paths are listed in the report's Deleted.
+	// Mirroring is refused unless deletions go to TrashPrefix or TrashDir or are approved by ConfirmDelete, and
+	// mirroring from an empty source, which would delete everything, is refused unless ConfirmDelete is set
+	Mirror bool
+
+	// TrashPrefix, when mirroring up, moves deleted objects under this prefix, in a folder named for the time of
+	// the sync, instead of deleting them outright. It must be outside the synced prefix
+	TrashPrefix string
+
+	// TrashDir, when mirroring down, moves deleted files into this directory, in a folder named for the time of
+	// the sync, instead of removing them. It must be outside the synced directory
+	TrashDir string
+
+	// IndexPages, when syncing up, regenerates an index.html listing for the prefix and every prefix under it once
+	// the sync succeeds, as WriteIndexPages does. Generated pages are never mirrored away
+	IndexPages bool
+
+	// ConfirmDelete, if set, is given the names a mirror would delete and must return true for them to be
+	// deleted, for example after asking the user or writing them to a manifest for review
+	ConfirmDelete func(names []string) bool
+
+	// DeliveryManifest, when syncing up, is the name of a manifest written into the prefix once the sync succeeds,
+	// as with UploadObjectsOptions. It lists every file in the directory, including those skipped as unchanged,
+	// so each is hashed on every sync unless Hashing has a CacheFile
+	DeliveryManifest string
+
+	// Hashing controls how files are hashed for the DeliveryManifest
+	Hashing HashOptions
+}
+
+// localFiles returns the regular files under dir keyed by their slash separated path relative to dir.
+func localFiles(dir string) (map[string]FileInfo, error) {
+	files := make(map[string]FileInfo)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		files[name] = FileInfo{Path: path, Name: name, Size: info.Size(), ModTime: info.ModTime(), Mode: info.Mode()}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Couldn't walk directory %v: %v", dir, err)
+	}
+
+	return files, err
+}
+
+// checkPrefix returns an error unless a synced prefix is empty or ends in "/". Names are the keys with the prefix
+// trimmed, so a prefix such as "backup" would also take in everything under backup-2023/, with mangled names.
+func checkPrefix(prefix string) error {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		log.Printf("Prefix must be empty or end in '/'\n")
+		return fmt.Errorf("prefix %q must be empty or end in '/'", prefix)
+	}
+
+	return nil
+}
+
+// remoteObjects returns the objects under prefix keyed by their key relative to prefix. Directory markers are left out.
+func (basics BucketBasics) remoteObjects(ctx context.Context, bucketName string, prefix string) (map[string]ObjectInfo, error) {
+	objects := make(map[string]ObjectInfo)
+
+	for object, err := range basics.listObjectsSeq(ctx, bucketName, prefix) {
+		if err != nil {
+			return nil, err
+		}
+
+		key := aws.ToString(object.Key)
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, prefix)
+		objects[name] = ObjectInfo{
+			Key:          key,
+			Name:         name,
+			Size:         aws.ToInt64(object.Size),
+			ETag:         strings.Trim(aws.ToString(object.ETag), `"`),
+			LastModified: aws.ToTime(object.LastModified),
+		}
+	}
+
+	return objects, nil
+}
+
+// SyncUp takes a local directory, a prefix, and a bucket name and uploads the files in the directory that are
+// missing from the prefix or that the skip decider says have changed. The prefix must be empty or end in "/". The
+// report includes the files skipped.
+func (basics BucketBasics) SyncUp(localDir string, prefix string, bucketName string, options SyncOptions) (*TransferReport, error) {
+	return basics.SyncUpWithContext(context.Background(), localDir, prefix, bucketName, options)
+}
+
+// SyncUpWithContext is SyncUp with a context.
+func (basics BucketBasics) SyncUpWithContext(ctx context.Context, localDir string, prefix string, bucketName string, options SyncOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+	basics.detectRegions(ctx, bucketName)
+
+	uploader := basics.newUploader(bucketName)
+	report, err := syncUp(ctx, basics.Store(bucketName), localDir, prefix, options, basics.Backpressure, func(ctx context.Context, file FileUpload, bar *progressbar.ProgressBar) error {
+		return basics.UploadObjectWithContext(ctx, file.Path, file.Key, bucketName, UploadObjectOptions{bar: bar, uploader: uploader})
+	})
+	if err != nil || len(report.Failed()) > 0 {
+		return report, err
+	}
+
+	if options.DeliveryManifest != "" {
+		if err := basics.writeDeliveryManifest(ctx, nil, ".", report, prefix, options.DeliveryManifest, bucketName, options.Hashing); err != nil {
+			return report, err
+		}
+	}
+
+	if options.IndexPages {
+		_, err = basics.writeIndexPages(ctx, prefix, bucketName)
+	}
+
+	return report, err
+}
+
+// SyncDown takes a prefix, a local directory, and a bucket name and downloads the objects under the prefix that are
+// missing from the directory or that the skip decider says have changed. The prefix must be empty or end in "/".
+// The report includes the objects skipped.
+func (basics BucketBasics) SyncDown(prefix string, localDir string, bucketName string, options SyncOptions) (*TransferReport, error) {
+	return basics.SyncDownWithContext(context.Background(), prefix, localDir, bucketName, options)
+}
+
+// SyncDownWithContext is SyncDown with a context.
+func (basics BucketBasics) SyncDownWithContext(ctx context.Context, prefix string, localDir string, bucketName string, options SyncOptions) (*TransferReport, error) {
+	options.Quiet = defaultQuiet(options.Quiet)
+	basics.detectRegions(ctx, bucketName)
+
+	// DownloadObject names the file after the key, so download into the file's directory
+	downloader := basics.newDownloader(bucketName)
+	return syncDown(ctx, basics.Store(bucketName), prefix, localDir, bucketName+"/"+prefix, options, basics.Backpressure, func(ctx context.Context, file FileDownload, bar *progressbar.ProgressBar) error {
+		return basics.DownloadObjectWithContext(ctx, file.Key, filepath.Dir(file.Destination), bucketName, DownloadObjectOptions{Quiet: options.Quiet, bar: bar, downloader: downloader, small: file.Size < smallObjectSize})
+	})
+}
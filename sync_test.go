@@ -0,0 +1,189 @@
+package boto3manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSkipUnchanged(t *testing.T) {
+	t.Parallel()
+
+	written := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		local      FileInfo
+		remote     ObjectInfo
+		wantedUp   bool
+		wantedDown bool
+	}{
+		{
+			name:       "uploaded after last change",
+			local:      FileInfo{Size: 10, ModTime: written.Add(-time.Hour)},
+			remote:     ObjectInfo{Size: 10, LastModified: written},
+			wantedUp:   true,
+			wantedDown: false,
+		},
+		{
+			name:       "downloaded after last change",
+			local:      FileInfo{Size: 10, ModTime: written.Add(time.Hour)},
+			remote:     ObjectInfo{Size: 10, LastModified: written},
+			wantedUp:   false,
+			wantedDown: true,
+		},
+		{
+			name:       "sub-second modification time",
+			local:      FileInfo{Size: 10, ModTime: written.Add(500 * time.Millisecond)},
+			remote:     ObjectInfo{Size: 10, LastModified: written},
+			wantedUp:   true,
+			wantedDown: true,
+		},
+		{
+			name:       "different size",
+			local:      FileInfo{Size: 10, ModTime: written},
+			remote:     ObjectInfo{Size: 11, LastModified: written},
+			wantedUp:   false,
+			wantedDown: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := skipUnchangedUpload(tt.local, tt.remote); got != tt.wantedUp {
+				t.Errorf("skipUnchangedUpload(%+v, %+v) = %v, want %v", tt.local, tt.remote, got, tt.wantedUp)
+			}
+
+			if got, _ := skipUnchangedDownload(tt.local, tt.remote); got != tt.wantedDown {
+				t.Errorf("skipUnchangedDownload(%+v, %+v) = %v, want %v", tt.local, tt.remote, got, tt.wantedDown)
+			}
+		})
+	}
+}
+
+func TestLocalFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "a", "b"), 0755)
+	os.WriteFile(filepath.Join(dir, "top.txt"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(dir, "a", "b", "deep.txt"), []byte("22"), 0644)
+
+	files, err := localFiles(dir)
+	if err != nil {
+		t.Fatalf("localFiles() = %v", err)
+	}
+
+	wanted := map[string]int64{"top.txt": 1, "a/b/deep.txt": 2}
+	if len(files) != len(wanted) {
+		t.Fatalf("localFiles() returned %v files, want %v", len(files), len(wanted))
+	}
+
+	for name, size := range wanted {
+		if files[name].Size != size {
+			t.Errorf("localFiles()[%q].Size = %v, want %v", name, files[name].Size, size)
+		}
+	}
+}
+
+func TestCheckPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prefix string
+		wanted bool
+	}{
+		{prefix: "", wanted: true},
+		{prefix: "backup/", wanted: true},
+		{prefix: "a/b/", wanted: true},
+		{prefix: "backup", wanted: false},
+		{prefix: "a/b", wanted: false},
+	}
+
+	for _, tt := range tests {
+		if got := checkPrefix(tt.prefix) == nil; got != tt.wanted {
+			t.Errorf("checkPrefix(%q) = %v, want valid %v", tt.prefix, checkPrefix(tt.prefix), tt.wanted)
+		}
+	}
+}
+
+func TestSyncRejectsPrefix(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newMemStore()
+	store.objects["backup-2023/a.txt"] = []byte("sibling")
+
+	dir := t.TempDir()
+	options := SyncOptions{Mirror: true, Quiet: true}
+
+	if _, err := SyncUpStore(ctx, store, dir, "backup", options); err == nil {
+		t.Errorf("SyncUpStore() with prefix %q = nil error, want an error", "backup")
+	}
+	if _, err := SyncDownStore(ctx, store, "backup", dir, options); err == nil {
+		t.Errorf("SyncDownStore() with prefix %q = nil error, want an error", "backup")
+	}
+	if _, ok := store.objects["backup-2023/a.txt"]; !ok {
+		t.Errorf("SyncUpStore() deleted backup-2023/a.txt under a sibling prefix")
+	}
+
+	var basics BucketBasics
+	if _, err := basics.SyncUp(dir, "backup", "bucket", options); err == nil {
+		t.Errorf("SyncUp() with prefix %q = nil error, want an error", "backup")
+	}
+	if _, err := basics.SyncDown("backup", dir, "bucket", options); err == nil {
+		t.Errorf("SyncDown() with prefix %q = nil error, want an error", "backup")
+	}
+}
+
+func TestSyncBucketMirror(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "data")
+	fake.put("data", "sync/stale.txt", "stale", nil)
+
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "sub"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{"a.txt": "alpha", "sub/b.txt": "bravo"} {
+		if err := os.WriteFile(filepath.Join(src, filepath.FromSlash(name)), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deleted objects go to the trash through the same store the listing came from
+	options := SyncOptions{Mirror: true, TrashPrefix: "trash/", Quiet: true}
+	if _, err := fake.basics().SyncUp(src, "sync/", "data", options); err != nil {
+		t.Fatalf("SyncUp() = %v, want nil", err)
+	}
+
+	keys := fake.keys("data")
+	if len(keys) != 3 || keys[0] != "sync/a.txt" || keys[1] != "sync/sub/b.txt" || !strings.HasSuffix(keys[2], "/stale.txt") || !strings.HasPrefix(keys[2], "trash/") {
+		t.Errorf("SyncUp() left %v, want sync/a.txt, sync/sub/b.txt, and stale.txt in the trash", keys)
+	}
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "dest")
+	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "old.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	options = SyncOptions{Mirror: true, TrashDir: filepath.Join(dir, "trash"), Quiet: true}
+	if _, err := fake.basics().SyncDown("sync/", dest, "data", options); err != nil {
+		t.Fatalf("SyncDown() = %v, want nil", err)
+	}
+
+	if got, _ := os.ReadFile(filepath.Join(dest, "sub", "b.txt")); string(got) != "bravo" {
+		t.Errorf("SyncDown() wrote sub/b.txt = %q, want %q", got, "bravo")
+	}
+	if moved, _ := filepath.Glob(filepath.Join(dir, "trash", "*", "old.txt")); len(moved) != 1 {
+		t.Errorf("SyncDown() moved old.txt to %v, want one copy in the trash", moved)
+	}
+}
@@ -0,0 +1,211 @@
+package boto3manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// defaultPieceLength is the torrent piece length used when none is given.
+const defaultPieceLength = 4 * 1024 * 1024
+
+type TorrentOptions struct {
+	// PieceLength is the size of each hashed piece in bytes. Defaults to 4 MiB
+	PieceLength int64
+
+	// Trackers are announce URLs. The first one is used as the primary tracker
+	Trackers []string
+
+	// WebSeedURL is a public base URL for the bucket, e.g. https://s3.example.com/bucket/. If empty, a presigned
+	// URL for the object is used as the web seed instead
+	WebSeedURL string
+
+	// PresignExpiry is how long a presigned web seed URL stays valid. Defaults to 7 days, the maximum S3 allows
+	PresignExpiry time.Duration
+
+	Comment string
+}
+
+// ExportTorrent takes a key and a bucket name and writes a single-file .torrent for the object to w. The object
+// is streamed once to compute piece hashes, and the bucket is listed as a web seed so it stays the seed of record.
+// Empty objects have no pieces to share, so they are refused.
+func (basics BucketBasics) ExportTorrent(key string, bucketName string, w io.Writer, options TorrentOptions) error {
+	pieceLength := options.PieceLength
+	if pieceLength <= 0 {
+		pieceLength = defaultPieceLength
+	}
+
+	// Stream the object to hash its pieces
+	obj, err := basics.client(bucketName).GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get object %v: %v", key, err)
+		return err
+	}
+
+	defer obj.Body.Close()
+
+	pieces, length, err := hashPieces(obj.Body, pieceLength)
+
+	if err != nil {
+		log.Printf("Couldn't read object %v: %v", key, err)
+		return err
+	}
+
+	// A torrent needs at least one piece, and clients refuse one without
+	if length == 0 {
+		log.Printf("Couldn't make a torrent of %v, which is empty", key)
+		return fmt.Errorf("can't make a torrent of empty object %v", key)
+	}
+
+	// Get the URL peers can fetch the object from over HTTP
+	webSeed, err := basics.webSeedURL(key, bucketName, options)
+
+	if err != nil {
+		log.Printf("Couldn't create web seed URL for %v: %v", key, err)
+		return err
+	}
+
+	torrent := map[string]any{
+		"info": map[string]any{
+			"name":         path.Base(key),
+			"length":       length,
+			"piece length": pieceLength,
+			"pieces":       string(pieces),
+		},
+		"url-list":      []any{webSeed},
+		"creation date": time.Now().Unix(),
+		"created by":    "boto3-manager",
+	}
+
+	if len(options.Trackers) > 0 {
+		torrent["announce"] = options.Trackers[0]
+
+		tiers := make([]any, 0, len(options.Trackers))
+		for _, tracker := range options.Trackers {
+			tiers = append(tiers, []any{tracker})
+		}
+		torrent["announce-list"] = tiers
+	}
+
+	if options.Comment != "" {
+		torrent["comment"] = options.Comment
+	}
+
+	encoded, err := bencode(torrent)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(encoded)
+	return err
+}
+
+// webSeedURL returns the public or presigned URL for an object. The key is escaped in a public URL, so characters
+// such as spaces, # and ? are part of the path.
+func (basics BucketBasics) webSeedURL(key string, bucketName string, options TorrentOptions) (string, error) {
+	if options.WebSeedURL != "" {
+		return strings.TrimSuffix(options.WebSeedURL, "/") + "/" + escapeKey(key), nil
+	}
+
+	expiry := options.PresignExpiry
+	if expiry <= 0 {
+		expiry = 7 * 24 * time.Hour
+	}
+
+	presigner := s3.NewPresignClient(basics.client(bucketName), s3.WithPresignExpires(expiry))
+	req, err := presigner.PresignGetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return req.URL, nil
+}
+
+// hashPieces reads r to the end and returns the concatenated SHA-1 hashes of each piece and the total length.
+func hashPieces(r io.Reader, pieceLength int64) ([]byte, int64, error) {
+	var pieces []byte
+	var length int64
+
+	buf := make([]byte, pieceLength)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			sum := sha1.Sum(buf[:n])
+			pieces = append(pieces, sum[:]...)
+			length += int64(n)
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return pieces, length, nil
+		}
+
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
+// bencode encodes strings, integers, lists, and dictionaries in the BitTorrent bencoding format.
+func bencode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	err := bencodeTo(&buf, v)
+	return buf.Bytes(), err
+}
+
+func bencodeTo(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case string:
+		fmt.Fprintf(buf, "%d:%s", len(v), v)
+	case int:
+		fmt.Fprintf(buf, "i%de", v)
+	case int64:
+		fmt.Fprintf(buf, "i%de", v)
+	case []any:
+		buf.WriteByte('l')
+		for _, item := range v {
+			if err := bencodeTo(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	case map[string]any:
+		// Dictionary keys must be sorted
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('d')
+		for _, key := range keys {
+			bencodeTo(buf, key)
+			if err := bencodeTo(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	default:
+		return fmt.Errorf("can't bencode value of type %T", v)
+	}
+
+	return nil
+}
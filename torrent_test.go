@@ -0,0 +1,168 @@
+package boto3manager
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBencode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		value  any
+		wanted string
+	}{
+		{
+			name:   "string",
+			value:  "spam",
+			wanted: "4:spam",
+		},
+		{
+			name:   "integer",
+			value:  int64(-3),
+			wanted: "i-3e",
+		},
+		{
+			name:   "list",
+			value:  []any{"spam", 42},
+			wanted: "l4:spami42ee",
+		},
+		{
+			name:   "dictionary with sorted keys",
+			value:  map[string]any{"spam": []any{"a", "b"}, "cow": "moo"},
+			wanted: "d3:cow3:moo4:spaml1:a1:bee",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bencode(tt.value)
+			if err != nil || string(got) != tt.wanted {
+				t.Errorf("bencode(%v) = %q, %v, want %q", tt.value, got, err, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestWebSeedURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		key    string
+		wanted string
+	}{
+		{name: "plain key", key: "data/file.iso", wanted: "https://cdn.example.com/pub/data/file.iso"},
+		{name: "space", key: "my data/file one.iso", wanted: "https://cdn.example.com/pub/my%20data/file%20one.iso"},
+		{name: "fragment", key: "data/#1.iso", wanted: "https://cdn.example.com/pub/data/%231.iso"},
+		{name: "query", key: "data/file?.iso", wanted: "https://cdn.example.com/pub/data/file%3F.iso"},
+		{name: "percent", key: "data/100%.iso", wanted: "https://cdn.example.com/pub/data/100%25.iso"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BucketBasics{}.webSeedURL(tt.key, "bucket", TorrentOptions{WebSeedURL: "https://cdn.example.com/pub/"})
+			if err != nil || got != tt.wanted {
+				t.Errorf("webSeedURL(%q) = %v, %v, want %v", tt.key, got, err, tt.wanted)
+			}
+		})
+	}
+}
+
+// bdecode decodes one bencoded value from the start of data, returning it and the rest of data.
+func bdecode(t *testing.T, data string) (any, string) {
+	t.Helper()
+
+	switch {
+	case data == "":
+		t.Fatal("bdecode() ran out of data")
+	case data[0] == 'i':
+		end := strings.IndexByte(data, 'e')
+		n, err := strconv.ParseInt(data[1:end], 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n, data[end+1:]
+	case data[0] == 'l':
+		list := make([]any, 0)
+		data = data[1:]
+		for data[0] != 'e' {
+			var item any
+			item, data = bdecode(t, data)
+			list = append(list, item)
+		}
+		return list, data[1:]
+	case data[0] == 'd':
+		dict := make(map[string]any)
+		data = data[1:]
+		for data[0] != 'e' {
+			var key, value any
+			key, data = bdecode(t, data)
+			value, data = bdecode(t, data)
+			dict[key.(string)] = value
+		}
+		return dict, data[1:]
+	}
+
+	colon := strings.IndexByte(data, ':')
+	n, err := strconv.Atoi(data[:colon])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data[colon+1 : colon+1+n], data[colon+1+n:]
+}
+
+func TestExportTorrent(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("a", 10) + strings.Repeat("b", 10) + "ccc"
+
+	fake := newFakeS3(t, "bucket")
+	fake.put("bucket", "data/big file.bin", body, nil)
+	fake.put("bucket", "data/empty.bin", "", nil)
+
+	options := TorrentOptions{PieceLength: 10, WebSeedURL: "https://s3.example.com/bucket/", Trackers: []string{"udp://tracker.example.com:1337"}}
+
+	var out bytes.Buffer
+	if err := fake.basics().ExportTorrent("data/big file.bin", "bucket", &out, options); err != nil {
+		t.Fatalf("ExportTorrent() = %v, want nil", err)
+	}
+
+	decoded, rest := bdecode(t, out.String())
+	if rest != "" {
+		t.Errorf("ExportTorrent() wrote %q after the torrent", rest)
+	}
+	torrent := decoded.(map[string]any)
+	info := torrent["info"].(map[string]any)
+
+	var pieces strings.Builder
+	for _, piece := range []string{body[:10], body[10:20], body[20:]} {
+		sum := sha1.Sum([]byte(piece))
+		pieces.Write(sum[:])
+	}
+
+	if info["pieces"] != pieces.String() {
+		t.Errorf("ExportTorrent() wrote pieces %x, want %x", info["pieces"], pieces.String())
+	}
+	if info["length"] != int64(len(body)) || info["piece length"] != int64(10) || info["name"] != "big file.bin" {
+		t.Errorf("ExportTorrent() wrote info %v, want %v bytes in pieces of 10 named big file.bin", info, len(body))
+	}
+	if seeds := torrent["url-list"].([]any); len(seeds) != 1 || seeds[0] != "https://s3.example.com/bucket/data/big%20file.bin" {
+		t.Errorf("ExportTorrent() wrote web seeds %v, want the escaped public URL", seeds)
+	}
+	if torrent["announce"] != options.Trackers[0] {
+		t.Errorf("ExportTorrent() announced to %v, want %v", torrent["announce"], options.Trackers[0])
+	}
+
+	out.Reset()
+	if err := fake.basics().ExportTorrent("data/empty.bin", "bucket", &out, options); err == nil {
+		t.Errorf("ExportTorrent() of an empty object = nil error, want an error")
+	}
+	if out.Len() != 0 {
+		t.Errorf("ExportTorrent() of an empty object wrote %q, want nothing", out.String())
+	}
+}
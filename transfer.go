@@ -0,0 +1,141 @@
+package boto3manager
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// transferItem is a file that can be sent through a batch.
+type transferItem interface {
+	result() TransferResult
+}
+
+func (file FileUpload) result() TransferResult {
+	return TransferResult{Key: file.Key, Path: file.Path, Size: file.Size}
+}
+
+func (file FileDownload) result() TransferResult {
+	return TransferResult{Key: file.Key, Path: file.Destination, Size: file.Size}
+}
+
+// batchConfig holds the settings shared by the workers of a batch operation.
+type batchConfig struct {
+	workerCount int
+	policy      RetryPolicy
+	report      *TransferReport
+
+	// heartbeat, if set, is kept up to date while the batch runs
+	heartbeat *heartbeat
+
+	// hooks, if set, are called as items start and finish
+	hooks *Hooks
+
+	// stats, if set, has live statistics of the batch passed to its callback
+	stats *StatsOptions
+
+	// backpressure tunes how the workers slow down when the server is overloaded
+	backpressure BackpressureOptions
+}
+
+// runBatch sends each item to a pool of workers that call fn, retrying according to the policy, and records a result
+// for every item in the report. Workers pause and drop out together when the server asks them to slow down. If the
+// context is cancelled, no new items are started, the items that were never
+// started are recorded with the context's error, and that error is returned.
+func runBatch[T transferItem](ctx context.Context, items []T, config batchConfig, fn func(context.Context, T) error) error {
+	config.report.start()
+
+	if config.heartbeat != nil {
+		config.heartbeat.start(config.report)
+	}
+
+	var totalBytes int64
+	for _, item := range items {
+		totalBytes += item.result().Size
+	}
+
+	stats := newBatchStats(config.stats, config.report, len(items), totalBytes, config.workerCount)
+	stats.start()
+
+	pressure := newBackpressure(config.backpressure, config.workerCount)
+
+	// Make a queue for items to transfer
+	queue := make(chan T)
+
+	var wg sync.WaitGroup
+
+	// Create a goroutine for each worker
+	for worker := 0; worker < config.workerCount; worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				// Wait for a turn before taking an item, so an item isn't held by a worker above the limit
+				if err := pressure.wait(ctx, worker); err != nil {
+					return
+				}
+
+				// Get item from queue
+				item, ok := <-queue
+				if !ok {
+					return
+				}
+
+				config.hooks.objectStart(item.result())
+				stats.begin(worker)
+
+				started := time.Now()
+				attempts, err := config.policy.do(ctx, func() error {
+					if err := pressure.wait(ctx, worker); err != nil {
+						return err
+					}
+
+					err := fn(ctx, item)
+					pressure.observe(err)
+					return err
+				})
+
+				result := item.result()
+				result.Attempts = attempts
+				result.Duration = time.Since(started)
+				result.Err = classifyError(err)
+				config.report.record(result)
+				stats.end(worker)
+				config.hooks.objectDone(result)
+			}
+		}()
+	}
+
+	// Send each item to the queue
+dispatch:
+	for i, item := range items {
+		select {
+		case queue <- item:
+		case <-ctx.Done():
+			// Record the items that were never started so the report accounts for every one
+			for _, item := range items[i:] {
+				result := item.result()
+				result.Err = ctx.Err()
+				config.report.record(result)
+			}
+			break dispatch
+		}
+	}
+
+	close(queue)
+	pressure.drain()
+
+	wg.Wait()
+
+	config.report.finish()
+	stats.finish()
+	config.hooks.batchComplete(config.report)
+
+	if config.heartbeat != nil {
+		config.heartbeat.finish(config.report, ctx.Err())
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,51 @@
+package boto3manager
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// putVersioning sets the versioning state of a bucket.
+func (basics BucketBasics) putVersioning(bucketName string, status types.BucketVersioningStatus) error {
+	_, err := basics.client(bucketName).PutBucketVersioning(context.TODO(), &s3.PutBucketVersioningInput{
+		Bucket:                  aws.String(bucketName),
+		VersioningConfiguration: &types.VersioningConfiguration{Status: status},
+	})
+
+	if err != nil {
+		log.Printf("Couldn't set versioning of bucket %v to %v: %v", bucketName, status, err)
+	}
+
+	return err
+}
+
+// EnableVersioning takes a bucket name and turns on versioning, so overwritten and deleted objects are kept as
+// noncurrent versions.
+func (basics BucketBasics) EnableVersioning(bucketName string) error {
+	return basics.putVersioning(bucketName, types.BucketVersioningStatusEnabled)
+}
+
+// SuspendVersioning takes a bucket name and stops it creating new versions. Versions that already exist are kept.
+// Versioning can't be turned off entirely once it has been enabled.
+func (basics BucketBasics) SuspendVersioning(bucketName string) error {
+	return basics.putVersioning(bucketName, types.BucketVersioningStatusSuspended)
+}
+
+// GetVersioningStatus takes a bucket name and returns whether versioning is Enabled or Suspended. It is empty if
+// versioning has never been enabled.
+func (basics BucketBasics) GetVersioningStatus(bucketName string) (types.BucketVersioningStatus, error) {
+	output, err := basics.client(bucketName).GetBucketVersioning(context.TODO(), &s3.GetBucketVersioningInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't get versioning of bucket %v: %v", bucketName, err)
+		return "", err
+	}
+
+	return output.Status, nil
+}
@@ -0,0 +1,74 @@
+package boto3manager
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestVersioning(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	basics := fake.basics()
+
+	put := func(body string) {
+		t.Helper()
+		_, err := fake.client().PutObject(context.TODO(), &s3.PutObjectInput{Bucket: aws.String("bucket"), Key: aws.String("data/a.txt"), Body: strings.NewReader(body)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status := func(wanted types.BucketVersioningStatus) {
+		t.Helper()
+		if got, err := basics.GetVersioningStatus("bucket"); err != nil || got != wanted {
+			t.Errorf("GetVersioningStatus() = %q, %v, want %q", got, err, wanted)
+		}
+	}
+
+	// Nothing is kept before versioning is enabled
+	status("")
+	put("unversioned")
+
+	if err := basics.EnableVersioning("bucket"); err != nil {
+		t.Fatalf("EnableVersioning() = %v, want nil", err)
+	}
+	status(types.BucketVersioningStatusEnabled)
+
+	put("first")
+	first, _ := fake.object("bucket", "data/a.txt")
+	put("second")
+
+	// The version current when the first write landed is still there after the second
+	versions, err := basics.ListVersionsAsOf("data/", "bucket", first.lastModified)
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("ListVersionsAsOf() = %+v, %v, want one version", versions, err)
+	}
+
+	object, err := fake.client().GetObject(context.TODO(), &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("data/a.txt"), VersionId: aws.String(versions[0].VersionId)})
+	if err != nil {
+		t.Fatalf("GetObject(%v) = %v", versions[0].VersionId, err)
+	}
+	body, err := io.ReadAll(object.Body)
+	object.Body.Close()
+	if err != nil || string(body) != "first" {
+		t.Errorf("version %v holds %q, %v, want %q", versions[0].VersionId, body, err, "first")
+	}
+
+	if err := basics.SuspendVersioning("bucket"); err != nil {
+		t.Fatalf("SuspendVersioning() = %v, want nil", err)
+	}
+	status(types.BucketVersioningStatusSuspended)
+
+	// Existing versions are kept, and no new ones are made
+	put("third")
+	if got := len(fake.versions["bucket"]); got != 2 {
+		t.Errorf("SuspendVersioning() left %v versions, want 2", got)
+	}
+}
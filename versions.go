@@ -0,0 +1,211 @@
+package boto3manager
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/schollz/progressbar/v3"
+)
+
+// ObjectVersion is a version of an object in a versioned bucket, and where to download it.
+type ObjectVersion struct {
+	Key          string
+	VersionId    string
+	Size         int64
+	LastModified time.Time
+	Destination  string
+}
+
+func (version ObjectVersion) result() TransferResult {
+	return TransferResult{Key: version.Key, Path: version.Destination, Size: version.Size}
+}
+
+type DownloadAsOfOptions struct {
+	// RetryPolicy controls retries of each object. If nil, DefaultRetryPolicy is used
+	RetryPolicy *RetryPolicy
+}
+
+// versionsAsOf returns, for every key, the version that was current at a point in time: the newest version or
+// delete marker last modified at or before it. Keys that didn't exist yet or were deleted are left out, as are
+// directory markers. The result is sorted by key.
+func versionsAsOf(versions []types.ObjectVersion, markers []types.DeleteMarkerEntry, asOf time.Time) []ObjectVersion {
+	type current struct {
+		version      ObjectVersion
+		deleted      bool
+		lastModified time.Time
+	}
+
+	currents := make(map[string]current)
+
+	// consider keeps an entry if it is newer than what the key has so far without being after asOf
+	consider := func(key string, lastModified time.Time, entry current) {
+		if lastModified.After(asOf) {
+			return
+		}
+
+		if existing, ok := currents[key]; ok && !lastModified.After(existing.lastModified) {
+			return
+		}
+
+		entry.lastModified = lastModified
+		currents[key] = entry
+	}
+
+	// S3 lists the newer of two entries modified in the same second first, with delete markers ahead of versions,
+	// so markers are considered first and a tie keeps the entry seen first
+	for _, marker := range markers {
+		consider(aws.ToString(marker.Key), aws.ToTime(marker.LastModified), current{deleted: true})
+	}
+
+	for _, version := range versions {
+		key := aws.ToString(version.Key)
+		lastModified := aws.ToTime(version.LastModified)
+
+		// Directory markers have nothing to restore
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+
+		consider(key, lastModified, current{version: ObjectVersion{
+			Key:          key,
+			VersionId:    aws.ToString(version.VersionId),
+			Size:         aws.ToInt64(version.Size),
+			LastModified: lastModified,
+		}})
+	}
+
+	results := make([]ObjectVersion, 0, len(currents))
+	for _, entry := range currents {
+		if !entry.deleted {
+			results = append(results, entry.version)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+
+	return results
+}
+
+// ListVersionsAsOf takes a prefix, a bucket name, and a time and returns the version of every object under the
+// prefix that was current at that time in a versioned bucket. Objects created later or deleted by then are left out.
+func (basics BucketBasics) ListVersionsAsOf(prefix string, bucketName string, asOf time.Time) ([]ObjectVersion, error) {
+	return basics.listVersionsAsOf(context.TODO(), prefix, bucketName, asOf)
+}
+
+// listVersionsAsOf lists every version and delete marker under a prefix and picks the ones current at asOf.
+func (basics BucketBasics) listVersionsAsOf(ctx context.Context, prefix string, bucketName string, asOf time.Time) ([]ObjectVersion, error) {
+	params := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucketName),
+	}
+
+	if len(prefix) > 0 {
+		params.Prefix = aws.String(prefix)
+	}
+
+	versions := make([]types.ObjectVersion, 0)
+	markers := make([]types.DeleteMarkerEntry, 0)
+
+	p := s3.NewListObjectVersionsPaginator(basics.client(bucketName), params)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+
+		if err != nil {
+			log.Printf("Couldn't list versions in bucket %v: %v", bucketName, err)
+			return nil, classifyError(err)
+		}
+
+		versions = append(versions, page.Versions...)
+		markers = append(markers, page.DeleteMarkers...)
+	}
+
+	return versionsAsOf(versions, markers, asOf), nil
+}
+
+// DownloadAsOf takes a prefix, a destination directory, a bucket name, and a time and downloads the objects under
+// the prefix, which must be empty or end in "/", as they were at that time, using the object versions of a versioned
+// bucket. Each object is written to the destination at its key relative to the prefix, so a prefix can be recovered
+// to a point in time without a separate backup. The returned report holds the result of every object.
+func (basics BucketBasics) DownloadAsOf(prefix string, dest string, bucketName string, asOf time.Time, options DownloadAsOfOptions) (*TransferReport, error) {
+	return basics.DownloadAsOfWithContext(context.Background(), prefix, dest, bucketName, asOf, options)
+}
+
+// DownloadAsOfWithContext is DownloadAsOf with a context.
+func (basics BucketBasics) DownloadAsOfWithContext(ctx context.Context, prefix string, dest string, bucketName string, asOf time.Time, options DownloadAsOfOptions) (*TransferReport, error) {
+	basics.detectRegions(ctx, bucketName)
+
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	versions, err := basics.listVersionsAsOf(ctx, prefix, bucketName, asOf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var totalSize int64
+	for i, version := range versions {
+		versions[i].Destination = filepath.Join(dest, filepath.FromSlash(version.Key[len(prefix):]))
+		totalSize += version.Size
+	}
+
+	bar := newBytesBar(totalSize, "restoring")
+	downloader := basics.newDownloader(bucketName)
+
+	report := &TransferReport{}
+	config := batchConfig{
+		workerCount:  50,
+		policy:       retryPolicy(options.RetryPolicy),
+		report:       report,
+		backpressure: basics.Backpressure,
+	}
+
+	err = runBatch(ctx, versions, config, func(ctx context.Context, version ObjectVersion) error {
+		return basics.downloadVersion(ctx, downloader, version, bucketName, bar)
+	})
+
+	return report, err
+}
+
+// downloadVersion downloads a single version of an object to its destination, removing the file if it fails.
+func (basics BucketBasics) downloadVersion(ctx context.Context, downloader *manager.Downloader, version ObjectVersion, bucketName string, bar *progressbar.ProgressBar) error {
+	if err := os.MkdirAll(filepath.Dir(version.Destination), os.ModePerm); err != nil {
+		log.Printf("Couldn't create directory for %v: %v", version.Destination, err)
+		return err
+	}
+
+	f, err := os.Create(version.Destination)
+
+	if err != nil {
+		log.Printf("Couldn't open file %v: %v", version.Destination, err)
+		return err
+	}
+
+	defer f.Close()
+
+	w := &progressWriterAt{w: f, bar: bar}
+
+	_, err = downloader.Download(ctx, w, &s3.GetObjectInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(version.Key),
+		VersionId: aws.String(version.VersionId),
+	})
+
+	if err != nil {
+		log.Printf("Couldn't download version %v of %v: %v", version.VersionId, version.Key, err)
+		w.rollback()
+		os.Remove(version.Destination)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package boto3manager
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestVersionsAsOf(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	version := func(key string, id string, d int) types.ObjectVersion {
+		return types.ObjectVersion{Key: aws.String(key), VersionId: aws.String(id), Size: aws.Int64(1), LastModified: day(d)}
+	}
+
+	versions := []types.ObjectVersion{
+		version("a", "a2", 3),
+		version("a", "a1", 1),
+		version("b", "b1", 1),
+		version("c", "c1", 4),
+		version("d", "d2", 5),
+		version("d", "d1", 1),
+		version("e", "e2", 3),
+		version("e", "e1", 3),
+		version("f", "f1", 1),
+		version("dir/", "dir1", 1),
+	}
+
+	markers := []types.DeleteMarkerEntry{
+		{Key: aws.String("b"), LastModified: day(2)},
+		{Key: aws.String("d"), LastModified: day(2)},
+		{Key: aws.String("f"), LastModified: day(1)},
+	}
+
+	tests := []struct {
+		name   string
+		asOf   time.Time
+		wanted []string
+	}{
+		{
+			name:   "before anything",
+			asOf:   *day(0),
+			wanted: []string{},
+		},
+		{
+			name:   "first day",
+			asOf:   *day(1),
+			wanted: []string{"a1", "b1", "d1"},
+		},
+		{
+			name:   "after deletes",
+			asOf:   *day(2),
+			wanted: []string{"a1"},
+		},
+		{
+			name:   "latest",
+			asOf:   *day(10),
+			wanted: []string{"a2", "c1", "d2", "e2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := make([]string, 0)
+			for _, version := range versionsAsOf(versions, markers, tt.asOf) {
+				got = append(got, version.VersionId)
+			}
+
+			if !slices.Equal(got, tt.wanted) {
+				t.Errorf("versionsAsOf(%v) = %v, want %v", tt.asOf, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestDownloadAsOf(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "bucket")
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	fake.versions["bucket"] = []fakeVersion{
+		{key: "docs/", versionID: "m1", lastModified: day(1)},
+		{key: "docs/a.txt", versionID: "a2", lastModified: day(3), body: []byte("new")},
+		{key: "docs/a.txt", versionID: "a1", lastModified: day(1), body: []byte("old")},
+		{key: "docs/gone.txt", versionID: "g1", lastModified: day(1), body: []byte("gone")},
+		{key: "docs/gone.txt", versionID: "g2", lastModified: day(1), deleteMarker: true},
+		{key: "docs-v2/b.txt", versionID: "b1", lastModified: day(1), body: []byte("sibling")},
+	}
+	basics := fake.basics()
+
+	if _, err := basics.DownloadAsOf("docs", t.TempDir(), "bucket", day(2), DownloadAsOfOptions{}); err == nil {
+		t.Errorf("DownloadAsOf() with prefix %q = nil error, want an error", "docs")
+	}
+
+	dest := t.TempDir()
+	report, err := basics.DownloadAsOf("docs/", dest, "bucket", day(2), DownloadAsOfOptions{})
+	if err != nil {
+		t.Fatalf("DownloadAsOf() = %v", err)
+	}
+
+	if got := len(report.Succeeded()); got != 1 {
+		t.Errorf("DownloadAsOf() restored %v objects, want 1", got)
+	}
+	if got, err := os.ReadFile(filepath.Join(dest, "a.txt")); err != nil || string(got) != "old" {
+		t.Errorf("DownloadAsOf() wrote a.txt = %q, %v, want %q", got, err, "old")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "gone.txt")); !os.IsNotExist(err) {
+		t.Errorf("DownloadAsOf() restored gone.txt, deleted in the same second it was written")
+	}
+}